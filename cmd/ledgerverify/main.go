@@ -0,0 +1,29 @@
+// Ledgerverify проверяет цепочки хешей таблиц transactions и purchases и завершается с кодом 1,
+// если история была изменена.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"os"
+
+	"API-Avito-shop/internal/app"
+)
+
+func main() {
+	result, err := app.VerifyLedger(context.Background())
+	if err != nil {
+		log.Fatalf("Error verifying ledger: %v", err)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err = encoder.Encode(result); err != nil {
+		log.Fatalf("Error writing result: %v", err)
+	}
+
+	if !result.Valid {
+		os.Exit(1)
+	}
+}
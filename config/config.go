@@ -9,17 +9,44 @@ import (
 )
 
 type Config struct {
-	ApiServerConfig ApiServer
-	DatabaseConfig  Database
+	ApiServerConfig   ApiServer
+	JWTConfig         JWT
+	DatabaseConfig    Database
+	OutboxConfig      Outbox
+	WebhookConfig     Webhook
+	NotifyConfig      Notifications
+	RateLimitConfig   RateLimit
+	LockoutConfig     Lockout
+	PasswordConfig    Password
+	OIDCConfig        OIDC
+	TwoFactorConfig   TwoFactor
+	LeaderboardConfig Leaderboard
+	FraudConfig       Fraud
+	CoinExpiryConfig  CoinExpiry
+	LedgerConfig      Ledger
+	AuditConfig       Audit
 }
 
 // ApiServer представляет конфигурацию сервера API
 type ApiServer struct {
 	Host          string        `env:"API_SERVER_HOST" env-default:"localhost"`
 	Port          string        `env:"API_SERVER_PORT" env-default:"8080"`
-	AuthSecretKey string        `env:"API_SERVER_AUTH_SECRET_KEY" env-required:"true"`
+	AuthSecretKey string        `env:"API_SERVER_AUTH_SECRET_KEY"`
 	Timeout       time.Duration `env:"API_SERVER_TIMEOUT" env-default:"4s"`
 	IdleTimeout   time.Duration `env:"API_SERVER_IDLE_TIMEOUT" env-default:"60s"`
+	// Прокси, которым доверяем заголовок X-Forwarded-For при определении IP клиента
+	TrustedProxies []string `env:"API_SERVER_TRUSTED_PROXIES" env-separator:","`
+}
+
+// JWT представляет конфигурацию асимметричной подписи токенов.
+// Без JWT_KEYS_DIR токены подписываются HS256 ключом API_SERVER_AUTH_SECRET_KEY.
+type JWT struct {
+	KeysDir           string `env:"JWT_KEYS_DIR"`
+	ActiveKeyID       string `env:"JWT_ACTIVE_KEY_ID"`
+	AcceptLegacyHS256 bool   `env:"JWT_ACCEPT_LEGACY_HS256" env-default:"false"`
+	// StateCacheTTL время, на которое кешируется проверенная версия токена, 0 отключает кеш.
+	// Отзыв токенов и отключение учетных записей вступают в силу не позже чем через это время.
+	StateCacheTTL time.Duration `env:"JWT_STATE_CACHE_TTL" env-default:"5s"`
 }
 
 // Database представляет конфигурацию подключения к базе данных
@@ -33,6 +60,146 @@ type Database struct {
 	SSLMode  string `env:"DB_SSLMODE" env-default:"disable"`
 }
 
+// Outbox представляет конфигурацию доставки доменных событий
+type Outbox struct {
+	Publisher      string        `env:"OUTBOX_PUBLISHER" env-default:"log"`
+	WebhookURL     string        `env:"OUTBOX_WEBHOOK_URL"`
+	WebhookTimeout time.Duration `env:"OUTBOX_WEBHOOK_TIMEOUT" env-default:"5s"`
+	PollInterval   time.Duration `env:"OUTBOX_POLL_INTERVAL" env-default:"2s"`
+	BatchSize      int           `env:"OUTBOX_BATCH_SIZE" env-default:"100"`
+	Lease          time.Duration `env:"OUTBOX_LEASE" env-default:"5m"`
+	RetryDelay     time.Duration `env:"OUTBOX_RETRY_DELAY" env-default:"5s"`
+	MaxRetryDelay  time.Duration `env:"OUTBOX_MAX_RETRY_DELAY" env-default:"10m"`
+}
+
+// Webhook представляет конфигурацию доставки событий подписчикам
+type Webhook struct {
+	Timeout       time.Duration `env:"WEBHOOK_TIMEOUT" env-default:"5s"`
+	PollInterval  time.Duration `env:"WEBHOOK_POLL_INTERVAL" env-default:"2s"`
+	BatchSize     int           `env:"WEBHOOK_BATCH_SIZE" env-default:"50"`
+	MaxAttempts   int           `env:"WEBHOOK_MAX_ATTEMPTS" env-default:"8"`
+	RetryDelay    time.Duration `env:"WEBHOOK_RETRY_DELAY" env-default:"10s"`
+	MaxRetryDelay time.Duration `env:"WEBHOOK_MAX_RETRY_DELAY" env-default:"1h"`
+	// AllowPrivateTargets разрешает доставку на внутренние адреса, только для локальной разработки
+	AllowPrivateTargets bool `env:"WEBHOOK_ALLOW_PRIVATE_TARGETS" env-default:"false"`
+}
+
+// Notifications представляет конфигурацию уведомлений в реальном времени
+type Notifications struct {
+	HeartbeatInterval time.Duration `env:"NOTIFY_HEARTBEAT_INTERVAL" env-default:"15s"`
+	BufferSize        int           `env:"NOTIFY_BUFFER_SIZE" env-default:"32"`
+	ReconnectDelay    time.Duration `env:"NOTIFY_RECONNECT_DELAY" env-default:"3s"`
+}
+
+// RateLimit представляет конфигурацию ограничения частоты запросов
+type RateLimit struct {
+	Enabled         bool          `env:"RATE_LIMIT_ENABLED" env-default:"true"`
+	Store           string        `env:"RATE_LIMIT_STORE" env-default:"memory"`
+	AuthPerMinute   float64       `env:"RATE_LIMIT_AUTH_PER_MINUTE" env-default:"10"`
+	AuthBurst       int           `env:"RATE_LIMIT_AUTH_BURST" env-default:"5"`
+	UserPerMinute   float64       `env:"RATE_LIMIT_USER_PER_MINUTE" env-default:"600"`
+	UserBurst       int           `env:"RATE_LIMIT_USER_BURST" env-default:"100"`
+	CleanupInterval time.Duration `env:"RATE_LIMIT_CLEANUP_INTERVAL" env-default:"5m"`
+}
+
+// Lockout представляет конфигурацию защиты входа от перебора паролей
+type Lockout struct {
+	MaxUserFailures int           `env:"LOCKOUT_MAX_USER_FAILURES" env-default:"5"`
+	MaxIPFailures   int           `env:"LOCKOUT_MAX_IP_FAILURES" env-default:"20"`
+	FailureWindow   time.Duration `env:"LOCKOUT_FAILURE_WINDOW" env-default:"15m"`
+	BaseDuration    time.Duration `env:"LOCKOUT_BASE_DURATION" env-default:"1m"`
+	MaxDuration     time.Duration `env:"LOCKOUT_MAX_DURATION" env-default:"1h"`
+}
+
+// Password представляет конфигурацию политики паролей и сброса пароля
+type Password struct {
+	MinLength      int           `env:"PASSWORD_MIN_LENGTH" env-default:"8"`
+	MaxLength      int           `env:"PASSWORD_MAX_LENGTH" env-default:"72"`
+	RequireUpper   bool          `env:"PASSWORD_REQUIRE_UPPER" env-default:"true"`
+	RequireLower   bool          `env:"PASSWORD_REQUIRE_LOWER" env-default:"true"`
+	RequireDigit   bool          `env:"PASSWORD_REQUIRE_DIGIT" env-default:"true"`
+	RequireSpecial bool          `env:"PASSWORD_REQUIRE_SPECIAL" env-default:"false"`
+	CheckBreached  bool          `env:"PASSWORD_CHECK_BREACHED" env-default:"true"`
+	ResetTokenTTL  time.Duration `env:"PASSWORD_RESET_TOKEN_TTL" env-default:"1h"`
+	// Алгоритм для новых хешей (bcrypt или argon2id), старые хеши обновляются при входе
+	HashAlgorithm     string `env:"PASSWORD_HASH_ALGORITHM" env-default:"argon2id"`
+	BcryptCost        int    `env:"PASSWORD_BCRYPT_COST" env-default:"10"`
+	Argon2Memory      uint32 `env:"PASSWORD_ARGON2_MEMORY" env-default:"65536"`
+	Argon2Iterations  uint32 `env:"PASSWORD_ARGON2_ITERATIONS" env-default:"3"`
+	Argon2Parallelism uint8  `env:"PASSWORD_ARGON2_PARALLELISM" env-default:"2"`
+}
+
+// OIDC представляет конфигурацию входа через корпоративного провайдера OpenID Connect
+type OIDC struct {
+	Enabled       bool     `env:"OIDC_ENABLED" env-default:"false"`
+	Issuer        string   `env:"OIDC_ISSUER"`
+	ClientID      string   `env:"OIDC_CLIENT_ID"`
+	ClientSecret  string   `env:"OIDC_CLIENT_SECRET"`
+	RedirectURL   string   `env:"OIDC_REDIRECT_URL"`
+	Scopes        []string `env:"OIDC_SCOPES" env-separator:"," env-default:"openid,profile,email"`
+	UsernameClaim string   `env:"OIDC_USERNAME_CLAIM" env-default:"preferred_username"`
+	StripDomain   bool     `env:"OIDC_USERNAME_STRIP_DOMAIN" env-default:"true"`
+	// AllowedDomains домены почты, из адресов которых допускается получать username
+	AllowedDomains []string      `env:"OIDC_ALLOWED_DOMAINS" env-separator:","`
+	StateTTL       time.Duration `env:"OIDC_STATE_TTL" env-default:"10m"`
+	Timeout        time.Duration `env:"OIDC_TIMEOUT" env-default:"5s"`
+}
+
+// TwoFactor представляет конфигурацию двухфакторной аутентификации
+type TwoFactor struct {
+	Issuer       string        `env:"TOTP_ISSUER" env-default:"Merch Store"`
+	ChallengeTTL time.Duration `env:"TOTP_CHALLENGE_TTL" env-default:"5m"`
+	MaxAttempts  int           `env:"TOTP_MAX_ATTEMPTS" env-default:"5"`
+	// Запрещает администраторам доступ к /api/admin без включенной двухфакторной аутентификации
+	RequireForAdmins bool `env:"TOTP_REQUIRE_FOR_ADMINS" env-default:"false"`
+}
+
+// Leaderboard представляет конфигурацию обновления рейтингов
+type Leaderboard struct {
+	RefreshInterval time.Duration `env:"LEADERBOARD_REFRESH_INTERVAL" env-default:"30s"`
+	BatchSize       int           `env:"LEADERBOARD_BATCH_SIZE" env-default:"1000"`
+}
+
+// Fraud представляет конфигурацию правил обнаружения мошенничества при переводах.
+// Действие правила: off, flag (выполнить и отметить), hold (задержать до проверки) или block (отклонить).
+type Fraud struct {
+	Enabled              bool          `env:"FRAUD_ENABLED" env-default:"true"`
+	VelocityWindow       time.Duration `env:"FRAUD_VELOCITY_WINDOW" env-default:"1h"`
+	VelocityMaxTransfers int           `env:"FRAUD_VELOCITY_MAX_TRANSFERS" env-default:"20"`
+	VelocityMaxAmount    int           `env:"FRAUD_VELOCITY_MAX_AMOUNT" env-default:"2000"`
+	VelocityAction       string        `env:"FRAUD_VELOCITY_ACTION" env-default:"hold"`
+	NewAccountAge        time.Duration `env:"FRAUD_NEW_ACCOUNT_AGE" env-default:"72h"`
+	FanInWindow          time.Duration `env:"FRAUD_FAN_IN_WINDOW" env-default:"24h"`
+	FanInMaxNewSenders   int           `env:"FRAUD_FAN_IN_MAX_NEW_SENDERS" env-default:"3"`
+	FanInAction          string        `env:"FRAUD_FAN_IN_ACTION" env-default:"hold"`
+	RoundTripWindow      time.Duration `env:"FRAUD_ROUND_TRIP_WINDOW" env-default:"24h"`
+	RoundTripAction      string        `env:"FRAUD_ROUND_TRIP_ACTION" env-default:"flag"`
+}
+
+// CoinExpiry представляет конфигурацию сгорания монет: none, rolling (через COIN_EXPIRY_MONTHS
+// после начисления) или year_end (в конце календарного года начисления)
+type CoinExpiry struct {
+	Policy    string        `env:"COIN_EXPIRY_POLICY" env-default:"none"`
+	Months    int           `env:"COIN_EXPIRY_MONTHS" env-default:"12"`
+	Interval  time.Duration `env:"COIN_EXPIRY_INTERVAL" env-default:"1h"`
+	BatchSize int           `env:"COIN_EXPIRY_BATCH_SIZE" env-default:"500"`
+}
+
+// Ledger представляет конфигурацию цепочек хешей переводов и покупок.
+// Ключ хранится вне базы данных, иначе доступ к ней позволяет пересчитать цепочку.
+type Ledger struct {
+	ChainKey      string        `env:"LEDGER_CHAIN_KEY"`
+	SealInterval  time.Duration `env:"LEDGER_SEAL_INTERVAL" env-default:"1s"`
+	SealBatchSize int           `env:"LEDGER_SEAL_BATCH_SIZE" env-default:"1000"`
+}
+
+// Audit представляет конфигурацию цепочки хешей журнала аудита
+type Audit struct {
+	ChainKey      string        `env:"AUDIT_CHAIN_KEY"`
+	SealInterval  time.Duration `env:"AUDIT_SEAL_INTERVAL" env-default:"1s"`
+	SealBatchSize int           `env:"AUDIT_SEAL_BATCH_SIZE" env-default:"1000"`
+}
+
 // MustLoad загружает конфигурацию
 func MustLoad() (*Config, error) {
 	cfg := &Config{}
@@ -51,8 +218,13 @@ func MustLoad() (*Config, error) {
 }
 
 func (c *Config) validate() error {
-	if c.ApiServerConfig.AuthSecretKey == "" {
-		return fmt.Errorf("API_SERVER_AUTH_SECRET_KEY is required")
+	if c.JWTConfig.KeysDir == "" || c.JWTConfig.AcceptLegacyHS256 {
+		if c.ApiServerConfig.AuthSecretKey == "" {
+			return fmt.Errorf("API_SERVER_AUTH_SECRET_KEY is required for HS256 tokens")
+		}
+	}
+	if c.JWTConfig.KeysDir != "" && c.JWTConfig.ActiveKeyID == "" {
+		return fmt.Errorf("JWT_ACTIVE_KEY_ID is required when JWT_KEYS_DIR is set")
 	}
 	if c.ApiServerConfig.Host == "" || c.ApiServerConfig.Port == "" {
 		return fmt.Errorf("API_SERVER_HOST and API_SERVER_PORT are required")
@@ -60,5 +232,114 @@ func (c *Config) validate() error {
 	if c.DatabaseConfig.Driver == "" || c.DatabaseConfig.Name == "" || c.DatabaseConfig.User == "" || c.DatabaseConfig.Password == "" {
 		return fmt.Errorf("database configuration is incomplete")
 	}
+	switch c.OutboxConfig.Publisher {
+	case "log":
+	case "webhook":
+		if c.OutboxConfig.WebhookURL == "" {
+			return fmt.Errorf("OUTBOX_WEBHOOK_URL is required for webhook publisher")
+		}
+	default:
+		return fmt.Errorf("unknown OUTBOX_PUBLISHER: %s", c.OutboxConfig.Publisher)
+	}
+	if c.OutboxConfig.PollInterval <= 0 || c.OutboxConfig.BatchSize <= 0 || c.OutboxConfig.Lease <= 0 {
+		return fmt.Errorf("OUTBOX_POLL_INTERVAL, OUTBOX_BATCH_SIZE and OUTBOX_LEASE must be positive")
+	}
+	if c.WebhookConfig.PollInterval <= 0 || c.WebhookConfig.BatchSize <= 0 || c.WebhookConfig.MaxAttempts <= 0 {
+		return fmt.Errorf("WEBHOOK_POLL_INTERVAL, WEBHOOK_BATCH_SIZE and WEBHOOK_MAX_ATTEMPTS must be positive")
+	}
+	if c.NotifyConfig.HeartbeatInterval <= 0 || c.NotifyConfig.BufferSize <= 0 {
+		return fmt.Errorf("NOTIFY_HEARTBEAT_INTERVAL and NOTIFY_BUFFER_SIZE must be positive")
+	}
+	if c.RateLimitConfig.Store != "memory" && c.RateLimitConfig.Store != "postgres" {
+		return fmt.Errorf("unknown RATE_LIMIT_STORE: %s", c.RateLimitConfig.Store)
+	}
+	if c.RateLimitConfig.AuthPerMinute <= 0 || c.RateLimitConfig.AuthBurst <= 0 ||
+		c.RateLimitConfig.UserPerMinute <= 0 || c.RateLimitConfig.UserBurst <= 0 {
+		return fmt.Errorf("rate limits must be positive")
+	}
+	if c.LockoutConfig.MaxUserFailures <= 0 || c.LockoutConfig.MaxIPFailures <= 0 || c.LockoutConfig.BaseDuration <= 0 {
+		return fmt.Errorf("lockout thresholds and duration must be positive")
+	}
+	if c.PasswordConfig.MinLength <= 0 || c.PasswordConfig.MaxLength < c.PasswordConfig.MinLength || c.PasswordConfig.MaxLength > 72 {
+		return fmt.Errorf("PASSWORD_MIN_LENGTH and PASSWORD_MAX_LENGTH must satisfy 0 < min <= max <= 72")
+	}
+	if c.PasswordConfig.ResetTokenTTL <= 0 {
+		return fmt.Errorf("PASSWORD_RESET_TOKEN_TTL must be positive")
+	}
+	if c.OIDCConfig.Enabled {
+		if c.OIDCConfig.Issuer == "" || c.OIDCConfig.ClientID == "" || c.OIDCConfig.RedirectURL == "" {
+			return fmt.Errorf("OIDC_ISSUER, OIDC_CLIENT_ID and OIDC_REDIRECT_URL are required when OIDC is enabled")
+		}
+		if c.OIDCConfig.StateTTL <= 0 {
+			return fmt.Errorf("OIDC_STATE_TTL must be positive")
+		}
+		if c.OIDCConfig.StripDomain && len(c.OIDCConfig.AllowedDomains) == 0 {
+			return fmt.Errorf("OIDC_ALLOWED_DOMAINS is required when OIDC_USERNAME_STRIP_DOMAIN is enabled")
+		}
+	}
+	if c.TwoFactorConfig.ChallengeTTL <= 0 || c.TwoFactorConfig.MaxAttempts <= 0 {
+		return fmt.Errorf("TOTP_CHALLENGE_TTL and TOTP_MAX_ATTEMPTS must be positive")
+	}
+	if c.LeaderboardConfig.RefreshInterval <= 0 || c.LeaderboardConfig.BatchSize <= 0 {
+		return fmt.Errorf("LEADERBOARD_REFRESH_INTERVAL and LEADERBOARD_BATCH_SIZE must be positive")
+	}
+	if c.FraudConfig.VelocityWindow <= 0 || c.FraudConfig.NewAccountAge <= 0 || c.FraudConfig.FanInWindow <= 0 || c.FraudConfig.RoundTripWindow <= 0 {
+		return fmt.Errorf("FRAUD_*_WINDOW and FRAUD_NEW_ACCOUNT_AGE must be positive")
+	}
+	if c.FraudConfig.VelocityMaxTransfers <= 0 || c.FraudConfig.VelocityMaxAmount <= 0 || c.FraudConfig.FanInMaxNewSenders <= 0 {
+		return fmt.Errorf("FRAUD_VELOCITY_MAX_TRANSFERS, FRAUD_VELOCITY_MAX_AMOUNT and FRAUD_FAN_IN_MAX_NEW_SENDERS must be positive")
+	}
+	for name, action := range map[string]string{
+		"FRAUD_VELOCITY_ACTION":   c.FraudConfig.VelocityAction,
+		"FRAUD_FAN_IN_ACTION":     c.FraudConfig.FanInAction,
+		"FRAUD_ROUND_TRIP_ACTION": c.FraudConfig.RoundTripAction,
+	} {
+		switch action {
+		case "off", "flag", "hold", "block":
+		default:
+			return fmt.Errorf("unknown %s: %s", name, action)
+		}
+	}
+	switch c.CoinExpiryConfig.Policy {
+	case "none", "year_end":
+	case "rolling":
+		if c.CoinExpiryConfig.Months <= 0 {
+			return fmt.Errorf("COIN_EXPIRY_MONTHS must be positive for rolling policy")
+		}
+	default:
+		return fmt.Errorf("unknown COIN_EXPIRY_POLICY: %s", c.CoinExpiryConfig.Policy)
+	}
+	if c.CoinExpiryConfig.Interval <= 0 || c.CoinExpiryConfig.BatchSize <= 0 {
+		return fmt.Errorf("COIN_EXPIRY_INTERVAL and COIN_EXPIRY_BATCH_SIZE must be positive")
+	}
+	if len(c.LedgerConfig.ChainKey) < 32 {
+		return fmt.Errorf("LEDGER_CHAIN_KEY must be at least 32 characters")
+	}
+	if c.LedgerConfig.SealInterval <= 0 || c.LedgerConfig.SealBatchSize <= 0 {
+		return fmt.Errorf("LEDGER_SEAL_INTERVAL and LEDGER_SEAL_BATCH_SIZE must be positive")
+	}
+	if len(c.AuditConfig.ChainKey) < 32 {
+		return fmt.Errorf("AUDIT_CHAIN_KEY must be at least 32 characters")
+	}
+	if c.AuditConfig.SealInterval <= 0 || c.AuditConfig.SealBatchSize <= 0 {
+		return fmt.Errorf("AUDIT_SEAL_INTERVAL and AUDIT_SEAL_BATCH_SIZE must be positive")
+	}
+	switch c.PasswordConfig.HashAlgorithm {
+	case "bcrypt":
+		if c.PasswordConfig.BcryptCost < 4 || c.PasswordConfig.BcryptCost > 31 {
+			return fmt.Errorf("PASSWORD_BCRYPT_COST must be between 4 and 31")
+		}
+	case "argon2id":
+		if c.PasswordConfig.Argon2Memory < 8*uint32(c.PasswordConfig.Argon2Parallelism) ||
+			c.PasswordConfig.Argon2Iterations == 0 || c.PasswordConfig.Argon2Parallelism == 0 {
+			return fmt.Errorf("argon2 parameters are invalid: memory must be at least 8*parallelism KiB")
+		}
+		// Хеши с большими параметрами не будут приняты при входе
+		if c.PasswordConfig.Argon2Memory > 1<<20 || c.PasswordConfig.Argon2Iterations > 100 {
+			return fmt.Errorf("argon2 parameters are invalid: memory must be at most 1048576 KiB and iterations at most 100")
+		}
+	default:
+		return fmt.Errorf("unknown PASSWORD_HASH_ALGORITHM: %s", c.PasswordConfig.HashAlgorithm)
+	}
 	return nil
 }
@@ -10,26 +10,41 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
+	"sync"
 	"syscall"
 	"time"
 
 	"API-Avito-shop/config"
 	"API-Avito-shop/internal/delivery"
+	"API-Avito-shop/internal/events"
 	"API-Avito-shop/internal/middleware"
+	"API-Avito-shop/internal/models"
+	"API-Avito-shop/internal/oidc"
+	"API-Avito-shop/internal/ratelimit"
 	"API-Avito-shop/internal/repositories"
 	"API-Avito-shop/internal/services"
+	"API-Avito-shop/internal/utils/jwks"
 	"API-Avito-shop/internal/utils/logger"
+	"API-Avito-shop/internal/utils/password"
 	_ "API-Avito-shop/internal/utils/validation"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// worker представляет фоновый процесс, работающий до отмены контекста
+type worker interface {
+	Run(ctx context.Context)
+}
+
 type App struct {
 	dbPool    *pgxpool.Pool
 	config    *config.Config
 	logger    *slog.Logger
 	apiServer *http.Server
+	workers   []worker
+	workersWg sync.WaitGroup
 }
 
 // New создает приложение
@@ -68,6 +83,15 @@ func (app *App) Run() error {
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
+	// Запускаем фоновые процессы
+	for _, w := range app.workers {
+		app.workersWg.Add(1)
+		go func() {
+			defer app.workersWg.Done()
+			w.Run(ctx)
+		}()
+	}
+
 	go func() {
 		app.logger.Info("API server started successfully", "address", app.apiServer.Addr)
 		if err := app.apiServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
@@ -95,6 +119,9 @@ func (app *App) shutdown() error {
 		}
 	}
 
+	// Дожидаемся завершения фоновых процессов
+	app.workersWg.Wait()
+
 	if app.dbPool != nil {
 		app.dbPool.Close()
 		app.logger.Info("Database connection closed successfully")
@@ -107,30 +134,188 @@ func (app *App) shutdown() error {
 // setupAPIServer настраивает HTTP-сервер
 func (app *App) setupAPIServer() error {
 	secretKey := app.config.ApiServerConfig.AuthSecretKey
-	token := services.NewToken(secretKey, app.logger)
+	jwtKeys, err := app.newJWTKeys()
+	if err != nil {
+		return fmt.Errorf("failed to load jwt keys: %w", err)
+	}
+	token := services.NewToken(jwtKeys, app.logger)
 
 	// Инициализация репозитория
 	userRepo := repositories.NewUserRepository(app.dbPool, app.logger)
 	shopRepo := repositories.NewShopRepository(app.dbPool, app.logger)
 	transactionRepo := repositories.NewTransactionRepository(app.dbPool, app.logger)
+	outboxRepo := repositories.NewOutboxRepository(app.dbPool, app.logger)
+	webhookRepo := repositories.NewWebhookRepository(app.dbPool, app.logger)
+	notificationRepo := repositories.NewNotificationRepository(app.dbPool, app.logger)
+	loginAttemptRepo := repositories.NewLoginAttemptRepository(app.dbPool, app.logger)
+	passwordResetRepo := repositories.NewPasswordResetRepository(app.dbPool, app.logger)
+	apiKeyRepo := repositories.NewAPIKeyRepository(app.dbPool, app.logger)
+	twoFactorRepo := repositories.NewTwoFactorRepository(app.dbPool, app.logger)
+	auditRepo := repositories.NewAuditRepository(app.dbPool, app.logger)
+	profileRepo := repositories.NewProfileRepository(app.dbPool, app.logger)
+	leaderboardRepo := repositories.NewLeaderboardRepository(app.dbPool, app.logger)
+	statementRepo := repositories.NewStatementRepository(app.dbPool, app.logger)
+	reportRepo := repositories.NewReportRepository(app.dbPool, app.logger)
+	fraudRepo := repositories.NewFraudRepository(app.dbPool, app.logger)
+	coinLotRepo := repositories.NewCoinLotRepository(app.dbPool, app.logger)
+	pricingRepo := repositories.NewPricingRepository(app.dbPool, app.logger)
+	promoCodeRepo := repositories.NewPromoCodeRepository(app.dbPool, app.logger)
+	inventoryRepo := repositories.NewInventoryRepository(app.dbPool, app.logger)
 
 	// Инициализация сервисного слоя
 	txExecutor := services.NewTxExecutor(app.dbPool, app.logger)
-	userService := services.NewUserService(userRepo, shopRepo, transactionRepo, txExecutor, app.logger)
-	transactionService := services.NewTransactionService(userRepo, transactionRepo, txExecutor, app.logger)
-	shopService := services.NewShopService(userRepo, shopRepo, txExecutor, app.logger)
+	auditCfg := app.config.AuditConfig
+	auditService := services.NewAuditService(auditRepo, txExecutor, []byte(auditCfg.ChainKey), app.logger)
+	lockoutCfg := app.config.LockoutConfig
+	loginGuard := services.NewLoginGuard(loginAttemptRepo, outboxRepo, auditService, txExecutor, services.LockoutPolicy{
+		MaxUserFailures: lockoutCfg.MaxUserFailures,
+		MaxIPFailures:   lockoutCfg.MaxIPFailures,
+		FailureWindow:   lockoutCfg.FailureWindow,
+		BaseDuration:    lockoutCfg.BaseDuration,
+		MaxDuration:     lockoutCfg.MaxDuration,
+	}, app.logger)
+	passwordCfg := app.config.PasswordConfig
+	passwordPolicy := password.Policy{
+		MinLength:      passwordCfg.MinLength,
+		MaxLength:      passwordCfg.MaxLength,
+		RequireUpper:   passwordCfg.RequireUpper,
+		RequireLower:   passwordCfg.RequireLower,
+		RequireDigit:   passwordCfg.RequireDigit,
+		RequireSpecial: passwordCfg.RequireSpecial,
+		CheckBreached:  passwordCfg.CheckBreached,
+	}
+	passwordHasher := app.newPasswordHasher()
+	coinExpiryCfg := app.config.CoinExpiryConfig
+	expiryPolicy := services.CoinExpiryPolicy{Mode: coinExpiryCfg.Policy, Months: coinExpiryCfg.Months}
+	userService := services.NewUserService(userRepo, shopRepo, transactionRepo, outboxRepo, coinLotRepo, loginGuard, auditService, txExecutor,
+		passwordHasher, passwordPolicy, expiryPolicy, app.config.JWTConfig.StateCacheTTL, app.logger)
+	passwordService := services.NewPasswordService(userRepo, passwordResetRepo, outboxRepo, loginGuard, auditService, txExecutor,
+		passwordHasher, passwordPolicy, passwordCfg.ResetTokenTTL, app.logger)
+	apiKeyService := services.NewAPIKeyService(userRepo, apiKeyRepo, outboxRepo, passwordHasher, auditService, txExecutor, app.logger)
+	twoFactorCfg := app.config.TwoFactorConfig
+	twoFactorService := services.NewTwoFactorService(twoFactorRepo, userRepo, outboxRepo, loginGuard, auditService, txExecutor, services.TwoFactorPolicy{
+		Issuer:       twoFactorCfg.Issuer,
+		ChallengeTTL: twoFactorCfg.ChallengeTTL,
+		MaxAttempts:  twoFactorCfg.MaxAttempts,
+	}, app.logger)
+	fraudCfg := app.config.FraudConfig
+	fraudDetector := services.NewFraudDetector(fraudRepo, services.FraudPolicy{
+		Enabled:              fraudCfg.Enabled,
+		VelocityWindow:       fraudCfg.VelocityWindow,
+		VelocityMaxTransfers: fraudCfg.VelocityMaxTransfers,
+		VelocityMaxAmount:    fraudCfg.VelocityMaxAmount,
+		VelocityAction:       fraudCfg.VelocityAction,
+		NewAccountAge:        fraudCfg.NewAccountAge,
+		FanInWindow:          fraudCfg.FanInWindow,
+		FanInMaxNewSenders:   fraudCfg.FanInMaxNewSenders,
+		FanInAction:          fraudCfg.FanInAction,
+		RoundTripWindow:      fraudCfg.RoundTripWindow,
+		RoundTripAction:      fraudCfg.RoundTripAction,
+	}, app.logger)
+	transactionService := services.NewTransactionService(userRepo, transactionRepo, outboxRepo, notificationRepo, fraudRepo, coinLotRepo, fraudDetector,
+		auditService, txExecutor, app.logger)
+	fraudReviewService := services.NewFraudReviewService(fraudRepo, transactionService, auditService, txExecutor, app.logger)
+	pricingService := services.NewPricingService(pricingRepo, shopRepo, auditService, txExecutor, app.logger)
+	promoCodeService := services.NewPromoCodeService(promoCodeRepo, userRepo, shopRepo, auditService, txExecutor, app.logger)
+	shopService := services.NewShopService(userRepo, shopRepo, coinLotRepo, inventoryRepo, pricingService, promoCodeService, outboxRepo,
+		notificationRepo, auditService, txExecutor, app.logger)
+	inventoryService := services.NewInventoryService(inventoryRepo, userRepo, shopRepo, notificationRepo, auditService, txExecutor, app.logger)
+	userManagementService := services.NewUserManagementService(userRepo, apiKeyRepo, webhookRepo, outboxRepo, userService, auditService, txExecutor, app.logger)
+	profileService := services.NewProfileService(profileRepo, app.logger)
+	webhookService := services.NewWebhookService(userRepo, webhookRepo, txExecutor, app.logger)
+	ledgerCfg := app.config.LedgerConfig
+	ledgerService := services.NewLedgerService(transactionRepo, shopRepo, []byte(ledgerCfg.ChainKey), app.logger)
+	leaderboardService := services.NewLeaderboardService(leaderboardRepo, app.logger)
+	statementService := services.NewStatementService(statementRepo, app.logger)
+	reportService := services.NewReportService(reportRepo, app.logger)
+
+	// Инициализация фоновых процессов
+	outboxCfg := app.config.OutboxConfig
+	publisher := events.MultiPublisher{webhookService, app.newPublisher()}
+	outboxRelay := services.NewOutboxRelay(outboxRepo, publisher, txExecutor,
+		outboxCfg.PollInterval, outboxCfg.BatchSize, outboxCfg.Lease, outboxCfg.RetryDelay, outboxCfg.MaxRetryDelay, app.logger)
+
+	webhookCfg := app.config.WebhookConfig
+	webhookDispatcher := services.NewWebhookDispatcher(webhookRepo, txExecutor, webhookCfg.Timeout, webhookCfg.PollInterval,
+		webhookCfg.BatchSize, webhookCfg.MaxAttempts, webhookCfg.RetryDelay, webhookCfg.MaxRetryDelay, webhookCfg.AllowPrivateTargets, app.logger)
+
+	notifyCfg := app.config.NotifyConfig
+	notificationHub := services.NewNotificationHub(notifyCfg.BufferSize, app.logger)
+	notificationListener := services.NewNotificationListener(notificationRepo, notificationHub, notifyCfg.ReconnectDelay, app.logger)
+	leaderboardCfg := app.config.LeaderboardConfig
+	leaderboardRefresher := services.NewLeaderboardRefresher(leaderboardRepo, txExecutor, leaderboardCfg.RefreshInterval, leaderboardCfg.BatchSize, app.logger)
+	app.workers = append(app.workers, outboxRelay, webhookDispatcher, notificationListener, leaderboardRefresher,
+		services.NewChainSealer("transactions", transactionRepo, []byte(ledgerCfg.ChainKey), txExecutor,
+			ledgerCfg.SealInterval, ledgerCfg.SealBatchSize, app.logger),
+		services.NewChainSealer("purchases", shopRepo, []byte(ledgerCfg.ChainKey), txExecutor,
+			ledgerCfg.SealInterval, ledgerCfg.SealBatchSize, app.logger),
+		services.NewChainSealer("audit", auditRepo, []byte(auditCfg.ChainKey), txExecutor,
+			auditCfg.SealInterval, auditCfg.SealBatchSize, app.logger))
+	if expiryPolicy.Mode != models.ExpiryNone {
+		app.workers = append(app.workers, services.NewCoinExpiryJob(coinLotRepo, outboxRepo, notificationRepo, auditService, txExecutor,
+			expiryPolicy, coinExpiryCfg.Interval, coinExpiryCfg.BatchSize, app.logger))
+	}
 
 	// Инициализация обработчиков
-	userHandler := delivery.NewUserHandler(userService, token)
-	transactionHandler := delivery.NewTransactionHandler(transactionService)
-	shopHandler := delivery.NewShopHandler(shopService)
+	handlers := Handlers{
+		User:         delivery.NewUserHandler(userService, twoFactorService, token),
+		Transaction:  delivery.NewTransactionHandler(transactionService),
+		Shop:         delivery.NewShopHandler(shopService),
+		Webhook:      delivery.NewWebhookHandler(webhookService),
+		Notification: delivery.NewNotificationHandler(notificationHub, notifyCfg.HeartbeatInterval),
+		Admin:        delivery.NewAdminHandler(loginGuard, userManagementService),
+		Password:     delivery.NewPasswordHandler(passwordService, token),
+		JWKS:         delivery.NewJWKSHandler(jwtKeys),
+		Service:      delivery.NewServiceAccountHandler(apiKeyService),
+		TwoFactor:    delivery.NewTwoFactorHandler(twoFactorService, token),
+		Audit:        delivery.NewAuditHandler(auditService),
+		Ledger:       delivery.NewLedgerHandler(ledgerService),
+		Profile:      delivery.NewProfileHandler(profileService),
+		Leaderboard:  delivery.NewLeaderboardHandler(leaderboardService),
+		Statement:    delivery.NewStatementHandler(statementService),
+		Report:       delivery.NewReportHandler(reportService),
+		Fraud:        delivery.NewFraudHandler(fraudReviewService),
+		Pricing:      delivery.NewPricingHandler(pricingService),
+		PromoCode:    delivery.NewPromoCodeHandler(promoCodeService),
+		Inventory:    delivery.NewInventoryHandler(inventoryService),
+	}
+	if oidcCfg := app.config.OIDCConfig; oidcCfg.Enabled {
+		provider := oidc.NewProvider(oidc.Config{
+			Issuer:       oidcCfg.Issuer,
+			ClientID:     oidcCfg.ClientID,
+			ClientSecret: oidcCfg.ClientSecret,
+			RedirectURL:  oidcCfg.RedirectURL,
+			Scopes:       oidcCfg.Scopes,
+		}, oidcCfg.Timeout, app.logger)
+		oidcRepo := repositories.NewOIDCRepository(app.dbPool, app.logger)
+		oidcService := services.NewOIDCService(provider, oidcRepo, userRepo, outboxRepo, auditService, txExecutor, passwordHasher, services.IdentityMapping{
+			UsernameClaim:  oidcCfg.UsernameClaim,
+			StripDomain:    oidcCfg.StripDomain,
+			AllowedDomains: oidcCfg.AllowedDomains,
+		}, oidcCfg.StateTTL, app.logger)
+		secureCookie := strings.HasPrefix(oidcCfg.RedirectURL, "https://")
+		handlers.OIDC = delivery.NewOIDCHandler(oidcService, twoFactorService, token, oidcCfg.StateTTL, secureCookie)
+	}
 
 	// Инициализация middleware
-	authMiddleware := middleware.NewAuthMiddleware(token, secretKey, app.logger)
+	rateLimitCfg := app.config.RateLimitConfig
+	rateLimitStore := app.newRateLimitStore()
+	app.workers = append(app.workers, ratelimit.NewJanitor(rateLimitStore, rateLimitCfg.CleanupInterval, time.Hour, app.logger))
+
+	middlewares := Middlewares{
+		Auth:  middleware.NewAuthMiddleware(token, userService, apiKeyService, secretKey, app.logger),
+		Admin: middleware.NewAdminMiddleware(userService, twoFactorService, twoFactorCfg.RequireForAdmins, app.logger),
+		RateLimit: middleware.NewRateLimitMiddleware(rateLimitStore, rateLimitCfg.Enabled,
+			ratelimit.PerMinute(rateLimitCfg.AuthPerMinute, rateLimitCfg.AuthBurst),
+			ratelimit.PerMinute(rateLimitCfg.UserPerMinute, rateLimitCfg.UserBurst), app.logger),
+	}
 
 	// Настройка маршрутов API
 	router := gin.Default()
-	app.RegisterRoutes(router, userHandler, transactionHandler, shopHandler, authMiddleware)
+	if err := router.SetTrustedProxies(app.config.ApiServerConfig.TrustedProxies); err != nil {
+		return fmt.Errorf("invalid trusted proxies: %w", err)
+	}
+	app.RegisterRoutes(router, handlers, middlewares)
 
 	// Формируем адрес для сервера из конфигурации
 	host := app.config.ApiServerConfig.Host
@@ -140,10 +325,67 @@ func (app *App) setupAPIServer() error {
 		Addr:    addr,
 		Handler: router,
 	}
+	// Закрываем потоки уведомлений, иначе остановка сервера будет ждать их завершения
+	app.apiServer.RegisterOnShutdown(notificationHub.Close)
 
 	return nil
 }
 
+// newPublisher создает издателя доменных событий согласно конфигурации
+func (app *App) newPublisher() events.Publisher {
+	outboxCfg := app.config.OutboxConfig
+	if outboxCfg.Publisher == "webhook" {
+		return events.NewWebhookPublisher(outboxCfg.WebhookURL, outboxCfg.WebhookTimeout, app.logger)
+	}
+	return events.NewLogPublisher(app.logger)
+}
+
+// newRateLimitStore создает хранилище лимитов согласно конфигурации
+func (app *App) newRateLimitStore() ratelimit.Store {
+	if app.config.RateLimitConfig.Store == "postgres" {
+		return repositories.NewRateLimitRepository(app.dbPool, app.logger)
+	}
+	return ratelimit.NewMemoryStore()
+}
+
+// newJWTKeys загружает ключи подписи токенов согласно конфигурации
+func (app *App) newJWTKeys() (*jwks.KeySet, error) {
+	jwtCfg := app.config.JWTConfig
+	if jwtCfg.KeysDir == "" {
+		return jwks.NewHMAC(app.config.ApiServerConfig.AuthSecretKey), nil
+	}
+
+	keys, err := jwks.LoadDir(jwtCfg.KeysDir, jwtCfg.ActiveKeyID)
+	if err != nil {
+		return nil, err
+	}
+	if jwtCfg.AcceptLegacyHS256 {
+		keys.AcceptHMAC(app.config.ApiServerConfig.AuthSecretKey)
+	}
+
+	app.logger.Info("JWT signing keys loaded", "active_kid", jwtCfg.ActiveKeyID, "keys", len(keys.Document().Keys))
+	return keys, nil
+}
+
+// newPasswordHasher создает хешер паролей согласно конфигурации.
+// Хеши обоих алгоритмов проверяются всегда, чтобы смена алгоритма не требовала сброса паролей.
+func (app *App) newPasswordHasher() *services.DefaultPasswordHasher {
+	passwordCfg := app.config.PasswordConfig
+	bcryptAlg := password.Bcrypt{Cost: passwordCfg.BcryptCost}
+	argon2Alg := password.Argon2id{
+		Memory:      passwordCfg.Argon2Memory,
+		Iterations:  passwordCfg.Argon2Iterations,
+		Parallelism: passwordCfg.Argon2Parallelism,
+		SaltLength:  16,
+		KeyLength:   32,
+	}
+
+	if passwordCfg.HashAlgorithm == "bcrypt" {
+		return services.NewPasswordHasher(bcryptAlg, []password.Algorithm{argon2Alg}, app.logger)
+	}
+	return services.NewPasswordHasher(argon2Alg, []password.Algorithm{bcryptAlg}, app.logger)
+}
+
 // newDBConn устанавливает подключение к базе данных с использованием строки подключения
 func newDBConn(dbcfg *config.Database) (*pgxpool.Pool, error) {
 	// Получаем строку подключения
@@ -3,21 +3,156 @@ package app
 import (
 	h "API-Avito-shop/internal/delivery"
 	"API-Avito-shop/internal/middleware"
+	"API-Avito-shop/internal/models"
 
 	"github.com/gin-gonic/gin"
 )
 
-func (app *App) RegisterRoutes(r *gin.Engine, userHandler *h.UserHandler, coinHandler *h.TransactionHandler, shopHandler *h.ShopHandler, authMiddleware *middleware.AuthMiddleware) {
+// Handlers объединяет обработчики HTTP-запросов приложения
+type Handlers struct {
+	User         *h.UserHandler
+	Transaction  *h.TransactionHandler
+	Shop         *h.ShopHandler
+	Webhook      *h.WebhookHandler
+	Notification *h.NotificationHandler
+	Admin        *h.AdminHandler
+	Password     *h.PasswordHandler
+	JWKS         *h.JWKSHandler
+	Service      *h.ServiceAccountHandler
+	TwoFactor    *h.TwoFactorHandler
+	Audit        *h.AuditHandler
+	Ledger       *h.LedgerHandler
+	Profile      *h.ProfileHandler
+	Leaderboard  *h.LeaderboardHandler
+	Statement    *h.StatementHandler
+	Report       *h.ReportHandler
+	Fraud        *h.FraudHandler
+	Pricing      *h.PricingHandler
+	PromoCode    *h.PromoCodeHandler
+	Inventory    *h.InventoryHandler
+	// OIDC равен nil, если вход через провайдера отключен
+	OIDC *h.OIDCHandler
+}
+
+// Middlewares объединяет промежуточные обработчики приложения
+type Middlewares struct {
+	Auth      *middleware.AuthMiddleware
+	Admin     *middleware.AdminMiddleware
+	RateLimit *middleware.RateLimitMiddleware
+}
+
+func (app *App) RegisterRoutes(r *gin.Engine, handlers Handlers, middlewares Middlewares) {
+	r.Use(middleware.RequestMeta())
+
+	r.GET("/.well-known/jwks.json", handlers.JWKS.JWKSHandler)
+
 	users := r.Group("/api")
 	{
-		users.POST("/auth", userHandler.AuthHandler)
+		users.POST("/auth", middlewares.RateLimit.AuthRateLimit(), handlers.User.AuthHandler)
+		users.POST("/auth/2fa", middlewares.RateLimit.AuthRateLimit(), handlers.TwoFactor.LoginHandler)
+		users.POST("/password/reset", middlewares.RateLimit.AuthRateLimit(), handlers.Password.ResetPasswordHandler)
+	}
+
+	if handlers.OIDC != nil {
+		users.GET("/auth/oidc/login", middlewares.RateLimit.AuthRateLimit(), handlers.OIDC.LoginHandler)
+		users.GET("/auth/oidc/callback", middlewares.RateLimit.AuthRateLimit(), handlers.OIDC.CallbackHandler)
+	}
+
+	private := users.Group("/", middlewares.Auth.AuthMiddleware(), middlewares.RateLimit.UserRateLimit())
+
+	// Маршруты, доступные API-ключам с соответствующей областью доступа
+	{
+		private.GET("/info", middlewares.Auth.RequireScope(models.ScopeShopRead), handlers.User.InfoHandler)
+		private.POST("/sendCoin", middlewares.Auth.RequireScope(models.ScopeCoinsSend), handlers.Transaction.SendCoinHandler)
+		private.GET("/shop/items", middlewares.Auth.RequireScope(models.ScopeShopRead), handlers.Pricing.CatalogHandler)
+		private.GET("/buy/:item", middlewares.Auth.RequireScope(models.ScopeShopBuy), handlers.Shop.BuyHandler)
+		private.POST("/gift", middlewares.Auth.RequireScope(models.ScopeShopBuy), handlers.Shop.GiftHandler)
+		private.GET("/events", middlewares.Auth.RequireScope(models.ScopeEventsRead), handlers.Notification.EventsHandler)
+	}
+
+	// Маршруты только для пользователей
+	userOnly := private.Group("/", middlewares.Auth.RequireUser())
+	{
+		userOnly.POST("/password", handlers.Password.ChangePasswordHandler)
+		userOnly.GET("/profile", handlers.Profile.GetOwnProfileHandler)
+		userOnly.PUT("/profile", handlers.Profile.UpdateProfileHandler)
+		userOnly.GET("/leaderboard", handlers.Leaderboard.LeaderboardHandler)
+		userOnly.GET("/statement", handlers.Statement.StatementHandler)
+		userOnly.GET("/users/search", handlers.Profile.SearchUsersHandler)
+		userOnly.GET("/users/recent-recipients", handlers.Profile.RecentRecipientsHandler)
+		userOnly.GET("/users/:username/profile", handlers.Profile.GetProfileHandler)
+	}
+
+	if handlers.OIDC != nil {
+		userOnly.POST("/auth/oidc/link", handlers.OIDC.LinkHandler)
+	}
+
+	inventory := userOnly.Group("/inventory")
+	{
+		inventory.POST("/give", handlers.Inventory.GiveItemsHandler)
+		inventory.GET("/transfers", handlers.Inventory.ListTransfersHandler)
+	}
+
+	trades := userOnly.Group("/trades")
+	{
+		trades.POST("", handlers.Inventory.ProposeTradeHandler)
+		trades.GET("", handlers.Inventory.ListTradesHandler)
+		trades.GET("/:id", handlers.Inventory.GetTradeHandler)
+		trades.POST("/:id/accept", handlers.Inventory.AcceptTradeHandler)
+		trades.POST("/:id/decline", handlers.Inventory.DeclineTradeHandler)
+		trades.POST("/:id/cancel", handlers.Inventory.CancelTradeHandler)
 	}
 
-	private := users.Group("/", authMiddleware.AuthMiddleware())
+	twoFactor := userOnly.Group("/2fa")
+	{
+		twoFactor.POST("/enroll", handlers.TwoFactor.EnrollHandler)
+		twoFactor.POST("/confirm", handlers.TwoFactor.ConfirmHandler)
+		twoFactor.POST("/disable", handlers.TwoFactor.DisableHandler)
+		twoFactor.POST("/recovery-codes", handlers.TwoFactor.RecoveryCodesHandler)
+	}
+
+	webhooks := userOnly.Group("/webhooks")
+	{
+		webhooks.POST("", handlers.Webhook.CreateWebhookHandler)
+		webhooks.GET("", handlers.Webhook.ListWebhooksHandler)
+		webhooks.DELETE("/:id", handlers.Webhook.DeleteWebhookHandler)
+		webhooks.GET("/:id/deliveries", handlers.Webhook.ListDeliveriesHandler)
+		webhooks.POST("/:id/deliveries/:deliveryId/retry", handlers.Webhook.RetryDeliveryHandler)
+	}
 
+	admin := userOnly.Group("/admin", middlewares.Admin.AdminMiddleware())
 	{
-		private.GET("/info", userHandler.InfoHandler)
-		private.POST("/sendCoin", coinHandler.SendCoinHandler)
-		private.GET("/buy/:item", shopHandler.BuyHandler)
+		admin.GET("/users", handlers.Admin.SearchUsersHandler)
+		admin.GET("/users/:username", handlers.Admin.GetUserHandler)
+		admin.POST("/users/:username/disable", handlers.Admin.DisableUserHandler)
+		admin.POST("/users/:username/enable", handlers.Admin.EnableUserHandler)
+		admin.DELETE("/users/:username", handlers.Admin.DeleteUserHandler)
+		admin.POST("/users/:username/unlock", handlers.Admin.UnlockUserHandler)
+		admin.POST("/users/:username/password-reset", handlers.Password.CreateResetTokenHandler)
+		admin.POST("/service-accounts", handlers.Service.CreateServiceAccountHandler)
+		admin.GET("/service-accounts", handlers.Service.ListServiceAccountsHandler)
+		admin.POST("/service-accounts/:username/keys", handlers.Service.CreateKeyHandler)
+		admin.GET("/service-accounts/:username/keys", handlers.Service.ListKeysHandler)
+		admin.DELETE("/service-accounts/:username/keys/:id", handlers.Service.RevokeKeyHandler)
+		admin.GET("/audit", handlers.Audit.ListAuditHandler)
+		admin.GET("/audit/export", handlers.Audit.ExportAuditHandler)
+		admin.GET("/audit/verify", handlers.Audit.VerifyAuditHandler)
+		admin.GET("/ledger/verify", handlers.Ledger.VerifyLedgerHandler)
+		admin.GET("/reports/economy", handlers.Report.EconomyReportHandler)
+		admin.GET("/fraud/reviews", handlers.Fraud.ListReviewsHandler)
+		admin.GET("/fraud/reviews/:id", handlers.Fraud.GetReviewHandler)
+		admin.POST("/fraud/reviews/:id/approve", handlers.Fraud.ApproveReviewHandler)
+		admin.POST("/fraud/reviews/:id/reject", handlers.Fraud.RejectReviewHandler)
+		admin.GET("/products/:item/prices", handlers.Pricing.ListPriceChangesHandler)
+		admin.POST("/products/:item/prices", handlers.Pricing.SchedulePriceHandler)
+		admin.DELETE("/products/:item/prices/:id", handlers.Pricing.CancelPriceChangeHandler)
+		admin.GET("/promotions", handlers.Pricing.ListPromotionsHandler)
+		admin.POST("/promotions", handlers.Pricing.CreatePromotionHandler)
+		admin.DELETE("/promotions/:id", handlers.Pricing.CancelPromotionHandler)
+		admin.GET("/promo-codes", handlers.PromoCode.ListCodesHandler)
+		admin.POST("/promo-codes", handlers.PromoCode.CreateCodeHandler)
+		admin.GET("/promo-codes/:id", handlers.PromoCode.GetCodeHandler)
+		admin.GET("/promo-codes/:id/redemptions", handlers.PromoCode.ListRedemptionsHandler)
+		admin.DELETE("/promo-codes/:id", handlers.PromoCode.DisableCodeHandler)
 	}
 }
@@ -0,0 +1,38 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"API-Avito-shop/config"
+	"API-Avito-shop/internal/dto"
+	"API-Avito-shop/internal/repositories"
+	"API-Avito-shop/internal/services"
+	"API-Avito-shop/internal/utils/logger"
+)
+
+// VerifyLedger проверяет цепочки хешей переводов и покупок без запуска API-сервера
+func VerifyLedger(ctx context.Context) (dto.LedgerVerification, error) {
+	cfg, err := config.MustLoad()
+	if err != nil {
+		return dto.LedgerVerification{}, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Результат выводится в stdout, поэтому логируются только ошибки
+	log := logger.InitLogger(slog.LevelError)
+
+	pool, err := newDBConn(&cfg.DatabaseConfig)
+	if err != nil {
+		return dto.LedgerVerification{}, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer pool.Close()
+
+	ledgerService := services.NewLedgerService(
+		repositories.NewTransactionRepository(pool, log),
+		repositories.NewShopRepository(pool, log),
+		[]byte(cfg.LedgerConfig.ChainKey),
+		log,
+	)
+	return ledgerService.VerifyLedger(ctx)
+}
@@ -0,0 +1,148 @@
+package delivery
+
+import (
+	"errors"
+	"net/http"
+
+	"API-Avito-shop/internal/dto"
+	e "API-Avito-shop/internal/errors"
+	s "API-Avito-shop/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AdminHandler struct {
+	loginGuard            s.LoginGuard
+	userManagementService s.UserManagementService
+}
+
+func NewAdminHandler(loginGuard s.LoginGuard, userManagementService s.UserManagementService) *AdminHandler {
+	return &AdminHandler{
+		loginGuard:            loginGuard,
+		userManagementService: userManagementService,
+	}
+}
+
+// UnlockUserHandler обрабатывает запрос администратора на снятие блокировки входа
+func (h *AdminHandler) UnlockUserHandler(c *gin.Context) {
+	admin, err := getUsername(c)
+	if err != nil {
+		handleError(c, http.StatusBadRequest, "Failed to get user_id from context", err)
+		return
+	}
+
+	var query dto.UnlockQuery
+	if err = c.ShouldBindQuery(&query); err != nil {
+		handleError(c, http.StatusBadRequest, "Invalid query parameters", err)
+		return
+	}
+
+	if err = h.loginGuard.Unlock(c.Request.Context(), admin, c.Param("username"), query.IP); err != nil {
+		handleError(c, http.StatusInternalServerError, "Failed to unlock user", err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// SearchUsersHandler обрабатывает запрос администратора на поиск пользователей
+func (h *AdminHandler) SearchUsersHandler(c *gin.Context) {
+	var query dto.UserSearchQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		handleError(c, http.StatusBadRequest, "Invalid query parameters", err)
+		return
+	}
+
+	limit, offset, err := getPagination(c)
+	if err != nil {
+		handleError(c, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	users, err := h.userManagementService.SearchUsers(c.Request.Context(), &query, limit, offset)
+	if err != nil {
+		handleError(c, http.StatusInternalServerError, "Failed to search users", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"users": users})
+}
+
+// GetUserHandler обрабатывает запрос администратора на просмотр профиля, баланса и истории пользователя
+func (h *AdminHandler) GetUserHandler(c *gin.Context) {
+	details, err := h.userManagementService.GetUserDetails(c.Request.Context(), c.Param("username"))
+	if err != nil {
+		h.handleUserManagementError(c, err, "Failed to get user")
+		return
+	}
+
+	c.JSON(http.StatusOK, details)
+}
+
+// DisableUserHandler обрабатывает запрос администратора на отключение учетной записи
+func (h *AdminHandler) DisableUserHandler(c *gin.Context) {
+	admin, err := getUsername(c)
+	if err != nil {
+		handleError(c, http.StatusBadRequest, "Failed to get user_id from context", err)
+		return
+	}
+
+	var disableDTO dto.DisableUser
+	if err = c.ShouldBindJSON(&disableDTO); err != nil {
+		handleError(c, http.StatusBadRequest, "Invalid request data", err)
+		return
+	}
+
+	if err = h.userManagementService.DisableUser(c.Request.Context(), admin, c.Param("username"), disableDTO.Reason); err != nil {
+		h.handleUserManagementError(c, err, "Failed to disable user")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// EnableUserHandler обрабатывает запрос администратора на включение учетной записи
+func (h *AdminHandler) EnableUserHandler(c *gin.Context) {
+	admin, err := getUsername(c)
+	if err != nil {
+		handleError(c, http.StatusBadRequest, "Failed to get user_id from context", err)
+		return
+	}
+
+	if err = h.userManagementService.EnableUser(c.Request.Context(), admin, c.Param("username")); err != nil {
+		h.handleUserManagementError(c, err, "Failed to enable user")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// DeleteUserHandler обрабатывает запрос администратора на удаление учетной записи
+func (h *AdminHandler) DeleteUserHandler(c *gin.Context) {
+	admin, err := getUsername(c)
+	if err != nil {
+		handleError(c, http.StatusBadRequest, "Failed to get user_id from context", err)
+		return
+	}
+
+	if err = h.userManagementService.DeleteUser(c.Request.Context(), admin, c.Param("username")); err != nil {
+		h.handleUserManagementError(c, err, "Failed to delete user")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// handleUserManagementError отправляет ответ в зависимости от ошибки сервиса управления пользователями
+func (h *AdminHandler) handleUserManagementError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, e.ErrInvalidUser):
+		handleError(c, http.StatusNotFound, "User not found", err)
+	case errors.Is(err, e.ErrForbidden):
+		handleError(c, http.StatusForbidden, "Admins cannot change status of their own account", err)
+	case errors.Is(err, e.ErrUserDeleted):
+		handleError(c, http.StatusConflict, "User is deleted", err)
+	default:
+		handleError(c, http.StatusInternalServerError, message, err)
+	}
+}
@@ -0,0 +1,131 @@
+package delivery
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"API-Avito-shop/internal/dto"
+	s "API-Avito-shop/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// auditCSVHeader заголовок выгрузки журнала в формате CSV
+var auditCSVHeader = []string{"id", "created_at", "actor", "action", "target", "amount", "details", "ip", "user_agent", "request_id", "prev_hash", "hash"}
+
+type AuditHandler struct {
+	auditService s.AuditService
+}
+
+func NewAuditHandler(auditService s.AuditService) *AuditHandler {
+	return &AuditHandler{
+		auditService: auditService,
+	}
+}
+
+// ListAuditHandler обрабатывает запрос администратора на просмотр журнала аудита
+func (h *AuditHandler) ListAuditHandler(c *gin.Context) {
+	var query dto.AuditQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		handleError(c, http.StatusBadRequest, "Invalid query parameters", err)
+		return
+	}
+
+	limit, offset, err := getPagination(c)
+	if err != nil {
+		handleError(c, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	entries, err := h.auditService.ListEntries(c.Request.Context(), &query, limit, offset)
+	if err != nil {
+		handleError(c, http.StatusInternalServerError, "Failed to list audit entries", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"entries": entries})
+}
+
+// ExportAuditHandler обрабатывает запрос администратора на выгрузку журнала в формате CSV или NDJSON
+func (h *AuditHandler) ExportAuditHandler(c *gin.Context) {
+	admin, err := getUsername(c)
+	if err != nil {
+		handleError(c, http.StatusBadRequest, "Failed to get user_id from context", err)
+		return
+	}
+
+	var query dto.AuditQuery
+	if err = c.ShouldBindQuery(&query); err != nil {
+		handleError(c, http.StatusBadRequest, "Invalid query parameters", err)
+		return
+	}
+
+	var write func(dto.AuditEntry) error
+	switch format := c.DefaultQuery("format", "csv"); format {
+	case "csv":
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		c.Header("Content-Disposition", `attachment; filename="audit.csv"`)
+		w := csv.NewWriter(c.Writer)
+		defer w.Flush()
+		if err = w.Write(auditCSVHeader); err != nil {
+			return
+		}
+		write = func(entry dto.AuditEntry) error {
+			return w.Write(auditCSVRecord(entry))
+		}
+	case "json":
+		c.Header("Content-Type", "application/x-ndjson")
+		c.Header("Content-Disposition", `attachment; filename="audit.ndjson"`)
+		encoder := json.NewEncoder(c.Writer)
+		write = func(entry dto.AuditEntry) error {
+			return encoder.Encode(entry)
+		}
+	default:
+		handleError(c, http.StatusBadRequest, "format must be csv or json", nil)
+		return
+	}
+
+	c.Status(http.StatusOK)
+	if err = h.auditService.ExportEntries(c.Request.Context(), admin, &query, write); err != nil {
+		// Заголовки уже отправлены, поэтому ошибку можно только залогировать
+		slog.Error("Failed to export audit entries", "admin", admin, "error", err)
+	}
+}
+
+// VerifyAuditHandler обрабатывает запрос администратора на проверку целостности журнала
+func (h *AuditHandler) VerifyAuditHandler(c *gin.Context) {
+	result, err := h.auditService.VerifyChain(c.Request.Context())
+	if err != nil {
+		handleError(c, http.StatusInternalServerError, "Failed to verify audit log", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// auditCSVRecord преобразует запись журнала в строку CSV
+func auditCSVRecord(entry dto.AuditEntry) []string {
+	var amount string
+	if entry.Amount != nil {
+		amount = strconv.Itoa(*entry.Amount)
+	}
+
+	return []string{
+		strconv.FormatInt(entry.ID, 10),
+		entry.CreatedAt.Format(time.RFC3339Nano),
+		entry.Actor,
+		entry.Action,
+		entry.Target,
+		amount,
+		string(entry.Details),
+		entry.IP,
+		entry.UserAgent,
+		entry.RequestID,
+		entry.PrevHash,
+		entry.Hash,
+	}
+}
@@ -0,0 +1,119 @@
+package delivery
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"API-Avito-shop/internal/dto"
+	e "API-Avito-shop/internal/errors"
+	s "API-Avito-shop/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type FraudHandler struct {
+	fraudReviewService s.FraudReviewService
+}
+
+func NewFraudHandler(fraudReviewService s.FraudReviewService) *FraudHandler {
+	return &FraudHandler{
+		fraudReviewService: fraudReviewService,
+	}
+}
+
+// ListReviewsHandler обрабатывает запрос администратора на просмотр очереди проверки переводов
+func (h *FraudHandler) ListReviewsHandler(c *gin.Context) {
+	var query dto.FraudReviewQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		handleError(c, http.StatusBadRequest, "Invalid query parameters", err)
+		return
+	}
+
+	limit, offset, err := getPagination(c)
+	if err != nil {
+		handleError(c, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	reviews, err := h.fraudReviewService.ListReviews(c.Request.Context(), &query, limit, offset)
+	if err != nil {
+		handleError(c, http.StatusInternalServerError, "Failed to list fraud reviews", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"reviews": reviews})
+}
+
+// GetReviewHandler обрабатывает запрос администратора на просмотр перевода из очереди проверки
+func (h *FraudHandler) GetReviewHandler(c *gin.Context) {
+	id, err := getIDParam(c, "id")
+	if err != nil {
+		handleError(c, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	review, err := h.fraudReviewService.GetReview(c.Request.Context(), id)
+	if err != nil {
+		h.handleReviewError(c, err, "Failed to get fraud review")
+		return
+	}
+
+	c.JSON(http.StatusOK, review)
+}
+
+// ApproveReviewHandler обрабатывает решение администратора признать перевод законным
+func (h *FraudHandler) ApproveReviewHandler(c *gin.Context) {
+	h.resolveReview(c, h.fraudReviewService.ApproveReview, "Failed to approve fraud review")
+}
+
+// RejectReviewHandler обрабатывает решение администратора подтвердить подозрение
+func (h *FraudHandler) RejectReviewHandler(c *gin.Context) {
+	h.resolveReview(c, h.fraudReviewService.RejectReview, "Failed to reject fraud review")
+}
+
+// resolveReview извлекает администратора, идентификатор и комментарий и выполняет решение
+func (h *FraudHandler) resolveReview(c *gin.Context, resolve func(ctx context.Context, admin string, id int64, note string) error, message string) {
+	admin, err := getUsername(c)
+	if err != nil {
+		handleError(c, http.StatusBadRequest, "Failed to get user_id from context", err)
+		return
+	}
+
+	id, err := getIDParam(c, "id")
+	if err != nil {
+		handleError(c, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	var resolveDTO dto.ResolveFraudReview
+	if c.Request.ContentLength != 0 {
+		if err = c.ShouldBindJSON(&resolveDTO); err != nil {
+			handleError(c, http.StatusBadRequest, "Invalid request data", err)
+			return
+		}
+	}
+
+	if err = resolve(c.Request.Context(), admin, id, resolveDTO.Note); err != nil {
+		h.handleReviewError(c, err, message)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// handleReviewError отправляет ответ в зависимости от ошибки сервиса проверки переводов
+func (h *FraudHandler) handleReviewError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, e.ErrReviewNotFound):
+		handleError(c, http.StatusNotFound, "Fraud review not found", err)
+	case errors.Is(err, e.ErrReviewResolved):
+		handleError(c, http.StatusConflict, "Fraud review already resolved", err)
+	case errors.Is(err, e.ErrNotEnoughCoins):
+		handleError(c, http.StatusConflict, "Sender no longer has enough coins", err)
+	case errors.Is(err, e.ErrInvalidUser):
+		handleError(c, http.StatusConflict, "Recipient is no longer available", err)
+	default:
+		handleError(c, http.StatusInternalServerError, message, err)
+	}
+}
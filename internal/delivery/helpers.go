@@ -3,6 +3,12 @@ package delivery
 import (
 	"fmt"
 	"log/slog"
+	"net/http"
+	"strconv"
+
+	"API-Avito-shop/internal/dto"
+	"API-Avito-shop/internal/models"
+	s "API-Avito-shop/internal/services"
 
 	"github.com/gin-gonic/gin"
 )
@@ -29,3 +35,49 @@ func handleError(c *gin.Context, status int, message string, err error) {
 	}
 	c.JSON(status, gin.H{"error": message})
 }
+
+// getIDParam извлекает числовой идентификатор из параметра пути
+func getIDParam(c *gin.Context, name string) (int64, error) {
+	id, err := strconv.ParseInt(c.Param(name), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("invalid %s parameter", name)
+	}
+	return id, nil
+}
+
+// getPagination извлекает параметры limit и offset из строки запроса
+func getPagination(c *gin.Context) (int, int, error) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit < 1 || limit > 100 {
+		return 0, 0, fmt.Errorf("limit must be between 1 and 100")
+	}
+
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		return 0, 0, fmt.Errorf("offset must be non-negative")
+	}
+
+	return limit, offset, nil
+}
+
+// respondWithLoginToken выдает токен после первого шага входа либо, если у пользователя включена
+// двухфакторная аутентификация, токен второго шага
+func respondWithLoginToken(c *gin.Context, token s.Token, twoFactorService s.TwoFactorService, user *models.User) {
+	challenge, err := twoFactorService.BeginLogin(c.Request.Context(), user.UserName)
+	if err != nil {
+		handleError(c, http.StatusInternalServerError, "Failed to start two-factor login", err)
+		return
+	}
+	if challenge != "" {
+		c.JSON(http.StatusOK, dto.TwoFactorChallenge{TwoFactorRequired: true, ChallengeToken: challenge})
+		return
+	}
+
+	tokenString, err := token.GenerateToken(c.Request.Context(), user.UserName, user.TokenVersion)
+	if err != nil {
+		handleError(c, http.StatusInternalServerError, "Failed to generate token", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"token": tokenString})
+}
@@ -0,0 +1,201 @@
+package delivery
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"API-Avito-shop/internal/dto"
+	e "API-Avito-shop/internal/errors"
+	s "API-Avito-shop/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+type InventoryHandler struct {
+	inventoryService s.InventoryService
+}
+
+func NewInventoryHandler(inventoryService s.InventoryService) *InventoryHandler {
+	return &InventoryHandler{
+		inventoryService: inventoryService,
+	}
+}
+
+// GiveItemsHandler обрабатывает запрос на передачу товаров из инвентаря другому пользователю
+func (h *InventoryHandler) GiveItemsHandler(c *gin.Context) {
+	username, err := getUsername(c)
+	if err != nil {
+		handleError(c, http.StatusBadRequest, "Failed to get user_id from context", err)
+		return
+	}
+
+	var giveDTO dto.GiveItems
+	if err = c.ShouldBindJSON(&giveDTO); err != nil {
+		handleError(c, http.StatusBadRequest, "Invalid request data", err)
+		return
+	}
+
+	if err = h.inventoryService.GiveItems(c.Request.Context(), username, &giveDTO); err != nil {
+		h.handleInventoryError(c, err, "Failed to give items")
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// ListTransfersHandler обрабатывает запрос на просмотр истории передачи товаров
+func (h *InventoryHandler) ListTransfersHandler(c *gin.Context) {
+	username, err := getUsername(c)
+	if err != nil {
+		handleError(c, http.StatusBadRequest, "Failed to get user_id from context", err)
+		return
+	}
+
+	limit, offset, err := getPagination(c)
+	if err != nil {
+		handleError(c, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	transfers, err := h.inventoryService.ListTransfers(c.Request.Context(), username, limit, offset)
+	if err != nil {
+		handleError(c, http.StatusInternalServerError, "Failed to list item transfers", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"transfers": transfers})
+}
+
+// ProposeTradeHandler обрабатывает запрос на создание предложения обмена
+func (h *InventoryHandler) ProposeTradeHandler(c *gin.Context) {
+	username, err := getUsername(c)
+	if err != nil {
+		handleError(c, http.StatusBadRequest, "Failed to get user_id from context", err)
+		return
+	}
+
+	var proposeDTO dto.ProposeTrade
+	if err = c.ShouldBindJSON(&proposeDTO); err != nil {
+		handleError(c, http.StatusBadRequest, "Invalid request data", err)
+		return
+	}
+
+	trade, err := h.inventoryService.ProposeTrade(c.Request.Context(), username, &proposeDTO)
+	if err != nil {
+		h.handleInventoryError(c, err, "Failed to propose trade")
+		return
+	}
+
+	c.JSON(http.StatusCreated, trade)
+}
+
+// ListTradesHandler обрабатывает запрос на просмотр обменов пользователя
+func (h *InventoryHandler) ListTradesHandler(c *gin.Context) {
+	username, err := getUsername(c)
+	if err != nil {
+		handleError(c, http.StatusBadRequest, "Failed to get user_id from context", err)
+		return
+	}
+
+	var query dto.TradeQuery
+	if err = c.ShouldBindQuery(&query); err != nil {
+		handleError(c, http.StatusBadRequest, "Invalid query parameters", err)
+		return
+	}
+
+	limit, offset, err := getPagination(c)
+	if err != nil {
+		handleError(c, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	trades, err := h.inventoryService.ListTrades(c.Request.Context(), username, &query, limit, offset)
+	if err != nil {
+		handleError(c, http.StatusInternalServerError, "Failed to list trades", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"trades": trades})
+}
+
+// GetTradeHandler обрабатывает запрос на просмотр обмена
+func (h *InventoryHandler) GetTradeHandler(c *gin.Context) {
+	username, err := getUsername(c)
+	if err != nil {
+		handleError(c, http.StatusBadRequest, "Failed to get user_id from context", err)
+		return
+	}
+
+	id, err := getIDParam(c, "id")
+	if err != nil {
+		handleError(c, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	trade, err := h.inventoryService.GetTrade(c.Request.Context(), username, id)
+	if err != nil {
+		h.handleInventoryError(c, err, "Failed to get trade")
+		return
+	}
+
+	c.JSON(http.StatusOK, trade)
+}
+
+// AcceptTradeHandler обрабатывает принятие обмена получателем
+func (h *InventoryHandler) AcceptTradeHandler(c *gin.Context) {
+	h.resolveTrade(c, h.inventoryService.AcceptTrade, "Failed to accept trade")
+}
+
+// DeclineTradeHandler обрабатывает отклонение обмена получателем
+func (h *InventoryHandler) DeclineTradeHandler(c *gin.Context) {
+	h.resolveTrade(c, h.inventoryService.DeclineTrade, "Failed to decline trade")
+}
+
+// CancelTradeHandler обрабатывает отмену обмена инициатором
+func (h *InventoryHandler) CancelTradeHandler(c *gin.Context) {
+	h.resolveTrade(c, h.inventoryService.CancelTrade, "Failed to cancel trade")
+}
+
+// resolveTrade извлекает пользователя и идентификатор обмена и выполняет решение
+func (h *InventoryHandler) resolveTrade(c *gin.Context, resolve func(ctx context.Context, username string, id int64) error, message string) {
+	username, err := getUsername(c)
+	if err != nil {
+		handleError(c, http.StatusBadRequest, "Failed to get user_id from context", err)
+		return
+	}
+
+	id, err := getIDParam(c, "id")
+	if err != nil {
+		handleError(c, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	if err = resolve(c.Request.Context(), username, id); err != nil {
+		h.handleInventoryError(c, err, message)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// handleInventoryError отправляет ответ в зависимости от ошибки сервиса инвентаря
+func (h *InventoryHandler) handleInventoryError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, e.ErrInvalidUser):
+		handleError(c, http.StatusBadRequest, "Invalid recipient", err)
+	case errors.Is(err, e.ErrItemNotFound) || errors.Is(err, pgx.ErrNoRows):
+		handleError(c, http.StatusBadRequest, "Invalid item", err)
+	case errors.Is(err, e.ErrNotEnoughItems):
+		handleError(c, http.StatusConflict, "Not enough items in inventory", err)
+	case errors.Is(err, e.ErrTradeNotFound):
+		handleError(c, http.StatusNotFound, "Trade not found", err)
+	case errors.Is(err, e.ErrTradeResolved):
+		handleError(c, http.StatusConflict, "Trade already resolved", err)
+	case errors.Is(err, e.ErrTradeForbidden):
+		handleError(c, http.StatusForbidden, "Only the recipient can accept or decline a trade, only the proposer can cancel it", err)
+	default:
+		handleError(c, http.StatusInternalServerError, message, err)
+	}
+}
@@ -0,0 +1,25 @@
+package delivery
+
+import (
+	"net/http"
+
+	"API-Avito-shop/internal/utils/jwks"
+
+	"github.com/gin-gonic/gin"
+)
+
+type JWKSHandler struct {
+	keys *jwks.KeySet
+}
+
+func NewJWKSHandler(keys *jwks.KeySet) *JWKSHandler {
+	return &JWKSHandler{
+		keys: keys,
+	}
+}
+
+// JWKSHandler отдает открытые ключи для проверки токенов другими сервисами
+func (h *JWKSHandler) JWKSHandler(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.keys.Document())
+}
@@ -0,0 +1,37 @@
+package delivery
+
+import (
+	"net/http"
+
+	"API-Avito-shop/internal/dto"
+	s "API-Avito-shop/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LeaderboardHandler struct {
+	leaderboardService s.LeaderboardService
+}
+
+func NewLeaderboardHandler(leaderboardService s.LeaderboardService) *LeaderboardHandler {
+	return &LeaderboardHandler{
+		leaderboardService: leaderboardService,
+	}
+}
+
+// LeaderboardHandler обрабатывает запрос на получение рейтингов за период
+func (h *LeaderboardHandler) LeaderboardHandler(c *gin.Context) {
+	var query dto.LeaderboardQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		handleError(c, http.StatusBadRequest, "Invalid query parameters", err)
+		return
+	}
+
+	leaderboard, err := h.leaderboardService.GetLeaderboard(c.Request.Context(), &query)
+	if err != nil {
+		handleError(c, http.StatusInternalServerError, "Failed to get leaderboard", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, leaderboard)
+}
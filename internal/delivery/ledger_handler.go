@@ -0,0 +1,30 @@
+package delivery
+
+import (
+	"net/http"
+
+	s "API-Avito-shop/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LedgerHandler struct {
+	ledgerService s.LedgerService
+}
+
+func NewLedgerHandler(ledgerService s.LedgerService) *LedgerHandler {
+	return &LedgerHandler{
+		ledgerService: ledgerService,
+	}
+}
+
+// VerifyLedgerHandler обрабатывает запрос администратора на проверку цепочек хешей переводов и покупок
+func (h *LedgerHandler) VerifyLedgerHandler(c *gin.Context) {
+	result, err := h.ledgerService.VerifyLedger(c.Request.Context())
+	if err != nil {
+		handleError(c, http.StatusInternalServerError, "Failed to verify ledger", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
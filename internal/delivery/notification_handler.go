@@ -0,0 +1,62 @@
+package delivery
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	s "API-Avito-shop/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type NotificationHandler struct {
+	hub               *s.NotificationHub
+	heartbeatInterval time.Duration
+}
+
+func NewNotificationHandler(hub *s.NotificationHub, heartbeatInterval time.Duration) *NotificationHandler {
+	return &NotificationHandler{
+		hub:               hub,
+		heartbeatInterval: heartbeatInterval,
+	}
+}
+
+// EventsHandler открывает поток Server-Sent Events с уведомлениями пользователя
+func (h *NotificationHandler) EventsHandler(c *gin.Context) {
+	username, err := getUsername(c)
+	if err != nil {
+		handleError(c, http.StatusBadRequest, "Failed to get user_id from context", err)
+		return
+	}
+
+	notifications, unsubscribe := h.hub.Subscribe(username)
+	defer unsubscribe()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(h.heartbeatInterval)
+	defer heartbeat.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case notification, ok := <-notifications:
+			if !ok {
+				return false
+			}
+			c.SSEvent(notification.Type, notification)
+			return true
+		case <-heartbeat.C:
+			// Комментарий SSE не попадает к клиенту, но не дает прокси закрыть соединение
+			_, err := io.WriteString(w, ": ping\n\n")
+			return err == nil
+		}
+	})
+}
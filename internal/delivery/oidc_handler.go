@@ -0,0 +1,125 @@
+package delivery
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"API-Avito-shop/internal/dto"
+	e "API-Avito-shop/internal/errors"
+	"API-Avito-shop/internal/oidc"
+	s "API-Avito-shop/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Cookie, привязывающий state входа к браузеру, который этот вход начал
+const (
+	stateCookieName = "oidc_state"
+	stateCookiePath = "/api/auth/oidc"
+)
+
+type OIDCHandler struct {
+	oidcService      s.OIDCService
+	twoFactorService s.TwoFactorService
+	token            s.Token
+	stateTTL         time.Duration
+	secureCookie     bool
+}
+
+func NewOIDCHandler(oidcService s.OIDCService, twoFactorService s.TwoFactorService, token s.Token, stateTTL time.Duration, secureCookie bool) *OIDCHandler {
+	return &OIDCHandler{
+		oidcService:      oidcService,
+		twoFactorService: twoFactorService,
+		token:            token,
+		stateTTL:         stateTTL,
+		secureCookie:     secureCookie,
+	}
+}
+
+// LoginHandler перенаправляет пользователя на страницу входа провайдера
+func (h *OIDCHandler) LoginHandler(c *gin.Context) {
+	state, authURL, err := h.oidcService.BeginLogin(c.Request.Context())
+	if err != nil {
+		handleError(c, http.StatusBadGateway, "Identity provider is unavailable", err)
+		return
+	}
+
+	h.setStateCookie(c, state, int(h.stateTTL.Seconds()))
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// LinkHandler начинает связывание учетной записи провайдера с текущим пользователем.
+// Адрес возвращается в ответе, так как запрос выполняется с токеном магазина, а не переходом браузера.
+func (h *OIDCHandler) LinkHandler(c *gin.Context) {
+	username, err := getUsername(c)
+	if err != nil {
+		handleError(c, http.StatusUnauthorized, "Unauthorized", err)
+		return
+	}
+
+	state, authURL, err := h.oidcService.BeginLink(c.Request.Context(), username)
+	if err != nil {
+		handleError(c, http.StatusBadGateway, "Identity provider is unavailable", err)
+		return
+	}
+
+	h.setStateCookie(c, state, int(h.stateTTL.Seconds()))
+	c.JSON(http.StatusOK, dto.OIDCLink{AuthURL: authURL})
+}
+
+// CallbackHandler завершает вход через провайдера и выдает токен магазина
+func (h *OIDCHandler) CallbackHandler(c *gin.Context) {
+	if providerErr := c.Query("error"); providerErr != "" {
+		handleError(c, http.StatusUnauthorized, "Identity provider denied login",
+			fmt.Errorf("%s: %s", providerErr, c.Query("error_description")))
+		return
+	}
+
+	state, code := c.Query("state"), c.Query("code")
+	if state == "" || code == "" {
+		handleError(c, http.StatusBadRequest, "Missing state or code", nil)
+		return
+	}
+
+	// state должен совпасть с cookie, иначе callback открыт в чужом браузере
+	boundState, err := c.Cookie(stateCookieName)
+	h.setStateCookie(c, "", -1)
+	if err != nil || subtle.ConstantTimeCompare([]byte(boundState), []byte(state)) != 1 {
+		handleError(c, http.StatusBadRequest, "Login session expired, start again", e.ErrInvalidLoginState)
+		return
+	}
+
+	user, err := h.oidcService.CompleteLogin(c.Request.Context(), state, code)
+	if err != nil {
+		switch {
+		case errors.Is(err, e.ErrInvalidLoginState):
+			handleError(c, http.StatusBadRequest, "Login session expired, start again", err)
+		case errors.Is(err, oidc.ErrInvalidIDToken):
+			handleError(c, http.StatusUnauthorized, "Authorization failed", err)
+		case errors.Is(err, e.ErrIdentityUnverified):
+			handleError(c, http.StatusForbidden, "Identity provider has not verified the email", err)
+		case errors.Is(err, e.ErrIdentityMapping):
+			handleError(c, http.StatusForbidden, "Account cannot be mapped to a shop username", err)
+		case errors.Is(err, e.ErrIdentityConflict):
+			handleError(c, http.StatusConflict, "Username is already taken, sign in and link the account instead", err)
+		case errors.Is(err, e.ErrUserDisabled):
+			handleError(c, http.StatusForbidden, "Account disabled", err)
+		case errors.Is(err, e.ErrFailedExecuteQuery):
+			handleError(c, http.StatusInternalServerError, "SSO login failed", err)
+		default:
+			handleError(c, http.StatusBadGateway, "SSO login failed", err)
+		}
+		return
+	}
+
+	respondWithLoginToken(c, h.token, h.twoFactorService, user)
+}
+
+// setStateCookie сохраняет state входа в cookie браузера, отрицательный maxAge удаляет cookie
+func (h *OIDCHandler) setStateCookie(c *gin.Context, state string, maxAge int) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(stateCookieName, state, maxAge, stateCookiePath, "", h.secureCookie, true)
+}
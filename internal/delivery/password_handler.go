@@ -0,0 +1,109 @@
+package delivery
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"API-Avito-shop/internal/dto"
+	e "API-Avito-shop/internal/errors"
+	s "API-Avito-shop/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PasswordHandler struct {
+	passwordService s.PasswordService
+	token           s.Token
+}
+
+func NewPasswordHandler(passwordService s.PasswordService, token s.Token) *PasswordHandler {
+	return &PasswordHandler{
+		passwordService: passwordService,
+		token:           token,
+	}
+}
+
+// ChangePasswordHandler обрабатывает запрос на смену пароля и выдает новый токен взамен отозванных
+func (h *PasswordHandler) ChangePasswordHandler(c *gin.Context) {
+	username, err := getUsername(c)
+	if err != nil {
+		handleError(c, http.StatusBadRequest, "Failed to get user_id from context", err)
+		return
+	}
+
+	var changeDTO dto.ChangePassword
+	if err = c.ShouldBindJSON(&changeDTO); err != nil {
+		handleError(c, http.StatusBadRequest, "Invalid request format", err)
+		return
+	}
+
+	version, err := h.passwordService.ChangePassword(c.Request.Context(), username, &changeDTO, c.ClientIP())
+	if err != nil {
+		h.handlePasswordError(c, err)
+		return
+	}
+
+	token, err := h.token.GenerateToken(c.Request.Context(), username, version)
+	if err != nil {
+		handleError(c, http.StatusInternalServerError, "Failed to generate token", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"token": token})
+}
+
+// ResetPasswordHandler обрабатывает запрос на установку пароля по одноразовому токену сброса
+func (h *PasswordHandler) ResetPasswordHandler(c *gin.Context) {
+	var resetDTO dto.ResetPassword
+	if err := c.ShouldBindJSON(&resetDTO); err != nil {
+		handleError(c, http.StatusBadRequest, "Invalid request format", err)
+		return
+	}
+
+	if err := h.passwordService.ResetPassword(c.Request.Context(), &resetDTO); err != nil {
+		h.handlePasswordError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// CreateResetTokenHandler обрабатывает запрос администратора на выдачу токена сброса пароля
+func (h *PasswordHandler) CreateResetTokenHandler(c *gin.Context) {
+	admin, err := getUsername(c)
+	if err != nil {
+		handleError(c, http.StatusBadRequest, "Failed to get user_id from context", err)
+		return
+	}
+
+	resetToken, err := h.passwordService.CreateResetToken(c.Request.Context(), admin, c.Param("username"))
+	if err != nil {
+		h.handlePasswordError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, resetToken)
+}
+
+// handlePasswordError сопоставляет ошибки сервиса паролей с HTTP-ответами
+func (h *PasswordHandler) handlePasswordError(c *gin.Context, err error) {
+	var policyErr *e.PolicyError
+	var lockoutErr *e.LockoutError
+
+	switch {
+	case errors.As(err, &policyErr):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Password does not satisfy policy", "violations": policyErr.Violations})
+	case errors.Is(err, e.ErrInvalidPass):
+		handleError(c, http.StatusUnauthorized, "Current password is incorrect", err)
+	case errors.Is(err, e.ErrInvalidResetToken):
+		handleError(c, http.StatusBadRequest, "Invalid or expired reset token", err)
+	case errors.Is(err, e.ErrInvalidUser):
+		handleError(c, http.StatusNotFound, "User not found", err)
+	case errors.As(err, &lockoutErr):
+		c.Header("Retry-After", strconv.Itoa(int(lockoutErr.RetryAfter.Seconds())+1))
+		handleError(c, http.StatusTooManyRequests, "Too many failed login attempts", err)
+	default:
+		handleError(c, http.StatusInternalServerError, "Password service error", err)
+	}
+}
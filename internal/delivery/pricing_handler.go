@@ -0,0 +1,177 @@
+package delivery
+
+import (
+	"errors"
+	"net/http"
+
+	"API-Avito-shop/internal/dto"
+	e "API-Avito-shop/internal/errors"
+	s "API-Avito-shop/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PricingHandler struct {
+	pricingService s.PricingService
+}
+
+func NewPricingHandler(pricingService s.PricingService) *PricingHandler {
+	return &PricingHandler{
+		pricingService: pricingService,
+	}
+}
+
+// CatalogHandler обрабатывает запрос на получение каталога с текущими ценами
+func (h *PricingHandler) CatalogHandler(c *gin.Context) {
+	catalog, err := h.pricingService.Catalog(c.Request.Context())
+	if err != nil {
+		handleError(c, http.StatusInternalServerError, "Failed to get catalog", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": catalog})
+}
+
+// ListPriceChangesHandler обрабатывает запрос администратора на просмотр истории цен товара
+func (h *PricingHandler) ListPriceChangesHandler(c *gin.Context) {
+	changes, err := h.pricingService.ListPriceChanges(c.Request.Context(), c.Param("item"))
+	if err != nil {
+		h.handlePricingError(c, err, "Failed to list price changes")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"prices": changes})
+}
+
+// SchedulePriceHandler обрабатывает запрос администратора на изменение цены товара
+func (h *PricingHandler) SchedulePriceHandler(c *gin.Context) {
+	admin, err := getUsername(c)
+	if err != nil {
+		handleError(c, http.StatusBadRequest, "Failed to get user_id from context", err)
+		return
+	}
+
+	var scheduleDTO dto.SchedulePrice
+	if err = c.ShouldBindJSON(&scheduleDTO); err != nil {
+		handleError(c, http.StatusBadRequest, "Invalid request data", err)
+		return
+	}
+
+	change, err := h.pricingService.SchedulePrice(c.Request.Context(), admin, c.Param("item"), &scheduleDTO)
+	if err != nil {
+		h.handlePricingError(c, err, "Failed to schedule price change")
+		return
+	}
+
+	c.JSON(http.StatusCreated, change)
+}
+
+// CancelPriceChangeHandler обрабатывает запрос администратора на отмену запланированного изменения цены
+func (h *PricingHandler) CancelPriceChangeHandler(c *gin.Context) {
+	admin, err := getUsername(c)
+	if err != nil {
+		handleError(c, http.StatusBadRequest, "Failed to get user_id from context", err)
+		return
+	}
+
+	id, err := getIDParam(c, "id")
+	if err != nil {
+		handleError(c, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	if err = h.pricingService.CancelPriceChange(c.Request.Context(), admin, c.Param("item"), id); err != nil {
+		h.handlePricingError(c, err, "Failed to cancel price change")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListPromotionsHandler обрабатывает запрос администратора на просмотр акций
+func (h *PricingHandler) ListPromotionsHandler(c *gin.Context) {
+	var query dto.PromotionQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		handleError(c, http.StatusBadRequest, "Invalid query parameters", err)
+		return
+	}
+
+	limit, offset, err := getPagination(c)
+	if err != nil {
+		handleError(c, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	promotions, err := h.pricingService.ListPromotions(c.Request.Context(), &query, limit, offset)
+	if err != nil {
+		handleError(c, http.StatusInternalServerError, "Failed to list promotions", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"promotions": promotions})
+}
+
+// CreatePromotionHandler обрабатывает запрос администратора на создание акции
+func (h *PricingHandler) CreatePromotionHandler(c *gin.Context) {
+	admin, err := getUsername(c)
+	if err != nil {
+		handleError(c, http.StatusBadRequest, "Failed to get user_id from context", err)
+		return
+	}
+
+	var createDTO dto.CreatePromotion
+	if err = c.ShouldBindJSON(&createDTO); err != nil {
+		handleError(c, http.StatusBadRequest, "Invalid request data", err)
+		return
+	}
+
+	promotion, err := h.pricingService.CreatePromotion(c.Request.Context(), admin, &createDTO)
+	if err != nil {
+		h.handlePricingError(c, err, "Failed to create promotion")
+		return
+	}
+
+	c.JSON(http.StatusCreated, promotion)
+}
+
+// CancelPromotionHandler обрабатывает запрос администратора на отмену акции
+func (h *PricingHandler) CancelPromotionHandler(c *gin.Context) {
+	admin, err := getUsername(c)
+	if err != nil {
+		handleError(c, http.StatusBadRequest, "Failed to get user_id from context", err)
+		return
+	}
+
+	id, err := getIDParam(c, "id")
+	if err != nil {
+		handleError(c, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	if err = h.pricingService.CancelPromotion(c.Request.Context(), admin, id); err != nil {
+		h.handlePricingError(c, err, "Failed to cancel promotion")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// handlePricingError отправляет ответ в зависимости от ошибки сервиса цен
+func (h *PricingHandler) handlePricingError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, e.ErrItemNotFound):
+		handleError(c, http.StatusNotFound, "Item not found", err)
+	case errors.Is(err, e.ErrPriceChangeNotFound):
+		handleError(c, http.StatusNotFound, "Scheduled price change not found", err)
+	case errors.Is(err, e.ErrPromotionNotFound):
+		handleError(c, http.StatusNotFound, "Active or scheduled promotion not found", err)
+	case errors.Is(err, e.ErrPriceChangeExists):
+		handleError(c, http.StatusConflict, "Price change at this time already exists", err)
+	case errors.Is(err, e.ErrPriceChangeInPast):
+		handleError(c, http.StatusBadRequest, "Price change must not take effect in the past", err)
+	case errors.Is(err, e.ErrInvalidPromotion):
+		handleError(c, http.StatusBadRequest, "Promotion must end after it starts and in the future, percent discount must not exceed 100", err)
+	default:
+		handleError(c, http.StatusInternalServerError, message, err)
+	}
+}
@@ -0,0 +1,132 @@
+package delivery
+
+import (
+	"errors"
+	"net/http"
+
+	"API-Avito-shop/internal/dto"
+	e "API-Avito-shop/internal/errors"
+	s "API-Avito-shop/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ProfileHandler struct {
+	profileService s.ProfileService
+}
+
+func NewProfileHandler(profileService s.ProfileService) *ProfileHandler {
+	return &ProfileHandler{
+		profileService: profileService,
+	}
+}
+
+// GetOwnProfileHandler обрабатывает запрос на получение своего профиля
+func (h *ProfileHandler) GetOwnProfileHandler(c *gin.Context) {
+	username, err := getUsername(c)
+	if err != nil {
+		handleError(c, http.StatusBadRequest, "Failed to get user_id from context", err)
+		return
+	}
+
+	h.respondWithProfile(c, username)
+}
+
+// GetProfileHandler обрабатывает запрос на просмотр профиля другого пользователя
+func (h *ProfileHandler) GetProfileHandler(c *gin.Context) {
+	h.respondWithProfile(c, c.Param("username"))
+}
+
+// UpdateProfileHandler обрабатывает запрос на изменение своего профиля
+func (h *ProfileHandler) UpdateProfileHandler(c *gin.Context) {
+	username, err := getUsername(c)
+	if err != nil {
+		handleError(c, http.StatusBadRequest, "Failed to get user_id from context", err)
+		return
+	}
+
+	var updateDTO dto.UpdateProfile
+	if err = c.ShouldBindJSON(&updateDTO); err != nil {
+		handleError(c, http.StatusBadRequest, "Invalid request data", err)
+		return
+	}
+
+	profile, err := h.profileService.UpdateProfile(c.Request.Context(), username, &updateDTO)
+	if err != nil {
+		if errors.Is(err, e.ErrDisplayNameTaken) {
+			handleError(c, http.StatusConflict, "Display name is taken", err)
+			return
+		}
+		handleError(c, http.StatusInternalServerError, "Failed to update profile", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, profile)
+}
+
+// SearchUsersHandler обрабатывает запрос на поиск коллег по логину и отображаемому имени
+func (h *ProfileHandler) SearchUsersHandler(c *gin.Context) {
+	username, err := getUsername(c)
+	if err != nil {
+		handleError(c, http.StatusBadRequest, "Failed to get user_id from context", err)
+		return
+	}
+
+	var query dto.DirectorySearchQuery
+	if err = c.ShouldBindQuery(&query); err != nil {
+		handleError(c, http.StatusBadRequest, "Invalid query parameters", err)
+		return
+	}
+
+	limit, offset, err := getPagination(c)
+	if err != nil {
+		handleError(c, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	users, err := h.profileService.SearchDirectory(c.Request.Context(), username, query.Query, limit, offset)
+	if err != nil {
+		handleError(c, http.StatusInternalServerError, "Failed to search users", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"users": users})
+}
+
+// RecentRecipientsHandler обрабатывает запрос на получение недавних получателей переводов
+func (h *ProfileHandler) RecentRecipientsHandler(c *gin.Context) {
+	username, err := getUsername(c)
+	if err != nil {
+		handleError(c, http.StatusBadRequest, "Failed to get user_id from context", err)
+		return
+	}
+
+	limit, offset, err := getPagination(c)
+	if err != nil {
+		handleError(c, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	recipients, err := h.profileService.RecentRecipients(c.Request.Context(), username, limit, offset)
+	if err != nil {
+		handleError(c, http.StatusInternalServerError, "Failed to get recent recipients", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"recipients": recipients})
+}
+
+// respondWithProfile отправляет профиль пользователя
+func (h *ProfileHandler) respondWithProfile(c *gin.Context, username string) {
+	profile, err := h.profileService.GetProfile(c.Request.Context(), username)
+	if err != nil {
+		if errors.Is(err, e.ErrInvalidUser) {
+			handleError(c, http.StatusNotFound, "User not found", err)
+			return
+		}
+		handleError(c, http.StatusInternalServerError, "Failed to get profile", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, profile)
+}
@@ -0,0 +1,148 @@
+package delivery
+
+import (
+	"errors"
+	"net/http"
+
+	"API-Avito-shop/internal/dto"
+	e "API-Avito-shop/internal/errors"
+	s "API-Avito-shop/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PromoCodeHandler struct {
+	promoCodeService s.PromoCodeService
+}
+
+func NewPromoCodeHandler(promoCodeService s.PromoCodeService) *PromoCodeHandler {
+	return &PromoCodeHandler{
+		promoCodeService: promoCodeService,
+	}
+}
+
+// ListCodesHandler обрабатывает запрос администратора на просмотр промокодов
+func (h *PromoCodeHandler) ListCodesHandler(c *gin.Context) {
+	var query dto.PromoCodeQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		handleError(c, http.StatusBadRequest, "Invalid query parameters", err)
+		return
+	}
+
+	limit, offset, err := getPagination(c)
+	if err != nil {
+		handleError(c, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	codes, err := h.promoCodeService.ListCodes(c.Request.Context(), &query, limit, offset)
+	if err != nil {
+		handleError(c, http.StatusInternalServerError, "Failed to list promo codes", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"codes": codes})
+}
+
+// GetCodeHandler обрабатывает запрос администратора на просмотр промокода
+func (h *PromoCodeHandler) GetCodeHandler(c *gin.Context) {
+	id, err := getIDParam(c, "id")
+	if err != nil {
+		handleError(c, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	code, err := h.promoCodeService.GetCode(c.Request.Context(), id)
+	if err != nil {
+		h.handlePromoCodeError(c, err, "Failed to get promo code")
+		return
+	}
+
+	c.JSON(http.StatusOK, code)
+}
+
+// ListRedemptionsHandler обрабатывает запрос администратора на просмотр погашений промокода
+func (h *PromoCodeHandler) ListRedemptionsHandler(c *gin.Context) {
+	id, err := getIDParam(c, "id")
+	if err != nil {
+		handleError(c, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	limit, offset, err := getPagination(c)
+	if err != nil {
+		handleError(c, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	redemptions, err := h.promoCodeService.ListRedemptions(c.Request.Context(), id, limit, offset)
+	if err != nil {
+		h.handlePromoCodeError(c, err, "Failed to list promo code redemptions")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"redemptions": redemptions})
+}
+
+// CreateCodeHandler обрабатывает запрос администратора на создание промокода
+func (h *PromoCodeHandler) CreateCodeHandler(c *gin.Context) {
+	admin, err := getUsername(c)
+	if err != nil {
+		handleError(c, http.StatusBadRequest, "Failed to get user_id from context", err)
+		return
+	}
+
+	var createDTO dto.CreatePromoCode
+	if err = c.ShouldBindJSON(&createDTO); err != nil {
+		handleError(c, http.StatusBadRequest, "Invalid request data", err)
+		return
+	}
+
+	code, err := h.promoCodeService.CreateCode(c.Request.Context(), admin, &createDTO)
+	if err != nil {
+		h.handlePromoCodeError(c, err, "Failed to create promo code")
+		return
+	}
+
+	c.JSON(http.StatusCreated, code)
+}
+
+// DisableCodeHandler обрабатывает запрос администратора на отключение промокода
+func (h *PromoCodeHandler) DisableCodeHandler(c *gin.Context) {
+	admin, err := getUsername(c)
+	if err != nil {
+		handleError(c, http.StatusBadRequest, "Failed to get user_id from context", err)
+		return
+	}
+
+	id, err := getIDParam(c, "id")
+	if err != nil {
+		handleError(c, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	if err = h.promoCodeService.DisableCode(c.Request.Context(), admin, id); err != nil {
+		h.handlePromoCodeError(c, err, "Failed to disable promo code")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// handlePromoCodeError отправляет ответ в зависимости от ошибки сервиса промокодов
+func (h *PromoCodeHandler) handlePromoCodeError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, e.ErrPromoCodeNotFound):
+		handleError(c, http.StatusNotFound, "Promo code not found", err)
+	case errors.Is(err, e.ErrPromoCodeExists):
+		handleError(c, http.StatusConflict, "Promo code already exists", err)
+	case errors.Is(err, e.ErrItemNotFound):
+		handleError(c, http.StatusBadRequest, "Item not found", err)
+	case errors.Is(err, e.ErrInvalidUser):
+		handleError(c, http.StatusBadRequest, "User not found", err)
+	case errors.Is(err, e.ErrInvalidPromoCode):
+		handleError(c, http.StatusBadRequest, "Promo code must expire in the future, percent discount must not exceed 100", err)
+	default:
+		handleError(c, http.StatusInternalServerError, message, err)
+	}
+}
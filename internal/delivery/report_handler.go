@@ -0,0 +1,125 @@
+package delivery
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"API-Avito-shop/internal/dto"
+	e "API-Avito-shop/internal/errors"
+	s "API-Avito-shop/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+var reportCSVHeader = []string{"section", "key", "metric", "value"}
+
+type ReportHandler struct {
+	reportService s.ReportService
+}
+
+func NewReportHandler(reportService s.ReportService) *ReportHandler {
+	return &ReportHandler{
+		reportService: reportService,
+	}
+}
+
+// EconomyReportHandler обрабатывает запрос администратора на получение отчета об экономике монет в формате JSON или CSV
+func (h *ReportHandler) EconomyReportHandler(c *gin.Context) {
+	var query dto.EconomyReportQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		handleError(c, http.StatusBadRequest, "Invalid query parameters", err)
+		return
+	}
+
+	report, err := h.reportService.EconomyReport(c.Request.Context(), &query)
+	if err != nil {
+		switch {
+		case errors.Is(err, e.ErrInvalidPeriod):
+			handleError(c, http.StatusBadRequest, "Period must not be reversed or longer than a year", err)
+		default:
+			handleError(c, http.StatusInternalServerError, "Failed to build economy report", err)
+		}
+		return
+	}
+
+	if query.Format != "csv" {
+		c.JSON(http.StatusOK, report)
+		return
+	}
+
+	filename := fmt.Sprintf("economy-%s-%s.csv", report.From.Format(statementDateLayout), report.To.Format(statementDateLayout))
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Status(http.StatusOK)
+	if err = writeReportCSV(c, report); err != nil {
+		// Заголовки уже отправлены, поэтому ошибку можно только залогировать
+		slog.Error("Failed to write economy report", "error", err)
+	}
+}
+
+// writeReportCSV записывает отчет в формате CSV: одна строка на показатель,
+// ключом служит товар или имя пользователя для показателей из списков
+func writeReportCSV(c *gin.Context, report dto.EconomyReport) error {
+	integer := func(v int64) string { return strconv.FormatInt(v, 10) }
+	decimal := func(v float64) string { return strconv.FormatFloat(v, 'f', 2, 64) }
+
+	records := [][]string{
+		reportCSVHeader,
+		{"period", "", "from", report.From.Format(statementDateLayout)},
+		{"period", "", "to", report.To.Format(statementDateLayout)},
+		{"period", "", "generated_at", report.GeneratedAt.Format(time.RFC3339)},
+		{"totals", "", "circulation", integer(report.Totals.Circulation)},
+		{"totals", "", "minted", integer(report.Totals.Minted)},
+		{"totals", "", "spent_in_shop", integer(report.Totals.SpentInShop)},
+		{"totals", "", "purchases", integer(report.Totals.Purchases)},
+		{"totals", "", "expired", integer(report.Totals.Expired)},
+		{"totals", "", "net_issuance", integer(report.Totals.NetIssuance)},
+		{"transfers", "", "count", integer(report.Transfers.Count)},
+		{"transfers", "", "volume", integer(report.Transfers.Volume)},
+		{"transfers", "", "senders", integer(report.Transfers.Senders)},
+		{"transfers", "", "receivers", integer(report.Transfers.Receivers)},
+		{"transfers", "", "velocity", strconv.FormatFloat(report.Transfers.Velocity, 'f', 4, 64)},
+		{"transfers", "", "avg_daily_volume", decimal(report.Transfers.AvgDailyVolume)},
+	}
+	for _, item := range report.TopItems {
+		records = append(records,
+			[]string{"top_items", item.Item, "quantity", integer(item.Quantity)},
+			[]string{"top_items", item.Item, "revenue", integer(item.Revenue)})
+	}
+	records = append(records,
+		[]string{"dormant", "", "count", integer(report.Dormant.Count)},
+		[]string{"dormant", "", "coins", integer(report.Dormant.Coins)})
+	for _, account := range report.Dormant.Accounts {
+		lastActivity := ""
+		if account.LastActivityAt != nil {
+			lastActivity = account.LastActivityAt.Format(time.RFC3339)
+		}
+		records = append(records,
+			[]string{"dormant_accounts", account.UserName, "balance", strconv.Itoa(account.Balance)},
+			[]string{"dormant_accounts", account.UserName, "last_activity_at", lastActivity})
+	}
+
+	distribution := report.BalanceDistribution
+	records = append(records,
+		[]string{"balances", "", "accounts", integer(distribution.Accounts)},
+		[]string{"balances", "", "min", strconv.Itoa(distribution.Min)},
+		[]string{"balances", "", "max", strconv.Itoa(distribution.Max)},
+		[]string{"balances", "", "mean", decimal(distribution.Mean)},
+		[]string{"balances", "", "p10", decimal(distribution.P10)},
+		[]string{"balances", "", "p25", decimal(distribution.P25)},
+		[]string{"balances", "", "p50", decimal(distribution.P50)},
+		[]string{"balances", "", "p75", decimal(distribution.P75)},
+		[]string{"balances", "", "p90", decimal(distribution.P90)},
+		[]string{"balances", "", "p99", decimal(distribution.P99)})
+
+	w := csv.NewWriter(c.Writer)
+	if err := w.WriteAll(records); err != nil {
+		return err
+	}
+	return w.Error()
+}
@@ -0,0 +1,126 @@
+package delivery
+
+import (
+	"errors"
+	"net/http"
+
+	"API-Avito-shop/internal/dto"
+	e "API-Avito-shop/internal/errors"
+	s "API-Avito-shop/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ServiceAccountHandler struct {
+	apiKeyService s.APIKeyService
+}
+
+func NewServiceAccountHandler(apiKeyService s.APIKeyService) *ServiceAccountHandler {
+	return &ServiceAccountHandler{
+		apiKeyService: apiKeyService,
+	}
+}
+
+// CreateServiceAccountHandler обрабатывает запрос администратора на создание сервисной учетной записи
+func (h *ServiceAccountHandler) CreateServiceAccountHandler(c *gin.Context) {
+	admin, err := getUsername(c)
+	if err != nil {
+		handleError(c, http.StatusBadRequest, "Failed to get user_id from context", err)
+		return
+	}
+
+	var createDTO dto.CreateServiceAccount
+	if err = c.ShouldBindJSON(&createDTO); err != nil {
+		handleError(c, http.StatusBadRequest, "Invalid request data", err)
+		return
+	}
+
+	account, err := h.apiKeyService.CreateServiceAccount(c.Request.Context(), admin, &createDTO)
+	if err != nil {
+		h.handleServiceAccountError(c, err, "Failed to create service account")
+		return
+	}
+
+	c.JSON(http.StatusCreated, account)
+}
+
+// ListServiceAccountsHandler обрабатывает запрос на получение списка сервисных учетных записей
+func (h *ServiceAccountHandler) ListServiceAccountsHandler(c *gin.Context) {
+	accounts, err := h.apiKeyService.ListServiceAccounts(c.Request.Context())
+	if err != nil {
+		handleError(c, http.StatusInternalServerError, "Failed to list service accounts", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"serviceAccounts": accounts})
+}
+
+// CreateKeyHandler обрабатывает запрос на выпуск API-ключа сервисной учетной записи
+func (h *ServiceAccountHandler) CreateKeyHandler(c *gin.Context) {
+	admin, err := getUsername(c)
+	if err != nil {
+		handleError(c, http.StatusBadRequest, "Failed to get user_id from context", err)
+		return
+	}
+
+	var createDTO dto.CreateAPIKey
+	if err = c.ShouldBindJSON(&createDTO); err != nil {
+		handleError(c, http.StatusBadRequest, "Invalid request data", err)
+		return
+	}
+
+	key, err := h.apiKeyService.CreateKey(c.Request.Context(), admin, c.Param("username"), &createDTO)
+	if err != nil {
+		h.handleServiceAccountError(c, err, "Failed to create api key")
+		return
+	}
+
+	c.JSON(http.StatusCreated, key)
+}
+
+// ListKeysHandler обрабатывает запрос на получение API-ключей сервисной учетной записи
+func (h *ServiceAccountHandler) ListKeysHandler(c *gin.Context) {
+	keys, err := h.apiKeyService.ListKeys(c.Request.Context(), c.Param("username"))
+	if err != nil {
+		h.handleServiceAccountError(c, err, "Failed to list api keys")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"keys": keys})
+}
+
+// RevokeKeyHandler обрабатывает запрос на отзыв API-ключа
+func (h *ServiceAccountHandler) RevokeKeyHandler(c *gin.Context) {
+	admin, err := getUsername(c)
+	if err != nil {
+		handleError(c, http.StatusBadRequest, "Failed to get user_id from context", err)
+		return
+	}
+
+	id, err := getIDParam(c, "id")
+	if err != nil {
+		handleError(c, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	if err = h.apiKeyService.RevokeKey(c.Request.Context(), admin, c.Param("username"), id); err != nil {
+		h.handleServiceAccountError(c, err, "Failed to revoke api key")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// handleServiceAccountError отправляет ответ в зависимости от ошибки сервиса API-ключей
+func (h *ServiceAccountHandler) handleServiceAccountError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, e.ErrInvalidUser):
+		handleError(c, http.StatusNotFound, "Service account not found", err)
+	case errors.Is(err, e.ErrUserExists):
+		handleError(c, http.StatusConflict, "Username is already taken", err)
+	case errors.Is(err, e.ErrAPIKeyNotFound):
+		handleError(c, http.StatusNotFound, "API key not found or already revoked", err)
+	default:
+		handleError(c, http.StatusInternalServerError, message, err)
+	}
+}
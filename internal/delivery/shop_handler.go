@@ -4,7 +4,9 @@ import (
 	"errors"
 	"net/http"
 
+	"API-Avito-shop/internal/dto"
 	e "API-Avito-shop/internal/errors"
+	"API-Avito-shop/internal/models"
 	s "API-Avito-shop/internal/services"
 
 	"github.com/gin-gonic/gin"
@@ -36,18 +38,63 @@ func (h *ShopHandler) BuyHandler(c *gin.Context) {
 		return
 	}
 
-	err = h.shopService.BuyProduct(c.Request.Context(), item, username)
+	var query dto.BuyQuery
+	if err = c.ShouldBindQuery(&query); err != nil {
+		handleError(c, http.StatusBadRequest, "Invalid query parameters", err)
+		return
+	}
+
+	if err = h.shopService.BuyProduct(c.Request.Context(), item, username, query.Code); err != nil {
+		handlePurchaseError(c, err, "Failed to complete purchase")
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// GiftHandler обрабатывает запрос на покупку товара в подарок другому пользователю
+func (h *ShopHandler) GiftHandler(c *gin.Context) {
+	username, err := getUsername(c)
 	if err != nil {
-		switch {
-		case errors.Is(err, pgx.ErrNoRows):
-			handleError(c, http.StatusBadRequest, "Invalid item", err)
-		case errors.Is(err, e.ErrNotEnoughCoins):
-			handleError(c, http.StatusBadRequest, "Insufficient balance", err)
-		default:
-			handleError(c, http.StatusInternalServerError, "Failed to complete purchase", err)
-		}
+		handleError(c, http.StatusBadRequest, "Failed to get user_id from context", err)
+		return
+	}
+
+	var giftDTO dto.GiftPurchase
+	if err = c.ShouldBindJSON(&giftDTO); err != nil {
+		handleError(c, http.StatusBadRequest, "Invalid request data", err)
+		return
+	}
+
+	gift := models.Gift{ToUser: giftDTO.ToUser, Message: giftDTO.Message}
+	if err = h.shopService.GiftProduct(c.Request.Context(), giftDTO.Item, username, giftDTO.Code, gift); err != nil {
+		handlePurchaseError(c, err, "Failed to complete gift purchase")
 		return
 	}
 
 	c.Status(http.StatusOK)
 }
+
+// handlePurchaseError отправляет ответ в зависимости от ошибки покупки
+func handlePurchaseError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		handleError(c, http.StatusBadRequest, "Invalid item", err)
+	case errors.Is(err, e.ErrNotEnoughCoins):
+		handleError(c, http.StatusBadRequest, "Insufficient balance", err)
+	case errors.Is(err, e.ErrInvalidUser):
+		handleError(c, http.StatusBadRequest, "Invalid recipient", err)
+	case errors.Is(err, e.ErrPromoCodeNotFound):
+		handleError(c, http.StatusBadRequest, "Invalid promo code", err)
+	case errors.Is(err, e.ErrPromoCodeExpired):
+		handleError(c, http.StatusBadRequest, "Promo code expired", err)
+	case errors.Is(err, e.ErrPromoCodeNotApplicable):
+		handleError(c, http.StatusBadRequest, "Promo code does not apply to this item", err)
+	case errors.Is(err, e.ErrPromoCodeExhausted):
+		handleError(c, http.StatusConflict, "Promo code redemption limit reached", err)
+	case errors.Is(err, e.ErrPromoCodeUserLimit):
+		handleError(c, http.StatusConflict, "Promo code already redeemed", err)
+	default:
+		handleError(c, http.StatusInternalServerError, message, err)
+	}
+}
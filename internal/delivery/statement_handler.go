@@ -0,0 +1,146 @@
+package delivery
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"API-Avito-shop/internal/dto"
+	e "API-Avito-shop/internal/errors"
+	s "API-Avito-shop/internal/services"
+	"API-Avito-shop/internal/utils/pdf"
+
+	"github.com/gin-gonic/gin"
+)
+
+const statementDateLayout = "2006-01-02"
+
+var statementCSVHeader = []string{"date", "type", "counterparty", "item", "reason", "amount", "balance"}
+
+type StatementHandler struct {
+	statementService s.StatementService
+}
+
+func NewStatementHandler(statementService s.StatementService) *StatementHandler {
+	return &StatementHandler{
+		statementService: statementService,
+	}
+}
+
+// StatementHandler обрабатывает запрос на получение выписки за период в формате JSON, CSV или PDF
+func (h *StatementHandler) StatementHandler(c *gin.Context) {
+	username, err := getUsername(c)
+	if err != nil {
+		handleError(c, http.StatusBadRequest, "Failed to get user_id from context", err)
+		return
+	}
+
+	var query dto.StatementQuery
+	if err = c.ShouldBindQuery(&query); err != nil {
+		handleError(c, http.StatusBadRequest, "Invalid query parameters", err)
+		return
+	}
+
+	statement, err := h.statementService.Statement(c.Request.Context(), username, &query)
+	if err != nil {
+		switch {
+		case errors.Is(err, e.ErrInvalidPeriod):
+			handleError(c, http.StatusBadRequest, "Period must not be reversed or longer than a year", err)
+		default:
+			handleError(c, http.StatusInternalServerError, "Failed to build statement", err)
+		}
+		return
+	}
+
+	filename := fmt.Sprintf("statement-%s-%s-%s", statement.UserName,
+		statement.From.Format(statementDateLayout), statement.To.Format(statementDateLayout))
+
+	switch query.Format {
+	case "csv":
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		c.Header("Content-Disposition", `attachment; filename="`+filename+`.csv"`)
+		c.Status(http.StatusOK)
+		err = writeStatementCSV(c, statement)
+	case "pdf":
+		c.Header("Content-Type", "application/pdf")
+		c.Header("Content-Disposition", `attachment; filename="`+filename+`.pdf"`)
+		c.Status(http.StatusOK)
+		_, err = statementPDF(statement).WriteTo(c.Writer)
+	default:
+		c.JSON(http.StatusOK, statement)
+	}
+	if err != nil {
+		// Заголовки уже отправлены, поэтому ошибку можно только залогировать
+		slog.Error("Failed to write statement", "username", username, "format", query.Format, "error", err)
+	}
+}
+
+// writeStatementCSV записывает операции выписки в формате CSV
+func writeStatementCSV(c *gin.Context, statement dto.Statement) error {
+	w := csv.NewWriter(c.Writer)
+	if err := w.Write(statementCSVHeader); err != nil {
+		return err
+	}
+
+	for _, entry := range statement.Entries {
+		record := []string{
+			entry.Date.Format(time.RFC3339),
+			entry.Type,
+			entry.Counterparty,
+			entry.Item,
+			entry.Reason,
+			strconv.Itoa(entry.Amount),
+			strconv.Itoa(entry.Balance),
+		}
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+
+	w.Flush()
+	return w.Error()
+}
+
+// statementPDF формирует выписку в виде текстового PDF-документа
+func statementPDF(statement dto.Statement) *pdf.Document {
+	doc := pdf.New()
+	doc.Linef("Coin statement: %s", statement.UserName)
+	doc.Linef("Period: %s - %s", statement.From.Format(statementDateLayout), statement.To.Format(statementDateLayout))
+	doc.Line("")
+	doc.Linef("Opening balance: %d", statement.OpeningBalance)
+	doc.Linef("Credits: +%d   Debits: -%d", statement.TotalCredits, statement.TotalDebits)
+	doc.Linef("Closing balance: %d", statement.ClosingBalance)
+	doc.Line("")
+
+	doc.Linef("%-19s  %-12s  %-30s  %8s  %8s", "Date", "Type", "Details", "Amount", "Balance")
+	doc.Line(strings.Repeat("-", 83))
+	for _, entry := range statement.Entries {
+		detail := entry.Counterparty + entry.Item + entry.Reason
+		if entry.Item != "" && entry.Counterparty != "" {
+			detail = entry.Item + " -> " + entry.Counterparty
+		}
+		doc.Linef("%-19s  %-12s  %-30s  %+8d  %8d", entry.Date.Format("2006-01-02 15:04:05"), entry.Type, detail, entry.Amount, entry.Balance)
+	}
+	if len(statement.Entries) == 0 {
+		doc.Line("No operations in this period")
+	}
+
+	doc.Line("")
+	doc.Line("Summary")
+	doc.Line(strings.Repeat("-", 83))
+	doc.Linef("%-30s  %6d  %+8d", "Coins granted", statement.Summary.Grants.Count, statement.Summary.Grants.Amount)
+	doc.Linef("%-30s  %6d  %+8d", "Transfers received", statement.Summary.TransfersIn.Count, statement.Summary.TransfersIn.Amount)
+	doc.Linef("%-30s  %6d  %+8d", "Transfers sent", statement.Summary.TransfersOut.Count, -statement.Summary.TransfersOut.Amount)
+	doc.Linef("%-30s  %6d  %+8d", "Purchases", statement.Summary.Purchases.Count, -statement.Summary.Purchases.Amount)
+	doc.Linef("%-30s  %6d  %+8d", "Coins expired", statement.Summary.Expired.Count, -statement.Summary.Expired.Amount)
+	for _, item := range statement.Summary.ByItem {
+		doc.Linef("  %-28s  %6d  %+8d", item.Item, item.Quantity, -item.Amount)
+	}
+
+	return doc
+}
@@ -6,6 +6,7 @@ import (
 
 	"API-Avito-shop/internal/dto"
 	e "API-Avito-shop/internal/errors"
+	"API-Avito-shop/internal/models"
 	s "API-Avito-shop/internal/services"
 
 	"github.com/gin-gonic/gin"
@@ -37,18 +38,26 @@ func (h *TransactionHandler) SendCoinHandler(c *gin.Context) {
 		return
 	}
 
-	err = h.transactionService.SendCoin(c.Request.Context(), username, &sendCoinDTO)
+	result, err := h.transactionService.SendCoin(c.Request.Context(), username, &sendCoinDTO)
 	if err != nil {
 		switch {
 		case errors.Is(err, e.ErrNotEnoughCoins):
 			handleError(c, http.StatusBadRequest, "Insufficient balance", err)
 		case errors.Is(err, pgx.ErrNoRows) || errors.Is(err, e.ErrInvalidUser):
 			handleError(c, http.StatusBadRequest, "Invalid recipient", err)
+		case errors.Is(err, e.ErrTransferBlocked):
+			handleError(c, http.StatusForbidden, "Transfer blocked", err)
 		default:
 			handleError(c, http.StatusInternalServerError, "Failed to send coins", err)
 		}
 		return
 	}
 
+	// Задержанный перевод будет выполнен только после одобрения администратором
+	if result.Status == models.TransferHeld {
+		c.JSON(http.StatusAccepted, result)
+		return
+	}
+
 	c.Status(http.StatusOK)
 }
@@ -0,0 +1,169 @@
+package delivery
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"API-Avito-shop/internal/dto"
+	e "API-Avito-shop/internal/errors"
+	s "API-Avito-shop/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TwoFactorHandler struct {
+	twoFactorService s.TwoFactorService
+	token            s.Token
+}
+
+func NewTwoFactorHandler(twoFactorService s.TwoFactorService, token s.Token) *TwoFactorHandler {
+	return &TwoFactorHandler{
+		twoFactorService: twoFactorService,
+		token:            token,
+	}
+}
+
+// EnrollHandler обрабатывает запрос на получение секрета для приложения-аутентификатора
+func (h *TwoFactorHandler) EnrollHandler(c *gin.Context) {
+	username, err := getUsername(c)
+	if err != nil {
+		handleError(c, http.StatusBadRequest, "Failed to get user_id from context", err)
+		return
+	}
+
+	enrollment, err := h.twoFactorService.Enroll(c.Request.Context(), username)
+	if err != nil {
+		h.handleTwoFactorError(c, err, "Failed to start two-factor enrollment")
+		return
+	}
+
+	c.JSON(http.StatusOK, enrollment)
+}
+
+// ConfirmHandler обрабатывает подтверждение настройки, выдает коды восстановления и новый токен
+func (h *TwoFactorHandler) ConfirmHandler(c *gin.Context) {
+	username, err := getUsername(c)
+	if err != nil {
+		handleError(c, http.StatusBadRequest, "Failed to get user_id from context", err)
+		return
+	}
+
+	var codeDTO dto.TwoFactorCode
+	if err = c.ShouldBindJSON(&codeDTO); err != nil {
+		handleError(c, http.StatusBadRequest, "Invalid request data", err)
+		return
+	}
+
+	recoveryCodes, version, err := h.twoFactorService.Confirm(c.Request.Context(), username, codeDTO.Code)
+	if err != nil {
+		h.handleTwoFactorError(c, err, "Failed to confirm two-factor authentication")
+		return
+	}
+
+	token, err := h.token.GenerateToken(c.Request.Context(), username, version)
+	if err != nil {
+		handleError(c, http.StatusInternalServerError, "Failed to generate token", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"token": token, "recoveryCodes": recoveryCodes})
+}
+
+// DisableHandler обрабатывает запрос на отключение двухфакторной аутентификации и выдает новый токен
+func (h *TwoFactorHandler) DisableHandler(c *gin.Context) {
+	username, err := getUsername(c)
+	if err != nil {
+		handleError(c, http.StatusBadRequest, "Failed to get user_id from context", err)
+		return
+	}
+
+	var codeDTO dto.TwoFactorCode
+	if err = c.ShouldBindJSON(&codeDTO); err != nil {
+		handleError(c, http.StatusBadRequest, "Invalid request data", err)
+		return
+	}
+
+	version, err := h.twoFactorService.Disable(c.Request.Context(), username, codeDTO.Code)
+	if err != nil {
+		h.handleTwoFactorError(c, err, "Failed to disable two-factor authentication")
+		return
+	}
+
+	token, err := h.token.GenerateToken(c.Request.Context(), username, version)
+	if err != nil {
+		handleError(c, http.StatusInternalServerError, "Failed to generate token", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"token": token})
+}
+
+// RecoveryCodesHandler обрабатывает запрос на выпуск новых кодов восстановления
+func (h *TwoFactorHandler) RecoveryCodesHandler(c *gin.Context) {
+	username, err := getUsername(c)
+	if err != nil {
+		handleError(c, http.StatusBadRequest, "Failed to get user_id from context", err)
+		return
+	}
+
+	var codeDTO dto.TwoFactorCode
+	if err = c.ShouldBindJSON(&codeDTO); err != nil {
+		handleError(c, http.StatusBadRequest, "Invalid request data", err)
+		return
+	}
+
+	recoveryCodes, err := h.twoFactorService.RegenerateRecoveryCodes(c.Request.Context(), username, codeDTO.Code)
+	if err != nil {
+		h.handleTwoFactorError(c, err, "Failed to regenerate recovery codes")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"recoveryCodes": recoveryCodes})
+}
+
+// LoginHandler обрабатывает второй шаг входа и выдает токен
+func (h *TwoFactorHandler) LoginHandler(c *gin.Context) {
+	var loginDTO dto.TwoFactorLogin
+	if err := c.ShouldBindJSON(&loginDTO); err != nil {
+		handleError(c, http.StatusBadRequest, "Invalid request data", err)
+		return
+	}
+
+	user, err := h.twoFactorService.CompleteLogin(c.Request.Context(), &loginDTO, c.ClientIP())
+	if err != nil {
+		h.handleTwoFactorError(c, err, "Two-factor login failed")
+		return
+	}
+
+	token, err := h.token.GenerateToken(c.Request.Context(), user.UserName, user.TokenVersion)
+	if err != nil {
+		handleError(c, http.StatusInternalServerError, "Failed to generate token", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"token": token})
+}
+
+// handleTwoFactorError отправляет ответ в зависимости от ошибки сервиса двухфакторной аутентификации
+func (h *TwoFactorHandler) handleTwoFactorError(c *gin.Context, err error, message string) {
+	var lockoutErr *e.LockoutError
+
+	switch {
+	case errors.Is(err, e.ErrInvalidTwoFactor):
+		handleError(c, http.StatusUnauthorized, "Invalid two-factor code", err)
+	case errors.Is(err, e.ErrInvalidChallenge):
+		handleError(c, http.StatusUnauthorized, "Login challenge expired, sign in again", err)
+	case errors.Is(err, e.ErrTwoFactorEnabled):
+		handleError(c, http.StatusConflict, "Two-factor authentication is already enabled", err)
+	case errors.Is(err, e.ErrTwoFactorNotEnabled):
+		handleError(c, http.StatusConflict, "Two-factor authentication is not enabled", err)
+	case errors.Is(err, e.ErrUserDisabled):
+		handleError(c, http.StatusForbidden, "Account disabled", err)
+	case errors.As(err, &lockoutErr):
+		c.Header("Retry-After", strconv.Itoa(int(lockoutErr.RetryAfter.Seconds())+1))
+		handleError(c, http.StatusTooManyRequests, "Too many failed login attempts", err)
+	default:
+		handleError(c, http.StatusInternalServerError, message, err)
+	}
+}
@@ -3,6 +3,7 @@ package delivery
 import (
 	"errors"
 	"net/http"
+	"strconv"
 
 	"API-Avito-shop/internal/dto"
 	e "API-Avito-shop/internal/errors"
@@ -12,14 +13,16 @@ import (
 )
 
 type UserHandler struct {
-	userService s.UserService
-	token       s.Token
+	userService      s.UserService
+	twoFactorService s.TwoFactorService
+	token            s.Token
 }
 
-func NewUserHandler(userService s.UserService, token s.Token) *UserHandler {
+func NewUserHandler(userService s.UserService, twoFactorService s.TwoFactorService, token s.Token) *UserHandler {
 	return &UserHandler{
-		userService: userService,
-		token:       token,
+		userService:      userService,
+		twoFactorService: twoFactorService,
+		token:            token,
 	}
 }
 
@@ -32,23 +35,32 @@ func (h *UserHandler) AuthHandler(c *gin.Context) {
 		return
 	}
 
-	err := h.userService.AuthUser(c.Request.Context(), &userAuthDTO)
+	user, err := h.userService.AuthUser(c.Request.Context(), &userAuthDTO, c.ClientIP())
 	if err != nil {
 		if errors.Is(err, e.ErrInvalidPass) {
 			handleError(c, http.StatusUnauthorized, "Authorization failed", err)
 			return
 		}
+		if errors.Is(err, e.ErrUserDisabled) {
+			handleError(c, http.StatusForbidden, "Account disabled", err)
+			return
+		}
+		var policyErr *e.PolicyError
+		if errors.As(err, &policyErr) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Password does not satisfy policy", "violations": policyErr.Violations})
+			return
+		}
+		var lockoutErr *e.LockoutError
+		if errors.As(err, &lockoutErr) {
+			c.Header("Retry-After", strconv.Itoa(int(lockoutErr.RetryAfter.Seconds())+1))
+			handleError(c, http.StatusTooManyRequests, "Too many failed login attempts", err)
+			return
+		}
 		handleError(c, http.StatusInternalServerError, "Authentication service error", err)
 		return
 	}
 
-	token, err := h.token.GenerateToken(c.Request.Context(), userAuthDTO.UserName)
-	if err != nil {
-		handleError(c, http.StatusInternalServerError, "Failed to generate token", err)
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{"token": token})
+	respondWithLoginToken(c, h.token, h.twoFactorService, user)
 }
 
 // InfoHandler обрабатывает запрос на получение информации о балансе и действиях пользователя
@@ -65,11 +77,5 @@ func (h *UserHandler) InfoHandler(c *gin.Context) {
 		return
 	}
 
-	response := dto.InfoResponse{
-		Coins:       userInfo.Coins,
-		Inventory:   userInfo.Inventory,
-		CoinHistory: userInfo.CoinHistory,
-	}
-
-	c.JSON(http.StatusOK, response)
+	c.JSON(http.StatusOK, userInfo)
 }
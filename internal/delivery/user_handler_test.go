@@ -0,0 +1,87 @@
+package delivery
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"API-Avito-shop/internal/dto"
+	s "API-Avito-shop/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fakeUserService возвращает заданную информацию о пользователе, остальные методы не используются
+type fakeUserService struct {
+	s.UserService
+	info dto.InfoResponse
+}
+
+func (f *fakeUserService) UserInfo(context.Context, string) (dto.InfoResponse, error) {
+	return f.info, nil
+}
+
+func TestInfoHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	expiresAt := time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)
+	giftedAt := time.Date(2026, 3, 8, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		info dto.InfoResponse
+	}{
+		{
+			name: "balance and history",
+			info: dto.InfoResponse{
+				Coins:       900,
+				Inventory:   []dto.Item{{Type: "cup", Quantity: 1}},
+				CoinHistory: dto.CoinHistory{Sent: []dto.SentCoin{{ToUser: "bob", Amount: 80}}},
+			},
+		},
+		{
+			name: "expiring coins",
+			info: dto.InfoResponse{
+				Coins:         1000,
+				ExpiringCoins: []dto.ExpiringCoins{{Amount: 1000, ExpiresAt: expiresAt}},
+			},
+		},
+		{
+			name: "gifts",
+			info: dto.InfoResponse{
+				Coins: 980,
+				Gifts: dto.GiftHistory{
+					Received: []dto.ReceivedGift{{FromUser: "bob", Item: "pen", CreatedAt: giftedAt}},
+					Sent:     []dto.SentGift{{ToUser: "bob", Item: "cup", Message: "Спасибо!", CreatedAt: giftedAt}},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		handler := NewUserHandler(&fakeUserService{info: tt.info}, nil, nil)
+		recorder := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(recorder)
+		c.Request = httptest.NewRequest(http.MethodGet, "/api/info", nil)
+		c.Set("username", "alice")
+
+		handler.InfoHandler(c)
+
+		if recorder.Code != http.StatusOK {
+			t.Errorf("%s: InfoHandler() status = %d, want %d", tt.name, recorder.Code, http.StatusOK)
+			continue
+		}
+		// Ответ содержит все поля, заполненные сервисом
+		var got dto.InfoResponse
+		if err := json.Unmarshal(recorder.Body.Bytes(), &got); err != nil {
+			t.Errorf("%s: InfoHandler() returned invalid JSON: %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.info) {
+			t.Errorf("%s: InfoHandler() = %+v, want %+v", tt.name, got, tt.info)
+		}
+	}
+}
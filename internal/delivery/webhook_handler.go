@@ -0,0 +1,153 @@
+package delivery
+
+import (
+	"errors"
+	"net/http"
+
+	"API-Avito-shop/internal/dto"
+	e "API-Avito-shop/internal/errors"
+	s "API-Avito-shop/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WebhookHandler struct {
+	webhookService s.WebhookService
+}
+
+func NewWebhookHandler(webhookService s.WebhookService) *WebhookHandler {
+	return &WebhookHandler{
+		webhookService: webhookService,
+	}
+}
+
+// CreateWebhookHandler обрабатывает запрос на регистрацию webhook
+func (h *WebhookHandler) CreateWebhookHandler(c *gin.Context) {
+	username, err := getUsername(c)
+	if err != nil {
+		handleError(c, http.StatusBadRequest, "Failed to get user_id from context", err)
+		return
+	}
+
+	var createDTO dto.CreateWebhook
+	if err = c.ShouldBindJSON(&createDTO); err != nil {
+		handleError(c, http.StatusBadRequest, "Invalid request data", err)
+		return
+	}
+
+	webhook, err := h.webhookService.CreateWebhook(c.Request.Context(), username, &createDTO)
+	if err != nil {
+		h.handleWebhookError(c, err, "Failed to create webhook")
+		return
+	}
+
+	c.JSON(http.StatusCreated, webhook)
+}
+
+// ListWebhooksHandler обрабатывает запрос на получение списка webhook пользователя
+func (h *WebhookHandler) ListWebhooksHandler(c *gin.Context) {
+	username, err := getUsername(c)
+	if err != nil {
+		handleError(c, http.StatusBadRequest, "Failed to get user_id from context", err)
+		return
+	}
+
+	webhooks, err := h.webhookService.ListWebhooks(c.Request.Context(), username)
+	if err != nil {
+		handleError(c, http.StatusInternalServerError, "Failed to list webhooks", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"webhooks": webhooks})
+}
+
+// DeleteWebhookHandler обрабатывает запрос на удаление webhook
+func (h *WebhookHandler) DeleteWebhookHandler(c *gin.Context) {
+	username, err := getUsername(c)
+	if err != nil {
+		handleError(c, http.StatusBadRequest, "Failed to get user_id from context", err)
+		return
+	}
+
+	id, err := getIDParam(c, "id")
+	if err != nil {
+		handleError(c, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	if err = h.webhookService.DeleteWebhook(c.Request.Context(), username, id); err != nil {
+		h.handleWebhookError(c, err, "Failed to delete webhook")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListDeliveriesHandler обрабатывает запрос на получение истории доставок webhook
+func (h *WebhookHandler) ListDeliveriesHandler(c *gin.Context) {
+	username, err := getUsername(c)
+	if err != nil {
+		handleError(c, http.StatusBadRequest, "Failed to get user_id from context", err)
+		return
+	}
+
+	id, err := getIDParam(c, "id")
+	if err != nil {
+		handleError(c, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	limit, offset, err := getPagination(c)
+	if err != nil {
+		handleError(c, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	deliveries, err := h.webhookService.ListDeliveries(c.Request.Context(), username, id, limit, offset)
+	if err != nil {
+		h.handleWebhookError(c, err, "Failed to list deliveries")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"deliveries": deliveries})
+}
+
+// RetryDeliveryHandler обрабатывает запрос на повторную отправку доставки из dead-letter
+func (h *WebhookHandler) RetryDeliveryHandler(c *gin.Context) {
+	username, err := getUsername(c)
+	if err != nil {
+		handleError(c, http.StatusBadRequest, "Failed to get user_id from context", err)
+		return
+	}
+
+	id, err := getIDParam(c, "id")
+	if err != nil {
+		handleError(c, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	deliveryID, err := getIDParam(c, "deliveryId")
+	if err != nil {
+		handleError(c, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	if err = h.webhookService.RetryDelivery(c.Request.Context(), username, id, deliveryID); err != nil {
+		h.handleWebhookError(c, err, "Failed to retry delivery")
+		return
+	}
+
+	c.Status(http.StatusAccepted)
+}
+
+// handleWebhookError отправляет ответ в зависимости от ошибки сервиса webhook
+func (h *WebhookHandler) handleWebhookError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, e.ErrWebhookNotFound):
+		handleError(c, http.StatusNotFound, "Webhook not found", err)
+	case errors.Is(err, e.ErrForbidden):
+		handleError(c, http.StatusForbidden, "Admin role required", err)
+	default:
+		handleError(c, http.StatusInternalServerError, message, err)
+	}
+}
@@ -0,0 +1,41 @@
+package dto
+
+import "time"
+
+// CreateServiceAccount представляет данные для создания сервисной учетной записи
+type CreateServiceAccount struct {
+	UserName string `json:"username" binding:"required,username"`
+}
+
+// ServiceAccount представляет данные о сервисной учетной записи
+type ServiceAccount struct {
+	UserName string `json:"username"`
+	Balance  int    `json:"balance"`
+}
+
+// CreateAPIKey представляет данные для выпуска API-ключа
+type CreateAPIKey struct {
+	Name   string   `json:"name" binding:"required,max=64"`
+	Scopes []string `json:"scopes" binding:"required,min=1,dive,oneof=shop:read shop:buy coins:send events:read"`
+	// ExpiresInDays срок действия ключа, 0 — бессрочный
+	ExpiresInDays int `json:"expiresInDays" binding:"min=0,max=3650"`
+}
+
+// APIKey представляет данные об API-ключе без секретной части
+type APIKey struct {
+	ID         int64      `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	CreatedBy  string     `json:"createdBy"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty"`
+}
+
+// APIKeyCreated представляет выпущенный ключ, секрет показывается только один раз
+type APIKeyCreated struct {
+	APIKey
+	Key string `json:"key"`
+}
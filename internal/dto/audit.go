@@ -0,0 +1,41 @@
+package dto
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AuditQuery представляет фильтры выборки журнала аудита
+type AuditQuery struct {
+	Actor  string     `form:"actor" binding:"max=255"`
+	Action string     `form:"action" binding:"max=64"`
+	Target string     `form:"target" binding:"max=255"`
+	From   *time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To     *time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+}
+
+// AuditEntry представляет запись журнала аудита
+type AuditEntry struct {
+	ID        int64           `json:"id"`
+	Actor     string          `json:"actor"`
+	Action    string          `json:"action"`
+	Target    string          `json:"target,omitempty"`
+	Amount    *int            `json:"amount,omitempty"`
+	Details   json.RawMessage `json:"details,omitempty"`
+	IP        string          `json:"ip,omitempty"`
+	UserAgent string          `json:"userAgent,omitempty"`
+	RequestID string          `json:"requestId,omitempty"`
+	PrevHash  string          `json:"prevHash"`
+	Hash      string          `json:"hash"`
+	CreatedAt time.Time       `json:"createdAt"`
+}
+
+// AuditVerification представляет результат проверки цепочки хешей журнала
+type AuditVerification struct {
+	Valid   bool  `json:"valid"`
+	Checked int64 `json:"checked"`
+	// BrokenAt id первой записи, не совпадающей с цепочкой
+	BrokenAt *int64 `json:"brokenAt,omitempty"`
+	// Pending записи, еще не включенные в цепочку фоновым процессом
+	Pending int64 `json:"pending"`
+}
@@ -0,0 +1,36 @@
+package dto
+
+import "time"
+
+// SendCoinResult представляет результат перевода, задержанного до проверки администратором
+type SendCoinResult struct {
+	Status   string `json:"status"`
+	ReviewID int64  `json:"reviewId,omitempty"`
+}
+
+// FraudReviewQuery представляет фильтры очереди проверки переводов
+type FraudReviewQuery struct {
+	Status string `form:"status" binding:"omitempty,oneof=pending approved rejected blocked"`
+	Action string `form:"action" binding:"omitempty,oneof=flag hold block"`
+	User   string `form:"user" binding:"max=255"`
+}
+
+// FraudReview представляет перевод в очереди проверки
+type FraudReview struct {
+	ID         int64      `json:"id"`
+	FromUser   string     `json:"fromUser"`
+	ToUser     string     `json:"toUser"`
+	Amount     int        `json:"amount"`
+	Action     string     `json:"action"`
+	Rules      []string   `json:"rules"`
+	Status     string     `json:"status"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	ReviewedBy string     `json:"reviewedBy,omitempty"`
+	ReviewedAt *time.Time `json:"reviewedAt,omitempty"`
+	Note       string     `json:"note,omitempty"`
+}
+
+// ResolveFraudReview представляет решение администратора по переводу
+type ResolveFraudReview struct {
+	Note string `json:"note" binding:"max=500"`
+}
@@ -1,10 +1,21 @@
 package dto
 
+import "time"
+
 // InfoResponse представляет сводные данные о балансе и действиях пользователя
 type InfoResponse struct {
 	Coins       int         `json:"coins"`
 	Inventory   []Item      `json:"inventory"`
 	CoinHistory CoinHistory `json:"coinHistory"`
+	Gifts       GiftHistory `json:"gifts"`
+	// ExpiringCoins монеты баланса по дням сгорания, если монеты имеют срок действия
+	ExpiringCoins []ExpiringCoins `json:"expiringCoins,omitempty"`
+}
+
+// ExpiringCoins представляет монеты баланса, сгорающие в один день
+type ExpiringCoins struct {
+	Amount    int       `json:"amount"`
+	ExpiresAt time.Time `json:"expiresAt"`
 }
 
 // Item представляет данные о приобретенном товаре
@@ -21,12 +32,38 @@ type CoinHistory struct {
 
 // ReceivedCoin представляет данные от кого были получены монеты
 type ReceivedCoin struct {
-	FromUser string `json:"fromUser"`
-	Amount   int    `json:"amount"`
+	FromUser        string `json:"fromUser"`
+	FromDisplayName string `json:"fromDisplayName,omitempty"`
+	Amount          int    `json:"amount"`
 }
 
 // SentCoin представляет данные кому были отправлены монеты
 type SentCoin struct {
-	ToUser string `json:"toUser"`
-	Amount int    `json:"amount"`
+	ToUser        string `json:"toUser"`
+	ToDisplayName string `json:"toDisplayName,omitempty"`
+	Amount        int    `json:"amount"`
+}
+
+// GiftHistory представляет данные о подарках пользователя
+type GiftHistory struct {
+	Received []ReceivedGift `json:"received,omitempty"`
+	Sent     []SentGift     `json:"sent,omitempty"`
+}
+
+// ReceivedGift представляет данные о полученном подарке
+type ReceivedGift struct {
+	FromUser        string    `json:"fromUser"`
+	FromDisplayName string    `json:"fromDisplayName,omitempty"`
+	Item            string    `json:"item"`
+	Message         string    `json:"message,omitempty"`
+	CreatedAt       time.Time `json:"createdAt"`
+}
+
+// SentGift представляет данные о подарке, купленном для другого пользователя
+type SentGift struct {
+	ToUser        string    `json:"toUser"`
+	ToDisplayName string    `json:"toDisplayName,omitempty"`
+	Item          string    `json:"item"`
+	Message       string    `json:"message,omitempty"`
+	CreatedAt     time.Time `json:"createdAt"`
 }
@@ -0,0 +1,53 @@
+package dto
+
+import "time"
+
+// GiveItems представляет данные для передачи товаров из инвентаря другому пользователю.
+// Без Quantity передается один экземпляр.
+type GiveItems struct {
+	ToUser   string `json:"toUser" binding:"required,username"`
+	Item     string `json:"item" binding:"required,max=20"`
+	Quantity int    `json:"quantity" binding:"omitempty,min=1,max=100"`
+}
+
+// TradeItem представляет товар и количество экземпляров в обмене
+type TradeItem struct {
+	Item     string `json:"item" binding:"required,max=20"`
+	Quantity int    `json:"quantity" binding:"required,min=1,max=100"`
+}
+
+// ProposeTrade представляет предложение обмена: Offer отдает инициатор, Request отдает получатель
+type ProposeTrade struct {
+	ToUser  string      `json:"toUser" binding:"required,username"`
+	Offer   []TradeItem `json:"offer" binding:"required,min=1,max=10,unique=Item,dive"`
+	Request []TradeItem `json:"request" binding:"required,min=1,max=10,unique=Item,dive"`
+	Message string      `json:"message" binding:"max=200"`
+}
+
+// TradeQuery представляет фильтр списка обменов
+type TradeQuery struct {
+	Status string `form:"status" binding:"omitempty,oneof=pending accepted declined cancelled"`
+}
+
+// Trade представляет предложение обмена
+type Trade struct {
+	ID         int64       `json:"id"`
+	Proposer   string      `json:"proposer"`
+	Recipient  string      `json:"recipient"`
+	Message    string      `json:"message,omitempty"`
+	Status     string      `json:"status"`
+	Offer      []TradeItem `json:"offer"`
+	Request    []TradeItem `json:"request"`
+	CreatedAt  time.Time   `json:"createdAt"`
+	ResolvedAt *time.Time  `json:"resolvedAt,omitempty"`
+}
+
+// ItemTransfer представляет передачу товаров между пользователями
+type ItemTransfer struct {
+	FromUser  string    `json:"fromUser"`
+	ToUser    string    `json:"toUser"`
+	Item      string    `json:"item"`
+	Quantity  int       `json:"quantity"`
+	TradeID   *int64    `json:"tradeId,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
@@ -0,0 +1,30 @@
+package dto
+
+import "time"
+
+// LeaderboardQuery представляет параметры запроса рейтингов
+type LeaderboardQuery struct {
+	Period string `form:"period" binding:"omitempty,oneof=week month all"`
+	// Date выбирает неделю или месяц, содержащие эту дату; по умолчанию текущие
+	Date  *time.Time `form:"date" time_format:"2006-01-02" time_utc:"1"`
+	Limit int        `form:"limit" binding:"omitempty,min=1,max=100"`
+}
+
+// LeaderboardEntry представляет место пользователя в рейтинге
+type LeaderboardEntry struct {
+	Rank        int    `json:"rank"`
+	UserName    string `json:"username"`
+	DisplayName string `json:"displayName"`
+	Amount      int64  `json:"amount"`
+}
+
+// Leaderboard представляет рейтинги за период
+type Leaderboard struct {
+	Period       string             `json:"period"`
+	From         *time.Time         `json:"from,omitempty"`
+	To           *time.Time         `json:"to,omitempty"`
+	UpdatedAt    time.Time          `json:"updatedAt"`
+	TopReceivers []LeaderboardEntry `json:"topReceivers"`
+	TopGivers    []LeaderboardEntry `json:"topGivers"`
+	TopSpenders  []LeaderboardEntry `json:"topSpenders"`
+}
@@ -0,0 +1,20 @@
+package dto
+
+// ChainVerification представляет результат проверки цепочки хешей таблицы
+type ChainVerification struct {
+	Valid   bool  `json:"valid"`
+	Checked int64 `json:"checked"`
+	// BrokenAt id первой строки, не совпадающей с цепочкой
+	BrokenAt *int64 `json:"brokenAt,omitempty"`
+	// Pending строки, еще не включенные в цепочку фоновым процессом
+	Pending int64 `json:"pending"`
+	// Legacy строки, добавленные до появления цепочки и ею не защищенные
+	Legacy int64 `json:"legacy,omitempty"`
+}
+
+// LedgerVerification представляет результат проверки истории переводов и покупок
+type LedgerVerification struct {
+	Valid        bool              `json:"valid"`
+	Transactions ChainVerification `json:"transactions"`
+	Purchases    ChainVerification `json:"purchases"`
+}
@@ -0,0 +1,6 @@
+package dto
+
+// OIDCLink представляет адрес страницы входа провайдера для связывания учетной записи
+type OIDCLink struct {
+	AuthURL string `json:"authUrl"`
+}
@@ -0,0 +1,10 @@
+package dto
+
+import "time"
+
+// PasswordResetToken представляет выданный администратором одноразовый токен сброса пароля
+type PasswordResetToken struct {
+	Username  string    `json:"username"`
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
@@ -0,0 +1,60 @@
+package dto
+
+import "time"
+
+// SchedulePrice представляет новую цену товара. Без EffectiveFrom цена вступает в силу сразу.
+type SchedulePrice struct {
+	Price         int        `json:"price" binding:"required,min=1"`
+	EffectiveFrom *time.Time `json:"effectiveFrom"`
+}
+
+// PriceChange представляет цену товара из истории или запланированную
+type PriceChange struct {
+	ID            int64     `json:"id"`
+	Item          string    `json:"item"`
+	Price         int       `json:"price"`
+	EffectiveFrom time.Time `json:"effectiveFrom"`
+	Status        string    `json:"status"`
+	CreatedBy     string    `json:"createdBy,omitempty"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+// CreatePromotion представляет новую акцию. Без Item акция действует на весь каталог,
+// без StartsAt начинается сразу.
+type CreatePromotion struct {
+	Name     string     `json:"name" binding:"required,max=100"`
+	Item     string     `json:"item" binding:"max=20"`
+	Kind     string     `json:"kind" binding:"required,oneof=percent fixed"`
+	Value    int        `json:"value" binding:"required,min=1"`
+	StartsAt *time.Time `json:"startsAt"`
+	EndsAt   time.Time  `json:"endsAt" binding:"required"`
+}
+
+// PromotionQuery представляет фильтр списка акций
+type PromotionQuery struct {
+	Status string `form:"status" binding:"omitempty,oneof=scheduled active ended cancelled"`
+}
+
+// Promotion представляет акцию
+type Promotion struct {
+	ID          int64      `json:"id"`
+	Name        string     `json:"name"`
+	Item        string     `json:"item,omitempty"`
+	Kind        string     `json:"kind"`
+	Value       int        `json:"value"`
+	StartsAt    time.Time  `json:"startsAt"`
+	EndsAt      time.Time  `json:"endsAt"`
+	Status      string     `json:"status"`
+	CreatedBy   string     `json:"createdBy"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	CancelledAt *time.Time `json:"cancelledAt,omitempty"`
+}
+
+// CatalogItem представляет товар с текущей ценой с учетом акций
+type CatalogItem struct {
+	Item            string     `json:"item"`
+	Price           int        `json:"price"`
+	BasePrice       int        `json:"basePrice"`
+	Promotion       string     `json:"promotion,omitempty"`
+	PromotionEndsAt *time.Time `json:"promotionEndsAt,omitempty"`
+}
@@ -0,0 +1,45 @@
+package dto
+
+import "time"
+
+// Profile представляет профиль пользователя
+type Profile struct {
+	UserName    string     `json:"username"`
+	DisplayName string     `json:"displayName"`
+	Department  string     `json:"department"`
+	Team        string     `json:"team"`
+	AvatarURL   string     `json:"avatarUrl"`
+	Bio         string     `json:"bio"`
+	UpdatedAt   *time.Time `json:"updatedAt,omitempty"`
+}
+
+// UpdateProfile представляет данные для изменения своего профиля, незаполненные поля очищаются
+type UpdateProfile struct {
+	DisplayName string `json:"displayName" binding:"max=64"`
+	Department  string `json:"department" binding:"max=64"`
+	Team        string `json:"team" binding:"max=64"`
+	AvatarURL   string `json:"avatarUrl" binding:"omitempty,max=512,http_url"`
+	Bio         string `json:"bio" binding:"max=500"`
+}
+
+// DirectorySearchQuery представляет параметры поиска коллег
+type DirectorySearchQuery struct {
+	Query string `form:"q" binding:"required,min=2,max=64"`
+}
+
+// DirectoryUser представляет коллегу в результатах поиска
+type DirectoryUser struct {
+	UserName    string `json:"username"`
+	DisplayName string `json:"displayName"`
+	Department  string `json:"department"`
+	Team        string `json:"team"`
+	AvatarURL   string `json:"avatarUrl"`
+}
+
+// RecentRecipient представляет недавнего получателя переводов пользователя
+type RecentRecipient struct {
+	UserName    string     `json:"username"`
+	DisplayName string     `json:"displayName"`
+	LastSentAt  *time.Time `json:"lastSentAt,omitempty"`
+	Transfers   int        `json:"transfers"`
+}
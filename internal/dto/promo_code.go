@@ -0,0 +1,55 @@
+package dto
+
+import "time"
+
+// BuyQuery представляет необязательные параметры покупки
+type BuyQuery struct {
+	Code string `form:"code" binding:"max=32"`
+}
+
+// CreatePromoCode представляет новый промокод. Без Code код генерируется, без Items действует на любой товар,
+// без MaxRedemptions не ограничен общим числом погашений, без PerUserLimit погашается пользователем один раз.
+type CreatePromoCode struct {
+	Code           string     `json:"code" binding:"omitempty,alphanum,min=4,max=32"`
+	Description    string     `json:"description" binding:"max=200"`
+	Kind           string     `json:"kind" binding:"required,oneof=percent fixed"`
+	Value          int        `json:"value" binding:"required,min=1"`
+	Items          []string   `json:"items" binding:"max=50,dive,required,max=20"`
+	User           string     `json:"user" binding:"max=255"`
+	MaxRedemptions *int       `json:"maxRedemptions" binding:"omitempty,min=1"`
+	PerUserLimit   int        `json:"perUserLimit" binding:"omitempty,min=1"`
+	ExpiresAt      *time.Time `json:"expiresAt"`
+}
+
+// PromoCodeQuery представляет фильтр списка промокодов
+type PromoCodeQuery struct {
+	Status string `form:"status" binding:"omitempty,oneof=active expired exhausted disabled"`
+}
+
+// PromoCode представляет промокод
+type PromoCode struct {
+	ID             int64      `json:"id"`
+	Code           string     `json:"code"`
+	Description    string     `json:"description,omitempty"`
+	Kind           string     `json:"kind"`
+	Value          int        `json:"value"`
+	Items          []string   `json:"items,omitempty"`
+	User           string     `json:"user,omitempty"`
+	MaxRedemptions *int       `json:"maxRedemptions,omitempty"`
+	PerUserLimit   int        `json:"perUserLimit"`
+	Redemptions    int        `json:"redemptions"`
+	ExpiresAt      *time.Time `json:"expiresAt,omitempty"`
+	Status         string     `json:"status"`
+	CreatedBy      string     `json:"createdBy"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	DisabledAt     *time.Time `json:"disabledAt,omitempty"`
+}
+
+// PromoRedemption представляет погашение промокода
+type PromoRedemption struct {
+	UserName  string    `json:"user"`
+	Item      string    `json:"item"`
+	Price     int       `json:"price"`
+	Discount  int       `json:"discount"`
+	CreatedAt time.Time `json:"createdAt"`
+}
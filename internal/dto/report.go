@@ -0,0 +1,85 @@
+package dto
+
+import "time"
+
+// EconomyReportQuery представляет период отчета, обе даты включаются
+type EconomyReportQuery struct {
+	From   *time.Time `form:"from" time_format:"2006-01-02" time_utc:"1"`
+	To     *time.Time `form:"to" time_format:"2006-01-02" time_utc:"1"`
+	Format string     `form:"format" binding:"omitempty,oneof=json csv"`
+}
+
+// EconomyTotals представляет итоговые показатели экономики монет
+type EconomyTotals struct {
+	// Circulation монеты на балансах всех пользователей на момент отчета
+	Circulation int64 `json:"circulation"`
+	// Minted монеты, выпущенные в оборот за период
+	Minted int64 `json:"minted"`
+	// SpentInShop монеты, выведенные из оборота покупками за период
+	SpentInShop int64 `json:"spentInShop"`
+	Purchases   int64 `json:"purchases"`
+	// Expired монеты, сгоревшие по истечении срока действия за период
+	Expired int64 `json:"expired"`
+	// NetIssuance изменение количества монет в обороте за период
+	NetIssuance int64 `json:"netIssuance"`
+}
+
+// TransferStats представляет показатели переводов за период
+type TransferStats struct {
+	Count     int64 `json:"count"`
+	Volume    int64 `json:"volume"`
+	Senders   int64 `json:"senders"`
+	Receivers int64 `json:"receivers"`
+	// Velocity отношение объема переводов за период к монетам в обороте
+	Velocity float64 `json:"velocity"`
+	// AvgDailyVolume средний объем переводов в день
+	AvgDailyVolume float64 `json:"avgDailyVolume"`
+}
+
+// ItemSales представляет продажи товара за период
+type ItemSales struct {
+	Item     string `json:"item"`
+	Quantity int64  `json:"quantity"`
+	Revenue  int64  `json:"revenue"`
+}
+
+// DormantAccount представляет учетную запись без операций за период
+type DormantAccount struct {
+	UserName       string     `json:"username"`
+	Balance        int        `json:"balance"`
+	LastActivityAt *time.Time `json:"lastActivityAt,omitempty"`
+}
+
+// DormantAccounts представляет учетные записи без переводов и покупок за период
+type DormantAccounts struct {
+	Count int64 `json:"count"`
+	Coins int64 `json:"coins"`
+	// Accounts учетные записи с наибольшим балансом
+	Accounts []DormantAccount `json:"accounts"`
+}
+
+// BalanceDistribution представляет распределение балансов пользователей на момент отчета
+type BalanceDistribution struct {
+	Accounts int64   `json:"accounts"`
+	Min      int     `json:"min"`
+	Max      int     `json:"max"`
+	Mean     float64 `json:"mean"`
+	P10      float64 `json:"p10"`
+	P25      float64 `json:"p25"`
+	P50      float64 `json:"p50"`
+	P75      float64 `json:"p75"`
+	P90      float64 `json:"p90"`
+	P99      float64 `json:"p99"`
+}
+
+// EconomyReport представляет отчет о состоянии экономики монет за период
+type EconomyReport struct {
+	From                time.Time           `json:"from"`
+	To                  time.Time           `json:"to"`
+	GeneratedAt         time.Time           `json:"generatedAt"`
+	Totals              EconomyTotals       `json:"totals"`
+	Transfers           TransferStats       `json:"transfers"`
+	TopItems            []ItemSales         `json:"topItems"`
+	Dormant             DormantAccounts     `json:"dormant"`
+	BalanceDistribution BalanceDistribution `json:"balanceDistribution"`
+}
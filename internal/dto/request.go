@@ -3,7 +3,7 @@ package dto
 // UserAuth представляет данные для аутентификации пользователя
 type UserAuth struct {
 	UserName string `json:"username" binding:"required,username"`
-	Password string `json:"password" binding:"required,min=8,max=20"`
+	Password string `json:"password" binding:"required,min=8,max=72"`
 }
 
 // SendCoin представляет данные для отправки монет
@@ -11,3 +11,23 @@ type SendCoin struct {
 	ToUser string `json:"toUser" binding:"required,username"`
 	Amount int    `json:"amount" binding:"required,min=1"`
 }
+
+// ChangePassword представляет данные для смены пароля
+type ChangePassword struct {
+	CurrentPassword string `json:"currentPassword" binding:"required,max=72"`
+	NewPassword     string `json:"newPassword" binding:"required"`
+}
+
+// ResetPassword представляет данные для установки пароля по токену сброса
+type ResetPassword struct {
+	Token       string `json:"token" binding:"required,hexadecimal"`
+	NewPassword string `json:"newPassword" binding:"required"`
+}
+
+// GiftPurchase представляет данные для покупки товара в подарок
+type GiftPurchase struct {
+	Item    string `json:"item" binding:"required,max=20"`
+	ToUser  string `json:"toUser" binding:"required,username"`
+	Message string `json:"message" binding:"max=200"`
+	Code    string `json:"code" binding:"max=32"`
+}
@@ -0,0 +1,58 @@
+package dto
+
+import "time"
+
+// StatementQuery представляет период выписки, обе даты включаются.
+// По умолчанию выписка строится с начала текущего месяца по сегодняшний день.
+type StatementQuery struct {
+	From   *time.Time `form:"from" time_format:"2006-01-02" time_utc:"1"`
+	To     *time.Time `form:"to" time_format:"2006-01-02" time_utc:"1"`
+	Format string     `form:"format" binding:"omitempty,oneof=json csv pdf"`
+}
+
+// StatementEntry представляет операцию выписки с остатком после нее
+type StatementEntry struct {
+	Date         time.Time `json:"date"`
+	Type         string    `json:"type"`
+	Counterparty string    `json:"counterparty,omitempty"`
+	Item         string    `json:"item,omitempty"`
+	Reason       string    `json:"reason,omitempty"`
+	Amount       int       `json:"amount"`
+	Balance      int       `json:"balance"`
+}
+
+// StatementTotal представляет количество и сумму операций одного вида
+type StatementTotal struct {
+	Count  int `json:"count"`
+	Amount int `json:"amount"`
+}
+
+// StatementItemTotal представляет покупки одного товара за период
+type StatementItemTotal struct {
+	Item     string `json:"item"`
+	Quantity int    `json:"quantity"`
+	Amount   int    `json:"amount"`
+}
+
+// StatementSummary представляет итоги выписки по видам операций
+type StatementSummary struct {
+	Grants       StatementTotal       `json:"grants"`
+	Expired      StatementTotal       `json:"expired"`
+	TransfersIn  StatementTotal       `json:"transfersIn"`
+	TransfersOut StatementTotal       `json:"transfersOut"`
+	Purchases    StatementTotal       `json:"purchases"`
+	ByItem       []StatementItemTotal `json:"byItem"`
+}
+
+// Statement представляет выписку по кошельку пользователя за период
+type Statement struct {
+	UserName       string           `json:"username"`
+	From           time.Time        `json:"from"`
+	To             time.Time        `json:"to"`
+	OpeningBalance int              `json:"openingBalance"`
+	ClosingBalance int              `json:"closingBalance"`
+	TotalCredits   int              `json:"totalCredits"`
+	TotalDebits    int              `json:"totalDebits"`
+	Entries        []StatementEntry `json:"entries"`
+	Summary        StatementSummary `json:"summary"`
+}
@@ -0,0 +1,24 @@
+package dto
+
+// TOTPEnrollment представляет секрет для настройки приложения-аутентификатора
+type TOTPEnrollment struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauthUri"`
+}
+
+// TwoFactorCode представляет код из приложения-аутентификатора или код восстановления
+type TwoFactorCode struct {
+	Code string `json:"code" binding:"required,max=32"`
+}
+
+// TwoFactorLogin представляет данные второго шага входа
+type TwoFactorLogin struct {
+	ChallengeToken string `json:"challengeToken" binding:"required,hexadecimal"`
+	Code           string `json:"code" binding:"required,max=32"`
+}
+
+// TwoFactorChallenge представляет ответ на первый шаг входа, если требуется второй фактор
+type TwoFactorChallenge struct {
+	TwoFactorRequired bool   `json:"twoFactorRequired"`
+	ChallengeToken    string `json:"challengeToken"`
+}
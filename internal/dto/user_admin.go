@@ -0,0 +1,40 @@
+package dto
+
+import "time"
+
+// UserSearchQuery представляет фильтры поиска пользователей администратором
+type UserSearchQuery struct {
+	Query  string `form:"query" binding:"max=255"`
+	Role   string `form:"role" binding:"omitempty,oneof=user admin service"`
+	Status string `form:"status" binding:"omitempty,oneof=active disabled deleted"`
+}
+
+// UnlockQuery представляет IP-адрес, блокировка которого снимается вместе с блокировкой учетной записи
+type UnlockQuery struct {
+	IP string `form:"ip" binding:"omitempty,ip"`
+}
+
+// AdminUser представляет данные об учетной записи для администратора
+type AdminUser struct {
+	UserName       string     `json:"username"`
+	Role           string     `json:"role"`
+	Status         string     `json:"status"`
+	Balance        int        `json:"balance"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	DisabledAt     *time.Time `json:"disabledAt,omitempty"`
+	DisabledReason string     `json:"disabledReason,omitempty"`
+	DeletedAt      *time.Time `json:"deletedAt,omitempty"`
+}
+
+// AdminUserDetails представляет профиль пользователя вместе с инвентарем, историей переводов и подарков
+type AdminUserDetails struct {
+	AdminUser
+	Inventory   []Item      `json:"inventory"`
+	CoinHistory CoinHistory `json:"coinHistory"`
+	Gifts       GiftHistory `json:"gifts"`
+}
+
+// DisableUser представляет данные для отключения учетной записи
+type DisableUser struct {
+	Reason string `json:"reason" binding:"required,max=500"`
+}
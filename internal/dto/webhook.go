@@ -0,0 +1,50 @@
+package dto
+
+import "time"
+
+// CreateWebhook представляет данные для регистрации webhook
+type CreateWebhook struct {
+	URL        string   `json:"url" binding:"required,url,startswith=http"`
+	EventTypes []string `json:"eventTypes" binding:"required,min=1,dive,oneof=UserCreated CoinsSent ItemPurchased AccountLocked AccountUnlocked PasswordChanged TwoFactorEnabled TwoFactorDisabled UserDisabled UserEnabled UserDeleted CoinsExpired"`
+	Global     bool     `json:"global"`
+}
+
+// Webhook представляет данные о подписке на события
+type Webhook struct {
+	ID         int64     `json:"id"`
+	URL        string    `json:"url"`
+	EventTypes []string  `json:"eventTypes"`
+	Global     bool      `json:"global"`
+	Active     bool      `json:"active"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// WebhookCreated представляет созданную подписку вместе с секретом для проверки подписи
+type WebhookCreated struct {
+	Webhook
+	Secret string `json:"secret"`
+}
+
+// WebhookDelivery представляет данные о доставке события подписчику
+type WebhookDelivery struct {
+	ID             int64            `json:"id"`
+	EventID        int64            `json:"eventId"`
+	EventType      string           `json:"eventType"`
+	Status         string           `json:"status"`
+	Attempts       int              `json:"attempts"`
+	NextAttemptAt  *time.Time       `json:"nextAttemptAt,omitempty"`
+	LastStatusCode *int             `json:"lastStatusCode,omitempty"`
+	LastError      *string          `json:"lastError,omitempty"`
+	DeliveredAt    *time.Time       `json:"deliveredAt,omitempty"`
+	CreatedAt      time.Time        `json:"createdAt"`
+	AttemptLog     []WebhookAttempt `json:"attemptLog"`
+}
+
+// WebhookAttempt представляет данные об отдельной попытке доставки
+type WebhookAttempt struct {
+	Attempt    int       `json:"attempt"`
+	StatusCode *int      `json:"statusCode,omitempty"`
+	Error      *string   `json:"error,omitempty"`
+	DurationMs int       `json:"durationMs"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
@@ -1,10 +1,114 @@
 package errors
 
-import "errors"
+import (
+	"errors"
+	"strings"
+	"time"
+)
 
 var (
 	ErrInvalidPass        = errors.New("invalid password")
 	ErrFailedExecuteQuery = errors.New("failed to execute query")
 	ErrNotEnoughCoins     = errors.New("Not enough coins")
 	ErrInvalidUser        = errors.New("invalid user")
+	ErrForbidden          = errors.New("forbidden")
+	ErrWebhookNotFound    = errors.New("webhook not found")
+	ErrAccountLocked      = errors.New("account temporarily locked")
+	ErrWeakPassword       = errors.New("password does not satisfy policy")
+	ErrInvalidResetToken  = errors.New("invalid or expired reset token")
+	ErrTokenRevoked       = errors.New("token revoked")
+	ErrInvalidLoginState  = errors.New("invalid or expired login state")
+	ErrIdentityMapping    = errors.New("identity cannot be mapped to username")
+	ErrIdentityConflict   = errors.New("username is taken by another account")
+	ErrIdentityUnverified = errors.New("identity email is not verified")
+	ErrInvalidAPIKey      = errors.New("invalid api key")
+	ErrAPIKeyNotFound     = errors.New("api key not found")
+	ErrUserExists         = errors.New("user already exists")
+)
+
+// Ошибки двухфакторной аутентификации
+var (
+	ErrTwoFactorNotEnabled = errors.New("two-factor authentication is not enabled")
+	ErrTwoFactorEnabled    = errors.New("two-factor authentication is already enabled")
+	ErrInvalidTwoFactor    = errors.New("invalid two-factor code")
+	ErrInvalidChallenge    = errors.New("invalid or expired login challenge")
+	ErrTwoFactorRequired   = errors.New("two-factor authentication required")
+)
+
+// Ошибки управления учетными записями
+var (
+	ErrUserDisabled = errors.New("account disabled")
+	ErrUserDeleted  = errors.New("account deleted")
+)
+
+// Ошибки профилей
+var (
+	ErrDisplayNameTaken = errors.New("display name is taken")
+)
+
+// Ошибки выписок и отчетов
+var (
+	ErrInvalidPeriod = errors.New("invalid period")
+)
+
+// Ошибки проверки переводов
+var (
+	ErrTransferBlocked = errors.New("transfer blocked by fraud rules")
+	ErrReviewNotFound  = errors.New("fraud review not found")
+	ErrReviewResolved  = errors.New("fraud review already resolved")
 )
+
+// Ошибки цен и акций
+var (
+	ErrItemNotFound        = errors.New("item not found")
+	ErrPriceChangeExists   = errors.New("price change at this time already exists")
+	ErrPriceChangeNotFound = errors.New("scheduled price change not found")
+	ErrPriceChangeInPast   = errors.New("price change must not take effect in the past")
+	ErrPromotionNotFound   = errors.New("active or scheduled promotion not found")
+	ErrInvalidPromotion    = errors.New("invalid promotion")
+)
+
+// Ошибки промокодов
+var (
+	ErrPromoCodeNotFound      = errors.New("promo code not found")
+	ErrPromoCodeExists        = errors.New("promo code already exists")
+	ErrPromoCodeExpired       = errors.New("promo code expired")
+	ErrPromoCodeExhausted     = errors.New("promo code redemption limit reached")
+	ErrPromoCodeUserLimit     = errors.New("promo code already redeemed by user")
+	ErrPromoCodeNotApplicable = errors.New("promo code does not apply to this item")
+	ErrInvalidPromoCode       = errors.New("invalid promo code")
+)
+
+// Ошибки инвентаря и обмена
+var (
+	ErrNotEnoughItems = errors.New("not enough items in inventory")
+	ErrTradeNotFound  = errors.New("trade not found")
+	ErrTradeResolved  = errors.New("trade already resolved")
+	ErrTradeForbidden = errors.New("action not allowed for this trade participant")
+)
+
+// LockoutError сообщает о временной блокировке входа и времени до ее снятия
+type LockoutError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LockoutError) Error() string {
+	return ErrAccountLocked.Error()
+}
+
+func (e *LockoutError) Is(target error) bool {
+	return target == ErrAccountLocked
+}
+
+// PolicyError перечисляет нарушенные требования политики паролей
+type PolicyError struct {
+	Violations []string
+}
+
+func (e *PolicyError) Error() string {
+	return ErrWeakPassword.Error() + ": " + strings.Join(e.Violations, "; ")
+}
+
+func (e *PolicyError) Is(target error) bool {
+	return target == ErrWeakPassword
+}
@@ -0,0 +1,63 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"time"
+
+	"API-Avito-shop/internal/models"
+)
+
+// Publisher доставляет доменные события внешним потребителям
+type Publisher interface {
+	Publish(ctx context.Context, event models.Event) error
+}
+
+// Envelope представляет событие в том виде, в котором его получают потребители
+type Envelope struct {
+	ID        int64           `json:"id"`
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"createdAt"`
+	Payload   json.RawMessage `json:"payload"`
+}
+
+// NewEnvelope формирует конверт события для отправки
+func NewEnvelope(event models.Event) Envelope {
+	return Envelope{
+		ID:        event.ID,
+		Type:      event.Type,
+		CreatedAt: event.CreatedAt,
+		Payload:   event.Payload,
+	}
+}
+
+// LogPublisher выводит события в лог приложения
+type LogPublisher struct {
+	logger *slog.Logger
+}
+
+func NewLogPublisher(logger *slog.Logger) *LogPublisher {
+	return &LogPublisher{logger: logger}
+}
+
+// Publish записывает событие в лог
+func (p *LogPublisher) Publish(ctx context.Context, event models.Event) error {
+	p.logger.Info("Domain event published", "event_id", event.ID, "event_type", event.Type, "payload", string(event.Payload))
+	return nil
+}
+
+// MultiPublisher передает событие нескольким издателям, ошибки объединяются
+type MultiPublisher []Publisher
+
+// Publish передает событие каждому издателю
+func (m MultiPublisher) Publish(ctx context.Context, event models.Event) error {
+	var errs []error
+	for _, p := range m {
+		if err := p.Publish(ctx, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
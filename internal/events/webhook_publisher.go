@@ -0,0 +1,60 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"API-Avito-shop/internal/models"
+)
+
+// WebhookPublisher отправляет события POST-запросом на заданный URL
+type WebhookPublisher struct {
+	url    string
+	client *http.Client
+	logger *slog.Logger
+}
+
+func NewWebhookPublisher(url string, timeout time.Duration, logger *slog.Logger) *WebhookPublisher {
+	return &WebhookPublisher{
+		url:    url,
+		client: &http.Client{Timeout: timeout},
+		logger: logger,
+	}
+}
+
+// Publish отправляет событие на webhook, любой ответ кроме 2xx считается ошибкой
+func (p *WebhookPublisher) Publish(ctx context.Context, event models.Event) error {
+	body, err := json.Marshal(NewEnvelope(event))
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event-Type", event.Type)
+	req.Header.Set("X-Event-ID", strconv.FormatInt(event.ID, 10))
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		p.logger.Warn("Failed to deliver event to webhook", "event_id", event.ID, "error", err)
+		return fmt.Errorf("failed to deliver event: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		p.logger.Warn("Webhook rejected event", "event_id", event.ID, "status", resp.StatusCode)
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+
+	p.logger.Info("Event delivered to webhook", "event_id", event.ID, "event_type", event.Type)
+	return nil
+}
@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	e "API-Avito-shop/internal/errors"
+	"API-Avito-shop/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AdminMiddleware struct {
+	userService      services.UserService
+	twoFactorService services.TwoFactorService
+	// requireTwoFactor запрещает доступ администраторам без включенной двухфакторной аутентификации
+	requireTwoFactor bool
+	logger           *slog.Logger
+}
+
+func NewAdminMiddleware(userService services.UserService, twoFactorService services.TwoFactorService, requireTwoFactor bool, logger *slog.Logger) *AdminMiddleware {
+	return &AdminMiddleware{
+		userService:      userService,
+		twoFactorService: twoFactorService,
+		requireTwoFactor: requireTwoFactor,
+		logger:           logger,
+	}
+}
+
+// AdminMiddleware пропускает только администраторов, должен применяться после AuthMiddleware
+func (m *AdminMiddleware) AdminMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		username := c.GetString("username")
+
+		isAdmin, err := m.userService.IsAdmin(c.Request.Context(), username)
+		if err != nil && !errors.Is(err, e.ErrInvalidUser) {
+			m.logger.Error("Failed to check admin role", "username", username, "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check permissions"})
+			c.Abort()
+			return
+		}
+		if !isAdmin {
+			m.logger.Warn("Access to admin route denied", "username", username, "path", c.Request.URL.Path)
+			c.JSON(http.StatusForbidden, gin.H{"error": "admin role required"})
+			c.Abort()
+			return
+		}
+
+		if m.requireTwoFactor {
+			enabled, err := m.twoFactorService.IsEnabled(c.Request.Context(), username)
+			if err != nil {
+				m.logger.Error("Failed to check two-factor status", "username", username, "error", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check permissions"})
+				c.Abort()
+				return
+			}
+			if !enabled {
+				m.logger.Warn("Admin without two-factor authentication denied", "username", username, "path", c.Request.URL.Path)
+				c.JSON(http.StatusForbidden, gin.H{"error": "two-factor authentication required for admins"})
+				c.Abort()
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
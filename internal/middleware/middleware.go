@@ -1,31 +1,47 @@
 package middleware
 
 import (
+	"errors"
 	"log/slog"
 	"net/http"
 	"strings"
 
+	e "API-Avito-shop/internal/errors"
+	"API-Avito-shop/internal/models"
 	"API-Avito-shop/internal/services"
 
 	"github.com/gin-gonic/gin"
 )
 
+// apiKeyContextKey ключ контекста с API-ключом запроса, отсутствует при входе по JWT
+const apiKeyContextKey = "apiKey"
+
 type AuthMiddleware struct {
-	token     services.Token
-	secretKey []byte
-	logger    *slog.Logger
+	token         services.Token
+	userService   services.UserService
+	apiKeyService services.APIKeyService
+	secretKey     []byte
+	logger        *slog.Logger
 }
 
-func NewAuthMiddleware(token services.Token, secretKey string, logger *slog.Logger) *AuthMiddleware {
+func NewAuthMiddleware(token services.Token, userService services.UserService, apiKeyService services.APIKeyService, secretKey string, logger *slog.Logger) *AuthMiddleware {
 	return &AuthMiddleware{
-		token:     token,
-		secretKey: []byte(secretKey),
-		logger:    logger,
+		token:         token,
+		userService:   userService,
+		apiKeyService: apiKeyService,
+		secretKey:     []byte(secretKey),
+		logger:        logger,
 	}
 }
 
+// AuthMiddleware принимает Bearer JWT или API-ключ в заголовке X-API-Key
 func (m *AuthMiddleware) AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if apiKey := c.GetHeader("X-API-Key"); apiKey != "" {
+			m.authenticateAPIKey(c, apiKey)
+			return
+		}
+
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" || !strings.HasPrefix(authHeader, "Bearer ") {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "missing or invalid authorization header"})
@@ -35,14 +51,77 @@ func (m *AuthMiddleware) AuthMiddleware() gin.HandlerFunc {
 
 		token := strings.TrimPrefix(authHeader, "Bearer ")
 
-		username, err := m.token.ValidateToken(c.Request.Context(), token)
+		claims, err := m.token.ValidateToken(c.Request.Context(), token)
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
 			c.Abort()
 			return
 		}
 
-		c.Set("username", username)
+		// Токены, выданные до смены пароля, и токены отключенных учетных записей отклоняются
+		if err = m.userService.CheckTokenVersion(c.Request.Context(), claims.Username, claims.Version); err != nil {
+			switch {
+			case errors.Is(err, e.ErrTokenRevoked):
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "token revoked"})
+			case errors.Is(err, e.ErrUserDisabled):
+				c.JSON(http.StatusForbidden, gin.H{"error": "account disabled"})
+			default:
+				m.logger.Error("Failed to check token version", "username", claims.Username, "error", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check token"})
+			}
+			c.Abort()
+			return
+		}
+
+		c.Set("username", claims.Username)
 		c.Next()
 	}
 }
+
+// RequireScope пропускает запросы с API-ключом, только если ключу выдана область доступа.
+// Запросы пользователей с JWT проходят без проверки.
+func (m *AuthMiddleware) RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key, ok := c.Get(apiKeyContextKey)
+		if ok && !key.(*models.APIKey).HasScope(scope) {
+			m.logger.Warn("API key scope missing", "username", c.GetString("username"), "scope", scope, "path", c.Request.URL.Path)
+			c.JSON(http.StatusForbidden, gin.H{"error": "api key scope required: " + scope})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequireUser отклоняет запросы с API-ключом для маршрутов, доступных только пользователям
+func (m *AuthMiddleware) RequireUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := c.Get(apiKeyContextKey); ok {
+			c.JSON(http.StatusForbidden, gin.H{"error": "api keys are not allowed for this route"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// authenticateAPIKey проверяет API-ключ и сохраняет в контексте учетную запись и области доступа
+func (m *AuthMiddleware) authenticateAPIKey(c *gin.Context, apiKey string) {
+	key, err := m.apiKeyService.Authenticate(c.Request.Context(), apiKey)
+	if err != nil {
+		if errors.Is(err, e.ErrInvalidAPIKey) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid api key"})
+		} else {
+			m.logger.Error("Failed to check api key", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check api key"})
+		}
+		c.Abort()
+		return
+	}
+
+	c.Set("username", key.Username)
+	c.Set(apiKeyContextKey, key)
+	c.Next()
+}
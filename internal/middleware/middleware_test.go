@@ -0,0 +1,118 @@
+package middleware
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	e "API-Avito-shop/internal/errors"
+	"API-Avito-shop/internal/models"
+	"API-Avito-shop/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fakeAPIKeyService принимает ключи из списка, остальные методы не используются
+type fakeAPIKeyService struct {
+	services.APIKeyService
+	keys map[string]*models.APIKey
+	err  error
+}
+
+func (f *fakeAPIKeyService) Authenticate(_ context.Context, rawKey string) (*models.APIKey, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	key, ok := f.keys[rawKey]
+	if !ok {
+		return nil, e.ErrInvalidAPIKey
+	}
+	return key, nil
+}
+
+func newTestRouter(apiKeyService services.APIKeyService) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	m := NewAuthMiddleware(nil, nil, apiKeyService, "secret", slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	router := gin.New()
+	api := router.Group("/api", m.AuthMiddleware())
+	ok := func(c *gin.Context) { c.String(http.StatusOK, c.GetString("username")) }
+	api.GET("/items", m.RequireScope(models.ScopeShopRead), ok)
+	api.POST("/sendCoin", m.RequireScope(models.ScopeCoinsSend), ok)
+	api.GET("/profile", m.RequireUser(), ok)
+	return router
+}
+
+func TestAPIKeyScopes(t *testing.T) {
+	router := newTestRouter(&fakeAPIKeyService{keys: map[string]*models.APIKey{
+		"msk_reader": {Username: "crm", Scopes: []string{models.ScopeShopRead}},
+		"msk_all":    {Username: "bot", Scopes: []string{models.ScopeShopRead, models.ScopeShopBuy, models.ScopeCoinsSend, models.ScopeEventsRead}},
+		"msk_none":   {Username: "idle"},
+	}})
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		key    string
+		status int
+	}{
+		{"scope granted", http.MethodGet, "/api/items", "msk_reader", http.StatusOK},
+		{"scope missing", http.MethodPost, "/api/sendCoin", "msk_reader", http.StatusForbidden},
+		{"all scopes", http.MethodPost, "/api/sendCoin", "msk_all", http.StatusOK},
+		{"no scopes", http.MethodGet, "/api/items", "msk_none", http.StatusForbidden},
+		// Маршруты пользователей недоступны с API-ключом независимо от областей доступа
+		{"user route", http.MethodGet, "/api/profile", "msk_all", http.StatusForbidden},
+		{"unknown key", http.MethodGet, "/api/items", "msk_unknown", http.StatusUnauthorized},
+		{"no credentials", http.MethodGet, "/api/items", "", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		recorder := httptest.NewRecorder()
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		if tt.key != "" {
+			req.Header.Set("X-API-Key", tt.key)
+		}
+		router.ServeHTTP(recorder, req)
+
+		if recorder.Code != tt.status {
+			t.Errorf("%s: %s %s status = %d, want %d", tt.name, tt.method, tt.path, recorder.Code, tt.status)
+		}
+	}
+}
+
+func TestAPIKeyServiceError(t *testing.T) {
+	router := newTestRouter(&fakeAPIKeyService{err: e.ErrFailedExecuteQuery})
+
+	recorder := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/items", nil)
+	req.Header.Set("X-API-Key", "msk_reader")
+	router.ServeHTTP(recorder, req)
+
+	// Сбой проверки ключа не выдается за неверный ключ
+	if recorder.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want %d", recorder.Code, http.StatusInternalServerError)
+	}
+}
+
+func TestRequireScopeWithoutAPIKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	m := NewAuthMiddleware(nil, nil, nil, "secret", slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	// Запросы с JWT проходят без проверки областей доступа
+	for _, handler := range []gin.HandlerFunc{m.RequireScope(models.ScopeCoinsSend), m.RequireUser()} {
+		recorder := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(recorder)
+		c.Request = httptest.NewRequest(http.MethodPost, "/api/sendCoin", nil)
+		c.Set("username", "alice")
+
+		handler(c)
+
+		if c.IsAborted() {
+			t.Errorf("JWT request aborted with status %d, want it to pass", recorder.Code)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"API-Avito-shop/internal/ratelimit"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RateLimitMiddleware struct {
+	store     ratelimit.Store
+	enabled   bool
+	authLimit ratelimit.Limit
+	userLimit ratelimit.Limit
+	logger    *slog.Logger
+}
+
+func NewRateLimitMiddleware(store ratelimit.Store, enabled bool, authLimit, userLimit ratelimit.Limit, logger *slog.Logger) *RateLimitMiddleware {
+	return &RateLimitMiddleware{
+		store:     store,
+		enabled:   enabled,
+		authLimit: authLimit,
+		userLimit: userLimit,
+		logger:    logger,
+	}
+}
+
+// AuthRateLimit ограничивает частоту запросов аутентификации с одного IP-адреса
+func (m *RateLimitMiddleware) AuthRateLimit() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		m.limit(c, "auth:ip:"+c.ClientIP(), m.authLimit)
+	}
+}
+
+// UserRateLimit ограничивает частоту запросов пользователя, должен применяться после AuthMiddleware
+func (m *RateLimitMiddleware) UserRateLimit() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		m.limit(c, "user:"+c.GetString("username"), m.userLimit)
+	}
+}
+
+// limit списывает токен для ключа и выставляет заголовки X-RateLimit-*
+func (m *RateLimitMiddleware) limit(c *gin.Context, key string, limit ratelimit.Limit) {
+	if !m.enabled {
+		c.Next()
+		return
+	}
+
+	result, err := m.store.Take(c.Request.Context(), key, limit)
+	if err != nil {
+		// При недоступности хранилища не блокируем пользователей
+		m.logger.Error("Rate limit check failed", "key", key, "error", err)
+		c.Next()
+		return
+	}
+
+	c.Header("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+	c.Header("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	c.Header("X-RateLimit-Reset", strconv.Itoa(int(result.Reset.Seconds())))
+
+	if !result.Allowed {
+		m.logger.Warn("Rate limit exceeded", "key", key, "path", c.Request.URL.Path)
+		c.Header("Retry-After", strconv.Itoa(int(result.RetryAfter.Seconds())))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "too many requests"})
+		c.Abort()
+		return
+	}
+
+	c.Next()
+}
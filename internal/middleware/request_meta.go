@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"regexp"
+
+	"API-Avito-shop/internal/utils/requestmeta"
+
+	"github.com/gin-gonic/gin"
+)
+
+// requestIDHeader заголовок с идентификатором запроса, принимается от клиента или прокси
+const requestIDHeader = "X-Request-ID"
+
+// requestIDPattern ограничивает принимаемые идентификаторы, чтобы в журнал не попадали произвольные данные
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// RequestMeta сохраняет в контексте запроса IP-адрес, User-Agent и идентификатор запроса
+// для журнала аудита и возвращает идентификатор в заголовке X-Request-ID
+func RequestMeta() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(requestIDHeader)
+		if !requestIDPattern.MatchString(requestID) {
+			requestID = newRequestID()
+		}
+		c.Header(requestIDHeader, requestID)
+
+		ctx := requestmeta.WithMeta(c.Request.Context(), requestmeta.Meta{
+			IP:        c.ClientIP(),
+			UserAgent: c.Request.UserAgent(),
+			RequestID: requestID,
+		})
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+	}
+}
+
+// newRequestID генерирует случайный идентификатор запроса
+func newRequestID() string {
+	buf := make([]byte, 16)
+	_, _ = rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
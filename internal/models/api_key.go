@@ -0,0 +1,34 @@
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+// Области доступа API-ключей
+const (
+	ScopeShopRead   = "shop:read"
+	ScopeShopBuy    = "shop:buy"
+	ScopeCoinsSend  = "coins:send"
+	ScopeEventsRead = "events:read"
+)
+
+// APIKey представляет долгоживущий ключ доступа сервисной учетной записи
+type APIKey struct {
+	ID         int64      `db:"id"`
+	Username   string     `db:"username"`
+	Name       string     `db:"name"`
+	Prefix     string     `db:"prefix"`
+	KeyHash    string     `db:"key_hash"`
+	Scopes     []string   `db:"scopes"`
+	CreatedBy  string     `db:"created_by"`
+	CreatedAt  time.Time  `db:"created_at"`
+	LastUsedAt *time.Time `db:"last_used_at"`
+	ExpiresAt  *time.Time `db:"expires_at"`
+	RevokedAt  *time.Time `db:"revoked_at"`
+}
+
+// HasScope проверяет, выдана ли ключу область доступа
+func (k *APIKey) HasScope(scope string) bool {
+	return slices.Contains(k.Scopes, scope)
+}
@@ -0,0 +1,23 @@
+package models
+
+import "testing"
+
+func TestHasScope(t *testing.T) {
+	key := APIKey{Scopes: []string{ScopeShopRead, ScopeEventsRead}}
+
+	tests := []struct {
+		scope string
+		want  bool
+	}{
+		{ScopeShopRead, true},
+		{ScopeEventsRead, true},
+		{ScopeShopBuy, false},
+		{"shop", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := key.HasScope(tt.scope); got != tt.want {
+			t.Errorf("HasScope(%q) = %v, want %v", tt.scope, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package models
+
+import "time"
+
+// Действия, фиксируемые в журнале аудита
+const (
+	AuditLogin                 = "auth.login"
+	AuditLoginFailed           = "auth.login_failed"
+	AuditIdentityLinked        = "auth.identity_linked"
+	AuditCoinsSent             = "coins.sent"
+	AuditItemPurchased         = "shop.purchase"
+	AuditItemGifted            = "shop.gift"
+	AuditItemsGiven            = "inventory.given"
+	AuditTradeProposed         = "inventory.trade_proposed"
+	AuditTradeAccepted         = "inventory.trade_accepted"
+	AuditTradeDeclined         = "inventory.trade_declined"
+	AuditTradeCancelled        = "inventory.trade_cancelled"
+	AuditPasswordChanged       = "password.changed"
+	AuditPasswordReset         = "password.reset"
+	AuditPasswordResetIssued   = "admin.password_reset_issued"
+	AuditUserUnlocked          = "admin.user_unlocked"
+	AuditServiceAccountCreated = "admin.service_account_created"
+	AuditAPIKeyCreated         = "admin.api_key_created"
+	AuditAPIKeyRevoked         = "admin.api_key_revoked"
+	AuditLogExported           = "admin.audit_exported"
+	AuditTwoFactorEnabled      = "2fa.enabled"
+	AuditTwoFactorDisabled     = "2fa.disabled"
+	AuditUserDisabled          = "admin.user_disabled"
+	AuditUserEnabled           = "admin.user_enabled"
+	AuditUserDeleted           = "admin.user_deleted"
+	AuditTransferFlagged       = "coins.transfer_flagged"
+	AuditTransferHeld          = "coins.transfer_held"
+	AuditTransferBlocked       = "coins.transfer_blocked"
+	AuditFraudReviewApproved   = "admin.fraud_review_approved"
+	AuditFraudReviewRejected   = "admin.fraud_review_rejected"
+	AuditPriceScheduled        = "admin.price_scheduled"
+	AuditPriceChangeCancelled  = "admin.price_change_cancelled"
+	AuditPromotionCreated      = "admin.promotion_created"
+	AuditPromotionCancelled    = "admin.promotion_cancelled"
+	AuditPromoCodeCreated      = "admin.promo_code_created"
+	AuditPromoCodeDisabled     = "admin.promo_code_disabled"
+	AuditCoinsExpired          = "coins.expired"
+)
+
+// AuditActorSystem инициатор действий, выполняемых фоновыми процессами
+const AuditActorSystem = "system"
+
+// AuditEntry представляет запись журнала аудита.
+// Hash вычисляется от содержимого записи и PrevHash предыдущей записи, пока запись не включена в цепочку, оба пусты.
+type AuditEntry struct {
+	ID        int64     `db:"id"`
+	Actor     string    `db:"actor"`
+	Action    string    `db:"action"`
+	Target    string    `db:"target"`
+	Amount    *int      `db:"amount"`
+	Details   string    `db:"details"`
+	IP        string    `db:"ip"`
+	UserAgent string    `db:"user_agent"`
+	RequestID string    `db:"request_id"`
+	PrevHash  string    `db:"prev_hash"`
+	Hash      string    `db:"hash"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// AuditFilter задает условия выборки записей журнала, пустые поля не учитываются
+type AuditFilter struct {
+	Actor  string
+	Action string
+	Target string
+	From   *time.Time
+	To     *time.Time
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// Политики сгорания монет
+const (
+	// ExpiryNone монеты не сгорают
+	ExpiryNone = "none"
+	// ExpiryRolling монеты сгорают через заданное число месяцев после начисления
+	ExpiryRolling = "rolling"
+	// ExpiryYearEnd монеты сгорают в конце календарного года начисления (UTC)
+	ExpiryYearEnd = "year_end"
+)
+
+// CoinLot представляет монеты одной партии: количество и время первоначального начисления
+type CoinLot struct {
+	Amount   int
+	IssuedAt time.Time
+}
+
+// CoinExpiration представляет сгоревшие монеты пользователя и баланс после сгорания
+type CoinExpiration struct {
+	Username string
+	Amount   int
+	Balance  int
+}
@@ -0,0 +1,95 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Типы доменных событий
+const (
+	EventUserCreated     = "UserCreated"
+	EventCoinsSent       = "CoinsSent"
+	EventItemPurchased   = "ItemPurchased"
+	EventAccountLocked   = "AccountLocked"
+	EventAccountUnlocked = "AccountUnlocked"
+	EventPasswordChanged = "PasswordChanged"
+	// События двухфакторной аутентификации
+	EventTwoFactorEnabled  = "TwoFactorEnabled"
+	EventTwoFactorDisabled = "TwoFactorDisabled"
+	// События управления учетными записями
+	EventUserDisabled = "UserDisabled"
+	EventUserEnabled  = "UserEnabled"
+	EventUserDeleted  = "UserDeleted"
+	// Сгорание монет по истечении срока действия
+	EventCoinsExpired = "CoinsExpired"
+)
+
+// Event представляет доменное событие, сохраненное в outbox
+type Event struct {
+	ID        int64           `db:"id"`
+	Type      string          `db:"event_type"`
+	Payload   json.RawMessage `db:"payload"`
+	Attempts  int             `db:"attempts"`
+	CreatedAt time.Time       `db:"created_at"`
+}
+
+// UserCreatedPayload представляет данные события создания пользователя
+type UserCreatedPayload struct {
+	Username string `json:"username"`
+	Role     string `json:"role"`
+}
+
+// CoinsSentPayload представляет данные события передачи монет
+type CoinsSentPayload struct {
+	FromUser string `json:"fromUser"`
+	ToUser   string `json:"toUser"`
+	Amount   int    `json:"amount"`
+}
+
+// ItemPurchasedPayload представляет данные события покупки товара
+type ItemPurchasedPayload struct {
+	Username string `json:"username"`
+	Item     string `json:"item"`
+	Price    int    `json:"price"`
+	// GiftTo получатель товара, купленного в подарок
+	GiftTo string `json:"giftTo,omitempty"`
+}
+
+// AccountLockedPayload представляет данные события блокировки входа
+type AccountLockedPayload struct {
+	Scope       string    `json:"scope"`
+	Key         string    `json:"key"`
+	LockedUntil time.Time `json:"lockedUntil"`
+}
+
+// AccountUnlockedPayload представляет данные события снятия блокировки администратором
+type AccountUnlockedPayload struct {
+	Username string `json:"username"`
+	Admin    string `json:"admin"`
+	IP       string `json:"ip,omitempty"`
+}
+
+// PasswordChangedPayload представляет данные события смены пароля
+type PasswordChangedPayload struct {
+	Username string `json:"username"`
+	// Reset указывает, что пароль установлен по токену сброса
+	Reset bool `json:"reset"`
+}
+
+// TwoFactorPayload представляет данные событий включения и отключения двухфакторной аутентификации
+type TwoFactorPayload struct {
+	Username string `json:"username"`
+}
+
+// UserStatusPayload представляет данные событий отключения, включения и удаления учетной записи
+type UserStatusPayload struct {
+	Username string `json:"username"`
+	Admin    string `json:"admin"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// CoinsExpiredPayload представляет данные события сгорания монет
+type CoinsExpiredPayload struct {
+	Username string `json:"username"`
+	Amount   int    `json:"amount"`
+}
@@ -0,0 +1,85 @@
+package models
+
+import "time"
+
+// Действия правил обнаружения мошенничества в порядке возрастания строгости.
+// FraudActionOff отключает правило.
+const (
+	FraudActionOff   = "off"
+	FraudActionFlag  = "flag"
+	FraudActionHold  = "hold"
+	FraudActionBlock = "block"
+)
+
+// Правила обнаружения мошенничества при переводах
+const (
+	FraudRuleVelocity  = "velocity"
+	FraudRuleNewFanIn  = "new_account_fan_in"
+	FraudRuleRoundTrip = "round_trip"
+)
+
+// Статусы проверки перевода
+const (
+	FraudReviewPending  = "pending"
+	FraudReviewApproved = "approved"
+	FraudReviewRejected = "rejected"
+	FraudReviewBlocked  = "blocked"
+)
+
+// Результаты отправки перевода
+const (
+	TransferCompleted = "completed"
+	TransferHeld      = "held"
+)
+
+// FraudWindows задает начало окна каждого правила и границу возраста новой учетной записи
+type FraudWindows struct {
+	Velocity   time.Time
+	FanIn      time.Time
+	RoundTrip  time.Time
+	NewAccount time.Time
+}
+
+// TransferSignals представляет сведения о недавних переводах участников, по которым проверяются правила.
+// Задержанные до проверки переводы учитываются наравне с выполненными.
+type TransferSignals struct {
+	// RecipientActive получатель существует и не отключен
+	RecipientActive bool
+	// SenderTransfers и SenderVolume переводы отправителя за окно скорости
+	SenderTransfers int
+	SenderVolume    int
+	// SenderIsNew отправитель создан позже границы возраста новой учетной записи
+	SenderIsNew bool
+	// NewSenders другие новые учетные записи, переводившие получателю за окно
+	NewSenders int
+	// ReverseTransfers переводы получателя отправителю за окно
+	ReverseTransfers int
+}
+
+// FraudDecision представляет самое строгое действие сработавших правил
+type FraudDecision struct {
+	Action string
+	Rules  []string
+}
+
+// FraudReview представляет перевод в очереди проверки
+type FraudReview struct {
+	ID         int64      `db:"id"`
+	FromUser   string     `db:"from_username"`
+	ToUser     string     `db:"to_username"`
+	Amount     int        `db:"amount"`
+	Action     string     `db:"action"`
+	Rules      []string   `db:"rules"`
+	Status     string     `db:"status"`
+	CreatedAt  time.Time  `db:"created_at"`
+	ReviewedBy *string    `db:"reviewed_by"`
+	ReviewedAt *time.Time `db:"reviewed_at"`
+	ReviewNote *string    `db:"review_note"`
+}
+
+// FraudReviewFilter задает условия выборки очереди проверки, пустые поля не учитываются
+type FraudReviewFilter struct {
+	Status string
+	Action string
+	User   string
+}
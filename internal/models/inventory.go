@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+// Статусы предложений обмена
+const (
+	TradePending   = "pending"
+	TradeAccepted  = "accepted"
+	TradeDeclined  = "declined"
+	TradeCancelled = "cancelled"
+)
+
+// Стороны обмена: товары инициатора и товары, которые он просит взамен
+const (
+	TradeSideOffer   = "offer"
+	TradeSideRequest = "request"
+)
+
+// ItemQuantity представляет количество экземпляров товара
+type ItemQuantity struct {
+	Item     string `db:"item"`
+	Quantity int    `db:"quantity"`
+}
+
+// Trade представляет предложение обмена: Proposer отдает Offer и получает Request от Recipient
+type Trade struct {
+	ID         int64          `db:"id"`
+	Proposer   string         `db:"proposer"`
+	Recipient  string         `db:"recipient"`
+	Message    string         `db:"message"`
+	Status     string         `db:"status"`
+	Offer      []ItemQuantity `db:"-"`
+	Request    []ItemQuantity `db:"-"`
+	CreatedAt  time.Time      `db:"created_at"`
+	ResolvedAt *time.Time     `db:"resolved_at"`
+}
+
+// TradeFilter задает условия выборки обменов пользователя, пустой статус не учитывается
+type TradeFilter struct {
+	Username string
+	Status   string
+}
+
+// ItemTransfer представляет передачу экземпляров товара другому пользователю
+type ItemTransfer struct {
+	ID        int64     `db:"id"`
+	FromUser  string    `db:"from_username"`
+	ToUser    string    `db:"to_username"`
+	Item      string    `db:"item"`
+	Quantity  int       `db:"quantity"`
+	TradeID   *int64    `db:"trade_id"`
+	CreatedAt time.Time `db:"created_at"`
+}
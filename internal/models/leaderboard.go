@@ -0,0 +1,21 @@
+package models
+
+// Рейтинги пользователей
+const (
+	LeaderboardReceivers = "receivers"
+	LeaderboardGivers    = "givers"
+	LeaderboardSpenders  = "spenders"
+)
+
+// Периоды рейтингов, границы считаются по UTC
+const (
+	PeriodWeek  = "week"
+	PeriodMonth = "month"
+	PeriodAll   = "all"
+)
+
+// Источники дневных итогов рейтингов
+const (
+	LeaderboardSourceTransactions = "transactions"
+	LeaderboardSourcePurchases    = "purchases"
+)
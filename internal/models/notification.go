@@ -0,0 +1,82 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Типы уведомлений пользователю
+const (
+	NotificationBalanceChanged    = "balanceChanged"
+	NotificationCoinsReceived     = "coinsReceived"
+	NotificationCoinsSent         = "coinsSent"
+	NotificationPurchaseCompleted = "purchaseCompleted"
+	NotificationCoinsExpired      = "coinsExpired"
+	NotificationGiftReceived      = "giftReceived"
+	NotificationItemsReceived     = "itemsReceived"
+	NotificationTradeProposed     = "tradeProposed"
+	NotificationTradeResolved     = "tradeResolved"
+)
+
+// Notification представляет уведомление, адресованное конкретному пользователю
+type Notification struct {
+	Username  string          `json:"username"`
+	Type      string          `json:"type"`
+	Data      json.RawMessage `json:"data"`
+	CreatedAt time.Time       `json:"createdAt"`
+}
+
+// BalanceChangedData представляет данные уведомления об изменении баланса
+type BalanceChangedData struct {
+	Coins int `json:"coins"`
+}
+
+// CoinsReceivedData представляет данные уведомления о входящем переводе
+type CoinsReceivedData struct {
+	FromUser string `json:"fromUser"`
+	Amount   int    `json:"amount"`
+}
+
+// CoinsSentData представляет данные уведомления об исходящем переводе
+type CoinsSentData struct {
+	ToUser string `json:"toUser"`
+	Amount int    `json:"amount"`
+}
+
+// PurchaseCompletedData представляет данные уведомления о покупке
+type PurchaseCompletedData struct {
+	Item   string `json:"item"`
+	Price  int    `json:"price"`
+	ToUser string `json:"toUser,omitempty"`
+}
+
+// CoinsExpiredData представляет данные уведомления о сгорании монет
+type CoinsExpiredData struct {
+	Amount int `json:"amount"`
+}
+
+// GiftReceivedData представляет данные уведомления о полученном подарке
+type GiftReceivedData struct {
+	FromUser string `json:"fromUser"`
+	Item     string `json:"item"`
+	Message  string `json:"message,omitempty"`
+}
+
+// ItemsReceivedData представляет данные уведомления о товарах, переданных другим пользователем
+type ItemsReceivedData struct {
+	FromUser string `json:"fromUser"`
+	Item     string `json:"item"`
+	Quantity int    `json:"quantity"`
+}
+
+// TradeProposedData представляет данные уведомления о новом предложении обмена
+type TradeProposedData struct {
+	TradeID  int64  `json:"tradeId"`
+	FromUser string `json:"fromUser"`
+}
+
+// TradeResolvedData представляет данные уведомления о принятии, отклонении или отмене обмена
+type TradeResolvedData struct {
+	TradeID int64  `json:"tradeId"`
+	Status  string `json:"status"`
+}
@@ -0,0 +1,9 @@
+package models
+
+// LoginState представляет незавершенный вход через провайдера OpenID Connect
+type LoginState struct {
+	Nonce        string `db:"nonce"`
+	CodeVerifier string `db:"code_verifier"`
+	// LinkUsername задан, если вход начат пользователем магазина для связывания учетной записи провайдера
+	LinkUsername string `db:"username"`
+}
@@ -0,0 +1,86 @@
+package models
+
+import "time"
+
+// Виды скидок акций
+const (
+	PromotionPercent = "percent"
+	PromotionFixed   = "fixed"
+)
+
+// MinPrice — минимальная цена товара со скидкой: бесплатных покупок не бывает
+const MinPrice = 1
+
+// Статусы акций и изменений цены относительно текущего момента
+const (
+	PriceScheduled     = "scheduled"
+	PriceActive        = "active"
+	PriceSuperseded    = "superseded"
+	PromotionScheduled = "scheduled"
+	PromotionActive    = "active"
+	PromotionEnded     = "ended"
+	PromotionCancelled = "cancelled"
+)
+
+// PriceChange представляет цену товара, действующую с EffectiveFrom до следующего изменения
+type PriceChange struct {
+	ID            int64     `db:"id"`
+	Item          string    `db:"item"`
+	Price         int       `db:"price"`
+	EffectiveFrom time.Time `db:"effective_from"`
+	CreatedBy     *string   `db:"created_by"`
+	CreatedAt     time.Time `db:"created_at"`
+}
+
+// Promotion представляет акцию на товар или на весь каталог, если Item не задан.
+// Акция действует в полуинтервале [StartsAt, EndsAt).
+type Promotion struct {
+	ID          int64      `db:"id"`
+	Name        string     `db:"name"`
+	Item        *string    `db:"item"`
+	Kind        string     `db:"kind"`
+	Value       int        `db:"value"`
+	StartsAt    time.Time  `db:"starts_at"`
+	EndsAt      time.Time  `db:"ends_at"`
+	CreatedBy   string     `db:"created_by"`
+	CreatedAt   time.Time  `db:"created_at"`
+	CancelledAt *time.Time `db:"cancelled_at"`
+}
+
+// Apply возвращает цену со скидкой акции
+func (p *Promotion) Apply(price int) int {
+	return applyDiscount(p.Kind, p.Value, price)
+}
+
+// Status возвращает статус акции в момент now
+func (p *Promotion) Status(now time.Time) string {
+	switch {
+	case p.CancelledAt != nil:
+		return PromotionCancelled
+	case now.Before(p.StartsAt):
+		return PromotionScheduled
+	case now.Before(p.EndsAt):
+		return PromotionActive
+	default:
+		return PromotionEnded
+	}
+}
+
+// EffectivePrice представляет цену товара в заданный момент: базовую цену по истории
+// и итоговую цену с лучшей из действующих акций
+type EffectivePrice struct {
+	Item      string
+	BasePrice int
+	Price     int
+	Promotion *Promotion
+}
+
+// applyDiscount возвращает цену со скидкой в процентах или в монетах.
+// Процентная скидка округляется вниз, цена не опускается ниже MinPrice.
+func applyDiscount(kind string, value, price int) int {
+	discount := value
+	if kind == PromotionPercent {
+		discount = price * value / 100
+	}
+	return min(price, max(price-discount, MinPrice))
+}
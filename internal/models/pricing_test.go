@@ -0,0 +1,60 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestApplyDiscount(t *testing.T) {
+	tests := []struct {
+		name  string
+		kind  string
+		value int
+		price int
+		want  int
+	}{
+		{"percent", PromotionPercent, 20, 500, 400},
+		{"percent rounds in favour of the shop", PromotionPercent, 15, 99, 85},
+		{"percent below one coin of discount", PromotionPercent, 10, 5, 5},
+		{"percent at most 99", PromotionPercent, 99, 500, 5},
+		{"full percent keeps the minimum price", PromotionPercent, 100, 500, MinPrice},
+		{"fixed", PromotionFixed, 150, 500, 350},
+		{"fixed down to one coin", PromotionFixed, 499, 500, 1},
+		{"fixed equal to price", PromotionFixed, 500, 500, MinPrice},
+		{"fixed over price", PromotionFixed, 800, 500, MinPrice},
+		{"price already minimal", PromotionFixed, 10, 1, 1},
+	}
+
+	for _, tt := range tests {
+		if got := applyDiscount(tt.kind, tt.value, tt.price); got != tt.want {
+			t.Errorf("%s: applyDiscount(%s, %d, %d) = %d, want %d", tt.name, tt.kind, tt.value, tt.price, got, tt.want)
+		}
+	}
+}
+
+func TestPromotionStatus(t *testing.T) {
+	startsAt := time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)
+	endsAt := time.Date(2026, 11, 8, 0, 0, 0, 0, time.UTC)
+	cancelledAt := time.Date(2026, 10, 20, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		cancelled *time.Time
+		now       time.Time
+		want      string
+	}{
+		{"before start", nil, startsAt.Add(-time.Second), PromotionScheduled},
+		{"at start", nil, startsAt, PromotionActive},
+		{"just before end", nil, endsAt.Add(-time.Second), PromotionActive},
+		{"at end", nil, endsAt, PromotionEnded},
+		{"cancelled before start", &cancelledAt, startsAt.Add(-time.Hour), PromotionCancelled},
+		{"cancelled while active", &cancelledAt, startsAt.Add(time.Hour), PromotionCancelled},
+	}
+
+	for _, tt := range tests {
+		promotion := Promotion{StartsAt: startsAt, EndsAt: endsAt, CancelledAt: tt.cancelled}
+		if got := promotion.Status(tt.now); got != tt.want {
+			t.Errorf("%s: Status() = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
@@ -4,3 +4,9 @@ type Product struct {
 	Item  string `db:"item"`
 	Price int    `db:"price"`
 }
+
+// Gift представляет получателя купленного в подарок товара и сообщение к подарку
+type Gift struct {
+	ToUser  string
+	Message string
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// Profile представляет профиль пользователя, который видят коллеги
+type Profile struct {
+	Username    string `db:"username"`
+	DisplayName string `db:"display_name"`
+	Department  string `db:"department"`
+	Team        string `db:"team"`
+	AvatarURL   string `db:"avatar_url"`
+	Bio         string `db:"bio"`
+	// UpdatedAt не задано, если пользователь еще не заполнял профиль
+	UpdatedAt *time.Time `db:"updated_at"`
+}
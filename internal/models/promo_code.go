@@ -0,0 +1,69 @@
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+// Статусы промокодов относительно текущего момента
+const (
+	PromoCodeActive    = "active"
+	PromoCodeExpired   = "expired"
+	PromoCodeExhausted = "exhausted"
+	PromoCodeDisabled  = "disabled"
+)
+
+// PromoCode представляет промокод со скидкой в процентах или в монетах (виды скидок совпадают с акциями).
+// Пустой Items означает любой товар, Username закрепляет код за пользователем,
+// MaxRedemptions равный nil снимает ограничение на общее число погашений.
+type PromoCode struct {
+	ID             int64      `db:"id"`
+	Code           string     `db:"code"`
+	Description    string     `db:"description"`
+	Kind           string     `db:"kind"`
+	Value          int        `db:"value"`
+	Items          []string   `db:"items"`
+	Username       *string    `db:"username"`
+	MaxRedemptions *int       `db:"max_redemptions"`
+	PerUserLimit   int        `db:"per_user_limit"`
+	Redemptions    int        `db:"redemptions"`
+	ExpiresAt      *time.Time `db:"expires_at"`
+	CreatedBy      string     `db:"created_by"`
+	CreatedAt      time.Time  `db:"created_at"`
+	DisabledAt     *time.Time `db:"disabled_at"`
+}
+
+// Apply возвращает цену со скидкой промокода
+func (p *PromoCode) Apply(price int) int {
+	return applyDiscount(p.Kind, p.Value, price)
+}
+
+// AppliesTo проверяет, действует ли промокод на товар
+func (p *PromoCode) AppliesTo(item string) bool {
+	return len(p.Items) == 0 || slices.Contains(p.Items, item)
+}
+
+// Status возвращает статус промокода в момент now
+func (p *PromoCode) Status(now time.Time) string {
+	switch {
+	case p.DisabledAt != nil:
+		return PromoCodeDisabled
+	case p.ExpiresAt != nil && !now.Before(*p.ExpiresAt):
+		return PromoCodeExpired
+	case p.MaxRedemptions != nil && p.Redemptions >= *p.MaxRedemptions:
+		return PromoCodeExhausted
+	default:
+		return PromoCodeActive
+	}
+}
+
+// PromoRedemption представляет погашение промокода при покупке
+type PromoRedemption struct {
+	ID        int64     `db:"id"`
+	CodeID    int64     `db:"code_id"`
+	Username  string    `db:"username"`
+	Item      string    `db:"item"`
+	Price     int       `db:"price"`
+	Discount  int       `db:"discount"`
+	CreatedAt time.Time `db:"created_at"`
+}
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPromoCodeStatus(t *testing.T) {
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Second)
+	limit := 3
+
+	tests := []struct {
+		name string
+		code PromoCode
+		want string
+	}{
+		{"unlimited", PromoCode{Redemptions: 1000}, PromoCodeActive},
+		{"under the limit", PromoCode{MaxRedemptions: &limit, Redemptions: 2}, PromoCodeActive},
+		{"limit reached", PromoCode{MaxRedemptions: &limit, Redemptions: 3}, PromoCodeExhausted},
+		{"not expired yet", PromoCode{ExpiresAt: &future}, PromoCodeActive},
+		{"expires now", PromoCode{ExpiresAt: &now}, PromoCodeExpired},
+		{"expired and exhausted", PromoCode{ExpiresAt: &past, MaxRedemptions: &limit, Redemptions: 3}, PromoCodeExpired},
+		{"disabled wins", PromoCode{DisabledAt: &past, ExpiresAt: &past, MaxRedemptions: &limit, Redemptions: 3}, PromoCodeDisabled},
+	}
+
+	for _, tt := range tests {
+		if got := tt.code.Status(now); got != tt.want {
+			t.Errorf("%s: Status() = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPromoCodeAppliesTo(t *testing.T) {
+	tests := []struct {
+		name  string
+		items []string
+		item  string
+		want  bool
+	}{
+		{"any item", nil, "cup", true},
+		{"listed item", []string{"cup", "pen"}, "pen", true},
+		{"other item", []string{"cup", "pen"}, "socks", false},
+		// Названия товаров сравниваются точно
+		{"different case", []string{"cup"}, "Cup", false},
+	}
+
+	for _, tt := range tests {
+		code := PromoCode{Items: tt.items}
+		if got := code.AppliesTo(tt.item); got != tt.want {
+			t.Errorf("%s: AppliesTo(%s) = %v, want %v", tt.name, tt.item, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// Типы операций выписки
+const (
+	MovementTransferIn  = "transfer_in"
+	MovementTransferOut = "transfer_out"
+	MovementPurchase    = "purchase"
+	MovementGrant       = "grant"
+	MovementExpiry      = "expiry"
+)
+
+// Причины начисления монет
+const (
+	// GrantSignup стартовый баланс при создании учетной записи
+	GrantSignup = "signup"
+)
+
+// Movement представляет движение монет пользователя: поступление с положительной суммой или списание с отрицательной
+type Movement struct {
+	Kind string
+	// Counterparty другой участник перевода, товар для покупки или причина начисления
+	Counterparty string
+	// GiftTo получатель товара, купленного в подарок
+	GiftTo    string
+	Amount    int
+	CreatedAt time.Time
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// TOTP представляет настройку двухфакторной аутентификации пользователя
+type TOTP struct {
+	Username     string     `db:"username"`
+	Secret       string     `db:"secret"`
+	ConfirmedAt  *time.Time `db:"confirmed_at"`
+	LastUsedStep *int64     `db:"last_used_step"`
+	CreatedAt    time.Time  `db:"created_at"`
+}
+
+// Enabled сообщает, подтверждена ли настройка
+func (t *TOTP) Enabled() bool {
+	return t.ConfirmedAt != nil
+}
@@ -1,7 +1,50 @@
 package models
 
+import "time"
+
+// Роли пользователей
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+	// RoleService сервисная учетная запись, работает только через API-ключи
+	RoleService = "service"
+)
+
 type User struct {
 	UserName string `db:"username"`
 	Password string `db:"password"`
 	Balance  int    `db:"balance"`
+	Role     string `db:"role"`
+	// TokenVersion увеличивается при смене пароля, токены с меньшей версией недействительны
+	TokenVersion   int        `db:"token_version"`
+	CreatedAt      time.Time  `db:"created_at"`
+	DisabledAt     *time.Time `db:"disabled_at"`
+	DisabledReason *string    `db:"disabled_reason"`
+	// DeletedAt задается при мягком удалении, удаленная учетная запись также отключена
+	DeletedAt *time.Time `db:"deleted_at"`
+}
+
+// Disabled сообщает, запрещены ли вход и получение монет
+func (u *User) Disabled() bool {
+	return u.DisabledAt != nil
+}
+
+// Deleted сообщает, удалена ли учетная запись
+func (u *User) Deleted() bool {
+	return u.DeletedAt != nil
+}
+
+// Статусы учетных записей для фильтрации списка пользователей
+const (
+	UserStatusActive   = "active"
+	UserStatusDisabled = "disabled"
+	UserStatusDeleted  = "deleted"
+)
+
+// UserFilter задает условия поиска пользователей, пустые поля не учитываются.
+// Без Status удаленные пользователи не показываются.
+type UserFilter struct {
+	Query  string
+	Role   string
+	Status string
 }
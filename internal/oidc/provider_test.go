@@ -0,0 +1,260 @@
+package oidc
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testClientID     = "merch-store"
+	testClientSecret = "secret"
+	testKeyID        = "idp-key"
+)
+
+// testIDP имитирует провайдера: выдает ID token, заданный тестом, в обмен на код
+type testIDP struct {
+	server  *httptest.Server
+	key     ed25519.PrivateKey
+	idToken string
+	// form запоминает параметры последнего запроса к token endpoint
+	form url.Values
+	user string
+	pass string
+}
+
+func newTestIDP(t *testing.T) *testIDP {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idp := &testIDP{key: key}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(discovery{
+			Issuer:                idp.server.URL,
+			AuthorizationEndpoint: idp.server.URL + "/authorize",
+			TokenEndpoint:         idp.server.URL + "/token",
+			JWKSURI:               idp.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		public := key.Public().(ed25519.PublicKey)
+		json.NewEncoder(w).Encode(map[string]any{"keys": []jwk{
+			{KeyType: "OKP", KeyID: testKeyID, Use: "sig", Curve: "Ed25519", X: base64.RawURLEncoding.EncodeToString(public)},
+			// Ключи шифрования не используются для проверки подписи
+			{KeyType: "OKP", KeyID: "enc", Use: "enc", Curve: "Ed25519", X: base64.RawURLEncoding.EncodeToString(public)},
+		}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		idp.form = r.PostForm
+		idp.user, idp.pass, _ = r.BasicAuth()
+		if r.PostForm.Get("code") == "bad" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(tokenResponse{Error: "invalid_grant", Description: "code expired"})
+			return
+		}
+		json.NewEncoder(w).Encode(tokenResponse{IDToken: idp.idToken, TokenType: "Bearer"})
+	})
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+	return idp
+}
+
+func (idp *testIDP) provider() *Provider {
+	return NewProvider(Config{
+		Issuer:       idp.server.URL,
+		ClientID:     testClientID,
+		ClientSecret: testClientSecret,
+		RedirectURL:  "http://localhost:8080/api/auth/oidc/callback",
+		Scopes:       []string{"openid", "email"},
+	}, 5*time.Second, slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+// claims возвращает корректные claims ID token для nonce
+func (idp *testIDP) claims(nonce string) jwt.MapClaims {
+	now := time.Now()
+	return jwt.MapClaims{
+		"iss":   idp.server.URL,
+		"aud":   testClientID,
+		"sub":   "user-42",
+		"email": "alice@example.com",
+		"nonce": nonce,
+		"iat":   now.Unix(),
+		"exp":   now.Add(5 * time.Minute).Unix(),
+	}
+}
+
+func (idp *testIDP) sign(t *testing.T, claims jwt.MapClaims, kid string, key ed25519.PrivateKey) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func TestAuthCodeURL(t *testing.T) {
+	idp := newTestIDP(t)
+
+	// Пример из RFC 7636, приложение B
+	const verifier = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	raw, err := idp.provider().AuthCodeURL(context.Background(), "state-1", "nonce-1", verifier)
+	if err != nil {
+		t.Fatalf("AuthCodeURL() error = %v", err)
+	}
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := parsed.Scheme + "://" + parsed.Host + parsed.Path; got != idp.server.URL+"/authorize" {
+		t.Errorf("AuthCodeURL() endpoint = %s, want %s/authorize", got, idp.server.URL)
+	}
+
+	want := map[string]string{
+		"response_type":         "code",
+		"client_id":             testClientID,
+		"redirect_uri":          "http://localhost:8080/api/auth/oidc/callback",
+		"scope":                 "openid email",
+		"state":                 "state-1",
+		"nonce":                 "nonce-1",
+		"code_challenge":        "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM",
+		"code_challenge_method": "S256",
+	}
+	query := parsed.Query()
+	for name, value := range want {
+		if got := query.Get(name); got != value {
+			t.Errorf("AuthCodeURL() %s = %q, want %q", name, got, value)
+		}
+	}
+	// Verifier не должен попадать в адрес, который видит браузер
+	if query.Has("code_verifier") {
+		t.Error("AuthCodeURL() exposes code_verifier")
+	}
+}
+
+func TestExchange(t *testing.T) {
+	idp := newTestIDP(t)
+	_, stranger, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	with := func(change func(jwt.MapClaims)) jwt.MapClaims {
+		claims := idp.claims("nonce-1")
+		change(claims)
+		return claims
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		code  string
+		valid bool
+	}{
+		{"valid", idp.sign(t, idp.claims("nonce-1"), testKeyID, idp.key), "code", true},
+		{"audience list", idp.sign(t, with(func(c jwt.MapClaims) { c["aud"] = []string{"other", testClientID} }), testKeyID, idp.key), "code", true},
+		{"expired within leeway", idp.sign(t, with(func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-30 * time.Second).Unix() }), testKeyID, idp.key), "code", true},
+		{"nonce mismatch", idp.sign(t, idp.claims("nonce-2"), testKeyID, idp.key), "code", false},
+		{"no nonce", idp.sign(t, with(func(c jwt.MapClaims) { delete(c, "nonce") }), testKeyID, idp.key), "code", false},
+		{"other issuer", idp.sign(t, with(func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" }), testKeyID, idp.key), "code", false},
+		{"other audience", idp.sign(t, with(func(c jwt.MapClaims) { c["aud"] = "other-client" }), testKeyID, idp.key), "code", false},
+		{"expired", idp.sign(t, with(func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-2 * time.Minute).Unix() }), testKeyID, idp.key), "code", false},
+		{"no expiry", idp.sign(t, with(func(c jwt.MapClaims) { delete(c, "exp") }), testKeyID, idp.key), "code", false},
+		{"no subject", idp.sign(t, with(func(c jwt.MapClaims) { delete(c, "sub") }), testKeyID, idp.key), "code", false},
+		{"foreign key", idp.sign(t, idp.claims("nonce-1"), testKeyID, stranger), "code", false},
+		{"unknown kid", idp.sign(t, idp.claims("nonce-1"), "rotated", idp.key), "code", false},
+		{"encryption key", idp.sign(t, idp.claims("nonce-1"), "enc", idp.key), "code", false},
+		{"symmetric algorithm", hs256Token(t, idp.claims("nonce-1")), "code", false},
+		{"token endpoint error", idp.sign(t, idp.claims("nonce-1"), testKeyID, idp.key), "bad", false},
+		{"no id token", "", "code", false},
+	}
+
+	for _, tt := range tests {
+		idp.idToken = tt.token
+		claims, err := idp.provider().Exchange(context.Background(), tt.code, "verifier-1", "nonce-1")
+		if tt.valid {
+			if err != nil || claims.Subject() != "user-42" || claims.String("email") != "alice@example.com" {
+				t.Errorf("%s: Exchange() = %v, %v, want claims of user-42", tt.name, claims, err)
+			}
+			continue
+		}
+		if err == nil {
+			t.Errorf("%s: Exchange() accepted the token", tt.name)
+		}
+	}
+
+	// Код обменивается вместе с verifier PKCE и учетными данными клиента
+	if idp.form.Get("grant_type") != "authorization_code" || idp.form.Get("code_verifier") != "verifier-1" ||
+		idp.user != testClientID || idp.pass != testClientSecret {
+		t.Errorf("token request = %v with client %s:%s, want authorization_code with verifier-1", idp.form, idp.user, idp.pass)
+	}
+}
+
+// hs256Token подписывает claims симметричным ключом, что провайдер OIDC делать не должен
+func hs256Token(t *testing.T, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	token.Header["kid"] = testKeyID
+	signed, err := token.SignedString([]byte(testClientSecret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func TestDiscoveryIssuerMismatch(t *testing.T) {
+	idp := newTestIDP(t)
+	provider := idp.provider()
+	provider.config.Issuer = idp.server.URL + "/"
+
+	_, err := provider.AuthCodeURL(context.Background(), "state", "nonce", "verifier")
+	if err == nil {
+		t.Error("AuthCodeURL() accepted a discovery document of another issuer")
+	}
+	if errors.Is(err, ErrInvalidIDToken) {
+		t.Errorf("AuthCodeURL() error = %v, want a discovery error", err)
+	}
+}
+
+func TestClaims(t *testing.T) {
+	claims := Claims{"sub": "user-42", "email_verified": true, "phone_verified": "true", "admin": "yes", "count": 3.0}
+
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"email_verified", true},
+		{"phone_verified", true},
+		{"admin", false},
+		{"count", false},
+		{"missing", false},
+	}
+	for _, tt := range tests {
+		if got := claims.Bool(tt.name); got != tt.want {
+			t.Errorf("Bool(%s) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+	if claims.Subject() != "user-42" || claims.String("count") != "" {
+		t.Errorf("Subject() = %q, String(count) = %q, want user-42 and empty", claims.Subject(), claims.String("count"))
+	}
+}
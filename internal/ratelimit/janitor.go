@@ -0,0 +1,36 @@
+package ratelimit
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Janitor периодически удаляет неиспользуемые корзины из хранилища
+type Janitor struct {
+	store    Store
+	interval time.Duration
+	idle     time.Duration
+	logger   *slog.Logger
+}
+
+func NewJanitor(store Store, interval, idle time.Duration, logger *slog.Logger) *Janitor {
+	return &Janitor{store: store, interval: interval, idle: idle, logger: logger}
+}
+
+// Run запускает периодическую очистку до отмены контекста
+func (j *Janitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := j.store.Cleanup(ctx, j.idle); err != nil {
+				j.logger.Error("Failed to cleanup rate limit buckets", "error", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// newTestMemoryStore возвращает хранилище с управляемыми часами
+func newTestMemoryStore() (*MemoryStore, func(time.Duration)) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+	return store, func(d time.Duration) { now = now.Add(d) }
+}
+
+func TestMemoryStoreTake(t *testing.T) {
+	ctx := context.Background()
+	limit := Limit{Rate: 1, Burst: 3}
+	store, advance := newTestMemoryStore()
+
+	take := func(key string) Result {
+		t.Helper()
+		result, err := store.Take(ctx, key, limit)
+		if err != nil {
+			t.Fatalf("Take(%s) error = %v", key, err)
+		}
+		return result
+	}
+
+	// Новая корзина заполнена: проходит burst запросов подряд
+	for i := 0; i < limit.Burst; i++ {
+		if result := take("alice"); !result.Allowed || result.Remaining != limit.Burst-1-i {
+			t.Errorf("request %d = %+v, want allowed with %d remaining", i, result, limit.Burst-1-i)
+		}
+	}
+	if result := take("alice"); result.Allowed || result.RetryAfter != time.Second {
+		t.Errorf("request over burst = %+v, want denied with retry after 1s", result)
+	}
+
+	// Отклоненный запрос не расходует токен, пополнение идет с момента последнего обращения
+	advance(500 * time.Millisecond)
+	if result := take("alice"); result.Allowed {
+		t.Errorf("request after half a token = %+v, want denied", result)
+	}
+	advance(500 * time.Millisecond)
+	if result := take("alice"); !result.Allowed || result.Remaining != 0 {
+		t.Errorf("request after a full token = %+v, want allowed with 0 remaining", result)
+	}
+
+	// Корзины разных ключей независимы
+	if result := take("bob"); !result.Allowed || result.Remaining != limit.Burst-1 {
+		t.Errorf("other key = %+v, want allowed with %d remaining", result, limit.Burst-1)
+	}
+
+	// После долгого простоя корзина заполняется не больше чем до burst
+	advance(time.Hour)
+	for i := 0; i < limit.Burst; i++ {
+		take("alice")
+	}
+	if result := take("alice"); result.Allowed {
+		t.Errorf("request after idle burst = %+v, want denied", result)
+	}
+}
+
+func TestMemoryStoreCleanup(t *testing.T) {
+	ctx := context.Background()
+	limit := Limit{Rate: 1, Burst: 1}
+	store, advance := newTestMemoryStore()
+
+	store.Take(ctx, "idle", limit)
+	advance(10 * time.Minute)
+	store.Take(ctx, "active", limit)
+
+	if err := store.Cleanup(ctx, 5*time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := store.buckets["idle"]; ok {
+		t.Error("idle bucket was not removed")
+	}
+	if _, ok := store.buckets["active"]; !ok {
+		t.Error("active bucket was removed")
+	}
+}
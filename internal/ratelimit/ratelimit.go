@@ -0,0 +1,60 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Limit описывает параметры token bucket: скорость пополнения и емкость
+type Limit struct {
+	Rate  float64 // токенов в секунду
+	Burst int
+}
+
+// PerMinute создает лимит из количества запросов в минуту
+func PerMinute(requests float64, burst int) Limit {
+	return Limit{Rate: requests / 60, Burst: burst}
+}
+
+// Result представляет результат попытки потратить токен
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	RetryAfter time.Duration
+	Reset      time.Duration
+}
+
+// Store хранит состояние корзин токенов
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+	Cleanup(ctx context.Context, idle time.Duration) error
+}
+
+// NewResult формирует результат по оставшемуся количеству токенов
+func NewResult(allowed bool, tokens float64, limit Limit) Result {
+	result := Result{
+		Allowed:   allowed,
+		Limit:     limit.Burst,
+		Remaining: max(0, int(math.Floor(tokens))),
+		Reset:     secondsToDuration((float64(limit.Burst) - tokens) / limit.Rate),
+	}
+	if !allowed {
+		result.RetryAfter = secondsToDuration((1 - tokens) / limit.Rate)
+	}
+	return result
+}
+
+// refill вычисляет количество токенов после пополнения за прошедшее время
+func refill(tokens float64, elapsed time.Duration, limit Limit) float64 {
+	return math.Min(float64(limit.Burst), tokens+elapsed.Seconds()*limit.Rate)
+}
+
+// secondsToDuration переводит секунды в длительность, округляя вверх до секунды
+func secondsToDuration(seconds float64) time.Duration {
+	if seconds <= 0 {
+		return 0
+	}
+	return time.Duration(math.Ceil(seconds)) * time.Second
+}
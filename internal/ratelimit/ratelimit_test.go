@@ -0,0 +1,52 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRefill(t *testing.T) {
+	limit := Limit{Rate: 2, Burst: 10}
+
+	tests := []struct {
+		name    string
+		tokens  float64
+		elapsed time.Duration
+		want    float64
+	}{
+		{"no time passed", 3, 0, 3},
+		{"partial token", 0, 250 * time.Millisecond, 0.5},
+		{"several tokens", 1, 2 * time.Second, 5},
+		{"capped at burst", 9, time.Minute, 10},
+		{"full bucket stays full", 10, time.Second, 10},
+	}
+
+	for _, tt := range tests {
+		if got := refill(tt.tokens, tt.elapsed, limit); got != tt.want {
+			t.Errorf("%s: refill(%v, %s) = %v, want %v", tt.name, tt.tokens, tt.elapsed, got, tt.want)
+		}
+	}
+}
+
+func TestNewResult(t *testing.T) {
+	limit := PerMinute(30, 5) // токен каждые 2 секунды
+
+	tests := []struct {
+		name    string
+		allowed bool
+		tokens  float64
+		want    Result
+	}{
+		{"full after take", true, 4, Result{Allowed: true, Limit: 5, Remaining: 4, Reset: 2 * time.Second}},
+		{"fraction is not a token", true, 2.5, Result{Allowed: true, Limit: 5, Remaining: 2, Reset: 5 * time.Second}},
+		{"empty", false, 0, Result{Allowed: false, Limit: 5, Remaining: 0, RetryAfter: 2 * time.Second, Reset: 10 * time.Second}},
+		// Ожидание округляется вверх, чтобы повтор после Retry-After был успешным
+		{"almost a token", false, 0.9, Result{Allowed: false, Limit: 5, Remaining: 0, RetryAfter: time.Second, Reset: 9 * time.Second}},
+	}
+
+	for _, tt := range tests {
+		if got := NewResult(tt.allowed, tt.tokens, limit); got != tt.want {
+			t.Errorf("%s: NewResult() = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	e "API-Avito-shop/internal/errors"
+	"API-Avito-shop/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type APIKeyRepository interface {
+	CreateKey(ctx context.Context, key *models.APIKey) error
+	ListKeys(ctx context.Context, username string) ([]models.APIKey, error)
+	RevokeKey(ctx context.Context, username string, id int64) error
+	RevokeAllKeys(ctx context.Context, tx pgx.Tx, username string) error
+	GetActiveKey(ctx context.Context, keyHash string) (*models.APIKey, error)
+	TouchKey(ctx context.Context, id int64) error
+}
+
+type APIKeyRepo struct {
+	pool   *pgxpool.Pool
+	logger *slog.Logger
+}
+
+func NewAPIKeyRepository(pool *pgxpool.Pool, logger *slog.Logger) *APIKeyRepo {
+	return &APIKeyRepo{pool: pool, logger: logger}
+}
+
+const (
+	queryCreateAPIKey = `INSERT INTO api_keys (username, name, prefix, key_hash, scopes, created_by, expires_at) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id, created_at`
+	queryListAPIKeys  = `SELECT id, username, name, prefix, key_hash, scopes, created_by, created_at, last_used_at, expires_at, revoked_at
+		FROM api_keys WHERE username = $1 ORDER BY id`
+	queryRevokeAPIKey     = `UPDATE api_keys SET revoked_at = NOW() WHERE id = $1 AND username = $2 AND revoked_at IS NULL`
+	queryRevokeAllAPIKeys = `UPDATE api_keys SET revoked_at = NOW() WHERE username = $1 AND revoked_at IS NULL`
+	// Ключи отключенных учетных записей не принимаются
+	queryGetActiveAPIKey = `SELECT id, username, name, prefix, key_hash, scopes, created_by, created_at, last_used_at, expires_at, revoked_at
+		FROM api_keys WHERE key_hash = $1 AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > NOW())
+		AND EXISTS (SELECT 1 FROM users u WHERE u.username = api_keys.username AND u.disabled_at IS NULL)`
+	// Время последнего использования обновляется не чаще раза в минуту, чтобы не писать в базу на каждый запрос
+	queryTouchAPIKey = `UPDATE api_keys SET last_used_at = NOW() WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL '1 minute')`
+)
+
+// CreateKey сохраняет новый API-ключ
+func (r *APIKeyRepo) CreateKey(ctx context.Context, key *models.APIKey) error {
+	r.logger.Info("Executing query", "query", queryCreateAPIKey, "username", key.Username)
+
+	err := r.pool.QueryRow(ctx, queryCreateAPIKey, key.Username, key.Name, key.Prefix, key.KeyHash, key.Scopes, key.CreatedBy, key.ExpiresAt).
+		Scan(&key.ID, &key.CreatedAt)
+	if err != nil {
+		r.logger.Error("Failed to execute query to create api key", "username", key.Username, "error", err)
+		return fmt.Errorf("CreateKey: %w", e.ErrFailedExecuteQuery)
+	}
+
+	r.logger.Info("API key created", "username", key.Username, "key_id", key.ID)
+	return nil
+}
+
+// ListKeys получение всех ключей учетной записи, включая отозванные
+func (r *APIKeyRepo) ListKeys(ctx context.Context, username string) ([]models.APIKey, error) {
+	rows, err := r.pool.Query(ctx, queryListAPIKeys, username)
+	if err != nil {
+		r.logger.Error("Failed to execute query to list api keys", "username", username, "error", err)
+		return nil, fmt.Errorf("ListKeys: %w", e.ErrFailedExecuteQuery)
+	}
+	defer rows.Close()
+
+	var keys []models.APIKey
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			r.logger.Error("Failed to scan api key", "error", err)
+			return nil, fmt.Errorf("ListKeys: %w", e.ErrFailedExecuteQuery)
+		}
+		keys = append(keys, *key)
+	}
+
+	return keys, rows.Err()
+}
+
+// RevokeKey отзывает ключ учетной записи
+func (r *APIKeyRepo) RevokeKey(ctx context.Context, username string, id int64) error {
+	r.logger.Info("Executing query", "query", queryRevokeAPIKey, "username", username, "key_id", id)
+
+	tag, err := r.pool.Exec(ctx, queryRevokeAPIKey, id, username)
+	if err != nil {
+		r.logger.Error("Failed to execute query to revoke api key", "key_id", id, "error", err)
+		return fmt.Errorf("RevokeKey: %w", e.ErrFailedExecuteQuery)
+	}
+	if tag.RowsAffected() == 0 {
+		return e.ErrAPIKeyNotFound
+	}
+
+	r.logger.Info("API key revoked", "username", username, "key_id", id)
+	return nil
+}
+
+// RevokeAllKeys отзывает все действующие ключи учетной записи
+func (r *APIKeyRepo) RevokeAllKeys(ctx context.Context, tx pgx.Tx, username string) error {
+	r.logger.Info("Executing query", "query", queryRevokeAllAPIKeys, "username", username)
+
+	if _, err := tx.Exec(ctx, queryRevokeAllAPIKeys, username); err != nil {
+		r.logger.Error("Failed to execute query to revoke api keys", "username", username, "error", err)
+		return fmt.Errorf("RevokeAllKeys: %w", e.ErrFailedExecuteQuery)
+	}
+
+	return nil
+}
+
+// GetActiveKey ищет действующий ключ по хешу
+func (r *APIKeyRepo) GetActiveKey(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	key, err := scanAPIKey(r.pool.QueryRow(ctx, queryGetActiveAPIKey, keyHash))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, e.ErrInvalidAPIKey
+		}
+		r.logger.Error("Failed to execute query to get api key", "error", err)
+		return nil, fmt.Errorf("GetActiveKey: %w", e.ErrFailedExecuteQuery)
+	}
+
+	return key, nil
+}
+
+// TouchKey обновляет время последнего использования ключа
+func (r *APIKeyRepo) TouchKey(ctx context.Context, id int64) error {
+	if _, err := r.pool.Exec(ctx, queryTouchAPIKey, id); err != nil {
+		r.logger.Error("Failed to execute query to touch api key", "key_id", id, "error", err)
+		return fmt.Errorf("TouchKey: %w", e.ErrFailedExecuteQuery)
+	}
+	return nil
+}
+
+// scanAPIKey считывает ключ из строки результата
+func scanAPIKey(row pgx.Row) (*models.APIKey, error) {
+	var key models.APIKey
+	err := row.Scan(&key.ID, &key.Username, &key.Name, &key.Prefix, &key.KeyHash, &key.Scopes, &key.CreatedBy,
+		&key.CreatedAt, &key.LastUsedAt, &key.ExpiresAt, &key.RevokedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
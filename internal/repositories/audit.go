@@ -0,0 +1,171 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+
+	"API-Avito-shop/internal/dto"
+	e "API-Avito-shop/internal/errors"
+	"API-Avito-shop/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type AuditRepository interface {
+	ChainRepository
+	Insert(ctx context.Context, tx pgx.Tx, entry *models.AuditEntry) error
+	ListEntries(ctx context.Context, filter models.AuditFilter, limit, offset int) ([]models.AuditEntry, error)
+	ScanEntries(ctx context.Context, filter models.AuditFilter, afterID int64, limit int) ([]models.AuditEntry, error)
+	VerifyChain(ctx context.Context, key []byte) (dto.ChainVerification, error)
+}
+
+type AuditRepo struct {
+	pool   *pgxpool.Pool
+	logger *slog.Logger
+}
+
+func NewAuditRepository(pool *pgxpool.Pool, logger *slog.Logger) *AuditRepo {
+	return &AuditRepo{pool: pool, logger: logger}
+}
+
+// auditChain запросы к цепочке хешей журнала
+var auditChain = chainQueries{
+	lockKey: 7_310_001,
+	last:    `SELECT chain_seq, hash FROM audit_log WHERE chain_seq IS NOT NULL ORDER BY chain_seq DESC LIMIT 1`,
+	pending: `SELECT id, 0, actor, action, target, amount, details, ip, user_agent, request_id, created_at, '', ''
+		FROM audit_log WHERE chain_seq IS NULL ORDER BY id LIMIT $1`,
+	seal: `UPDATE audit_log SET chain_seq = $2, prev_hash = $3, hash = $4 WHERE id = $1`,
+	sealed: `SELECT id, chain_seq, actor, action, target, amount, details, ip, user_agent, request_id, created_at, prev_hash, hash
+		FROM audit_log WHERE chain_seq > $1 ORDER BY chain_seq LIMIT $2`,
+	countPending: `SELECT COUNT(*) FROM audit_log WHERE chain_seq IS NULL`,
+}
+
+const (
+	queryInsertAudit = `INSERT INTO audit_log (actor, action, target, amount, details, ip, user_agent, request_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id, created_at`
+	// Пустые условия фильтра не ограничивают выборку
+	auditFilterCondition = `($1 = '' OR actor = $1) AND ($2 = '' OR action = $2) AND ($3 = '' OR target = $3)
+		AND ($4::timestamptz IS NULL OR created_at >= $4) AND ($5::timestamptz IS NULL OR created_at < $5)`
+	// Записи, еще не включенные в цепочку, возвращаются с пустыми хешами
+	auditColumns = `id, actor, action, target, amount, details, ip, user_agent, request_id,
+		COALESCE(prev_hash, ''), COALESCE(hash, ''), created_at`
+	queryListAudit = `SELECT ` + auditColumns + ` FROM audit_log WHERE ` + auditFilterCondition + ` ORDER BY id DESC LIMIT $6 OFFSET $7`
+	queryScanAudit = `SELECT ` + auditColumns + ` FROM audit_log WHERE ` + auditFilterCondition + ` AND id > $6 ORDER BY id LIMIT $7`
+)
+
+// Insert добавляет запись в журнал, время записи задает база данных.
+// В цепочку хешей запись включается после фиксации транзакции, поэтому запись не ждет другие транзакции.
+func (r *AuditRepo) Insert(ctx context.Context, tx pgx.Tx, entry *models.AuditEntry) error {
+	err := tx.QueryRow(ctx, queryInsertAudit, entry.Actor, entry.Action, entry.Target, entry.Amount, entry.Details,
+		entry.IP, entry.UserAgent, entry.RequestID).Scan(&entry.ID, &entry.CreatedAt)
+	if err != nil {
+		r.logger.Error("Failed to execute query to insert audit entry", "action", entry.Action, "error", err)
+		return fmt.Errorf("Insert: %w", e.ErrFailedExecuteQuery)
+	}
+
+	return nil
+}
+
+// ListEntries получение записей журнала начиная с последних
+func (r *AuditRepo) ListEntries(ctx context.Context, filter models.AuditFilter, limit, offset int) ([]models.AuditEntry, error) {
+	r.logger.Info("Executing query", "query", queryListAudit, "limit", limit, "offset", offset)
+
+	rows, err := r.pool.Query(ctx, queryListAudit, filter.Actor, filter.Action, filter.Target, filter.From, filter.To, limit, offset)
+	if err != nil {
+		r.logger.Error("Failed to execute query to list audit entries", "error", err)
+		return nil, fmt.Errorf("ListEntries: %w", e.ErrFailedExecuteQuery)
+	}
+
+	entries, err := scanAuditEntries(rows)
+	if err != nil {
+		r.logger.Error("Failed to scan audit entry", "error", err)
+		return nil, fmt.Errorf("ListEntries: %w", e.ErrFailedExecuteQuery)
+	}
+
+	return entries, nil
+}
+
+// ScanEntries получение записей журнала в порядке добавления после указанного id, используется для выгрузки и проверки
+func (r *AuditRepo) ScanEntries(ctx context.Context, filter models.AuditFilter, afterID int64, limit int) ([]models.AuditEntry, error) {
+	rows, err := r.pool.Query(ctx, queryScanAudit, filter.Actor, filter.Action, filter.Target, filter.From, filter.To, afterID, limit)
+	if err != nil {
+		r.logger.Error("Failed to execute query to scan audit entries", "after_id", afterID, "error", err)
+		return nil, fmt.Errorf("ScanEntries: %w", e.ErrFailedExecuteQuery)
+	}
+
+	entries, err := scanAuditEntries(rows)
+	if err != nil {
+		r.logger.Error("Failed to scan audit entry", "error", err)
+		return nil, fmt.Errorf("ScanEntries: %w", e.ErrFailedExecuteQuery)
+	}
+
+	return entries, nil
+}
+
+// SealChain включает в цепочку хешей пачку записей, добавленных после последнего вызова
+func (r *AuditRepo) SealChain(ctx context.Context, tx pgx.Tx, key []byte, limit int) (int, error) {
+	sealed, err := sealChain(ctx, tx, auditChain, scanAuditChainRow, key, limit)
+	if err != nil {
+		r.logger.Error("Failed to seal audit chain", "error", err)
+		return 0, fmt.Errorf("SealChain: %w", e.ErrFailedExecuteQuery)
+	}
+
+	return sealed, nil
+}
+
+// VerifyChain проверяет цепочку хешей журнала
+func (r *AuditRepo) VerifyChain(ctx context.Context, key []byte) (dto.ChainVerification, error) {
+	result, err := verifyChain(ctx, r.pool, auditChain, scanAuditChainRow, key)
+	if err != nil {
+		r.logger.Error("Failed to verify audit chain", "error", err)
+		return result, fmt.Errorf("VerifyChain: %w", e.ErrFailedExecuteQuery)
+	}
+
+	return result, nil
+}
+
+// scanAuditChainRow считывает запись журнала как строку цепочки хешей
+func scanAuditChainRow(rows pgx.Rows) (chainRow, error) {
+	var (
+		row chainRow
+		a   models.AuditEntry
+	)
+	err := rows.Scan(&row.id, &row.seq, &a.Actor, &a.Action, &a.Target, &a.Amount, &a.Details, &a.IP, &a.UserAgent,
+		&a.RequestID, &a.CreatedAt, &row.prevHash, &row.hash)
+	if err != nil {
+		return row, err
+	}
+	row.fields = auditChainFields(a)
+	return row, nil
+}
+
+// auditChainFields возвращает поля записи журнала, покрываемые хешем.
+// Пустая сумма отличается от любой заданной, так как число не бывает пустой строкой.
+func auditChainFields(a models.AuditEntry) []string {
+	var amount string
+	if a.Amount != nil {
+		amount = strconv.Itoa(*a.Amount)
+	}
+	return []string{a.Actor, a.Action, a.Target, amount, a.Details, a.IP, a.UserAgent, a.RequestID, chainTime(&a.CreatedAt)}
+}
+
+// scanAuditEntries читает записи журнала и закрывает rows
+func scanAuditEntries(rows pgx.Rows) ([]models.AuditEntry, error) {
+	defer rows.Close()
+
+	var entries []models.AuditEntry
+	for rows.Next() {
+		var a models.AuditEntry
+		err := rows.Scan(&a.ID, &a.Actor, &a.Action, &a.Target, &a.Amount, &a.Details, &a.IP, &a.UserAgent,
+			&a.RequestID, &a.PrevHash, &a.Hash, &a.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, a)
+	}
+
+	return entries, rows.Err()
+}
@@ -0,0 +1,188 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	e "API-Avito-shop/internal/errors"
+	"API-Avito-shop/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// CoinLotRepository ведет партии монет. Изменения партий выполняются после блокировки строки пользователя
+// изменением баланса, поэтому сумма остатков партий всегда равна балансу.
+type CoinLotRepository interface {
+	Consume(ctx context.Context, tx pgx.Tx, username string, amount int) ([]models.CoinLot, error)
+	Credit(ctx context.Context, tx pgx.Tx, username string, lots []models.CoinLot) error
+	ActiveLots(ctx context.Context, tx pgx.Tx, username string) ([]models.CoinLot, error)
+	LockExpiringUsers(ctx context.Context, tx pgx.Tx, cutoff time.Time, limit int) ([]string, error)
+	ExpireLots(ctx context.Context, tx pgx.Tx, usernames []string, cutoff time.Time) ([]models.CoinExpiration, error)
+}
+
+type CoinLotRepo struct {
+	pool   *pgxpool.Pool
+	logger *slog.Logger
+}
+
+func NewCoinLotRepository(pool *pgxpool.Pool, logger *slog.Logger) *CoinLotRepo {
+	return &CoinLotRepo{pool: pool, logger: logger}
+}
+
+const (
+	// Партии списываются в порядке начисления: каждая следующая партия берется, пока не набрана сумма
+	queryConsumeLots = `WITH ordered AS (
+			SELECT id, issued_at, remaining, SUM(remaining) OVER (ORDER BY issued_at, id) - remaining AS before
+			FROM coin_lots WHERE username = $1 AND remaining > 0
+		), taken AS (
+			SELECT id, issued_at, LEAST(remaining, $2 - before) AS amount FROM ordered WHERE before < $2
+		), updated AS (
+			UPDATE coin_lots l SET remaining = l.remaining - t.amount FROM taken t WHERE l.id = t.id
+		)
+		SELECT amount, issued_at FROM taken ORDER BY issued_at, id`
+	queryCreditLots = `INSERT INTO coin_lots (username, amount, remaining, issued_at)
+		SELECT $1, lot.amount, lot.amount, lot.issued_at FROM unnest($2::int[], $3::timestamptz[]) AS lot(amount, issued_at)`
+	queryActiveLots = `SELECT remaining, issued_at FROM coin_lots WHERE username = $1 AND remaining > 0 ORDER BY issued_at, id`
+	// Занятые переводами и покупками пользователи пропускаются и обрабатываются при следующем запуске
+	queryLockExpiringUsers = `SELECT username FROM users
+		WHERE username IN (SELECT username FROM coin_lots WHERE remaining > 0 AND issued_at < $1)
+		ORDER BY username LIMIT $2 FOR UPDATE SKIP LOCKED`
+	queryExpireLots = `WITH lots AS (
+			SELECT id, username, remaining FROM coin_lots WHERE username = ANY($1) AND remaining > 0 AND issued_at < $2
+		), zeroed AS (
+			UPDATE coin_lots SET remaining = 0 WHERE id IN (SELECT id FROM lots)
+		), totals AS (
+			SELECT username, SUM(remaining)::int AS amount FROM lots GROUP BY username
+		), recorded AS (
+			INSERT INTO coin_expirations (username, amount) SELECT username, amount FROM totals
+		)
+		UPDATE users u SET balance = u.balance - t.amount FROM totals t WHERE u.username = t.username
+		RETURNING u.username, t.amount, u.balance`
+)
+
+// Consume списывает монеты из партий пользователя в порядке начисления и возвращает списанные части партий
+func (r *CoinLotRepo) Consume(ctx context.Context, tx pgx.Tx, username string, amount int) ([]models.CoinLot, error) {
+	rows, err := tx.Query(ctx, queryConsumeLots, username, amount)
+	if err != nil {
+		r.logger.Error("Failed to execute query to consume coin lots", "username", username, "error", err)
+		return nil, fmt.Errorf("Consume: %w", e.ErrFailedExecuteQuery)
+	}
+
+	lots, err := scanCoinLots(rows)
+	if err != nil {
+		r.logger.Error("Failed to parse row", "error", err)
+		return nil, fmt.Errorf("Consume: %w", e.ErrFailedExecuteQuery)
+	}
+
+	consumed := 0
+	for _, lot := range lots {
+		consumed += lot.Amount
+	}
+	if consumed != amount {
+		r.logger.Error("Coin lots do not match balance", "username", username, "amount", amount, "consumed", consumed)
+		return nil, fmt.Errorf("Consume: coin lots do not match balance: %w", e.ErrFailedExecuteQuery)
+	}
+
+	return lots, nil
+}
+
+// Credit добавляет пользователю партии с сохранением времени первоначального начисления
+func (r *CoinLotRepo) Credit(ctx context.Context, tx pgx.Tx, username string, lots []models.CoinLot) error {
+	amounts := make([]int, 0, len(lots))
+	issued := make([]time.Time, 0, len(lots))
+	for _, lot := range lots {
+		amounts = append(amounts, lot.Amount)
+		issued = append(issued, lot.IssuedAt)
+	}
+
+	if _, err := tx.Exec(ctx, queryCreditLots, username, amounts, issued); err != nil {
+		r.logger.Error("Failed to execute query to credit coin lots", "username", username, "error", err)
+		return fmt.Errorf("Credit: %w", e.ErrFailedExecuteQuery)
+	}
+
+	return nil
+}
+
+// ActiveLots предоставляет непотраченные партии пользователя в порядке начисления
+func (r *CoinLotRepo) ActiveLots(ctx context.Context, tx pgx.Tx, username string) ([]models.CoinLot, error) {
+	rows, err := tx.Query(ctx, queryActiveLots, username)
+	if err != nil {
+		r.logger.Error("Failed to execute query to get coin lots", "username", username, "error", err)
+		return nil, fmt.Errorf("ActiveLots: %w", e.ErrFailedExecuteQuery)
+	}
+
+	lots, err := scanCoinLots(rows)
+	if err != nil {
+		r.logger.Error("Failed to parse row", "error", err)
+		return nil, fmt.Errorf("ActiveLots: %w", e.ErrFailedExecuteQuery)
+	}
+
+	return lots, nil
+}
+
+// LockExpiringUsers блокирует пользователей, у которых есть партии, начисленные до cutoff
+func (r *CoinLotRepo) LockExpiringUsers(ctx context.Context, tx pgx.Tx, cutoff time.Time, limit int) ([]string, error) {
+	rows, err := tx.Query(ctx, queryLockExpiringUsers, cutoff, limit)
+	if err != nil {
+		r.logger.Error("Failed to execute query to lock users with expiring coins", "error", err)
+		return nil, fmt.Errorf("LockExpiringUsers: %w", e.ErrFailedExecuteQuery)
+	}
+
+	defer rows.Close()
+
+	var usernames []string
+	for rows.Next() {
+		var username string
+		if err = rows.Scan(&username); err != nil {
+			r.logger.Error("Failed to parse row", "error", err)
+			return nil, fmt.Errorf("LockExpiringUsers: %w", e.ErrFailedExecuteQuery)
+		}
+		usernames = append(usernames, username)
+	}
+
+	return usernames, rows.Err()
+}
+
+// ExpireLots обнуляет партии пользователей, начисленные до cutoff, уменьшает балансы и записывает сгорание
+func (r *CoinLotRepo) ExpireLots(ctx context.Context, tx pgx.Tx, usernames []string, cutoff time.Time) ([]models.CoinExpiration, error) {
+	rows, err := tx.Query(ctx, queryExpireLots, usernames, cutoff)
+	if err != nil {
+		r.logger.Error("Failed to execute query to expire coin lots", "error", err)
+		return nil, fmt.Errorf("ExpireLots: %w", e.ErrFailedExecuteQuery)
+	}
+	defer rows.Close()
+
+	var expirations []models.CoinExpiration
+	for rows.Next() {
+		var expiration models.CoinExpiration
+		if err = rows.Scan(&expiration.Username, &expiration.Amount, &expiration.Balance); err != nil {
+			r.logger.Error("Failed to parse row", "error", err)
+			return nil, fmt.Errorf("ExpireLots: %w", e.ErrFailedExecuteQuery)
+		}
+		expirations = append(expirations, expiration)
+	}
+	if err = rows.Err(); err != nil {
+		r.logger.Error("Error during rows iteration", "error", err)
+		return nil, fmt.Errorf("ExpireLots: %w", e.ErrFailedExecuteQuery)
+	}
+
+	return expirations, nil
+}
+
+// scanCoinLots считывает части партий из результата запроса
+func scanCoinLots(rows pgx.Rows) ([]models.CoinLot, error) {
+	defer rows.Close()
+
+	var lots []models.CoinLot
+	for rows.Next() {
+		var lot models.CoinLot
+		if err := rows.Scan(&lot.Amount, &lot.IssuedAt); err != nil {
+			return nil, err
+		}
+		lots = append(lots, lot)
+	}
+	return lots, rows.Err()
+}
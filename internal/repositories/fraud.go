@@ -0,0 +1,170 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	e "API-Avito-shop/internal/errors"
+	"API-Avito-shop/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type FraudRepository interface {
+	TransferSignals(ctx context.Context, tx pgx.Tx, fromUser, toUser string, windows models.FraudWindows) (models.TransferSignals, error)
+	CreateReview(ctx context.Context, tx pgx.Tx, review *models.FraudReview) (int64, error)
+	GetReview(ctx context.Context, id int64) (*models.FraudReview, error)
+	GetReviewForUpdate(ctx context.Context, tx pgx.Tx, id int64) (*models.FraudReview, error)
+	ListReviews(ctx context.Context, filter models.FraudReviewFilter, limit, offset int) ([]models.FraudReview, error)
+	ResolveReview(ctx context.Context, tx pgx.Tx, id int64, status, admin, note string) error
+}
+
+type FraudRepo struct {
+	pool   *pgxpool.Pool
+	logger *slog.Logger
+}
+
+func NewFraudRepository(pool *pgxpool.Pool, logger *slog.Logger) *FraudRepo {
+	return &FraudRepo{pool: pool, logger: logger}
+}
+
+const (
+	fraudReviewColumns = `id, from_username, to_username, amount, action, rules, status, created_at, reviewed_by, reviewed_at, review_note`
+	// Недавние переводы участников вместе с задержанными до проверки; $7 — начало самого длинного окна
+	queryTransferSignals = `WITH recent AS (
+			SELECT from_username, to_username, amount, created_at FROM transactions
+			WHERE created_at >= $7 AND (from_username = $1 OR to_username = $2 OR (from_username = $2 AND to_username = $1))
+			UNION ALL
+			SELECT from_username, to_username, amount, created_at FROM fraud_reviews
+			WHERE action = 'hold' AND status = 'pending' AND created_at >= $7
+			AND (from_username = $1 OR to_username = $2 OR (from_username = $2 AND to_username = $1))
+		)
+		SELECT
+			EXISTS (SELECT 1 FROM users WHERE username = $2 AND disabled_at IS NULL),
+			(SELECT COUNT(*) FROM recent WHERE from_username = $1 AND created_at >= $3),
+			(SELECT COALESCE(SUM(amount), 0) FROM recent WHERE from_username = $1 AND created_at >= $3),
+			EXISTS (SELECT 1 FROM users WHERE username = $1 AND created_at >= $6),
+			(SELECT COUNT(DISTINCT t.from_username) FROM recent t JOIN users u ON u.username = t.from_username
+				WHERE t.to_username = $2 AND t.from_username <> $1 AND t.created_at >= $4 AND u.created_at >= $6),
+			(SELECT COUNT(*) FROM recent WHERE from_username = $2 AND to_username = $1 AND created_at >= $5)`
+	queryCreateFraudReview = `INSERT INTO fraud_reviews (from_username, to_username, amount, action, rules, status)
+		VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`
+	queryGetFraudReview          = `SELECT ` + fraudReviewColumns + ` FROM fraud_reviews WHERE id = $1`
+	queryGetFraudReviewForUpdate = `SELECT ` + fraudReviewColumns + ` FROM fraud_reviews WHERE id = $1 FOR UPDATE`
+	queryListFraudReviews        = `SELECT ` + fraudReviewColumns + ` FROM fraud_reviews
+		WHERE ($1 = '' OR status = $1) AND ($2 = '' OR action = $2) AND ($3 = '' OR from_username = $3 OR to_username = $3)
+		ORDER BY id DESC LIMIT $4 OFFSET $5`
+	queryResolveFraudReview = `UPDATE fraud_reviews SET status = $2, reviewed_by = $3, reviewed_at = NOW(), review_note = NULLIF($4, '')
+		WHERE id = $1`
+)
+
+// TransferSignals предоставляет сведения о недавних переводах отправителя и получателя для проверки правил
+func (r *FraudRepo) TransferSignals(ctx context.Context, tx pgx.Tx, fromUser, toUser string, windows models.FraudWindows) (models.TransferSignals, error) {
+	var signals models.TransferSignals
+
+	earliest := windows.Velocity
+	for _, since := range []time.Time{windows.FanIn, windows.RoundTrip} {
+		if since.Before(earliest) {
+			earliest = since
+		}
+	}
+
+	err := tx.QueryRow(ctx, queryTransferSignals, fromUser, toUser, windows.Velocity, windows.FanIn, windows.RoundTrip,
+		windows.NewAccount, earliest).Scan(&signals.RecipientActive, &signals.SenderTransfers, &signals.SenderVolume,
+		&signals.SenderIsNew, &signals.NewSenders, &signals.ReverseTransfers)
+	if err != nil {
+		r.logger.Error("Failed to execute query to get transfer signals", "from_user", fromUser, "to_user", toUser, "error", err)
+		return signals, fmt.Errorf("TransferSignals: %w", e.ErrFailedExecuteQuery)
+	}
+
+	return signals, nil
+}
+
+// CreateReview добавляет перевод в очередь проверки
+func (r *FraudRepo) CreateReview(ctx context.Context, tx pgx.Tx, review *models.FraudReview) (int64, error) {
+	var id int64
+
+	err := tx.QueryRow(ctx, queryCreateFraudReview, review.FromUser, review.ToUser, review.Amount,
+		review.Action, review.Rules, review.Status).Scan(&id)
+	if err != nil {
+		r.logger.Error("Failed to execute query to create fraud review", "from_user", review.FromUser, "to_user", review.ToUser, "error", err)
+		return 0, fmt.Errorf("CreateReview: %w", e.ErrFailedExecuteQuery)
+	}
+
+	return id, nil
+}
+
+// GetReview предоставляет перевод из очереди проверки
+func (r *FraudRepo) GetReview(ctx context.Context, id int64) (*models.FraudReview, error) {
+	review, err := scanFraudReview(r.pool.QueryRow(ctx, queryGetFraudReview, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, e.ErrReviewNotFound
+		}
+		r.logger.Error("Failed to execute query to get fraud review", "id", id, "error", err)
+		return nil, fmt.Errorf("GetReview: %w", e.ErrFailedExecuteQuery)
+	}
+
+	return review, nil
+}
+
+// GetReviewForUpdate предоставляет перевод из очереди проверки, блокируя строку до завершения транзакции
+func (r *FraudRepo) GetReviewForUpdate(ctx context.Context, tx pgx.Tx, id int64) (*models.FraudReview, error) {
+	review, err := scanFraudReview(tx.QueryRow(ctx, queryGetFraudReviewForUpdate, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, e.ErrReviewNotFound
+		}
+		r.logger.Error("Failed to execute query to lock fraud review", "id", id, "error", err)
+		return nil, fmt.Errorf("GetReviewForUpdate: %w", e.ErrFailedExecuteQuery)
+	}
+
+	return review, nil
+}
+
+// ListReviews предоставляет очередь проверки, начиная с последних
+func (r *FraudRepo) ListReviews(ctx context.Context, filter models.FraudReviewFilter, limit, offset int) ([]models.FraudReview, error) {
+	rows, err := r.pool.Query(ctx, queryListFraudReviews, filter.Status, filter.Action, filter.User, limit, offset)
+	if err != nil {
+		r.logger.Error("Failed to execute query to list fraud reviews", "error", err)
+		return nil, fmt.Errorf("ListReviews: %w", e.ErrFailedExecuteQuery)
+	}
+	defer rows.Close()
+
+	reviews := make([]models.FraudReview, 0, limit)
+	for rows.Next() {
+		review, err := scanFraudReview(rows)
+		if err != nil {
+			r.logger.Error("Failed to parse row", "error", err)
+			return nil, fmt.Errorf("ListReviews: %w", e.ErrFailedExecuteQuery)
+		}
+		reviews = append(reviews, *review)
+	}
+
+	return reviews, rows.Err()
+}
+
+// ResolveReview сохраняет решение по переводу из очереди проверки
+func (r *FraudRepo) ResolveReview(ctx context.Context, tx pgx.Tx, id int64, status, admin, note string) error {
+	if _, err := tx.Exec(ctx, queryResolveFraudReview, id, status, admin, note); err != nil {
+		r.logger.Error("Failed to execute query to resolve fraud review", "id", id, "error", err)
+		return fmt.Errorf("ResolveReview: %w", e.ErrFailedExecuteQuery)
+	}
+
+	return nil
+}
+
+// scanFraudReview считывает перевод из очереди проверки из строки результата
+func scanFraudReview(row pgx.Row) (*models.FraudReview, error) {
+	var review models.FraudReview
+	err := row.Scan(&review.ID, &review.FromUser, &review.ToUser, &review.Amount, &review.Action, &review.Rules,
+		&review.Status, &review.CreatedAt, &review.ReviewedBy, &review.ReviewedAt, &review.ReviewNote)
+	if err != nil {
+		return nil, err
+	}
+	return &review, nil
+}
@@ -0,0 +1,160 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"API-Avito-shop/internal/dto"
+	"API-Avito-shop/internal/utils/hashchain"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// chainBatchSize количество строк, читаемых за раз при проверке цепочки
+const chainBatchSize = 1000
+
+// ChainRepository включает в цепочку хешей строки, добавленные без хеша.
+// Строки добавляются без блокировки цепочки, а номер в цепочке, хеш предыдущей строки и свой хеш
+// получают позже в порядке фиксации, поэтому запись не ждет других транзакций.
+type ChainRepository interface {
+	SealChain(ctx context.Context, tx pgx.Tx, key []byte, limit int) (int, error)
+}
+
+// chainQueries запросы к таблице с цепочкой хешей.
+// Запросы pending и sealed возвращают id, номер в цепочке, хешируемые поля, хеш предыдущей строки и хеш.
+type chainQueries struct {
+	// lockKey ключ advisory-блокировки, которую держит только процесс, включающий строки в цепочку
+	lockKey int64
+	// last возвращает номер и хеш последней строки цепочки
+	last string
+	// pending выбирает строки, еще не включенные в цепочку, в порядке добавления, принимает размер пачки
+	pending string
+	// seal сохраняет номер строки в цепочке, хеш предыдущей строки и хеш строки
+	seal string
+	// sealed выбирает строки цепочки после указанного номера, принимает номер и размер пачки
+	sealed string
+	// countPending считает строки, еще не включенные в цепочку
+	countPending string
+	// countLegacy считает строки, добавленные до появления цепочки, пустой для таблиц без таких строк
+	countLegacy string
+}
+
+// chainRow представляет строку цепочки: хешируемые поля и сохраненные хеши
+type chainRow struct {
+	id       int64
+	seq      int64
+	fields   []string
+	prevHash string
+	hash     string
+}
+
+// sealChain включает в цепочку пачку строк без хеша и возвращает их количество.
+// Если цепочку в это время дополняет другой экземпляр сервиса, ничего не делает.
+func sealChain(ctx context.Context, tx pgx.Tx, q chainQueries, scan func(pgx.Rows) (chainRow, error), key []byte, limit int) (int, error) {
+	var locked bool
+	if err := tx.QueryRow(ctx, `SELECT pg_try_advisory_xact_lock($1)`, q.lockKey).Scan(&locked); err != nil {
+		return 0, err
+	}
+	if !locked {
+		return 0, nil
+	}
+
+	var (
+		seq      int64
+		prevHash string
+	)
+	err := tx.QueryRow(ctx, q.last).Scan(&seq, &prevHash)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return 0, err
+	}
+
+	rows, err := tx.Query(ctx, q.pending, limit)
+	if err != nil {
+		return 0, err
+	}
+	batch, err := collectChainRows(rows, scan)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, row := range batch {
+		seq++
+		hash := hashchain.Hash(key, prevHash, row.fields...)
+		if _, err = tx.Exec(ctx, q.seal, row.id, seq, prevHash, hash); err != nil {
+			return 0, err
+		}
+		prevHash = hash
+	}
+
+	return len(batch), nil
+}
+
+// verifyChain проходит цепочку по номерам строк и возвращает первую строку, нарушающую ее.
+// Строки, еще не включенные в цепочку, только подсчитываются.
+func verifyChain(ctx context.Context, pool *pgxpool.Pool, q chainQueries, scan func(pgx.Rows) (chainRow, error), key []byte) (dto.ChainVerification, error) {
+	var (
+		result   = dto.ChainVerification{Valid: true}
+		prevHash string
+		seq      int64
+	)
+	for {
+		rows, err := pool.Query(ctx, q.sealed, seq, chainBatchSize)
+		if err != nil {
+			return result, err
+		}
+		batch, err := collectChainRows(rows, scan)
+		if err != nil {
+			return result, err
+		}
+
+		for _, row := range batch {
+			if row.seq != seq+1 || row.prevHash != prevHash || hashchain.Hash(key, row.prevHash, row.fields...) != row.hash {
+				result.Valid = false
+				result.BrokenAt = &row.id
+				return result, nil
+			}
+			prevHash = row.hash
+			seq = row.seq
+			result.Checked++
+		}
+
+		if len(batch) < chainBatchSize {
+			break
+		}
+	}
+
+	if err := pool.QueryRow(ctx, q.countPending).Scan(&result.Pending); err != nil {
+		return result, err
+	}
+	if q.countLegacy != "" {
+		if err := pool.QueryRow(ctx, q.countLegacy).Scan(&result.Legacy); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// chainTime приводит время создания строки к виду, используемому в хеше
+func chainTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return hashchain.Time(*t)
+}
+
+// collectChainRows читает строки цепочки и закрывает rows
+func collectChainRows(rows pgx.Rows, scan func(pgx.Rows) (chainRow, error)) ([]chainRow, error) {
+	defer rows.Close()
+
+	var batch []chainRow
+	for rows.Next() {
+		row, err := scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		batch = append(batch, row)
+	}
+	return batch, rows.Err()
+}
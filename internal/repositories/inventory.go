@@ -0,0 +1,230 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	e "API-Avito-shop/internal/errors"
+	"API-Avito-shop/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type InventoryRepository interface {
+	AddItem(ctx context.Context, tx pgx.Tx, purchaseID int64, item, owner string) error
+	MoveItems(ctx context.Context, tx pgx.Tx, fromUser, toUser, item string, quantity int, tradeID *int64) error
+	ListTransfers(ctx context.Context, username string, limit, offset int) ([]models.ItemTransfer, error)
+	CreateTrade(ctx context.Context, tx pgx.Tx, trade *models.Trade) (int64, error)
+	GetTrade(ctx context.Context, id int64) (*models.Trade, error)
+	GetTradeForUpdate(ctx context.Context, tx pgx.Tx, id int64) (*models.Trade, error)
+	ListTrades(ctx context.Context, filter models.TradeFilter, limit, offset int) ([]models.Trade, error)
+	ResolveTrade(ctx context.Context, tx pgx.Tx, id int64, status string, now time.Time) error
+}
+
+type InventoryRepo struct {
+	pool   *pgxpool.Pool
+	logger *slog.Logger
+}
+
+func NewInventoryRepository(pool *pgxpool.Pool, logger *slog.Logger) *InventoryRepo {
+	return &InventoryRepo{pool: pool, logger: logger}
+}
+
+const (
+	tradeColumns = `t.id, t.proposer, t.recipient, t.message, t.status, t.created_at, t.resolved_at,
+		ARRAY(SELECT item FROM trade_items WHERE trade_id = t.id AND side = 'offer' ORDER BY item),
+		ARRAY(SELECT quantity FROM trade_items WHERE trade_id = t.id AND side = 'offer' ORDER BY item),
+		ARRAY(SELECT item FROM trade_items WHERE trade_id = t.id AND side = 'request' ORDER BY item),
+		ARRAY(SELECT quantity FROM trade_items WHERE trade_id = t.id AND side = 'request' ORDER BY item)`
+	queryAddInventoryItem = `INSERT INTO inventory_items (item, owner, purchase_id) VALUES ($1, $2, $3)`
+	// Передаются экземпляры, полученные раньше остальных. Время получения берется из базы, как и при покупке.
+	queryMoveInventoryItems = `UPDATE inventory_items SET owner = $2, acquired_at = CURRENT_TIMESTAMP WHERE id IN (
+			SELECT id FROM inventory_items WHERE owner = $1 AND item = $3 ORDER BY acquired_at, id LIMIT $4 FOR UPDATE
+		)`
+	queryAddItemTransfer = `INSERT INTO item_transfers (from_username, to_username, item, quantity, trade_id)
+		VALUES ($1, $2, $3, $4, $5)`
+	queryListItemTransfers = `SELECT id, from_username, to_username, item, quantity, trade_id, created_at FROM item_transfers
+		WHERE from_username = $1 OR to_username = $1 ORDER BY id DESC LIMIT $2 OFFSET $3`
+	queryCreateTrade     = `INSERT INTO trades (proposer, recipient, message) VALUES ($1, $2, $3) RETURNING id, status, created_at`
+	queryCreateTradeItem = `INSERT INTO trade_items (trade_id, side, item, quantity)
+		SELECT $1, $2, i.item, i.quantity FROM unnest($3::text[], $4::int[]) AS i(item, quantity)`
+	queryGetTrade          = `SELECT ` + tradeColumns + ` FROM trades t WHERE t.id = $1`
+	queryGetTradeForUpdate = `SELECT ` + tradeColumns + ` FROM trades t WHERE t.id = $1 FOR UPDATE`
+	queryListTrades        = `SELECT ` + tradeColumns + ` FROM trades t
+		WHERE (t.proposer = $1 OR t.recipient = $1) AND ($2 = '' OR t.status = $2)
+		ORDER BY t.id DESC LIMIT $3 OFFSET $4`
+	queryResolveTrade = `UPDATE trades SET status = $2, resolved_at = $3 WHERE id = $1`
+)
+
+// AddItem добавляет в инвентарь владельца экземпляр купленного товара
+func (r *InventoryRepo) AddItem(ctx context.Context, tx pgx.Tx, purchaseID int64, item, owner string) error {
+	if _, err := tx.Exec(ctx, queryAddInventoryItem, item, owner, purchaseID); err != nil {
+		r.logger.Error("Failed to execute query to add inventory item", "item", item, "owner", owner, "error", err)
+		return fmt.Errorf("AddItem: %w", e.ErrFailedExecuteQuery)
+	}
+
+	return nil
+}
+
+// MoveItems передает quantity экземпляров товара от fromUser к toUser и сохраняет передачу в истории.
+// Возвращает ErrNotEnoughItems, если у отправителя меньше экземпляров, транзакцию в этом случае нужно откатить.
+func (r *InventoryRepo) MoveItems(ctx context.Context, tx pgx.Tx, fromUser, toUser, item string, quantity int, tradeID *int64) error {
+	tag, err := tx.Exec(ctx, queryMoveInventoryItems, fromUser, toUser, item, quantity)
+	if err != nil {
+		r.logger.Error("Failed to execute query to move inventory items", "from_user", fromUser, "to_user", toUser, "item", item, "error", err)
+		return fmt.Errorf("MoveItems: %w", e.ErrFailedExecuteQuery)
+	}
+	if tag.RowsAffected() != int64(quantity) {
+		r.logger.Warn("Not enough items to move", "from_user", fromUser, "item", item, "quantity", quantity, "owned", tag.RowsAffected())
+		return e.ErrNotEnoughItems
+	}
+
+	if _, err = tx.Exec(ctx, queryAddItemTransfer, fromUser, toUser, item, quantity, tradeID); err != nil {
+		r.logger.Error("Failed to execute query to add item transfer", "from_user", fromUser, "to_user", toUser, "item", item, "error", err)
+		return fmt.Errorf("MoveItems: %w", e.ErrFailedExecuteQuery)
+	}
+
+	return nil
+}
+
+// ListTransfers предоставляет переданные и полученные пользователем товары, начиная с последних
+func (r *InventoryRepo) ListTransfers(ctx context.Context, username string, limit, offset int) ([]models.ItemTransfer, error) {
+	rows, err := r.pool.Query(ctx, queryListItemTransfers, username, limit, offset)
+	if err != nil {
+		r.logger.Error("Failed to execute query to list item transfers", "username", username, "error", err)
+		return nil, fmt.Errorf("ListTransfers: %w", e.ErrFailedExecuteQuery)
+	}
+	defer rows.Close()
+
+	transfers := make([]models.ItemTransfer, 0)
+	for rows.Next() {
+		var transfer models.ItemTransfer
+		err = rows.Scan(&transfer.ID, &transfer.FromUser, &transfer.ToUser, &transfer.Item, &transfer.Quantity,
+			&transfer.TradeID, &transfer.CreatedAt)
+		if err != nil {
+			r.logger.Error("Failed to parse row", "error", err)
+			return nil, fmt.Errorf("ListTransfers: %w", e.ErrFailedExecuteQuery)
+		}
+		transfers = append(transfers, transfer)
+	}
+
+	return transfers, rows.Err()
+}
+
+// CreateTrade сохраняет предложение обмена вместе с его составом
+func (r *InventoryRepo) CreateTrade(ctx context.Context, tx pgx.Tx, trade *models.Trade) (int64, error) {
+	err := tx.QueryRow(ctx, queryCreateTrade, trade.Proposer, trade.Recipient, trade.Message).
+		Scan(&trade.ID, &trade.Status, &trade.CreatedAt)
+	if err != nil {
+		r.logger.Error("Failed to execute query to create trade", "proposer", trade.Proposer, "error", err)
+		return 0, fmt.Errorf("CreateTrade: %w", e.ErrFailedExecuteQuery)
+	}
+
+	sides := map[string][]models.ItemQuantity{models.TradeSideOffer: trade.Offer, models.TradeSideRequest: trade.Request}
+	for side, items := range sides {
+		names := make([]string, 0, len(items))
+		quantities := make([]int, 0, len(items))
+		for _, item := range items {
+			names = append(names, item.Item)
+			quantities = append(quantities, item.Quantity)
+		}
+
+		if _, err = tx.Exec(ctx, queryCreateTradeItem, trade.ID, side, names, quantities); err != nil {
+			r.logger.Error("Failed to execute query to create trade items", "id", trade.ID, "error", err)
+			return 0, fmt.Errorf("CreateTrade: %w", e.ErrFailedExecuteQuery)
+		}
+	}
+
+	return trade.ID, nil
+}
+
+// GetTrade предоставляет предложение обмена
+func (r *InventoryRepo) GetTrade(ctx context.Context, id int64) (*models.Trade, error) {
+	trade, err := scanTrade(r.pool.QueryRow(ctx, queryGetTrade, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, e.ErrTradeNotFound
+		}
+		r.logger.Error("Failed to execute query to get trade", "id", id, "error", err)
+		return nil, fmt.Errorf("GetTrade: %w", e.ErrFailedExecuteQuery)
+	}
+
+	return trade, nil
+}
+
+// GetTradeForUpdate предоставляет предложение обмена и блокирует его до конца транзакции
+func (r *InventoryRepo) GetTradeForUpdate(ctx context.Context, tx pgx.Tx, id int64) (*models.Trade, error) {
+	trade, err := scanTrade(tx.QueryRow(ctx, queryGetTradeForUpdate, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, e.ErrTradeNotFound
+		}
+		r.logger.Error("Failed to execute query to get trade", "id", id, "error", err)
+		return nil, fmt.Errorf("GetTradeForUpdate: %w", e.ErrFailedExecuteQuery)
+	}
+
+	return trade, nil
+}
+
+// ListTrades предоставляет обмены, в которых участвует пользователь, начиная с последних
+func (r *InventoryRepo) ListTrades(ctx context.Context, filter models.TradeFilter, limit, offset int) ([]models.Trade, error) {
+	rows, err := r.pool.Query(ctx, queryListTrades, filter.Username, filter.Status, limit, offset)
+	if err != nil {
+		r.logger.Error("Failed to execute query to list trades", "username", filter.Username, "error", err)
+		return nil, fmt.Errorf("ListTrades: %w", e.ErrFailedExecuteQuery)
+	}
+	defer rows.Close()
+
+	trades := make([]models.Trade, 0)
+	for rows.Next() {
+		trade, err := scanTrade(rows)
+		if err != nil {
+			r.logger.Error("Failed to parse row", "error", err)
+			return nil, fmt.Errorf("ListTrades: %w", e.ErrFailedExecuteQuery)
+		}
+		trades = append(trades, *trade)
+	}
+
+	return trades, rows.Err()
+}
+
+// ResolveTrade сохраняет итоговый статус обмена
+func (r *InventoryRepo) ResolveTrade(ctx context.Context, tx pgx.Tx, id int64, status string, now time.Time) error {
+	if _, err := tx.Exec(ctx, queryResolveTrade, id, status, now); err != nil {
+		r.logger.Error("Failed to execute query to resolve trade", "id", id, "error", err)
+		return fmt.Errorf("ResolveTrade: %w", e.ErrFailedExecuteQuery)
+	}
+
+	return nil
+}
+
+// scanTrade считывает предложение обмена вместе с составом из строки результата
+func scanTrade(row pgx.Row) (*models.Trade, error) {
+	var (
+		trade                              models.Trade
+		offerItems, requestItems           []string
+		offerQuantities, requestQuantities []int
+	)
+	err := row.Scan(&trade.ID, &trade.Proposer, &trade.Recipient, &trade.Message, &trade.Status, &trade.CreatedAt,
+		&trade.ResolvedAt, &offerItems, &offerQuantities, &requestItems, &requestQuantities)
+	if err != nil {
+		return nil, err
+	}
+
+	trade.Offer = itemQuantities(offerItems, offerQuantities)
+	trade.Request = itemQuantities(requestItems, requestQuantities)
+	return &trade, nil
+}
+
+// itemQuantities собирает состав стороны обмена из параллельных массивов товаров и количеств
+func itemQuantities(items []string, quantities []int) []models.ItemQuantity {
+	result := make([]models.ItemQuantity, 0, len(items))
+	for i := range items {
+		result = append(result, models.ItemQuantity{Item: items[i], Quantity: quantities[i]})
+	}
+	return result
+}
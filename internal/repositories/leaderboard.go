@@ -0,0 +1,168 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"API-Avito-shop/internal/dto"
+	e "API-Avito-shop/internal/errors"
+	"API-Avito-shop/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type LeaderboardRepository interface {
+	LockWatermark(ctx context.Context, tx pgx.Tx, source string) (int64, error)
+	ApplyTransactions(ctx context.Context, tx pgx.Tx, afterSeq int64, limit int) (int64, int, error)
+	ApplyPurchases(ctx context.Context, tx pgx.Tx, afterSeq int64, limit int) (int64, int, error)
+	SaveWatermark(ctx context.Context, tx pgx.Tx, source string, lastSeq int64) error
+	TopUsers(ctx context.Context, board string, from, to *time.Time, limit int) ([]dto.LeaderboardEntry, error)
+	LastRefresh(ctx context.Context) (time.Time, error)
+}
+
+type LeaderboardRepo struct {
+	pool   *pgxpool.Pool
+	logger *slog.Logger
+}
+
+func NewLeaderboardRepository(pool *pgxpool.Pool, logger *slog.Logger) *LeaderboardRepo {
+	return &LeaderboardRepo{pool: pool, logger: logger}
+}
+
+// leaderboardColumns столбцы дневных итогов, по которым строятся рейтинги
+var leaderboardColumns = map[string]string{
+	models.LeaderboardReceivers: "received",
+	models.LeaderboardGivers:    "given",
+	models.LeaderboardSpenders:  "spent",
+}
+
+const (
+	queryLockWatermark = `SELECT last_seq FROM leaderboard_watermarks WHERE source = $1 FOR UPDATE`
+	querySaveWatermark = `UPDATE leaderboard_watermarks SET last_seq = $2, updated_at = NOW() WHERE source = $1`
+	// Пачка новых переводов, включенных в цепочку хешей, добавляется к дневным итогам отправителя и получателя.
+	// Дни считаются в UTC. Возвращает номер последней учтенной строки в цепочке и размер пачки.
+	queryApplyTransactions = `WITH batch AS (
+			SELECT chain_seq, from_username, to_username, amount, (COALESCE(created_at, NOW()) AT TIME ZONE 'UTC')::date AS day
+			FROM transactions WHERE chain_seq > $1 ORDER BY chain_seq LIMIT $2
+		), totals AS (
+			SELECT to_username AS username, day, SUM(amount) AS received, 0 AS given FROM batch GROUP BY to_username, day
+			UNION ALL
+			SELECT from_username, day, 0, SUM(amount) FROM batch GROUP BY from_username, day
+		), upsert AS (
+			INSERT INTO leaderboard_daily (username, day, received, given)
+			SELECT username, day, SUM(received), SUM(given) FROM totals GROUP BY username, day
+			ON CONFLICT (username, day) DO UPDATE SET received = leaderboard_daily.received + EXCLUDED.received,
+				given = leaderboard_daily.given + EXCLUDED.given
+		)
+		SELECT COALESCE(MAX(chain_seq), $1), COUNT(*) FROM batch`
+	queryApplyPurchases = `WITH batch AS (
+			SELECT chain_seq, username, price, (COALESCE(created_at, NOW()) AT TIME ZONE 'UTC')::date AS day
+			FROM purchases WHERE chain_seq > $1 ORDER BY chain_seq LIMIT $2
+		), upsert AS (
+			INSERT INTO leaderboard_daily (username, day, spent)
+			SELECT username, day, SUM(price) FROM batch GROUP BY username, day
+			ON CONFLICT (username, day) DO UPDATE SET spent = leaderboard_daily.spent + EXCLUDED.spent
+		)
+		SELECT COALESCE(MAX(chain_seq), $1), COUNT(*) FROM batch`
+	// Столбец подставляется только из leaderboardColumns. Отключенные пользователи в рейтинги не попадают.
+	queryTopUsers = `SELECT d.username, COALESCE(p.display_name, ''), SUM(d.%[1]s) AS total
+		FROM leaderboard_daily d
+		JOIN users u ON u.username = d.username
+		LEFT JOIN user_profiles p ON p.username = d.username
+		WHERE u.disabled_at IS NULL AND u.role <> 'service'
+		AND ($1::date IS NULL OR d.day >= $1::date) AND ($2::date IS NULL OR d.day < $2::date)
+		GROUP BY d.username, p.display_name
+		HAVING SUM(d.%[1]s) > 0
+		ORDER BY total DESC, d.username
+		LIMIT $3`
+	queryLastLeaderboardRefresh = `SELECT MIN(updated_at) FROM leaderboard_watermarks`
+)
+
+// LockWatermark блокирует позицию обработки источника до конца транзакции и возвращает номер последней учтенной строки
+func (r *LeaderboardRepo) LockWatermark(ctx context.Context, tx pgx.Tx, source string) (int64, error) {
+	var lastSeq int64
+
+	if err := tx.QueryRow(ctx, queryLockWatermark, source).Scan(&lastSeq); err != nil {
+		r.logger.Error("Failed to lock leaderboard watermark", "source", source, "error", err)
+		return 0, fmt.Errorf("LockWatermark: %w", e.ErrFailedExecuteQuery)
+	}
+
+	return lastSeq, nil
+}
+
+// ApplyTransactions добавляет к итогам переводы с номером в цепочке больше afterSeq
+func (r *LeaderboardRepo) ApplyTransactions(ctx context.Context, tx pgx.Tx, afterSeq int64, limit int) (int64, int, error) {
+	return r.apply(ctx, tx, queryApplyTransactions, "ApplyTransactions", afterSeq, limit)
+}
+
+// ApplyPurchases добавляет к итогам покупки с номером в цепочке больше afterSeq
+func (r *LeaderboardRepo) ApplyPurchases(ctx context.Context, tx pgx.Tx, afterSeq int64, limit int) (int64, int, error) {
+	return r.apply(ctx, tx, queryApplyPurchases, "ApplyPurchases", afterSeq, limit)
+}
+
+// apply выполняет запрос добавления пачки строк к дневным итогам
+func (r *LeaderboardRepo) apply(ctx context.Context, tx pgx.Tx, query, op string, afterSeq int64, limit int) (int64, int, error) {
+	var (
+		lastSeq int64
+		count   int
+	)
+
+	if err := tx.QueryRow(ctx, query, afterSeq, limit).Scan(&lastSeq, &count); err != nil {
+		r.logger.Error("Failed to apply rows to leaderboard", "op", op, "after_seq", afterSeq, "error", err)
+		return 0, 0, fmt.Errorf("%s: %w", op, e.ErrFailedExecuteQuery)
+	}
+
+	return lastSeq, count, nil
+}
+
+// SaveWatermark сохраняет позицию обработки источника
+func (r *LeaderboardRepo) SaveWatermark(ctx context.Context, tx pgx.Tx, source string, lastSeq int64) error {
+	if _, err := tx.Exec(ctx, querySaveWatermark, source, lastSeq); err != nil {
+		r.logger.Error("Failed to save leaderboard watermark", "source", source, "error", err)
+		return fmt.Errorf("SaveWatermark: %w", e.ErrFailedExecuteQuery)
+	}
+
+	return nil
+}
+
+// TopUsers предоставляет рейтинг за период [from, to), пустые границы не ограничивают период
+func (r *LeaderboardRepo) TopUsers(ctx context.Context, board string, from, to *time.Time, limit int) ([]dto.LeaderboardEntry, error) {
+	column, ok := leaderboardColumns[board]
+	if !ok {
+		return nil, fmt.Errorf("TopUsers: unknown leaderboard %q", board)
+	}
+
+	rows, err := r.pool.Query(ctx, fmt.Sprintf(queryTopUsers, column), from, to, limit)
+	if err != nil {
+		r.logger.Error("Failed to execute query to get leaderboard", "board", board, "error", err)
+		return nil, fmt.Errorf("TopUsers: %w", e.ErrFailedExecuteQuery)
+	}
+	defer rows.Close()
+
+	entries := make([]dto.LeaderboardEntry, 0, limit)
+	for rows.Next() {
+		entry := dto.LeaderboardEntry{Rank: len(entries) + 1}
+		if err = rows.Scan(&entry.UserName, &entry.DisplayName, &entry.Amount); err != nil {
+			r.logger.Error("Failed to parse row", "error", err)
+			return nil, fmt.Errorf("TopUsers: %w", e.ErrFailedExecuteQuery)
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
+// LastRefresh возвращает время последнего обновления итогов
+func (r *LeaderboardRepo) LastRefresh(ctx context.Context) (time.Time, error) {
+	var updatedAt time.Time
+
+	if err := r.pool.QueryRow(ctx, queryLastLeaderboardRefresh).Scan(&updatedAt); err != nil {
+		r.logger.Error("Failed to execute query to get leaderboard refresh time", "error", err)
+		return time.Time{}, fmt.Errorf("LastRefresh: %w", e.ErrFailedExecuteQuery)
+	}
+
+	return updatedAt, nil
+}
@@ -0,0 +1,103 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	e "API-Avito-shop/internal/errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Области учета неудачных попыток входа
+const (
+	LoginScopeUser = "user"
+	LoginScopeIP   = "ip"
+)
+
+type LoginAttemptRepository interface {
+	GetLockRemaining(ctx context.Context, scope, key string) (time.Duration, error)
+	RegisterFailure(ctx context.Context, tx pgx.Tx, scope, key string, window time.Duration) (int, int, error)
+	Lock(ctx context.Context, tx pgx.Tx, scope, key string, duration time.Duration) error
+	Reset(ctx context.Context, scope, key string) error
+}
+
+type LoginAttemptRepo struct {
+	pool   *pgxpool.Pool
+	logger *slog.Logger
+}
+
+func NewLoginAttemptRepository(pool *pgxpool.Pool, logger *slog.Logger) *LoginAttemptRepo {
+	return &LoginAttemptRepo{pool: pool, logger: logger}
+}
+
+const (
+	queryGetLockRemaining = `SELECT EXTRACT(EPOCH FROM locked_until - NOW())::double precision FROM login_failures WHERE scope = $1 AND key = $2 AND locked_until > NOW()`
+	queryRegisterFailure  = `INSERT INTO login_failures (scope, key, failures, updated_at) VALUES ($1, $2, 1, NOW())
+		ON CONFLICT (scope, key) DO UPDATE SET
+			failures = CASE WHEN login_failures.updated_at < NOW() - make_interval(secs => $3) THEN 1 ELSE login_failures.failures + 1 END,
+			updated_at = NOW()
+		RETURNING failures, lockouts`
+	queryLock         = `UPDATE login_failures SET failures = 0, lockouts = lockouts + 1, locked_until = NOW() + make_interval(secs => $3) WHERE scope = $1 AND key = $2`
+	queryResetFailure = `DELETE FROM login_failures WHERE scope = $1 AND key = $2`
+)
+
+// GetLockRemaining возвращает оставшееся время действующей блокировки или 0, если блокировки нет
+func (r *LoginAttemptRepo) GetLockRemaining(ctx context.Context, scope, key string) (time.Duration, error) {
+	var seconds float64
+
+	err := r.pool.QueryRow(ctx, queryGetLockRemaining, scope, key).Scan(&seconds)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, nil
+		}
+		r.logger.Error("Failed to execute query to get lock", "scope", scope, "key", key, "error", err)
+		return 0, fmt.Errorf("GetLockRemaining: %w", e.ErrFailedExecuteQuery)
+	}
+
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+// RegisterFailure увеличивает счетчик неудачных попыток, сбрасывая его, если последняя попытка была раньше окна.
+// Возвращает текущее количество неудач и количество предыдущих блокировок.
+func (r *LoginAttemptRepo) RegisterFailure(ctx context.Context, tx pgx.Tx, scope, key string, window time.Duration) (int, int, error) {
+	var failures, lockouts int
+
+	r.logger.Info("Executing query", "query", queryRegisterFailure, "scope", scope, "key", key)
+	err := tx.QueryRow(ctx, queryRegisterFailure, scope, key, window.Seconds()).Scan(&failures, &lockouts)
+	if err != nil {
+		r.logger.Error("Failed to execute query to register login failure", "scope", scope, "key", key, "error", err)
+		return 0, 0, fmt.Errorf("RegisterFailure: %w", e.ErrFailedExecuteQuery)
+	}
+
+	return failures, lockouts, nil
+}
+
+// Lock блокирует вход на заданное время и сбрасывает счетчик неудач
+func (r *LoginAttemptRepo) Lock(ctx context.Context, tx pgx.Tx, scope, key string, duration time.Duration) error {
+	r.logger.Info("Executing query", "query", queryLock, "scope", scope, "key", key)
+
+	if _, err := tx.Exec(ctx, queryLock, scope, key, duration.Seconds()); err != nil {
+		r.logger.Error("Failed to execute query to lock login", "scope", scope, "key", key, "error", err)
+		return fmt.Errorf("Lock: %w", e.ErrFailedExecuteQuery)
+	}
+
+	r.logger.Warn("Login locked", "scope", scope, "key", key, "duration", duration)
+	return nil
+}
+
+// Reset удаляет историю неудачных попыток и снимает блокировку
+func (r *LoginAttemptRepo) Reset(ctx context.Context, scope, key string) error {
+	r.logger.Info("Executing query", "query", queryResetFailure, "scope", scope, "key", key)
+
+	if _, err := r.pool.Exec(ctx, queryResetFailure, scope, key); err != nil {
+		r.logger.Error("Failed to execute query to reset login failures", "scope", scope, "key", key, "error", err)
+		return fmt.Errorf("Reset: %w", e.ErrFailedExecuteQuery)
+	}
+
+	return nil
+}
@@ -0,0 +1,93 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	e "API-Avito-shop/internal/errors"
+	"API-Avito-shop/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// NotificationChannel канал Postgres LISTEN/NOTIFY для уведомлений пользователей
+const NotificationChannel = "shop_notifications"
+
+type NotificationRepository interface {
+	Notify(ctx context.Context, tx pgx.Tx, username, notificationType string, data any) error
+	Listen(ctx context.Context, handler func(models.Notification)) error
+}
+
+type NotificationRepo struct {
+	pool   *pgxpool.Pool
+	logger *slog.Logger
+}
+
+func NewNotificationRepository(pool *pgxpool.Pool, logger *slog.Logger) *NotificationRepo {
+	return &NotificationRepo{pool: pool, logger: logger}
+}
+
+const (
+	queryNotify = `SELECT pg_notify($1, $2)`
+	queryListen = `LISTEN ` + NotificationChannel
+)
+
+// Notify отправляет уведомление через NOTIFY, доставка происходит только после фиксации транзакции
+func (r *NotificationRepo) Notify(ctx context.Context, tx pgx.Tx, username, notificationType string, data any) error {
+	r.logger.Info("Executing query", "query", queryNotify, "username", username, "type", notificationType)
+
+	rawData, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("Notify: %w", err)
+	}
+
+	payload, err := json.Marshal(models.Notification{
+		Username:  username,
+		Type:      notificationType,
+		Data:      rawData,
+		CreatedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("Notify: %w", err)
+	}
+
+	if _, err = tx.Exec(ctx, queryNotify, NotificationChannel, string(payload)); err != nil {
+		r.logger.Error("Failed to execute query to send notification", "username", username, "error", err)
+		return fmt.Errorf("Notify: %w", e.ErrFailedExecuteQuery)
+	}
+
+	return nil
+}
+
+// Listen подписывается на канал уведомлений и передает их обработчику до ошибки или отмены контекста
+func (r *NotificationRepo) Listen(ctx context.Context, handler func(models.Notification)) error {
+	conn, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("Listen: failed to acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	if _, err = conn.Exec(ctx, queryListen); err != nil {
+		return fmt.Errorf("Listen: %w", err)
+	}
+	r.logger.Info("Listening for notifications", "channel", NotificationChannel)
+
+	for {
+		pgNotification, err := conn.Conn().WaitForNotification(ctx)
+		if err != nil {
+			return fmt.Errorf("Listen: %w", err)
+		}
+
+		var notification models.Notification
+		if err = json.Unmarshal([]byte(pgNotification.Payload), &notification); err != nil {
+			r.logger.Warn("Failed to parse notification payload", "error", err)
+			continue
+		}
+
+		handler(notification)
+	}
+}
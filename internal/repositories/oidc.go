@@ -0,0 +1,102 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	e "API-Avito-shop/internal/errors"
+	"API-Avito-shop/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type OIDCRepository interface {
+	SaveState(ctx context.Context, state, nonce, codeVerifier, username string, ttl time.Duration) error
+	ConsumeState(ctx context.Context, state string) (*models.LoginState, error)
+	GetIdentityUser(ctx context.Context, tx pgx.Tx, issuer, subject string) (string, error)
+	LinkIdentity(ctx context.Context, tx pgx.Tx, issuer, subject, username string) error
+}
+
+type OIDCRepo struct {
+	pool   *pgxpool.Pool
+	logger *slog.Logger
+}
+
+func NewOIDCRepository(pool *pgxpool.Pool, logger *slog.Logger) *OIDCRepo {
+	return &OIDCRepo{pool: pool, logger: logger}
+}
+
+const (
+	queryDeleteExpiredStates = `DELETE FROM oidc_login_states WHERE expires_at <= NOW()`
+	querySaveState           = `INSERT INTO oidc_login_states (state, nonce, code_verifier, username, expires_at) VALUES ($1, $2, $3, NULLIF($4, ''), NOW() + make_interval(secs => $5))`
+	queryConsumeState        = `DELETE FROM oidc_login_states WHERE state = $1 AND expires_at > NOW() RETURNING nonce, code_verifier, COALESCE(username, '')`
+	queryGetIdentityUser     = `SELECT username FROM user_identities WHERE issuer = $1 AND subject = $2`
+	queryLinkIdentity        = `INSERT INTO user_identities (issuer, subject, username) VALUES ($1, $2, $3)`
+)
+
+// SaveState сохраняет параметры начатого входа, попутно удаляя просроченные.
+// Непустой username означает связывание учетной записи провайдера с этим пользователем.
+func (r *OIDCRepo) SaveState(ctx context.Context, state, nonce, codeVerifier, username string, ttl time.Duration) error {
+	if _, err := r.pool.Exec(ctx, queryDeleteExpiredStates); err != nil {
+		r.logger.Error("Failed to execute query to delete expired login states", "error", err)
+		return fmt.Errorf("SaveState: %w", e.ErrFailedExecuteQuery)
+	}
+
+	if _, err := r.pool.Exec(ctx, querySaveState, state, nonce, codeVerifier, username, ttl.Seconds()); err != nil {
+		r.logger.Error("Failed to execute query to save login state", "error", err)
+		return fmt.Errorf("SaveState: %w", e.ErrFailedExecuteQuery)
+	}
+
+	return nil
+}
+
+// ConsumeState удаляет state и возвращает связанные с ним параметры входа.
+// Повторное использование или просроченный state приводят к ErrInvalidLoginState.
+func (r *OIDCRepo) ConsumeState(ctx context.Context, state string) (*models.LoginState, error) {
+	var loginState models.LoginState
+
+	err := r.pool.QueryRow(ctx, queryConsumeState, state).Scan(&loginState.Nonce, &loginState.CodeVerifier, &loginState.LinkUsername)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			r.logger.Warn("Login state not found or expired")
+			return nil, e.ErrInvalidLoginState
+		}
+		r.logger.Error("Failed to execute query to consume login state", "error", err)
+		return nil, fmt.Errorf("ConsumeState: %w", e.ErrFailedExecuteQuery)
+	}
+
+	return &loginState, nil
+}
+
+// GetIdentityUser возвращает пользователя, связанного с внешней учетной записью, или ErrInvalidUser
+func (r *OIDCRepo) GetIdentityUser(ctx context.Context, tx pgx.Tx, issuer, subject string) (string, error) {
+	var username string
+
+	err := tx.QueryRow(ctx, queryGetIdentityUser, issuer, subject).Scan(&username)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", e.ErrInvalidUser
+		}
+		r.logger.Error("Failed to execute query to get identity", "issuer", issuer, "error", err)
+		return "", fmt.Errorf("GetIdentityUser: %w", e.ErrFailedExecuteQuery)
+	}
+
+	return username, nil
+}
+
+// LinkIdentity связывает внешнюю учетную запись с пользователем
+func (r *OIDCRepo) LinkIdentity(ctx context.Context, tx pgx.Tx, issuer, subject, username string) error {
+	r.logger.Info("Executing query", "query", queryLinkIdentity, "issuer", issuer, "username", username)
+
+	if _, err := tx.Exec(ctx, queryLinkIdentity, issuer, subject, username); err != nil {
+		r.logger.Error("Failed to execute query to link identity", "issuer", issuer, "username", username, "error", err)
+		return fmt.Errorf("LinkIdentity: %w", e.ErrFailedExecuteQuery)
+	}
+
+	r.logger.Info("Identity linked", "issuer", issuer, "username", username)
+	return nil
+}
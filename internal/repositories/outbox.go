@@ -0,0 +1,137 @@
+package repositories
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	e "API-Avito-shop/internal/errors"
+	"API-Avito-shop/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type OutboxRepository interface {
+	AddEvent(ctx context.Context, tx pgx.Tx, eventType string, payload any) error
+	HasPending(ctx context.Context) (bool, error)
+	ClaimPending(ctx context.Context, limit int, lease time.Duration) ([]models.Event, error)
+	MarkPublished(ctx context.Context, tx pgx.Tx, id int64) error
+	MarkFailed(ctx context.Context, tx pgx.Tx, id int64, reason string, retryAfter time.Duration) error
+}
+
+type OutboxRepo struct {
+	pool   *pgxpool.Pool
+	logger *slog.Logger
+}
+
+func NewOutboxRepository(pool *pgxpool.Pool, logger *slog.Logger) *OutboxRepo {
+	return &OutboxRepo{pool: pool, logger: logger}
+}
+
+const (
+	queryAddEvent     = `INSERT INTO outbox_events (event_type, payload) VALUES ($1, $2)`
+	queryHasPending   = `SELECT EXISTS (SELECT 1 FROM outbox_events WHERE published_at IS NULL AND next_attempt_at <= NOW() AND (locked_until IS NULL OR locked_until <= NOW()))`
+	queryClaimPending = `UPDATE outbox_events SET locked_until = NOW() + make_interval(secs => $2)
+		WHERE id IN (SELECT id FROM outbox_events WHERE published_at IS NULL AND next_attempt_at <= NOW() AND (locked_until IS NULL OR locked_until <= NOW())
+			ORDER BY id LIMIT $1 FOR UPDATE SKIP LOCKED)
+		RETURNING id, event_type, payload, attempts, created_at`
+	queryMarkPublished = `UPDATE outbox_events SET published_at = NOW(), attempts = attempts + 1, last_error = NULL, locked_until = NULL WHERE id = $1`
+	queryMarkFailed    = `UPDATE outbox_events SET attempts = attempts + 1, last_error = $2, next_attempt_at = NOW() + make_interval(secs => $3), locked_until = NULL WHERE id = $1`
+)
+
+// AddEvent сохраняет доменное событие в outbox в рамках текущей транзакции
+func (r *OutboxRepo) AddEvent(ctx context.Context, tx pgx.Tx, eventType string, payload any) error {
+	r.logger.Info("Executing query", "query", queryAddEvent, "event_type", eventType)
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		r.logger.Error("Failed to marshal event payload", "event_type", eventType, "error", err)
+		return fmt.Errorf("AddEvent: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, queryAddEvent, eventType, data)
+	if err != nil {
+		r.logger.Error("Failed to execute query to add event", "event_type", eventType, "error", err)
+		return fmt.Errorf("AddEvent: %w", e.ErrFailedExecuteQuery)
+	}
+
+	r.logger.Info("Event added to outbox", "event_type", eventType)
+	return nil
+}
+
+// HasPending проверяет, есть ли события, готовые к отправке
+func (r *OutboxRepo) HasPending(ctx context.Context) (bool, error) {
+	var exists bool
+
+	err := r.pool.QueryRow(ctx, queryHasPending).Scan(&exists)
+	if err != nil {
+		r.logger.Error("Failed to execute query to check pending events", "error", err)
+		return false, fmt.Errorf("HasPending: %w", e.ErrFailedExecuteQuery)
+	}
+
+	return exists, nil
+}
+
+// ClaimPending захватывает пачку неопубликованных событий на срок lease.
+// Захват фиксируется сразу, поэтому публикация выполняется без открытой транзакции,
+// а события, не отмеченные до окончания аренды, снова становятся доступны.
+func (r *OutboxRepo) ClaimPending(ctx context.Context, limit int, lease time.Duration) ([]models.Event, error) {
+	var events []models.Event
+
+	r.logger.Info("Executing query", "query", queryClaimPending, "limit", limit)
+	rows, err := r.pool.Query(ctx, queryClaimPending, limit, lease.Seconds())
+	if err != nil {
+		r.logger.Error("Failed to execute query to claim pending events", "error", err)
+		return events, fmt.Errorf("ClaimPending: %w", e.ErrFailedExecuteQuery)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var event models.Event
+		if err = rows.Scan(&event.ID, &event.Type, &event.Payload, &event.Attempts, &event.CreatedAt); err != nil {
+			r.logger.Error("Failed to parse row", "error", err)
+			return events, fmt.Errorf("ClaimPending: failed to parse rows: %w", err)
+		}
+		events = append(events, event)
+	}
+	if err = rows.Err(); err != nil {
+		r.logger.Error("Error during rows iteration", "error", err)
+		return events, fmt.Errorf("ClaimPending: error during rows iteration: %w", err)
+	}
+
+	slices.SortFunc(events, func(a, b models.Event) int { return cmp.Compare(a.ID, b.ID) })
+
+	r.logger.Info("Pending events claimed", "count", len(events))
+	return events, nil
+}
+
+// MarkPublished отмечает событие как доставленное
+func (r *OutboxRepo) MarkPublished(ctx context.Context, tx pgx.Tx, id int64) error {
+	r.logger.Info("Executing query", "query", queryMarkPublished, "event_id", id)
+
+	_, err := tx.Exec(ctx, queryMarkPublished, id)
+	if err != nil {
+		r.logger.Error("Failed to execute query to mark event published", "event_id", id, "error", err)
+		return fmt.Errorf("MarkPublished: %w", e.ErrFailedExecuteQuery)
+	}
+
+	return nil
+}
+
+// MarkFailed сохраняет ошибку доставки и откладывает следующую попытку
+func (r *OutboxRepo) MarkFailed(ctx context.Context, tx pgx.Tx, id int64, reason string, retryAfter time.Duration) error {
+	r.logger.Info("Executing query", "query", queryMarkFailed, "event_id", id)
+
+	_, err := tx.Exec(ctx, queryMarkFailed, id, reason, retryAfter.Seconds())
+	if err != nil {
+		r.logger.Error("Failed to execute query to mark event failed", "event_id", id, "error", err)
+		return fmt.Errorf("MarkFailed: %w", e.ErrFailedExecuteQuery)
+	}
+
+	return nil
+}
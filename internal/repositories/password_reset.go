@@ -0,0 +1,70 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	e "API-Avito-shop/internal/errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type PasswordResetRepository interface {
+	CreateToken(ctx context.Context, tx pgx.Tx, username, tokenHash, createdBy string, ttl time.Duration) error
+	ConsumeToken(ctx context.Context, tx pgx.Tx, tokenHash string) (string, error)
+}
+
+type PasswordResetRepo struct {
+	pool   *pgxpool.Pool
+	logger *slog.Logger
+}
+
+func NewPasswordResetRepository(pool *pgxpool.Pool, logger *slog.Logger) *PasswordResetRepo {
+	return &PasswordResetRepo{pool: pool, logger: logger}
+}
+
+const (
+	queryInvalidateResetTokens = `UPDATE password_reset_tokens SET used_at = NOW() WHERE username = $1 AND used_at IS NULL`
+	queryCreateResetToken      = `INSERT INTO password_reset_tokens (username, token_hash, created_by, expires_at) VALUES ($1, $2, $3, NOW() + make_interval(secs => $4))`
+	queryConsumeResetToken     = `UPDATE password_reset_tokens SET used_at = NOW() WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW() RETURNING username`
+)
+
+// CreateToken сохраняет хеш нового токена сброса, делая недействительными ранее выданные токены пользователя
+func (r *PasswordResetRepo) CreateToken(ctx context.Context, tx pgx.Tx, username, tokenHash, createdBy string, ttl time.Duration) error {
+	r.logger.Info("Executing query", "query", queryInvalidateResetTokens, "username", username)
+	if _, err := tx.Exec(ctx, queryInvalidateResetTokens, username); err != nil {
+		r.logger.Error("Failed to execute query to invalidate reset tokens", "username", username, "error", err)
+		return fmt.Errorf("CreateToken: %w", e.ErrFailedExecuteQuery)
+	}
+
+	r.logger.Info("Executing query", "query", queryCreateResetToken, "username", username)
+	if _, err := tx.Exec(ctx, queryCreateResetToken, username, tokenHash, createdBy, ttl.Seconds()); err != nil {
+		r.logger.Error("Failed to execute query to create reset token", "username", username, "error", err)
+		return fmt.Errorf("CreateToken: %w", e.ErrFailedExecuteQuery)
+	}
+
+	return nil
+}
+
+// ConsumeToken помечает токен использованным и возвращает имя пользователя.
+// Просроченный или уже использованный токен приводит к ErrInvalidResetToken.
+func (r *PasswordResetRepo) ConsumeToken(ctx context.Context, tx pgx.Tx, tokenHash string) (string, error) {
+	var username string
+
+	r.logger.Info("Executing query", "query", queryConsumeResetToken)
+	err := tx.QueryRow(ctx, queryConsumeResetToken, tokenHash).Scan(&username)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			r.logger.Warn("Reset token not found or expired")
+			return "", e.ErrInvalidResetToken
+		}
+		r.logger.Error("Failed to execute query to consume reset token", "error", err)
+		return "", fmt.Errorf("ConsumeToken: %w", e.ErrFailedExecuteQuery)
+	}
+
+	return username, nil
+}
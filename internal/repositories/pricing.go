@@ -0,0 +1,243 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	e "API-Avito-shop/internal/errors"
+	"API-Avito-shop/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type PricingRepository interface {
+	BasePrices(ctx context.Context, tx pgx.Tx, item string, at time.Time) ([]models.Product, error)
+	ActivePromotions(ctx context.Context, tx pgx.Tx, item string, at time.Time) ([]models.Promotion, error)
+	ListPriceChanges(ctx context.Context, item string) ([]models.PriceChange, error)
+	CreatePriceChange(ctx context.Context, tx pgx.Tx, change *models.PriceChange) (int64, error)
+	DeletePriceChange(ctx context.Context, tx pgx.Tx, item string, id int64, now time.Time) (*models.PriceChange, error)
+	ListPromotions(ctx context.Context, status string, now time.Time, limit, offset int) ([]models.Promotion, error)
+	CreatePromotion(ctx context.Context, tx pgx.Tx, promotion *models.Promotion) (int64, error)
+	CancelPromotion(ctx context.Context, tx pgx.Tx, id int64, now time.Time) (*models.Promotion, error)
+}
+
+type PricingRepo struct {
+	pool   *pgxpool.Pool
+	logger *slog.Logger
+}
+
+func NewPricingRepository(pool *pgxpool.Pool, logger *slog.Logger) *PricingRepo {
+	return &PricingRepo{pool: pool, logger: logger}
+}
+
+const (
+	priceChangeColumns = `id, item, price, effective_from, created_by, created_at`
+	promotionColumns   = `id, name, item, kind, value, starts_at, ends_at, created_by, created_at, cancelled_at`
+	// Базовая цена — последняя по истории на момент $2, products.price не читается: после изменений цены
+	// она устаревает. Товар без цены на этот момент не продается. Пустой $1 выбирает весь каталог.
+	queryBasePrices = `SELECT p.item, h.price FROM products p
+		JOIN LATERAL (
+			SELECT price FROM product_prices WHERE item = p.item AND effective_from <= $2 ORDER BY effective_from DESC LIMIT 1
+		) h ON TRUE
+		WHERE ($1 = '' OR p.item = $1) ORDER BY p.item`
+	queryActivePromotions = `SELECT ` + promotionColumns + ` FROM promotions
+		WHERE cancelled_at IS NULL AND starts_at <= $2 AND ends_at > $2 AND ($1 = '' OR item IS NULL OR item = $1)
+		ORDER BY id`
+	queryListPriceChanges  = `SELECT ` + priceChangeColumns + ` FROM product_prices WHERE item = $1 ORDER BY effective_from DESC`
+	queryCreatePriceChange = `INSERT INTO product_prices (item, price, effective_from, created_by) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (item, effective_from) DO NOTHING RETURNING id`
+	// Удалить можно только изменение, которое еще не вступило в силу
+	queryDeletePriceChange = `DELETE FROM product_prices WHERE id = $1 AND item = $2 AND effective_from > $3
+		RETURNING ` + priceChangeColumns
+	queryListPromotions = `SELECT ` + promotionColumns + ` FROM promotions
+		WHERE CASE $1
+			WHEN 'scheduled' THEN cancelled_at IS NULL AND starts_at > $2
+			WHEN 'active' THEN cancelled_at IS NULL AND starts_at <= $2 AND ends_at > $2
+			WHEN 'ended' THEN cancelled_at IS NULL AND ends_at <= $2
+			WHEN 'cancelled' THEN cancelled_at IS NOT NULL
+			ELSE TRUE
+		END
+		ORDER BY starts_at DESC, id DESC LIMIT $3 OFFSET $4`
+	queryCreatePromotion = `INSERT INTO promotions (name, item, kind, value, starts_at, ends_at, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`
+	// Отменить можно только незавершенную акцию
+	queryCancelPromotion = `UPDATE promotions SET cancelled_at = $2 WHERE id = $1 AND cancelled_at IS NULL AND ends_at > $2
+		RETURNING ` + promotionColumns
+)
+
+// BasePrices предоставляет базовые цены товара или всего каталога на момент at
+func (r *PricingRepo) BasePrices(ctx context.Context, tx pgx.Tx, item string, at time.Time) ([]models.Product, error) {
+	rows, err := tx.Query(ctx, queryBasePrices, item, at)
+	if err != nil {
+		r.logger.Error("Failed to execute query to get base prices", "item", item, "error", err)
+		return nil, fmt.Errorf("BasePrices: %w", e.ErrFailedExecuteQuery)
+	}
+	defer rows.Close()
+
+	var products []models.Product
+	for rows.Next() {
+		var product models.Product
+		if err = rows.Scan(&product.Item, &product.Price); err != nil {
+			r.logger.Error("Failed to parse row", "error", err)
+			return nil, fmt.Errorf("BasePrices: %w", e.ErrFailedExecuteQuery)
+		}
+		products = append(products, product)
+	}
+
+	return products, rows.Err()
+}
+
+// ActivePromotions предоставляет акции, действующие в момент at, на товар и на весь каталог.
+// Пустой item выбирает акции на все товары.
+func (r *PricingRepo) ActivePromotions(ctx context.Context, tx pgx.Tx, item string, at time.Time) ([]models.Promotion, error) {
+	rows, err := tx.Query(ctx, queryActivePromotions, item, at)
+	if err != nil {
+		r.logger.Error("Failed to execute query to get active promotions", "item", item, "error", err)
+		return nil, fmt.Errorf("ActivePromotions: %w", e.ErrFailedExecuteQuery)
+	}
+
+	promotions, err := scanPromotions(rows)
+	if err != nil {
+		r.logger.Error("Failed to parse row", "error", err)
+		return nil, fmt.Errorf("ActivePromotions: %w", e.ErrFailedExecuteQuery)
+	}
+
+	return promotions, nil
+}
+
+// ListPriceChanges предоставляет историю и запланированные изменения цены товара, начиная с последних
+func (r *PricingRepo) ListPriceChanges(ctx context.Context, item string) ([]models.PriceChange, error) {
+	rows, err := r.pool.Query(ctx, queryListPriceChanges, item)
+	if err != nil {
+		r.logger.Error("Failed to execute query to list price changes", "item", item, "error", err)
+		return nil, fmt.Errorf("ListPriceChanges: %w", e.ErrFailedExecuteQuery)
+	}
+	defer rows.Close()
+
+	changes := make([]models.PriceChange, 0)
+	for rows.Next() {
+		change, err := scanPriceChange(rows)
+		if err != nil {
+			r.logger.Error("Failed to parse row", "error", err)
+			return nil, fmt.Errorf("ListPriceChanges: %w", e.ErrFailedExecuteQuery)
+		}
+		changes = append(changes, *change)
+	}
+
+	return changes, rows.Err()
+}
+
+// CreatePriceChange сохраняет изменение цены. Возвращает ErrPriceChangeExists, если на этот момент изменение уже есть.
+func (r *PricingRepo) CreatePriceChange(ctx context.Context, tx pgx.Tx, change *models.PriceChange) (int64, error) {
+	var id int64
+
+	err := tx.QueryRow(ctx, queryCreatePriceChange, change.Item, change.Price, change.EffectiveFrom, change.CreatedBy).Scan(&id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, e.ErrPriceChangeExists
+		}
+		r.logger.Error("Failed to execute query to create price change", "item", change.Item, "error", err)
+		return 0, fmt.Errorf("CreatePriceChange: %w", e.ErrFailedExecuteQuery)
+	}
+
+	return id, nil
+}
+
+// DeletePriceChange удаляет запланированное изменение цены и возвращает его
+func (r *PricingRepo) DeletePriceChange(ctx context.Context, tx pgx.Tx, item string, id int64, now time.Time) (*models.PriceChange, error) {
+	change, err := scanPriceChange(tx.QueryRow(ctx, queryDeletePriceChange, id, item, now))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, e.ErrPriceChangeNotFound
+		}
+		r.logger.Error("Failed to execute query to delete price change", "id", id, "error", err)
+		return nil, fmt.Errorf("DeletePriceChange: %w", e.ErrFailedExecuteQuery)
+	}
+
+	return change, nil
+}
+
+// ListPromotions предоставляет акции с заданным статусом на момент now, начиная с последних
+func (r *PricingRepo) ListPromotions(ctx context.Context, status string, now time.Time, limit, offset int) ([]models.Promotion, error) {
+	rows, err := r.pool.Query(ctx, queryListPromotions, status, now, limit, offset)
+	if err != nil {
+		r.logger.Error("Failed to execute query to list promotions", "error", err)
+		return nil, fmt.Errorf("ListPromotions: %w", e.ErrFailedExecuteQuery)
+	}
+
+	promotions, err := scanPromotions(rows)
+	if err != nil {
+		r.logger.Error("Failed to parse row", "error", err)
+		return nil, fmt.Errorf("ListPromotions: %w", e.ErrFailedExecuteQuery)
+	}
+
+	return promotions, nil
+}
+
+// CreatePromotion сохраняет акцию
+func (r *PricingRepo) CreatePromotion(ctx context.Context, tx pgx.Tx, promotion *models.Promotion) (int64, error) {
+	var id int64
+
+	err := tx.QueryRow(ctx, queryCreatePromotion, promotion.Name, promotion.Item, promotion.Kind, promotion.Value,
+		promotion.StartsAt, promotion.EndsAt, promotion.CreatedBy).Scan(&id)
+	if err != nil {
+		r.logger.Error("Failed to execute query to create promotion", "name", promotion.Name, "error", err)
+		return 0, fmt.Errorf("CreatePromotion: %w", e.ErrFailedExecuteQuery)
+	}
+
+	return id, nil
+}
+
+// CancelPromotion отменяет незавершенную акцию и возвращает ее
+func (r *PricingRepo) CancelPromotion(ctx context.Context, tx pgx.Tx, id int64, now time.Time) (*models.Promotion, error) {
+	promotion, err := scanPromotion(tx.QueryRow(ctx, queryCancelPromotion, id, now))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, e.ErrPromotionNotFound
+		}
+		r.logger.Error("Failed to execute query to cancel promotion", "id", id, "error", err)
+		return nil, fmt.Errorf("CancelPromotion: %w", e.ErrFailedExecuteQuery)
+	}
+
+	return promotion, nil
+}
+
+// scanPriceChange считывает изменение цены из строки результата
+func scanPriceChange(row pgx.Row) (*models.PriceChange, error) {
+	var change models.PriceChange
+	err := row.Scan(&change.ID, &change.Item, &change.Price, &change.EffectiveFrom, &change.CreatedBy, &change.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &change, nil
+}
+
+// scanPromotion считывает акцию из строки результата
+func scanPromotion(row pgx.Row) (*models.Promotion, error) {
+	var promotion models.Promotion
+	err := row.Scan(&promotion.ID, &promotion.Name, &promotion.Item, &promotion.Kind, &promotion.Value, &promotion.StartsAt,
+		&promotion.EndsAt, &promotion.CreatedBy, &promotion.CreatedAt, &promotion.CancelledAt)
+	if err != nil {
+		return nil, err
+	}
+	return &promotion, nil
+}
+
+// scanPromotions считывает акции из результата запроса
+func scanPromotions(rows pgx.Rows) ([]models.Promotion, error) {
+	defer rows.Close()
+
+	promotions := make([]models.Promotion, 0)
+	for rows.Next() {
+		promotion, err := scanPromotion(rows)
+		if err != nil {
+			return nil, err
+		}
+		promotions = append(promotions, *promotion)
+	}
+	return promotions, rows.Err()
+}
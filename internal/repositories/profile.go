@@ -0,0 +1,157 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"API-Avito-shop/internal/dto"
+	e "API-Avito-shop/internal/errors"
+	"API-Avito-shop/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type ProfileRepository interface {
+	GetProfile(ctx context.Context, username string) (*models.Profile, error)
+	SaveProfile(ctx context.Context, profile *models.Profile) error
+	SearchDirectory(ctx context.Context, query, exclude string, limit, offset int) ([]dto.DirectoryUser, error)
+	RecentRecipients(ctx context.Context, username string, limit, offset int) ([]dto.RecentRecipient, error)
+}
+
+type ProfileRepo struct {
+	pool   *pgxpool.Pool
+	logger *slog.Logger
+}
+
+func NewProfileRepository(pool *pgxpool.Pool, logger *slog.Logger) *ProfileRepo {
+	return &ProfileRepo{pool: pool, logger: logger}
+}
+
+// constraintDisplayName — индекс уникальности отображаемых имен без учета регистра
+const constraintDisplayName = "idx_user_profiles_display_name"
+
+const (
+	// Пользователь без строки профиля получает пустой профиль, удаленные пользователи не показываются
+	queryGetProfile = `SELECT u.username, COALESCE(p.display_name, ''), COALESCE(p.department, ''), COALESCE(p.team, ''),
+		COALESCE(p.avatar_url, ''), COALESCE(p.bio, ''), p.updated_at
+		FROM users u LEFT JOIN user_profiles p ON p.username = u.username
+		WHERE u.username = $1 AND u.deleted_at IS NULL`
+	querySaveProfile = `INSERT INTO user_profiles (username, display_name, department, team, avatar_url, bio) VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (username) DO UPDATE SET display_name = EXCLUDED.display_name, department = EXCLUDED.department, team = EXCLUDED.team,
+		avatar_url = EXCLUDED.avatar_url, bio = EXCLUDED.bio, updated_at = NOW()
+		RETURNING updated_at`
+	// Совпадения по началу логина показываются первыми, затем по похожести логина или отображаемого имени.
+	// Отключенные и сервисные учетные записи в справочник не попадают.
+	querySearchDirectory = `SELECT u.username, COALESCE(p.display_name, ''), COALESCE(p.department, ''), COALESCE(p.team, ''), COALESCE(p.avatar_url, '')
+		FROM users u LEFT JOIN user_profiles p ON p.username = u.username
+		WHERE u.disabled_at IS NULL AND u.role <> 'service' AND u.username <> $3
+		AND (u.username ILIKE $2 || '%' OR p.display_name ILIKE '%' || $2 || '%' OR u.username % $1 OR p.display_name % $1)
+		ORDER BY u.username ILIKE $2 || '%' DESC,
+			GREATEST(similarity(u.username, $1), similarity(COALESCE(p.display_name, ''), $1)) DESC, u.username
+		LIMIT $4 OFFSET $5`
+	queryRecentRecipients = `SELECT t.to_username, COALESCE(p.display_name, ''), MAX(t.created_at), COUNT(*)
+		FROM transactions t
+		JOIN users u ON u.username = t.to_username
+		LEFT JOIN user_profiles p ON p.username = t.to_username
+		WHERE t.from_username = $1 AND u.disabled_at IS NULL
+		GROUP BY t.to_username, p.display_name
+		ORDER BY MAX(t.created_at) DESC NULLS LAST, t.to_username
+		LIMIT $2 OFFSET $3`
+)
+
+// GetProfile получение профиля пользователя или ErrInvalidUser, если пользователь не найден
+func (r *ProfileRepo) GetProfile(ctx context.Context, username string) (*models.Profile, error) {
+	var p models.Profile
+
+	r.logger.Info("Executing query", "query", queryGetProfile, "username", username)
+
+	err := r.pool.QueryRow(ctx, queryGetProfile, username).
+		Scan(&p.Username, &p.DisplayName, &p.Department, &p.Team, &p.AvatarURL, &p.Bio, &p.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, e.ErrInvalidUser
+		}
+		r.logger.Error("Failed to execute query to get profile", "username", username, "error", err)
+		return nil, fmt.Errorf("GetProfile: %w", e.ErrFailedExecuteQuery)
+	}
+
+	return &p, nil
+}
+
+// SaveProfile создает или заменяет профиль пользователя.
+// Возвращает ErrDisplayNameTaken, если отображаемое имя без учета регистра уже занято другим пользователем.
+func (r *ProfileRepo) SaveProfile(ctx context.Context, profile *models.Profile) error {
+	r.logger.Info("Executing query", "query", querySaveProfile, "username", profile.Username)
+
+	err := r.pool.QueryRow(ctx, querySaveProfile, profile.Username, profile.DisplayName, profile.Department,
+		profile.Team, profile.AvatarURL, profile.Bio).Scan(&profile.UpdatedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.ConstraintName == constraintDisplayName {
+			r.logger.Warn("Display name is taken", "username", profile.Username, "display_name", profile.DisplayName)
+			return e.ErrDisplayNameTaken
+		}
+		r.logger.Error("Failed to execute query to save profile", "username", profile.Username, "error", err)
+		return fmt.Errorf("SaveProfile: %w", e.ErrFailedExecuteQuery)
+	}
+
+	return nil
+}
+
+// SearchDirectory поиск коллег по логину и отображаемому имени, пользователь exclude не включается в результат
+func (r *ProfileRepo) SearchDirectory(ctx context.Context, query, exclude string, limit, offset int) ([]dto.DirectoryUser, error) {
+	r.logger.Info("Executing query", "query", querySearchDirectory, "search", query)
+
+	rows, err := r.pool.Query(ctx, querySearchDirectory, query, escapeLike(query), exclude, limit, offset)
+	if err != nil {
+		r.logger.Error("Failed to execute query to search directory", "error", err)
+		return nil, fmt.Errorf("SearchDirectory: %w", e.ErrFailedExecuteQuery)
+	}
+	defer rows.Close()
+
+	users := make([]dto.DirectoryUser, 0)
+	for rows.Next() {
+		var user dto.DirectoryUser
+		if err = rows.Scan(&user.UserName, &user.DisplayName, &user.Department, &user.Team, &user.AvatarURL); err != nil {
+			r.logger.Error("Failed to parse row", "error", err)
+			return nil, fmt.Errorf("SearchDirectory: %w", e.ErrFailedExecuteQuery)
+		}
+		users = append(users, user)
+	}
+
+	return users, rows.Err()
+}
+
+// RecentRecipients предоставляет получателей переводов пользователя, начиная с последнего
+func (r *ProfileRepo) RecentRecipients(ctx context.Context, username string, limit, offset int) ([]dto.RecentRecipient, error) {
+	r.logger.Info("Executing query", "query", queryRecentRecipients, "username", username)
+
+	rows, err := r.pool.Query(ctx, queryRecentRecipients, username, limit, offset)
+	if err != nil {
+		r.logger.Error("Failed to execute query to get recent recipients", "username", username, "error", err)
+		return nil, fmt.Errorf("RecentRecipients: %w", e.ErrFailedExecuteQuery)
+	}
+	defer rows.Close()
+
+	recipients := make([]dto.RecentRecipient, 0)
+	for rows.Next() {
+		var recipient dto.RecentRecipient
+		if err = rows.Scan(&recipient.UserName, &recipient.DisplayName, &recipient.LastSentAt, &recipient.Transfers); err != nil {
+			r.logger.Error("Failed to parse row", "error", err)
+			return nil, fmt.Errorf("RecentRecipients: %w", e.ErrFailedExecuteQuery)
+		}
+		recipients = append(recipients, recipient)
+	}
+
+	return recipients, rows.Err()
+}
+
+// escapeLike экранирует символы шаблона LIKE, чтобы строка поиска сравнивалась буквально
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
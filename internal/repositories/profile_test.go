@@ -0,0 +1,24 @@
+package repositories
+
+import "testing"
+
+func TestEscapeLike(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"alice", "alice"},
+		{"Алиса", "Алиса"},
+		// Символы шаблона сравниваются буквально, иначе "%" нашел бы всех пользователей
+		{"%", `\%`},
+		{"a_b", `a\_b`},
+		{`a\b`, `a\\b`},
+		{`\%`, `\\\%`},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := escapeLike(tt.query); got != tt.want {
+			t.Errorf("escapeLike(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
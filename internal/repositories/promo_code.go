@@ -0,0 +1,204 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	e "API-Avito-shop/internal/errors"
+	"API-Avito-shop/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type PromoCodeRepository interface {
+	GetByCodeForUpdate(ctx context.Context, tx pgx.Tx, code string) (*models.PromoCode, error)
+	CountUserRedemptions(ctx context.Context, tx pgx.Tx, codeID int64, username string) (int, error)
+	Redeem(ctx context.Context, tx pgx.Tx, redemption *models.PromoRedemption) error
+	Create(ctx context.Context, tx pgx.Tx, code *models.PromoCode) (int64, error)
+	Get(ctx context.Context, id int64) (*models.PromoCode, error)
+	List(ctx context.Context, status string, now time.Time, limit, offset int) ([]models.PromoCode, error)
+	ListRedemptions(ctx context.Context, codeID int64, limit, offset int) ([]models.PromoRedemption, error)
+	Disable(ctx context.Context, tx pgx.Tx, id int64, now time.Time) (*models.PromoCode, error)
+}
+
+type PromoCodeRepo struct {
+	pool   *pgxpool.Pool
+	logger *slog.Logger
+}
+
+func NewPromoCodeRepository(pool *pgxpool.Pool, logger *slog.Logger) *PromoCodeRepo {
+	return &PromoCodeRepo{pool: pool, logger: logger}
+}
+
+const (
+	promoCodeColumns = `id, code, description, kind, value, items, username, max_redemptions, per_user_limit,
+		redemptions, expires_at, created_by, created_at, disabled_at`
+	// Блокировка строки кода сериализует одновременные погашения, поэтому лимиты не превышаются
+	queryGetPromoCodeForUpdate    = `SELECT ` + promoCodeColumns + ` FROM promo_codes WHERE code = $1 FOR UPDATE`
+	queryCountUserPromoRedemption = `SELECT COUNT(*) FROM promo_redemptions WHERE code_id = $1 AND username = $2`
+	queryRedeemPromoCode          = `WITH redemption AS (
+			INSERT INTO promo_redemptions (code_id, username, item, price, discount) VALUES ($1, $2, $3, $4, $5)
+		)
+		UPDATE promo_codes SET redemptions = redemptions + 1 WHERE id = $1`
+	queryCreatePromoCode = `INSERT INTO promo_codes (code, description, kind, value, items, username, max_redemptions, per_user_limit, expires_at, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) ON CONFLICT (code) DO NOTHING RETURNING id, created_at`
+	queryGetPromoCode   = `SELECT ` + promoCodeColumns + ` FROM promo_codes WHERE id = $1`
+	queryListPromoCodes = `SELECT ` + promoCodeColumns + ` FROM promo_codes
+		WHERE CASE $1
+			WHEN 'disabled' THEN disabled_at IS NOT NULL
+			WHEN 'expired' THEN disabled_at IS NULL AND expires_at <= $2
+			WHEN 'exhausted' THEN disabled_at IS NULL AND (expires_at IS NULL OR expires_at > $2) AND redemptions >= max_redemptions
+			WHEN 'active' THEN disabled_at IS NULL AND (expires_at IS NULL OR expires_at > $2)
+				AND (max_redemptions IS NULL OR redemptions < max_redemptions)
+			ELSE TRUE
+		END
+		ORDER BY id DESC LIMIT $3 OFFSET $4`
+	queryListPromoRedemptions = `SELECT id, code_id, username, item, price, discount, created_at FROM promo_redemptions
+		WHERE code_id = $1 ORDER BY id DESC LIMIT $2 OFFSET $3`
+	queryDisablePromoCode = `UPDATE promo_codes SET disabled_at = $2 WHERE id = $1 AND disabled_at IS NULL RETURNING ` + promoCodeColumns
+)
+
+// GetByCodeForUpdate предоставляет промокод и блокирует его до конца транзакции покупки
+func (r *PromoCodeRepo) GetByCodeForUpdate(ctx context.Context, tx pgx.Tx, code string) (*models.PromoCode, error) {
+	promoCode, err := scanPromoCode(tx.QueryRow(ctx, queryGetPromoCodeForUpdate, code))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, e.ErrPromoCodeNotFound
+		}
+		r.logger.Error("Failed to execute query to get promo code", "error", err)
+		return nil, fmt.Errorf("GetByCodeForUpdate: %w", e.ErrFailedExecuteQuery)
+	}
+
+	return promoCode, nil
+}
+
+// CountUserRedemptions предоставляет число погашений промокода пользователем
+func (r *PromoCodeRepo) CountUserRedemptions(ctx context.Context, tx pgx.Tx, codeID int64, username string) (int, error) {
+	var count int
+
+	if err := tx.QueryRow(ctx, queryCountUserPromoRedemption, codeID, username).Scan(&count); err != nil {
+		r.logger.Error("Failed to execute query to count promo redemptions", "id", codeID, "username", username, "error", err)
+		return 0, fmt.Errorf("CountUserRedemptions: %w", e.ErrFailedExecuteQuery)
+	}
+
+	return count, nil
+}
+
+// Redeem сохраняет погашение промокода и увеличивает счетчик погашений
+func (r *PromoCodeRepo) Redeem(ctx context.Context, tx pgx.Tx, redemption *models.PromoRedemption) error {
+	_, err := tx.Exec(ctx, queryRedeemPromoCode, redemption.CodeID, redemption.Username, redemption.Item,
+		redemption.Price, redemption.Discount)
+	if err != nil {
+		r.logger.Error("Failed to execute query to redeem promo code", "id", redemption.CodeID, "username", redemption.Username, "error", err)
+		return fmt.Errorf("Redeem: %w", e.ErrFailedExecuteQuery)
+	}
+
+	return nil
+}
+
+// Create сохраняет промокод. Возвращает ErrPromoCodeExists, если код уже занят.
+func (r *PromoCodeRepo) Create(ctx context.Context, tx pgx.Tx, code *models.PromoCode) (int64, error) {
+	var id int64
+
+	err := tx.QueryRow(ctx, queryCreatePromoCode, code.Code, code.Description, code.Kind, code.Value, code.Items, code.Username,
+		code.MaxRedemptions, code.PerUserLimit, code.ExpiresAt, code.CreatedBy).Scan(&id, &code.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, e.ErrPromoCodeExists
+		}
+		r.logger.Error("Failed to execute query to create promo code", "error", err)
+		return 0, fmt.Errorf("Create: %w", e.ErrFailedExecuteQuery)
+	}
+
+	return id, nil
+}
+
+// Get предоставляет промокод по идентификатору
+func (r *PromoCodeRepo) Get(ctx context.Context, id int64) (*models.PromoCode, error) {
+	promoCode, err := scanPromoCode(r.pool.QueryRow(ctx, queryGetPromoCode, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, e.ErrPromoCodeNotFound
+		}
+		r.logger.Error("Failed to execute query to get promo code", "id", id, "error", err)
+		return nil, fmt.Errorf("Get: %w", e.ErrFailedExecuteQuery)
+	}
+
+	return promoCode, nil
+}
+
+// List предоставляет промокоды с заданным статусом на момент now, начиная с последних
+func (r *PromoCodeRepo) List(ctx context.Context, status string, now time.Time, limit, offset int) ([]models.PromoCode, error) {
+	rows, err := r.pool.Query(ctx, queryListPromoCodes, status, now, limit, offset)
+	if err != nil {
+		r.logger.Error("Failed to execute query to list promo codes", "error", err)
+		return nil, fmt.Errorf("List: %w", e.ErrFailedExecuteQuery)
+	}
+	defer rows.Close()
+
+	codes := make([]models.PromoCode, 0)
+	for rows.Next() {
+		promoCode, err := scanPromoCode(rows)
+		if err != nil {
+			r.logger.Error("Failed to parse row", "error", err)
+			return nil, fmt.Errorf("List: %w", e.ErrFailedExecuteQuery)
+		}
+		codes = append(codes, *promoCode)
+	}
+
+	return codes, rows.Err()
+}
+
+// ListRedemptions предоставляет погашения промокода, начиная с последних
+func (r *PromoCodeRepo) ListRedemptions(ctx context.Context, codeID int64, limit, offset int) ([]models.PromoRedemption, error) {
+	rows, err := r.pool.Query(ctx, queryListPromoRedemptions, codeID, limit, offset)
+	if err != nil {
+		r.logger.Error("Failed to execute query to list promo redemptions", "id", codeID, "error", err)
+		return nil, fmt.Errorf("ListRedemptions: %w", e.ErrFailedExecuteQuery)
+	}
+	defer rows.Close()
+
+	redemptions := make([]models.PromoRedemption, 0)
+	for rows.Next() {
+		var redemption models.PromoRedemption
+		err = rows.Scan(&redemption.ID, &redemption.CodeID, &redemption.Username, &redemption.Item,
+			&redemption.Price, &redemption.Discount, &redemption.CreatedAt)
+		if err != nil {
+			r.logger.Error("Failed to parse row", "error", err)
+			return nil, fmt.Errorf("ListRedemptions: %w", e.ErrFailedExecuteQuery)
+		}
+		redemptions = append(redemptions, redemption)
+	}
+
+	return redemptions, rows.Err()
+}
+
+// Disable отключает промокод и возвращает его. Погашения по коду остаются без изменений.
+func (r *PromoCodeRepo) Disable(ctx context.Context, tx pgx.Tx, id int64, now time.Time) (*models.PromoCode, error) {
+	promoCode, err := scanPromoCode(tx.QueryRow(ctx, queryDisablePromoCode, id, now))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, e.ErrPromoCodeNotFound
+		}
+		r.logger.Error("Failed to execute query to disable promo code", "id", id, "error", err)
+		return nil, fmt.Errorf("Disable: %w", e.ErrFailedExecuteQuery)
+	}
+
+	return promoCode, nil
+}
+
+// scanPromoCode считывает промокод из строки результата
+func scanPromoCode(row pgx.Row) (*models.PromoCode, error) {
+	var code models.PromoCode
+	err := row.Scan(&code.ID, &code.Code, &code.Description, &code.Kind, &code.Value, &code.Items, &code.Username,
+		&code.MaxRedemptions, &code.PerUserLimit, &code.Redemptions, &code.ExpiresAt, &code.CreatedBy, &code.CreatedAt,
+		&code.DisabledAt)
+	if err != nil {
+		return nil, err
+	}
+	return &code, nil
+}
@@ -0,0 +1,65 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	e "API-Avito-shop/internal/errors"
+	"API-Avito-shop/internal/ratelimit"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// RateLimitRepo хранит корзины токенов в Postgres, что позволяет разделять лимиты между экземплярами сервиса
+type RateLimitRepo struct {
+	pool   *pgxpool.Pool
+	logger *slog.Logger
+}
+
+func NewRateLimitRepository(pool *pgxpool.Pool, logger *slog.Logger) *RateLimitRepo {
+	return &RateLimitRepo{pool: pool, logger: logger}
+}
+
+const (
+	// Пополнение и списание токена выполняются одним атомарным UPSERT над заблокированной строкой
+	queryTakeToken = `INSERT INTO rate_limit_buckets AS b (key, tokens, allowed, updated_at) VALUES ($1, $2::double precision - 1, TRUE, NOW())
+		ON CONFLICT (key) DO UPDATE SET
+			allowed = ` + refilledTokens + ` >= 1,
+			tokens = ` + refilledTokens + ` - CASE WHEN ` + refilledTokens + ` >= 1 THEN 1 ELSE 0 END,
+			updated_at = NOW()
+		RETURNING tokens, allowed`
+	refilledTokens      = `LEAST($2::double precision, b.tokens + EXTRACT(EPOCH FROM NOW() - b.updated_at)::double precision * $3)`
+	queryCleanupBuckets = `DELETE FROM rate_limit_buckets WHERE updated_at < NOW() - make_interval(secs => $1)`
+)
+
+// Take пытается потратить токен из корзины ключа
+func (r *RateLimitRepo) Take(ctx context.Context, key string, limit ratelimit.Limit) (ratelimit.Result, error) {
+	var (
+		tokens  float64
+		allowed bool
+	)
+
+	err := r.pool.QueryRow(ctx, queryTakeToken, key, limit.Burst, limit.Rate).Scan(&tokens, &allowed)
+	if err != nil {
+		r.logger.Error("Failed to execute query to take rate limit token", "key", key, "error", err)
+		return ratelimit.Result{}, fmt.Errorf("Take: %w", e.ErrFailedExecuteQuery)
+	}
+
+	return ratelimit.NewResult(allowed, tokens, limit), nil
+}
+
+// Cleanup удаляет корзины, которые не использовались дольше idle
+func (r *RateLimitRepo) Cleanup(ctx context.Context, idle time.Duration) error {
+	r.logger.Info("Executing query", "query", queryCleanupBuckets)
+
+	tag, err := r.pool.Exec(ctx, queryCleanupBuckets, idle.Seconds())
+	if err != nil {
+		r.logger.Error("Failed to execute query to cleanup rate limit buckets", "error", err)
+		return fmt.Errorf("Cleanup: %w", e.ErrFailedExecuteQuery)
+	}
+
+	r.logger.Info("Rate limit buckets cleaned up", "deleted", tag.RowsAffected())
+	return nil
+}
@@ -0,0 +1,155 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"API-Avito-shop/internal/dto"
+	e "API-Avito-shop/internal/errors"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type ReportRepository interface {
+	EconomyTotals(ctx context.Context, from, to time.Time) (dto.EconomyTotals, dto.TransferStats, error)
+	TopItems(ctx context.Context, from, to time.Time, limit int) ([]dto.ItemSales, error)
+	DormantAccounts(ctx context.Context, from, to time.Time, limit int) (dto.DormantAccounts, error)
+	BalanceDistribution(ctx context.Context) (dto.BalanceDistribution, error)
+}
+
+type ReportRepo struct {
+	pool   *pgxpool.Pool
+	logger *slog.Logger
+}
+
+func NewReportRepository(pool *pgxpool.Pool, logger *slog.Logger) *ReportRepo {
+	return &ReportRepo{pool: pool, logger: logger}
+}
+
+const (
+	// Монеты в обороте считаются, как и распределение балансов, по действующим пользователям без сервисных учетных записей
+	queryEconomyTotals = `SELECT c.circulation, g.minted, p.spent, p.purchases, x.expired, t.transfers, t.volume, t.senders, t.receivers
+		FROM (SELECT COALESCE(SUM(balance), 0) AS circulation FROM users WHERE role = 'user' AND deleted_at IS NULL) c,
+		(SELECT COALESCE(SUM(amount), 0) AS minted FROM coin_grants WHERE created_at >= $1 AND created_at < $2) g,
+		(SELECT COALESCE(SUM(price), 0) AS spent, COUNT(*) AS purchases FROM purchases WHERE created_at >= $1 AND created_at < $2) p,
+		(SELECT COALESCE(SUM(amount), 0) AS expired FROM coin_expirations WHERE created_at >= $1 AND created_at < $2) x,
+		(SELECT COUNT(*) AS transfers, COALESCE(SUM(amount), 0) AS volume,
+			COUNT(DISTINCT from_username) AS senders, COUNT(DISTINCT to_username) AS receivers
+			FROM transactions WHERE created_at >= $1 AND created_at < $2) t`
+	queryTopItems = `SELECT item, COUNT(*), SUM(price) FROM purchases
+		WHERE created_at >= $1 AND created_at < $2
+		GROUP BY item ORDER BY COUNT(*) DESC, SUM(price) DESC, item LIMIT $3`
+	// Неактивными считаются действующие пользователи, созданные до конца периода и не совершавшие
+	// и не получавшие переводов и покупок за период. Количество и сумма считаются по всем таким учетным записям.
+	queryDormantAccounts = `WITH dormant AS (
+			SELECT u.username, u.balance,
+				GREATEST(
+					(SELECT MAX(created_at) FROM transactions WHERE from_username = u.username),
+					(SELECT MAX(created_at) FROM transactions WHERE to_username = u.username),
+					(SELECT MAX(created_at) FROM purchases WHERE username = u.username)
+				) AS last_activity
+			FROM users u
+			WHERE u.role = 'user' AND u.disabled_at IS NULL AND u.created_at < $2
+			AND NOT EXISTS (SELECT 1 FROM transactions t WHERE t.from_username = u.username AND t.created_at >= $1 AND t.created_at < $2)
+			AND NOT EXISTS (SELECT 1 FROM transactions t WHERE t.to_username = u.username AND t.created_at >= $1 AND t.created_at < $2)
+			AND NOT EXISTS (SELECT 1 FROM purchases p WHERE p.username = u.username AND p.created_at >= $1 AND p.created_at < $2)
+		)
+		SELECT (SELECT COUNT(*) FROM dormant), (SELECT COALESCE(SUM(balance), 0) FROM dormant),
+			d.username, d.balance, d.last_activity
+		FROM (SELECT 1) one
+		LEFT JOIN (SELECT * FROM dormant ORDER BY balance DESC, username LIMIT $3) d ON TRUE`
+	// Распределение считается по действующим пользователям без сервисных учетных записей
+	queryBalanceDistribution = `SELECT COUNT(*), COALESCE(MIN(balance), 0), COALESCE(MAX(balance), 0), COALESCE(AVG(balance), 0)::float8,
+		COALESCE(percentile_cont(ARRAY[0.1, 0.25, 0.5, 0.75, 0.9, 0.99]) WITHIN GROUP (ORDER BY balance), ARRAY[0, 0, 0, 0, 0, 0]::float8[])
+		FROM users WHERE role = 'user' AND deleted_at IS NULL`
+)
+
+// EconomyTotals предоставляет показатели выпуска, расходования и переводов монет за период [from, to)
+func (r *ReportRepo) EconomyTotals(ctx context.Context, from, to time.Time) (dto.EconomyTotals, dto.TransferStats, error) {
+	var (
+		totals    dto.EconomyTotals
+		transfers dto.TransferStats
+	)
+
+	err := r.pool.QueryRow(ctx, queryEconomyTotals, from, to).Scan(&totals.Circulation, &totals.Minted, &totals.SpentInShop,
+		&totals.Purchases, &totals.Expired, &transfers.Count, &transfers.Volume, &transfers.Senders, &transfers.Receivers)
+	if err != nil {
+		r.logger.Error("Failed to execute query to get economy totals", "error", err)
+		return totals, transfers, fmt.Errorf("EconomyTotals: %w", e.ErrFailedExecuteQuery)
+	}
+
+	return totals, transfers, nil
+}
+
+// TopItems предоставляет самые продаваемые товары за период
+func (r *ReportRepo) TopItems(ctx context.Context, from, to time.Time, limit int) ([]dto.ItemSales, error) {
+	rows, err := r.pool.Query(ctx, queryTopItems, from, to, limit)
+	if err != nil {
+		r.logger.Error("Failed to execute query to get top items", "error", err)
+		return nil, fmt.Errorf("TopItems: %w", e.ErrFailedExecuteQuery)
+	}
+	defer rows.Close()
+
+	items := make([]dto.ItemSales, 0, limit)
+	for rows.Next() {
+		var item dto.ItemSales
+		if err = rows.Scan(&item.Item, &item.Quantity, &item.Revenue); err != nil {
+			r.logger.Error("Failed to parse row", "error", err)
+			return nil, fmt.Errorf("TopItems: %w", e.ErrFailedExecuteQuery)
+		}
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+// DormantAccounts предоставляет количество и монеты неактивных за период учетных записей и список с наибольшим балансом
+func (r *ReportRepo) DormantAccounts(ctx context.Context, from, to time.Time, limit int) (dto.DormantAccounts, error) {
+	result := dto.DormantAccounts{Accounts: make([]dto.DormantAccount, 0, limit)}
+
+	rows, err := r.pool.Query(ctx, queryDormantAccounts, from, to, limit)
+	if err != nil {
+		r.logger.Error("Failed to execute query to get dormant accounts", "error", err)
+		return result, fmt.Errorf("DormantAccounts: %w", e.ErrFailedExecuteQuery)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			username     *string
+			balance      *int
+			lastActivity *time.Time
+		)
+		if err = rows.Scan(&result.Count, &result.Coins, &username, &balance, &lastActivity); err != nil {
+			r.logger.Error("Failed to parse row", "error", err)
+			return result, fmt.Errorf("DormantAccounts: %w", e.ErrFailedExecuteQuery)
+		}
+		// Без неактивных учетных записей запрос возвращает одну строку только с итогами
+		if username == nil {
+			continue
+		}
+		result.Accounts = append(result.Accounts, dto.DormantAccount{UserName: *username, Balance: *balance, LastActivityAt: lastActivity})
+	}
+
+	return result, rows.Err()
+}
+
+// BalanceDistribution предоставляет распределение текущих балансов пользователей
+func (r *ReportRepo) BalanceDistribution(ctx context.Context) (dto.BalanceDistribution, error) {
+	var (
+		result      dto.BalanceDistribution
+		percentiles []float64
+	)
+
+	err := r.pool.QueryRow(ctx, queryBalanceDistribution).Scan(&result.Accounts, &result.Min, &result.Max, &result.Mean, &percentiles)
+	if err != nil || len(percentiles) != 6 {
+		r.logger.Error("Failed to execute query to get balance distribution", "error", err)
+		return result, fmt.Errorf("BalanceDistribution: %w", e.ErrFailedExecuteQuery)
+	}
+
+	result.P10, result.P25, result.P50 = percentiles[0], percentiles[1], percentiles[2]
+	result.P75, result.P90, result.P99 = percentiles[3], percentiles[4], percentiles[5]
+	return result, nil
+}
@@ -5,6 +5,8 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"strconv"
+	"time"
 
 	"API-Avito-shop/internal/dto"
 	e "API-Avito-shop/internal/errors"
@@ -16,8 +18,12 @@ import (
 
 type ShopRepository interface {
 	GetItem(ctx context.Context, item string) (*models.Product, error)
-	AddPurchase(ctx context.Context, tx pgx.Tx, item, username string, price int) error
+	AddPurchase(ctx context.Context, tx pgx.Tx, item, username string, price int, gift *models.Gift) (int64, error)
 	GetPurchases(ctx context.Context, tx pgx.Tx, username string) ([]dto.Item, error)
+	ReceivedGifts(ctx context.Context, tx pgx.Tx, username string) ([]dto.ReceivedGift, error)
+	SentGifts(ctx context.Context, tx pgx.Tx, username string) ([]dto.SentGift, error)
+	ChainRepository
+	VerifyChain(ctx context.Context, key []byte) (dto.ChainVerification, error)
 }
 
 type ShopRepo struct {
@@ -29,18 +35,37 @@ func NewShopRepository(pool *pgxpool.Pool, logger *slog.Logger) *ShopRepo {
 	return &ShopRepo{pool: pool, logger: logger}
 }
 
+// purchasesChain запросы к цепочке хешей покупок
+var purchasesChain = chainQueries{
+	lockKey: 7_310_003,
+	last:    `SELECT chain_seq, hash FROM purchases WHERE chain_seq IS NOT NULL ORDER BY chain_seq DESC LIMIT 1`,
+	pending: `SELECT id, 0, username, item, price, created_at, gift_to, gift_message, '', ''
+		FROM purchases WHERE chain_seq IS NULL AND chained ORDER BY id LIMIT $1`,
+	seal: `UPDATE purchases SET chain_seq = $2, prev_hash = $3, hash = $4 WHERE id = $1`,
+	sealed: `SELECT id, chain_seq, username, item, price, created_at, gift_to, gift_message, prev_hash, hash
+		FROM purchases WHERE chain_seq > $1 ORDER BY chain_seq LIMIT $2`,
+	countPending: `SELECT COUNT(*) FROM purchases WHERE chain_seq IS NULL AND chained`,
+	countLegacy:  `SELECT COUNT(*) FROM purchases WHERE NOT chained`,
+}
+
 const (
-	queryGetItem      = `SELECT item, price FROM products WHERE item = $1 FOR UPDATE`
-	queryAddPurchase  = `INSERT INTO purchases (username, item, price) VALUES ($1, $2, $3)`
-	queryGetPurchases = `SELECT item, COUNT(*) AS total_purchased FROM purchases WHERE username = $1 GROUP BY item`
+	queryGetItem     = `SELECT item FROM products WHERE item = $1 FOR UPDATE`
+	queryAddPurchase = `INSERT INTO purchases (username, item, price, gift_to, gift_message) VALUES ($1, $2, $3, $4, $5) RETURNING id`
+	// Инвентарь составляют экземпляры товаров, которыми пользователь владеет сейчас
+	queryGetPurchases  = `SELECT item, COUNT(*) AS total_purchased FROM inventory_items WHERE owner = $1 GROUP BY item ORDER BY item`
+	queryReceivedGifts = `SELECT g.username, COALESCE(p.display_name, ''), g.item, COALESCE(g.gift_message, ''), g.created_at
+		FROM purchases g LEFT JOIN user_profiles p ON p.username = g.username WHERE g.gift_to = $1 ORDER BY g.id DESC`
+	querySentGifts = `SELECT g.gift_to, COALESCE(p.display_name, ''), g.item, COALESCE(g.gift_message, ''), g.created_at
+		FROM purchases g LEFT JOIN user_profiles p ON p.username = g.gift_to WHERE g.username = $1 AND g.gift_to IS NOT NULL ORDER BY g.id DESC`
 )
 
-// GetItem получение товара по названию из доступных к приобретению
+// GetItem получение товара по названию из доступных к приобретению. Цена не заполняется:
+// она определяется по истории цен.
 func (r *ShopRepo) GetItem(ctx context.Context, item string) (*models.Product, error) {
 	var product models.Product
 
 	r.logger.Info("Executing query", "query", queryGetItem, "item", item)
-	err := r.pool.QueryRow(ctx, queryGetItem, item).Scan(&product.Item, &product.Price)
+	err := r.pool.QueryRow(ctx, queryGetItem, item).Scan(&product.Item)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			r.logger.Info("Product not found", "item", item)
@@ -55,21 +80,31 @@ func (r *ShopRepo) GetItem(ctx context.Context, item string) (*models.Product, e
 	return &product, nil
 }
 
-// AddPurchase добавление совершенной покупки
-func (r *ShopRepo) AddPurchase(ctx context.Context, tx pgx.Tx, item, username string, price int) error {
+// AddPurchase добавление совершенной покупки, gift задается для покупки в подарок.
+// В цепочку хешей строка включается после фиксации транзакции. Возвращает идентификатор покупки.
+func (r *ShopRepo) AddPurchase(ctx context.Context, tx pgx.Tx, item, username string, price int, gift *models.Gift) (int64, error) {
+	var giftTo, giftMessage *string
+	if gift != nil {
+		giftTo = &gift.ToUser
+		if gift.Message != "" {
+			giftMessage = &gift.Message
+		}
+	}
+
 	r.logger.Info("Executing query", "query", queryAddPurchase, "item", item)
 
-	_, err := tx.Exec(ctx, queryAddPurchase, username, item, price)
+	var id int64
+	err := tx.QueryRow(ctx, queryAddPurchase, username, item, price, giftTo, giftMessage).Scan(&id)
 	if err != nil {
 		r.logger.Error("Failed to execute query to add purchase", "username", username, "item", item, "error", err)
-		return fmt.Errorf("AddPurchase: %w", e.ErrFailedExecuteQuery)
+		return 0, fmt.Errorf("AddPurchase: %w", e.ErrFailedExecuteQuery)
 	}
 
 	r.logger.Info("Purchase added", "username", username, "item", item)
-	return nil
+	return id, nil
 }
 
-// GetPurchases предоставляет список купленных товаров
+// GetPurchases предоставляет инвентарь пользователя: купленные, подаренные и полученные при обмене товары
 func (r *ShopRepo) GetPurchases(ctx context.Context, tx pgx.Tx, username string) ([]dto.Item, error) {
 	var purchases []dto.Item
 
@@ -97,3 +132,101 @@ func (r *ShopRepo) GetPurchases(ctx context.Context, tx pgx.Tx, username string)
 	r.logger.Info("Purchase list received")
 	return purchases, nil
 }
+
+// SealChain включает в цепочку хешей пачку покупок, добавленных после последнего вызова
+func (r *ShopRepo) SealChain(ctx context.Context, tx pgx.Tx, key []byte, limit int) (int, error) {
+	sealed, err := sealChain(ctx, tx, purchasesChain, scanPurchaseChainRow, key, limit)
+	if err != nil {
+		r.logger.Error("Failed to seal purchases chain", "error", err)
+		return 0, fmt.Errorf("SealChain: %w", e.ErrFailedExecuteQuery)
+	}
+
+	return sealed, nil
+}
+
+// VerifyChain проверяет цепочку хешей покупок
+func (r *ShopRepo) VerifyChain(ctx context.Context, key []byte) (dto.ChainVerification, error) {
+	result, err := verifyChain(ctx, r.pool, purchasesChain, scanPurchaseChainRow, key)
+	if err != nil {
+		r.logger.Error("Failed to verify purchases chain", "error", err)
+		return result, fmt.Errorf("VerifyChain: %w", e.ErrFailedExecuteQuery)
+	}
+
+	return result, nil
+}
+
+// ReceivedGifts предоставляет подарки, полученные пользователем, начиная с последних
+func (r *ShopRepo) ReceivedGifts(ctx context.Context, tx pgx.Tx, username string) ([]dto.ReceivedGift, error) {
+	var gifts []dto.ReceivedGift
+
+	rows, err := tx.Query(ctx, queryReceivedGifts, username)
+	if err != nil {
+		r.logger.Error("Failed to execute query to get received gifts", "username", username, "error", err)
+		return gifts, fmt.Errorf("ReceivedGifts: %w", e.ErrFailedExecuteQuery)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var gift dto.ReceivedGift
+		if err = rows.Scan(&gift.FromUser, &gift.FromDisplayName, &gift.Item, &gift.Message, &gift.CreatedAt); err != nil {
+			r.logger.Error("Failed to parse row", "error", err)
+			return gifts, fmt.Errorf("ReceivedGifts: %w", e.ErrFailedExecuteQuery)
+		}
+		gifts = append(gifts, gift)
+	}
+
+	return gifts, rows.Err()
+}
+
+// SentGifts предоставляет подарки, купленные пользователем для других, начиная с последних
+func (r *ShopRepo) SentGifts(ctx context.Context, tx pgx.Tx, username string) ([]dto.SentGift, error) {
+	var gifts []dto.SentGift
+
+	rows, err := tx.Query(ctx, querySentGifts, username)
+	if err != nil {
+		r.logger.Error("Failed to execute query to get sent gifts", "username", username, "error", err)
+		return gifts, fmt.Errorf("SentGifts: %w", e.ErrFailedExecuteQuery)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var gift dto.SentGift
+		if err = rows.Scan(&gift.ToUser, &gift.ToDisplayName, &gift.Item, &gift.Message, &gift.CreatedAt); err != nil {
+			r.logger.Error("Failed to parse row", "error", err)
+			return gifts, fmt.Errorf("SentGifts: %w", e.ErrFailedExecuteQuery)
+		}
+		gifts = append(gifts, gift)
+	}
+
+	return gifts, rows.Err()
+}
+
+// scanPurchaseChainRow считывает покупку как строку цепочки хешей
+func scanPurchaseChainRow(rows pgx.Rows) (chainRow, error) {
+	var (
+		row                 chainRow
+		username, item      string
+		price               int
+		createdAt           *time.Time
+		giftTo, giftMessage *string
+	)
+	if err := rows.Scan(&row.id, &row.seq, &username, &item, &price, &createdAt, &giftTo, &giftMessage, &row.prevHash, &row.hash); err != nil {
+		return row, err
+	}
+	row.fields = purchaseChainFields(username, item, price, createdAt, giftTo, giftMessage)
+	return row, nil
+}
+
+// purchaseChainFields возвращает поля покупки, покрываемые хешем.
+// Получатель и сообщение подарка добавляются только для подарков.
+func purchaseChainFields(username, item string, price int, createdAt *time.Time, giftTo, giftMessage *string) []string {
+	fields := []string{username, item, strconv.Itoa(price), chainTime(createdAt)}
+	if giftTo != nil {
+		message := ""
+		if giftMessage != nil {
+			message = *giftMessage
+		}
+		fields = append(fields, *giftTo, message)
+	}
+	return fields
+}
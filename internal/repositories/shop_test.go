@@ -0,0 +1,62 @@
+package repositories
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"API-Avito-shop/internal/utils/hashchain"
+)
+
+func TestPurchaseChainFields(t *testing.T) {
+	createdAt := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	at := hashchain.Time(createdAt)
+	bob := "bob"
+	empty := ""
+	message := "С днем рождения"
+
+	tests := []struct {
+		name        string
+		createdAt   *time.Time
+		giftTo      *string
+		giftMessage *string
+		want        []string
+	}{
+		{"purchase", &createdAt, nil, nil, []string{"alice", "cup", "20", at}},
+		{"purchase without time", nil, nil, nil, []string{"alice", "cup", "20", ""}},
+		// Сообщение без получателя не входит в хеш: это не подарок
+		{"message without recipient", &createdAt, nil, &message, []string{"alice", "cup", "20", at}},
+		{"gift", &createdAt, &bob, &message, []string{"alice", "cup", "20", at, "bob", message}},
+		{"gift without message", &createdAt, &bob, nil, []string{"alice", "cup", "20", at, "bob", ""}},
+		{"gift with empty message", &createdAt, &bob, &empty, []string{"alice", "cup", "20", at, "bob", ""}},
+		// Подарок с пустым получателем все равно отличается от обычной покупки
+		{"gift to empty name", &createdAt, &empty, nil, []string{"alice", "cup", "20", at, "", ""}},
+	}
+
+	for _, tt := range tests {
+		got := purchaseChainFields("alice", "cup", 20, tt.createdAt, tt.giftTo, tt.giftMessage)
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: purchaseChainFields() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPurchaseChainFieldsCoverGift(t *testing.T) {
+	// Подмена получателя или превращение подарка в покупку меняет хеш
+	key := []byte("0123456789abcdef0123456789abcdef")
+	createdAt := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	bob, carol := "bob", "carol"
+
+	hashes := map[string]string{
+		"purchase":      hashchain.Hash(key, "", purchaseChainFields("alice", "cup", 20, &createdAt, nil, nil)...),
+		"gift to bob":   hashchain.Hash(key, "", purchaseChainFields("alice", "cup", 20, &createdAt, &bob, nil)...),
+		"gift to carol": hashchain.Hash(key, "", purchaseChainFields("alice", "cup", 20, &createdAt, &carol, nil)...),
+	}
+	seen := make(map[string]string, len(hashes))
+	for name, hash := range hashes {
+		if other, ok := seen[hash]; ok {
+			t.Errorf("%s and %s have the same hash", name, other)
+		}
+		seen[hash] = name
+	}
+}
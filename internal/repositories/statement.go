@@ -0,0 +1,101 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	e "API-Avito-shop/internal/errors"
+	"API-Avito-shop/internal/models"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type StatementRepository interface {
+	MovementsSince(ctx context.Context, username string, from time.Time) (int, []models.Movement, error)
+}
+
+type StatementRepo struct {
+	pool   *pgxpool.Pool
+	logger *slog.Logger
+}
+
+func NewStatementRepository(pool *pgxpool.Pool, logger *slog.Logger) *StatementRepo {
+	return &StatementRepo{pool: pool, logger: logger}
+}
+
+// Текущий баланс и движения читаются одним запросом, чтобы баланс на начало периода
+// вычислялся по согласованному снимку данных
+const queryMovementsSince = `WITH movements AS (
+		SELECT 'transfer_in' AS kind, from_username AS counterparty, '' AS gift_to, amount, created_at, id FROM transactions
+		WHERE to_username = $1 AND created_at >= $2
+		UNION ALL
+		SELECT 'transfer_out', to_username, '', -amount, created_at, id FROM transactions
+		WHERE from_username = $1 AND created_at >= $2
+		UNION ALL
+		SELECT 'purchase', item, COALESCE(gift_to, ''), -price, created_at, id FROM purchases
+		WHERE username = $1 AND created_at >= $2
+		UNION ALL
+		SELECT 'grant', reason, '', amount, created_at, id FROM coin_grants
+		WHERE username = $1 AND created_at >= $2
+		UNION ALL
+		SELECT 'expiry', '', '', -amount, created_at, id FROM coin_expirations
+		WHERE username = $1 AND created_at >= $2
+	)
+	SELECT u.balance, m.kind, m.counterparty, m.gift_to, m.amount, m.created_at
+	FROM users u LEFT JOIN movements m ON TRUE
+	WHERE u.username = $1
+	ORDER BY m.created_at, m.kind, m.id`
+
+// MovementsSince возвращает текущий баланс пользователя и его движения монет начиная с from
+func (r *StatementRepo) MovementsSince(ctx context.Context, username string, from time.Time) (int, []models.Movement, error) {
+	r.logger.Info("Executing query", "query", queryMovementsSince, "username", username, "from", from)
+
+	rows, err := r.pool.Query(ctx, queryMovementsSince, username, from)
+	if err != nil {
+		r.logger.Error("Failed to execute query to get movements", "username", username, "error", err)
+		return 0, nil, fmt.Errorf("MovementsSince: %w", e.ErrFailedExecuteQuery)
+	}
+	defer rows.Close()
+
+	var (
+		balance   int
+		found     bool
+		movements []models.Movement
+	)
+	for rows.Next() {
+		var (
+			kind, counterparty *string
+			giftTo             *string
+			amount             *int
+			createdAt          *time.Time
+		)
+		if err = rows.Scan(&balance, &kind, &counterparty, &giftTo, &amount, &createdAt); err != nil {
+			r.logger.Error("Failed to parse row", "error", err)
+			return 0, nil, fmt.Errorf("MovementsSince: %w", e.ErrFailedExecuteQuery)
+		}
+		found = true
+
+		// Строка без движения означает, что за период движений не было
+		if kind == nil {
+			continue
+		}
+		movements = append(movements, models.Movement{
+			Kind:         *kind,
+			Counterparty: *counterparty,
+			GiftTo:       *giftTo,
+			Amount:       *amount,
+			CreatedAt:    *createdAt,
+		})
+	}
+	if err = rows.Err(); err != nil {
+		r.logger.Error("Error during rows iteration", "error", err)
+		return 0, nil, fmt.Errorf("MovementsSince: %w", e.ErrFailedExecuteQuery)
+	}
+	if !found {
+		return 0, nil, e.ErrInvalidUser
+	}
+
+	return balance, movements, nil
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"strconv"
+	"time"
 
 	"API-Avito-shop/internal/dto"
 	e "API-Avito-shop/internal/errors"
@@ -16,6 +18,8 @@ type TransactionRepository interface {
 	TransferCoin(ctx context.Context, tx pgx.Tx, fromUser, toUser string, coin int) error
 	ReceivedTransaction(ctx context.Context, tx pgx.Tx, username string) ([]dto.ReceivedCoin, error)
 	SentTransaction(ctx context.Context, tx pgx.Tx, username string) ([]dto.SentCoin, error)
+	ChainRepository
+	VerifyChain(ctx context.Context, key []byte) (dto.ChainVerification, error)
 }
 
 type TransactionRepo struct {
@@ -27,13 +31,29 @@ func NewTransactionRepository(pool *pgxpool.Pool, logger *slog.Logger) *Transact
 	return &TransactionRepo{pool: pool, logger: logger}
 }
 
+// transactionsChain запросы к цепочке хешей переводов
+var transactionsChain = chainQueries{
+	lockKey: 7_310_002,
+	last:    `SELECT chain_seq, hash FROM transactions WHERE chain_seq IS NOT NULL ORDER BY chain_seq DESC LIMIT 1`,
+	pending: `SELECT id, 0, from_username, to_username, amount, created_at, '', ''
+		FROM transactions WHERE chain_seq IS NULL AND chained ORDER BY id LIMIT $1`,
+	seal: `UPDATE transactions SET chain_seq = $2, prev_hash = $3, hash = $4 WHERE id = $1`,
+	sealed: `SELECT id, chain_seq, from_username, to_username, amount, created_at, prev_hash, hash
+		FROM transactions WHERE chain_seq > $1 ORDER BY chain_seq LIMIT $2`,
+	countPending: `SELECT COUNT(*) FROM transactions WHERE chain_seq IS NULL AND chained`,
+	countLegacy:  `SELECT COUNT(*) FROM transactions WHERE NOT chained`,
+}
+
 const (
-	querySaveTransaction     = `INSERT INTO transactions (from_username, to_username, amount) VALUES ($1, $2, $3)`
-	queryReceivedTransaction = `SELECT from_username, amount FROM transactions WHERE to_username = $1`
-	querySendTransaction     = `SELECT to_username, amount FROM transactions WHERE from_username = $1`
+	querySaveTransaction = `INSERT INTO transactions (from_username, to_username, amount) VALUES ($1, $2, $3)`
+	// Отображаемые имена берутся из профилей, у пользователей без профиля имя пустое
+	queryReceivedTransaction = `SELECT t.from_username, COALESCE(p.display_name, ''), t.amount
+		FROM transactions t LEFT JOIN user_profiles p ON p.username = t.from_username WHERE t.to_username = $1`
+	querySendTransaction = `SELECT t.to_username, COALESCE(p.display_name, ''), t.amount
+		FROM transactions t LEFT JOIN user_profiles p ON p.username = t.to_username WHERE t.from_username = $1`
 )
 
-// TransferCoin сохраняет данные транзакции монет
+// TransferCoin сохраняет данные транзакции монет. В цепочку хешей строка включается после фиксации транзакции.
 func (r *TransactionRepo) TransferCoin(ctx context.Context, tx pgx.Tx, fromUser, toUser string, amount int) error {
 	r.logger.Info("Executing query", "query", querySaveTransaction, "from_user", fromUser, "to_user", toUser)
 
@@ -61,7 +81,7 @@ func (r *TransactionRepo) ReceivedTransaction(ctx context.Context, tx pgx.Tx, us
 
 	for rows.Next() {
 		var transaction dto.ReceivedCoin
-		if err = rows.Scan(&transaction.FromUser, &transaction.Amount); err != nil {
+		if err = rows.Scan(&transaction.FromUser, &transaction.FromDisplayName, &transaction.Amount); err != nil {
 			r.logger.Error("Failed to parse row", "error", err)
 			return transactions, fmt.Errorf("ReceivedTransaction: failed to parse rows: %w", err)
 		}
@@ -90,7 +110,7 @@ func (r *TransactionRepo) SentTransaction(ctx context.Context, tx pgx.Tx, userna
 
 	for rows.Next() {
 		var transaction dto.SentCoin
-		if err = rows.Scan(&transaction.ToUser, &transaction.Amount); err != nil {
+		if err = rows.Scan(&transaction.ToUser, &transaction.ToDisplayName, &transaction.Amount); err != nil {
 			r.logger.Error("Failed to parse row", "error", err)
 			return transactions, fmt.Errorf("SendTransaction: failed to parse rows: %w", err)
 		}
@@ -104,3 +124,40 @@ func (r *TransactionRepo) SentTransaction(ctx context.Context, tx pgx.Tx, userna
 	r.logger.Info("Transactions received")
 	return transactions, nil
 }
+
+// SealChain включает в цепочку хешей пачку переводов, добавленных после последнего вызова
+func (r *TransactionRepo) SealChain(ctx context.Context, tx pgx.Tx, key []byte, limit int) (int, error) {
+	sealed, err := sealChain(ctx, tx, transactionsChain, scanTransactionChainRow, key, limit)
+	if err != nil {
+		r.logger.Error("Failed to seal transactions chain", "error", err)
+		return 0, fmt.Errorf("SealChain: %w", e.ErrFailedExecuteQuery)
+	}
+
+	return sealed, nil
+}
+
+// VerifyChain проверяет цепочку хешей транзакций
+func (r *TransactionRepo) VerifyChain(ctx context.Context, key []byte) (dto.ChainVerification, error) {
+	result, err := verifyChain(ctx, r.pool, transactionsChain, scanTransactionChainRow, key)
+	if err != nil {
+		r.logger.Error("Failed to verify transactions chain", "error", err)
+		return result, fmt.Errorf("VerifyChain: %w", e.ErrFailedExecuteQuery)
+	}
+
+	return result, nil
+}
+
+// scanTransactionChainRow считывает перевод как строку цепочки хешей
+func scanTransactionChainRow(rows pgx.Rows) (chainRow, error) {
+	var (
+		row              chainRow
+		fromUser, toUser string
+		amount           int
+		createdAt        *time.Time
+	)
+	if err := rows.Scan(&row.id, &row.seq, &fromUser, &toUser, &amount, &createdAt, &row.prevHash, &row.hash); err != nil {
+		return row, err
+	}
+	row.fields = []string{fromUser, toUser, strconv.Itoa(amount), chainTime(createdAt)}
+	return row, nil
+}
@@ -0,0 +1,212 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	e "API-Avito-shop/internal/errors"
+	"API-Avito-shop/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type TwoFactorRepository interface {
+	GetTOTP(ctx context.Context, username string) (*models.TOTP, error)
+	SaveSecret(ctx context.Context, username, secret string) error
+	Confirm(ctx context.Context, tx pgx.Tx, username string, step int64) error
+	UseStep(ctx context.Context, username string, step int64) (bool, error)
+	Delete(ctx context.Context, tx pgx.Tx, username string) error
+	ReplaceRecoveryCodes(ctx context.Context, tx pgx.Tx, username string, codeHashes []string) error
+	UseRecoveryCode(ctx context.Context, username, codeHash string) (bool, error)
+	CreateChallenge(ctx context.Context, tokenHash, username string, ttl time.Duration) error
+	GetChallenge(ctx context.Context, tokenHash string, maxAttempts int) (string, error)
+	FailChallenge(ctx context.Context, tokenHash string) error
+	DeleteChallenge(ctx context.Context, tokenHash string) error
+}
+
+type TwoFactorRepo struct {
+	pool   *pgxpool.Pool
+	logger *slog.Logger
+}
+
+func NewTwoFactorRepository(pool *pgxpool.Pool, logger *slog.Logger) *TwoFactorRepo {
+	return &TwoFactorRepo{pool: pool, logger: logger}
+}
+
+const (
+	queryGetTOTP = `SELECT username, secret, confirmed_at, last_used_step, created_at FROM user_totp WHERE username = $1`
+	// Неподтвержденный секрет заменяется при повторной настройке, подтвержденный остается без изменений
+	querySaveTOTPSecret = `INSERT INTO user_totp (username, secret) VALUES ($1, $2)
+		ON CONFLICT (username) DO UPDATE SET secret = EXCLUDED.secret, last_used_step = NULL, created_at = NOW()
+		WHERE user_totp.confirmed_at IS NULL`
+	queryConfirmTOTP         = `UPDATE user_totp SET confirmed_at = NOW(), last_used_step = $2 WHERE username = $1 AND confirmed_at IS NULL`
+	queryUseTOTPStep         = `UPDATE user_totp SET last_used_step = $2 WHERE username = $1 AND (last_used_step IS NULL OR last_used_step < $2)`
+	queryDeleteTOTP          = `DELETE FROM user_totp WHERE username = $1`
+	queryDeleteRecoveryCodes = `DELETE FROM recovery_codes WHERE username = $1`
+	queryInsertRecoveryCodes = `INSERT INTO recovery_codes (username, code_hash) SELECT $1, unnest($2::text[])`
+	queryUseRecoveryCode     = `UPDATE recovery_codes SET used_at = NOW() WHERE username = $1 AND code_hash = $2 AND used_at IS NULL`
+	queryDeleteExpiredChalls = `DELETE FROM login_challenges WHERE expires_at <= NOW()`
+	queryCreateChallenge     = `INSERT INTO login_challenges (token_hash, username, expires_at) VALUES ($1, $2, NOW() + make_interval(secs => $3))`
+	queryGetChallenge        = `SELECT username FROM login_challenges WHERE token_hash = $1 AND expires_at > NOW() AND attempts < $2`
+	queryFailChallenge       = `UPDATE login_challenges SET attempts = attempts + 1 WHERE token_hash = $1`
+	queryDeleteChallenge     = `DELETE FROM login_challenges WHERE token_hash = $1`
+)
+
+// GetTOTP получение настройки двухфакторной аутентификации или ErrTwoFactorNotEnabled
+func (r *TwoFactorRepo) GetTOTP(ctx context.Context, username string) (*models.TOTP, error) {
+	var t models.TOTP
+
+	err := r.pool.QueryRow(ctx, queryGetTOTP, username).Scan(&t.Username, &t.Secret, &t.ConfirmedAt, &t.LastUsedStep, &t.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, e.ErrTwoFactorNotEnabled
+		}
+		r.logger.Error("Failed to execute query to get totp", "username", username, "error", err)
+		return nil, fmt.Errorf("GetTOTP: %w", e.ErrFailedExecuteQuery)
+	}
+
+	return &t, nil
+}
+
+// SaveSecret сохраняет новый неподтвержденный секрет. Возвращает ErrTwoFactorEnabled, если настройка уже подтверждена.
+func (r *TwoFactorRepo) SaveSecret(ctx context.Context, username, secret string) error {
+	r.logger.Info("Executing query", "query", querySaveTOTPSecret, "username", username)
+
+	tag, err := r.pool.Exec(ctx, querySaveTOTPSecret, username, secret)
+	if err != nil {
+		r.logger.Error("Failed to execute query to save totp secret", "username", username, "error", err)
+		return fmt.Errorf("SaveSecret: %w", e.ErrFailedExecuteQuery)
+	}
+	if tag.RowsAffected() == 0 {
+		return e.ErrTwoFactorEnabled
+	}
+
+	return nil
+}
+
+// Confirm включает двухфакторную аутентификацию, запоминая шаг использованного кода
+func (r *TwoFactorRepo) Confirm(ctx context.Context, tx pgx.Tx, username string, step int64) error {
+	r.logger.Info("Executing query", "query", queryConfirmTOTP, "username", username)
+
+	tag, err := tx.Exec(ctx, queryConfirmTOTP, username, step)
+	if err != nil {
+		r.logger.Error("Failed to execute query to confirm totp", "username", username, "error", err)
+		return fmt.Errorf("Confirm: %w", e.ErrFailedExecuteQuery)
+	}
+	if tag.RowsAffected() == 0 {
+		return e.ErrTwoFactorEnabled
+	}
+
+	return nil
+}
+
+// UseStep отмечает шаг как использованный. Возвращает false, если код этого или более позднего шага уже принимался.
+func (r *TwoFactorRepo) UseStep(ctx context.Context, username string, step int64) (bool, error) {
+	tag, err := r.pool.Exec(ctx, queryUseTOTPStep, username, step)
+	if err != nil {
+		r.logger.Error("Failed to execute query to use totp step", "username", username, "error", err)
+		return false, fmt.Errorf("UseStep: %w", e.ErrFailedExecuteQuery)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// Delete отключает двухфакторную аутентификацию и удаляет коды восстановления
+func (r *TwoFactorRepo) Delete(ctx context.Context, tx pgx.Tx, username string) error {
+	r.logger.Info("Executing query", "query", queryDeleteTOTP, "username", username)
+
+	if _, err := tx.Exec(ctx, queryDeleteTOTP, username); err != nil {
+		r.logger.Error("Failed to execute query to delete totp", "username", username, "error", err)
+		return fmt.Errorf("Delete: %w", e.ErrFailedExecuteQuery)
+	}
+	if _, err := tx.Exec(ctx, queryDeleteRecoveryCodes, username); err != nil {
+		r.logger.Error("Failed to execute query to delete recovery codes", "username", username, "error", err)
+		return fmt.Errorf("Delete: %w", e.ErrFailedExecuteQuery)
+	}
+
+	return nil
+}
+
+// ReplaceRecoveryCodes заменяет все коды восстановления пользователя новыми
+func (r *TwoFactorRepo) ReplaceRecoveryCodes(ctx context.Context, tx pgx.Tx, username string, codeHashes []string) error {
+	r.logger.Info("Executing query", "query", queryInsertRecoveryCodes, "username", username)
+
+	if _, err := tx.Exec(ctx, queryDeleteRecoveryCodes, username); err != nil {
+		r.logger.Error("Failed to execute query to delete recovery codes", "username", username, "error", err)
+		return fmt.Errorf("ReplaceRecoveryCodes: %w", e.ErrFailedExecuteQuery)
+	}
+	if _, err := tx.Exec(ctx, queryInsertRecoveryCodes, username, codeHashes); err != nil {
+		r.logger.Error("Failed to execute query to insert recovery codes", "username", username, "error", err)
+		return fmt.Errorf("ReplaceRecoveryCodes: %w", e.ErrFailedExecuteQuery)
+	}
+
+	return nil
+}
+
+// UseRecoveryCode помечает код восстановления использованным, возвращает false для неизвестного или использованного кода
+func (r *TwoFactorRepo) UseRecoveryCode(ctx context.Context, username, codeHash string) (bool, error) {
+	tag, err := r.pool.Exec(ctx, queryUseRecoveryCode, username, codeHash)
+	if err != nil {
+		r.logger.Error("Failed to execute query to use recovery code", "username", username, "error", err)
+		return false, fmt.Errorf("UseRecoveryCode: %w", e.ErrFailedExecuteQuery)
+	}
+
+	if tag.RowsAffected() > 0 {
+		r.logger.Warn("Recovery code used", "username", username)
+		return true, nil
+	}
+	return false, nil
+}
+
+// CreateChallenge сохраняет вход, ожидающий второй фактор, попутно удаляя просроченные
+func (r *TwoFactorRepo) CreateChallenge(ctx context.Context, tokenHash, username string, ttl time.Duration) error {
+	if _, err := r.pool.Exec(ctx, queryDeleteExpiredChalls); err != nil {
+		r.logger.Error("Failed to execute query to delete expired challenges", "error", err)
+		return fmt.Errorf("CreateChallenge: %w", e.ErrFailedExecuteQuery)
+	}
+
+	if _, err := r.pool.Exec(ctx, queryCreateChallenge, tokenHash, username, ttl.Seconds()); err != nil {
+		r.logger.Error("Failed to execute query to create challenge", "username", username, "error", err)
+		return fmt.Errorf("CreateChallenge: %w", e.ErrFailedExecuteQuery)
+	}
+
+	return nil
+}
+
+// GetChallenge возвращает пользователя действующего входа или ErrInvalidChallenge,
+// если вход просрочен или исчерпал попытки
+func (r *TwoFactorRepo) GetChallenge(ctx context.Context, tokenHash string, maxAttempts int) (string, error) {
+	var username string
+
+	err := r.pool.QueryRow(ctx, queryGetChallenge, tokenHash, maxAttempts).Scan(&username)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", e.ErrInvalidChallenge
+		}
+		r.logger.Error("Failed to execute query to get challenge", "error", err)
+		return "", fmt.Errorf("GetChallenge: %w", e.ErrFailedExecuteQuery)
+	}
+
+	return username, nil
+}
+
+// FailChallenge учитывает неверный код
+func (r *TwoFactorRepo) FailChallenge(ctx context.Context, tokenHash string) error {
+	if _, err := r.pool.Exec(ctx, queryFailChallenge, tokenHash); err != nil {
+		r.logger.Error("Failed to execute query to fail challenge", "error", err)
+		return fmt.Errorf("FailChallenge: %w", e.ErrFailedExecuteQuery)
+	}
+	return nil
+}
+
+// DeleteChallenge удаляет завершенный вход
+func (r *TwoFactorRepo) DeleteChallenge(ctx context.Context, tokenHash string) error {
+	if _, err := r.pool.Exec(ctx, queryDeleteChallenge, tokenHash); err != nil {
+		r.logger.Error("Failed to execute query to delete challenge", "error", err)
+		return fmt.Errorf("DeleteChallenge: %w", e.ErrFailedExecuteQuery)
+	}
+	return nil
+}
@@ -7,16 +7,30 @@ import (
 	"log/slog"
 
 	e "API-Avito-shop/internal/errors"
+	"API-Avito-shop/internal/models"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type UserRepository interface {
-	GetOrCreateUser(ctx context.Context, username, password string) (string, error)
+	GetUser(ctx context.Context, username string) (*models.User, error)
+	GetOrCreateUser(ctx context.Context, tx pgx.Tx, username, password string) (*models.User, bool, error)
+	GetUserForUpdate(ctx context.Context, tx pgx.Tx, username string) (*models.User, error)
+	UpdatePassword(ctx context.Context, tx pgx.Tx, username, password string) (int, error)
+	RehashPassword(ctx context.Context, username, oldHash, newHash string) error
+	BumpTokenVersion(ctx context.Context, tx pgx.Tx, username string) (int, error)
+	GetTokenState(ctx context.Context, username string) (int, bool, error)
+	CreateUserWithRole(ctx context.Context, tx pgx.Tx, username, password, role string) error
+	ListUsersByRole(ctx context.Context, role string) ([]models.User, error)
 	GetBalance(ctx context.Context, tx pgx.Tx, username string) (int, error)
 	SubtractCoins(ctx context.Context, tx pgx.Tx, username string, coins int) error
 	AddCoins(ctx context.Context, tx pgx.Tx, username string, coins int) error
+	GetRole(ctx context.Context, username string) (string, error)
+	SearchUsers(ctx context.Context, filter models.UserFilter, limit, offset int) ([]models.User, error)
+	DisableUser(ctx context.Context, tx pgx.Tx, username, reason string) error
+	EnableUser(ctx context.Context, tx pgx.Tx, username string) error
+	SoftDeleteUser(ctx context.Context, tx pgx.Tx, username string) error
 }
 
 type UserRepo struct {
@@ -28,36 +42,171 @@ func NewUserRepository(pool *pgxpool.Pool, logger *slog.Logger) *UserRepo {
 	return &UserRepo{pool: pool, logger: logger}
 }
 
+// userColumns столбцы, считываемые scanUser
+const userColumns = `username, password, balance, role, token_version, created_at, disabled_at, disabled_reason, deleted_at`
+
 const (
-	queryCheckUser      = `SELECT password FROM users WHERE username = $1`
-	queryCreateUser     = `INSERT INTO users (username, password) VALUES ($1, $2) ON CONFLICT (username) DO NOTHING RETURNING password;`
-	queryGetBalanceByID = `SELECT balance FROM users WHERE username = $1`
-	querySubtractCoins  = `UPDATE users SET balance = balance - $1 WHERE username = $2 AND balance >= $1 RETURNING balance`
-	queryAddCoins       = `UPDATE users SET balance = balance + $1 WHERE username = $2`
+	queryCheckUser = `SELECT ` + userColumns + ` FROM users WHERE username = $1`
+	// Стартовый баланс нового пользователя записывается в начисления и в первую партию монет тем же запросом
+	queryCreateUser = `WITH created AS (
+			INSERT INTO users (username, password) VALUES ($1, $2) ON CONFLICT (username) DO NOTHING RETURNING ` + userColumns + `
+		), granted AS (
+			INSERT INTO coin_grants (username, amount, reason) SELECT username, balance, $3::text FROM created
+		), lot AS (
+			INSERT INTO coin_lots (username, amount, remaining, issued_at)
+			SELECT username, balance, balance, CURRENT_TIMESTAMP FROM created WHERE balance > 0
+		)
+		SELECT ` + userColumns + ` FROM created`
+	queryGetUserForUpdate = `SELECT ` + userColumns + ` FROM users WHERE username = $1 FOR UPDATE`
+	queryUpdatePassword   = `UPDATE users SET password = $2, token_version = token_version + 1 WHERE username = $1 RETURNING token_version`
+	queryGetTokenState    = `SELECT token_version, disabled_at IS NOT NULL FROM users WHERE username = $1`
+	queryBumpTokenVersion = `UPDATE users SET token_version = token_version + 1 WHERE username = $1 RETURNING token_version`
+	queryRehashPassword   = `UPDATE users SET password = $3 WHERE username = $1 AND password = $2`
+	queryGetBalanceByID   = `SELECT balance FROM users WHERE username = $1`
+	querySubtractCoins    = `UPDATE users SET balance = balance - $1 WHERE username = $2 AND balance >= $1 RETURNING balance`
+	// Отключенные и удаленные пользователи не могут получать монеты
+	queryAddCoins = `UPDATE users SET balance = balance + $1 WHERE username = $2 AND disabled_at IS NULL`
+	queryGetRole  = `SELECT role FROM users WHERE username = $1`
+	// Служебные учетные записи создаются без монет, поэтому начисления и партии для них не записываются
+	queryCreateUserRole = `INSERT INTO users (username, password, role, balance) VALUES ($1, $2, $3, 0)
+		ON CONFLICT (username) DO NOTHING`
+	queryListUsersByRole = `SELECT ` + userColumns + ` FROM users WHERE role = $1 AND deleted_at IS NULL ORDER BY username`
+	// Подстрока ищется без учета регистра, символы шаблона LIKE в ней экранируются
+	querySearchUsers = `SELECT ` + userColumns + ` FROM users
+		WHERE ($1 = '' OR username ILIKE '%' || replace(replace(replace($1, '\', '\\'), '%', '\%'), '_', '\_') || '%')
+		AND ($2 = '' OR role = $2)
+		AND CASE $3
+			WHEN 'active' THEN disabled_at IS NULL
+			WHEN 'disabled' THEN disabled_at IS NOT NULL AND deleted_at IS NULL
+			WHEN 'deleted' THEN deleted_at IS NOT NULL
+			ELSE deleted_at IS NULL
+		END
+		ORDER BY username LIMIT $4 OFFSET $5`
+	// Отключение отзывает выданные токены, чтобы после включения старые токены не действовали
+	// Повторное отключение обновляет причину, но сохраняет время первого отключения
+	queryDisableUser = `UPDATE users SET disabled_at = COALESCE(disabled_at, NOW()), disabled_reason = $2, token_version = token_version + 1 WHERE username = $1`
+	queryEnableUser  = `UPDATE users SET disabled_at = NULL, disabled_reason = NULL WHERE username = $1 AND deleted_at IS NULL`
+	queryDeleteUser  = `UPDATE users SET deleted_at = NOW(), disabled_at = COALESCE(disabled_at, NOW()), token_version = token_version + 1 WHERE username = $1`
 )
 
-// GetOrCreateUser находит пользователя по имени или создает нового
-func (r *UserRepo) GetOrCreateUser(ctx context.Context, username, password string) (string, error) {
-	var hashedPassword string
+// GetUser получение пользователя по имени
+func (r *UserRepo) GetUser(ctx context.Context, username string) (*models.User, error) {
+	user, err := scanUser(r.pool.QueryRow(ctx, queryCheckUser, username))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, e.ErrInvalidUser
+		}
+		r.logger.Error("Failed to execute query to get user", "username", username, "error", err)
+		return nil, fmt.Errorf("GetUser: %w", e.ErrFailedExecuteQuery)
+	}
+
+	return user, nil
+}
 
-	err := r.pool.QueryRow(ctx, queryCreateUser, username, password).Scan(&hashedPassword)
+// GetOrCreateUser находит пользователя по имени или создает нового, сообщая был ли он создан
+func (r *UserRepo) GetOrCreateUser(ctx context.Context, tx pgx.Tx, username, password string) (*models.User, bool, error) {
+	user, err := scanUser(tx.QueryRow(ctx, queryCreateUser, username, password, models.GrantSignup))
 	if err == nil {
 		r.logger.Info("User created", "username", username)
-		return hashedPassword, nil
+		return user, true, nil
 	}
 	if !errors.Is(err, pgx.ErrNoRows) {
 		r.logger.Error("Failed to execute query create user", "username", username, "error", err)
-		return "", err
+		return nil, false, err
 	}
 
-	err = r.pool.QueryRow(ctx, queryCheckUser, username).Scan(&hashedPassword)
+	user, err = scanUser(tx.QueryRow(ctx, queryCheckUser, username))
 	if err != nil {
 		r.logger.Error("Failed to execute query to check user", "error", err)
-		return "", e.ErrFailedExecuteQuery
+		return nil, false, e.ErrFailedExecuteQuery
 	}
 
 	r.logger.Info("User found", "username", username)
-	return hashedPassword, nil
+	return user, false, nil
+}
+
+// GetUserForUpdate получение пользователя с блокировкой строки до конца транзакции
+func (r *UserRepo) GetUserForUpdate(ctx context.Context, tx pgx.Tx, username string) (*models.User, error) {
+	r.logger.Info("Executing query", "query", queryGetUserForUpdate, "username", username)
+
+	user, err := scanUser(tx.QueryRow(ctx, queryGetUserForUpdate, username))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			r.logger.Warn("User not found", "username", username)
+			return nil, e.ErrInvalidUser
+		}
+		r.logger.Error("Failed to execute query to get user", "username", username, "error", err)
+		return nil, fmt.Errorf("GetUserForUpdate: %w", e.ErrFailedExecuteQuery)
+	}
+
+	return user, nil
+}
+
+// UpdatePassword сохраняет новый хеш пароля и увеличивает версию токенов
+func (r *UserRepo) UpdatePassword(ctx context.Context, tx pgx.Tx, username, password string) (int, error) {
+	var version int
+
+	r.logger.Info("Executing query", "query", queryUpdatePassword, "username", username)
+	err := tx.QueryRow(ctx, queryUpdatePassword, username, password).Scan(&version)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, e.ErrInvalidUser
+		}
+		r.logger.Error("Failed to execute query to update password", "username", username, "error", err)
+		return 0, fmt.Errorf("UpdatePassword: %w", e.ErrFailedExecuteQuery)
+	}
+
+	r.logger.Info("Password updated", "username", username)
+	return version, nil
+}
+
+// RehashPassword заменяет хеш пароля без отзыва токенов.
+// Хеш заменяется, только если он не изменился с момента проверки пароля.
+func (r *UserRepo) RehashPassword(ctx context.Context, username, oldHash, newHash string) error {
+	r.logger.Info("Executing query", "query", queryRehashPassword, "username", username)
+
+	if _, err := r.pool.Exec(ctx, queryRehashPassword, username, oldHash, newHash); err != nil {
+		r.logger.Error("Failed to execute query to rehash password", "username", username, "error", err)
+		return fmt.Errorf("RehashPassword: %w", e.ErrFailedExecuteQuery)
+	}
+
+	return nil
+}
+
+// BumpTokenVersion отзывает все выданные пользователю токены
+func (r *UserRepo) BumpTokenVersion(ctx context.Context, tx pgx.Tx, username string) (int, error) {
+	var version int
+
+	r.logger.Info("Executing query", "query", queryBumpTokenVersion, "username", username)
+	err := tx.QueryRow(ctx, queryBumpTokenVersion, username).Scan(&version)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, e.ErrInvalidUser
+		}
+		r.logger.Error("Failed to execute query to bump token version", "username", username, "error", err)
+		return 0, fmt.Errorf("BumpTokenVersion: %w", e.ErrFailedExecuteQuery)
+	}
+
+	return version, nil
+}
+
+// GetTokenState получение текущей версии токенов пользователя и признака отключения учетной записи
+func (r *UserRepo) GetTokenState(ctx context.Context, username string) (int, bool, error) {
+	var (
+		version  int
+		disabled bool
+	)
+
+	err := r.pool.QueryRow(ctx, queryGetTokenState, username).Scan(&version, &disabled)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, false, e.ErrInvalidUser
+		}
+		r.logger.Error("Failed to execute query to get token state", "username", username, "error", err)
+		return 0, false, fmt.Errorf("GetTokenState: %w", e.ErrFailedExecuteQuery)
+	}
+
+	return version, disabled, nil
 }
 
 // GetBalance получение баланса пользователя по его id
@@ -93,16 +242,150 @@ func (r *UserRepo) SubtractCoins(ctx context.Context, tx pgx.Tx, username string
 	return nil
 }
 
-// AddCoins обновление баланса после получения транзакции
+// AddCoins обновление баланса после получения транзакции.
+// Возвращает ErrInvalidUser, если получатель не существует или отключен.
 func (r *UserRepo) AddCoins(ctx context.Context, tx pgx.Tx, username string, coins int) error {
 	r.logger.Info("Executing query", "query", queryAddCoins, "username", username)
 
-	_, err := tx.Exec(ctx, queryAddCoins, coins, username)
+	tag, err := tx.Exec(ctx, queryAddCoins, coins, username)
 	if err != nil {
 		r.logger.Error("Failed to add coins from balance", "username", username, "error", err)
 		return fmt.Errorf("AddCoins: %w", e.ErrFailedExecuteQuery)
 	}
+	if tag.RowsAffected() == 0 {
+		r.logger.Warn("Recipient not found or disabled", "username", username)
+		return e.ErrInvalidUser
+	}
 
 	r.logger.Info("Balance updated", "username", username)
 	return nil
 }
+
+// GetRole получение роли пользователя
+func (r *UserRepo) GetRole(ctx context.Context, username string) (string, error) {
+	var role string
+
+	r.logger.Info("Executing query", "query", queryGetRole, "username", username)
+	err := r.pool.QueryRow(ctx, queryGetRole, username).Scan(&role)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			r.logger.Warn("User not found", "username", username)
+			return "", e.ErrInvalidUser
+		}
+		r.logger.Error("Failed to execute query to get user role", "username", username, "error", err)
+		return "", fmt.Errorf("GetRole: %w", e.ErrFailedExecuteQuery)
+	}
+
+	return role, nil
+}
+
+// CreateUserWithRole создает служебную учетную запись с заданной ролью и нулевым балансом,
+// возвращая ErrUserExists, если имя занято
+func (r *UserRepo) CreateUserWithRole(ctx context.Context, tx pgx.Tx, username, password, role string) error {
+	r.logger.Info("Executing query", "query", queryCreateUserRole, "username", username, "role", role)
+
+	tag, err := tx.Exec(ctx, queryCreateUserRole, username, password, role)
+	if err != nil {
+		r.logger.Error("Failed to execute query to create user", "username", username, "error", err)
+		return fmt.Errorf("CreateUserWithRole: %w", e.ErrFailedExecuteQuery)
+	}
+	if tag.RowsAffected() == 0 {
+		return e.ErrUserExists
+	}
+
+	r.logger.Info("User created", "username", username, "role", role)
+	return nil
+}
+
+// ListUsersByRole получение пользователей с заданной ролью
+func (r *UserRepo) ListUsersByRole(ctx context.Context, role string) ([]models.User, error) {
+	rows, err := r.pool.Query(ctx, queryListUsersByRole, role)
+	if err != nil {
+		r.logger.Error("Failed to execute query to list users", "role", role, "error", err)
+		return nil, fmt.Errorf("ListUsersByRole: %w", e.ErrFailedExecuteQuery)
+	}
+	defer rows.Close()
+
+	var users []models.User
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			r.logger.Error("Failed to scan user", "error", err)
+			return nil, fmt.Errorf("ListUsersByRole: %w", e.ErrFailedExecuteQuery)
+		}
+		users = append(users, *user)
+	}
+
+	return users, rows.Err()
+}
+
+// SearchUsers поиск пользователей по подстроке имени, роли и статусу
+func (r *UserRepo) SearchUsers(ctx context.Context, filter models.UserFilter, limit, offset int) ([]models.User, error) {
+	r.logger.Info("Executing query", "query", querySearchUsers, "search", filter.Query, "role", filter.Role, "status", filter.Status)
+
+	rows, err := r.pool.Query(ctx, querySearchUsers, filter.Query, filter.Role, filter.Status, limit, offset)
+	if err != nil {
+		r.logger.Error("Failed to execute query to search users", "error", err)
+		return nil, fmt.Errorf("SearchUsers: %w", e.ErrFailedExecuteQuery)
+	}
+	defer rows.Close()
+
+	var users []models.User
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			r.logger.Error("Failed to scan user", "error", err)
+			return nil, fmt.Errorf("SearchUsers: %w", e.ErrFailedExecuteQuery)
+		}
+		users = append(users, *user)
+	}
+
+	return users, rows.Err()
+}
+
+// DisableUser отключает учетную запись и отзывает выданные токены. Время отключения сохраняется с первого раза.
+func (r *UserRepo) DisableUser(ctx context.Context, tx pgx.Tx, username, reason string) error {
+	r.logger.Info("Executing query", "query", queryDisableUser, "username", username)
+
+	if _, err := tx.Exec(ctx, queryDisableUser, username, reason); err != nil {
+		r.logger.Error("Failed to execute query to disable user", "username", username, "error", err)
+		return fmt.Errorf("DisableUser: %w", e.ErrFailedExecuteQuery)
+	}
+
+	return nil
+}
+
+// EnableUser снова разрешает вход отключенной учетной записи, удаленные учетные записи не включаются
+func (r *UserRepo) EnableUser(ctx context.Context, tx pgx.Tx, username string) error {
+	r.logger.Info("Executing query", "query", queryEnableUser, "username", username)
+
+	if _, err := tx.Exec(ctx, queryEnableUser, username); err != nil {
+		r.logger.Error("Failed to execute query to enable user", "username", username, "error", err)
+		return fmt.Errorf("EnableUser: %w", e.ErrFailedExecuteQuery)
+	}
+
+	return nil
+}
+
+// SoftDeleteUser помечает учетную запись удаленной. Строка сохраняется, потому что на нее ссылается история переводов.
+func (r *UserRepo) SoftDeleteUser(ctx context.Context, tx pgx.Tx, username string) error {
+	r.logger.Info("Executing query", "query", queryDeleteUser, "username", username)
+
+	if _, err := tx.Exec(ctx, queryDeleteUser, username); err != nil {
+		r.logger.Error("Failed to execute query to delete user", "username", username, "error", err)
+		return fmt.Errorf("SoftDeleteUser: %w", e.ErrFailedExecuteQuery)
+	}
+
+	return nil
+}
+
+// scanUser считывает пользователя из строки результата
+func scanUser(row pgx.Row) (*models.User, error) {
+	var user models.User
+	err := row.Scan(&user.UserName, &user.Password, &user.Balance, &user.Role, &user.TokenVersion,
+		&user.CreatedAt, &user.DisabledAt, &user.DisabledReason, &user.DeletedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
@@ -0,0 +1,333 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	e "API-Avito-shop/internal/errors"
+	"API-Avito-shop/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type WebhookRepository interface {
+	CreateSubscription(ctx context.Context, sub *models.WebhookSubscription) error
+	GetSubscription(ctx context.Context, id int64) (*models.WebhookSubscription, error)
+	ListSubscriptions(ctx context.Context, owner string) ([]models.WebhookSubscription, error)
+	DeleteSubscription(ctx context.Context, id int64) error
+	DeactivateOwnerSubscriptions(ctx context.Context, tx pgx.Tx, owner string) error
+	ActiveSubscriptions(ctx context.Context, tx pgx.Tx, eventType string) ([]models.WebhookSubscription, error)
+	EnqueueDelivery(ctx context.Context, tx pgx.Tx, subscriptionID int64, event models.Event, payload []byte) error
+	HasDueDeliveries(ctx context.Context) (bool, error)
+	ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookDelivery, error)
+	RecordAttempt(ctx context.Context, tx pgx.Tx, delivery *models.WebhookDelivery, attempt *models.WebhookAttempt, retryAfter time.Duration) error
+	ListDeliveries(ctx context.Context, subscriptionID int64, limit, offset int) ([]models.WebhookDelivery, error)
+	ListAttempts(ctx context.Context, deliveryIDs []int64) ([]models.WebhookAttempt, error)
+	RetryDelivery(ctx context.Context, subscriptionID, deliveryID int64) error
+}
+
+type WebhookRepo struct {
+	pool   *pgxpool.Pool
+	logger *slog.Logger
+}
+
+func NewWebhookRepository(pool *pgxpool.Pool, logger *slog.Logger) *WebhookRepo {
+	return &WebhookRepo{pool: pool, logger: logger}
+}
+
+const (
+	queryCreateSubscription  = `INSERT INTO webhook_subscriptions (owner_username, url, secret, event_types, is_global) VALUES ($1, $2, $3, $4, $5) RETURNING id, active, created_at`
+	queryGetSubscription     = `SELECT id, owner_username, url, secret, event_types, is_global, active, created_at FROM webhook_subscriptions WHERE id = $1`
+	queryListSubscriptions   = `SELECT id, owner_username, url, secret, event_types, is_global, active, created_at FROM webhook_subscriptions WHERE owner_username = $1 ORDER BY id`
+	queryDeleteSubscription  = `DELETE FROM webhook_subscriptions WHERE id = $1`
+	queryDeactivateOwner     = `UPDATE webhook_subscriptions SET active = FALSE WHERE owner_username = $1 AND active`
+	queryActiveSubscriptions = `SELECT id, owner_username, url, secret, event_types, is_global, active, created_at FROM webhook_subscriptions WHERE active AND $1 = ANY(event_types)`
+	queryEnqueueDelivery     = `INSERT INTO webhook_deliveries (subscription_id, event_id, event_type, payload) VALUES ($1, $2, $3, $4) ON CONFLICT (subscription_id, event_id) DO NOTHING`
+	queryHasDueDeliveries    = `SELECT EXISTS (SELECT 1 FROM webhook_deliveries WHERE status = 'pending' AND next_attempt_at <= NOW() AND (locked_until IS NULL OR locked_until <= NOW()))`
+	queryClaimDueDeliveries  = `UPDATE webhook_deliveries d SET locked_until = NOW() + make_interval(secs => $2)
+		FROM webhook_subscriptions s
+		WHERE s.id = d.subscription_id AND d.id IN (
+			SELECT due.id FROM webhook_deliveries due JOIN webhook_subscriptions sub ON sub.id = due.subscription_id
+			WHERE due.status = 'pending' AND due.next_attempt_at <= NOW() AND (due.locked_until IS NULL OR due.locked_until <= NOW()) AND sub.active
+			ORDER BY due.next_attempt_at, due.id LIMIT $1 FOR UPDATE OF due SKIP LOCKED)
+		RETURNING d.id, d.subscription_id, d.event_id, d.event_type, d.payload, d.status, d.attempts, d.next_attempt_at, d.created_at, s.url, s.secret`
+	queryInsertAttempt  = `INSERT INTO webhook_delivery_attempts (delivery_id, attempt, status_code, error, duration_ms) VALUES ($1, $2, $3, $4, $5)`
+	queryUpdateDelivery = `UPDATE webhook_deliveries SET status = $2, attempts = $3, last_status_code = $4, last_error = $5,
+		next_attempt_at = NOW() + make_interval(secs => $6), delivered_at = CASE WHEN $2 = 'delivered' THEN NOW() END, locked_until = NULL WHERE id = $1`
+	queryListDeliveries = `SELECT id, subscription_id, event_id, event_type, payload, status, attempts, next_attempt_at, last_status_code, last_error, delivered_at, created_at
+		FROM webhook_deliveries WHERE subscription_id = $1 ORDER BY id DESC LIMIT $2 OFFSET $3`
+	queryListAttempts  = `SELECT id, delivery_id, attempt, status_code, error, duration_ms, created_at FROM webhook_delivery_attempts WHERE delivery_id = ANY($1) ORDER BY delivery_id, id`
+	queryRetryDelivery = `UPDATE webhook_deliveries SET status = 'pending', attempts = 0, last_status_code = NULL, last_error = NULL, next_attempt_at = NOW(), locked_until = NULL
+		WHERE id = $1 AND subscription_id = $2 AND status = 'dead'`
+)
+
+// CreateSubscription сохраняет новую подписку
+func (r *WebhookRepo) CreateSubscription(ctx context.Context, sub *models.WebhookSubscription) error {
+	r.logger.Info("Executing query", "query", queryCreateSubscription, "owner", sub.Owner)
+
+	err := r.pool.QueryRow(ctx, queryCreateSubscription, sub.Owner, sub.URL, sub.Secret, sub.EventTypes, sub.Global).
+		Scan(&sub.ID, &sub.Active, &sub.CreatedAt)
+	if err != nil {
+		r.logger.Error("Failed to execute query to create subscription", "owner", sub.Owner, "error", err)
+		return fmt.Errorf("CreateSubscription: %w", e.ErrFailedExecuteQuery)
+	}
+
+	r.logger.Info("Subscription created", "owner", sub.Owner, "subscription_id", sub.ID)
+	return nil
+}
+
+// GetSubscription получение подписки по id
+func (r *WebhookRepo) GetSubscription(ctx context.Context, id int64) (*models.WebhookSubscription, error) {
+	r.logger.Info("Executing query", "query", queryGetSubscription, "subscription_id", id)
+
+	sub, err := scanSubscription(r.pool.QueryRow(ctx, queryGetSubscription, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			r.logger.Info("Subscription not found", "subscription_id", id)
+			return nil, e.ErrWebhookNotFound
+		}
+		r.logger.Error("Failed to execute query to get subscription", "subscription_id", id, "error", err)
+		return nil, fmt.Errorf("GetSubscription: %w", e.ErrFailedExecuteQuery)
+	}
+
+	return &sub, nil
+}
+
+// ListSubscriptions предоставляет список подписок пользователя
+func (r *WebhookRepo) ListSubscriptions(ctx context.Context, owner string) ([]models.WebhookSubscription, error) {
+	r.logger.Info("Executing query", "query", queryListSubscriptions, "owner", owner)
+
+	rows, err := r.pool.Query(ctx, queryListSubscriptions, owner)
+	if err != nil {
+		r.logger.Error("Failed to execute query to list subscriptions", "owner", owner, "error", err)
+		return nil, fmt.Errorf("ListSubscriptions: %w", e.ErrFailedExecuteQuery)
+	}
+
+	return r.collectSubscriptions(rows, "ListSubscriptions")
+}
+
+// DeleteSubscription удаляет подписку вместе с историей доставок
+func (r *WebhookRepo) DeleteSubscription(ctx context.Context, id int64) error {
+	r.logger.Info("Executing query", "query", queryDeleteSubscription, "subscription_id", id)
+
+	tag, err := r.pool.Exec(ctx, queryDeleteSubscription, id)
+	if err != nil {
+		r.logger.Error("Failed to execute query to delete subscription", "subscription_id", id, "error", err)
+		return fmt.Errorf("DeleteSubscription: %w", e.ErrFailedExecuteQuery)
+	}
+	if tag.RowsAffected() == 0 {
+		return e.ErrWebhookNotFound
+	}
+
+	r.logger.Info("Subscription deleted", "subscription_id", id)
+	return nil
+}
+
+// DeactivateOwnerSubscriptions отключает все подписки владельца, история доставок сохраняется
+func (r *WebhookRepo) DeactivateOwnerSubscriptions(ctx context.Context, tx pgx.Tx, owner string) error {
+	r.logger.Info("Executing query", "query", queryDeactivateOwner, "owner", owner)
+
+	if _, err := tx.Exec(ctx, queryDeactivateOwner, owner); err != nil {
+		r.logger.Error("Failed to execute query to deactivate subscriptions", "owner", owner, "error", err)
+		return fmt.Errorf("DeactivateOwnerSubscriptions: %w", e.ErrFailedExecuteQuery)
+	}
+
+	return nil
+}
+
+// ActiveSubscriptions предоставляет активные подписки на тип события
+func (r *WebhookRepo) ActiveSubscriptions(ctx context.Context, tx pgx.Tx, eventType string) ([]models.WebhookSubscription, error) {
+	r.logger.Info("Executing query", "query", queryActiveSubscriptions, "event_type", eventType)
+
+	rows, err := tx.Query(ctx, queryActiveSubscriptions, eventType)
+	if err != nil {
+		r.logger.Error("Failed to execute query to get active subscriptions", "event_type", eventType, "error", err)
+		return nil, fmt.Errorf("ActiveSubscriptions: %w", e.ErrFailedExecuteQuery)
+	}
+
+	return r.collectSubscriptions(rows, "ActiveSubscriptions")
+}
+
+// EnqueueDelivery ставит событие в очередь доставки подписчику, повторная постановка игнорируется
+func (r *WebhookRepo) EnqueueDelivery(ctx context.Context, tx pgx.Tx, subscriptionID int64, event models.Event, payload []byte) error {
+	r.logger.Info("Executing query", "query", queryEnqueueDelivery, "subscription_id", subscriptionID, "event_id", event.ID)
+
+	_, err := tx.Exec(ctx, queryEnqueueDelivery, subscriptionID, event.ID, event.Type, payload)
+	if err != nil {
+		r.logger.Error("Failed to execute query to enqueue delivery", "subscription_id", subscriptionID, "event_id", event.ID, "error", err)
+		return fmt.Errorf("EnqueueDelivery: %w", e.ErrFailedExecuteQuery)
+	}
+
+	return nil
+}
+
+// HasDueDeliveries проверяет, есть ли доставки, готовые к отправке
+func (r *WebhookRepo) HasDueDeliveries(ctx context.Context) (bool, error) {
+	var exists bool
+
+	err := r.pool.QueryRow(ctx, queryHasDueDeliveries).Scan(&exists)
+	if err != nil {
+		r.logger.Error("Failed to execute query to check due deliveries", "error", err)
+		return false, fmt.Errorf("HasDueDeliveries: %w", e.ErrFailedExecuteQuery)
+	}
+
+	return exists, nil
+}
+
+// ClaimDueDeliveries захватывает доставки, время отправки которых наступило, на срок lease.
+// Захват фиксируется сразу, поэтому отправка выполняется без открытой транзакции,
+// а доставки, не завершенные до окончания аренды, снова становятся доступны.
+func (r *WebhookRepo) ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+
+	r.logger.Info("Executing query", "query", queryClaimDueDeliveries, "limit", limit)
+	rows, err := r.pool.Query(ctx, queryClaimDueDeliveries, limit, lease.Seconds())
+	if err != nil {
+		r.logger.Error("Failed to execute query to claim due deliveries", "error", err)
+		return deliveries, fmt.Errorf("ClaimDueDeliveries: %w", e.ErrFailedExecuteQuery)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var d models.WebhookDelivery
+		if err = rows.Scan(&d.ID, &d.SubscriptionID, &d.EventID, &d.EventType, &d.Payload, &d.Status, &d.Attempts,
+			&d.NextAttemptAt, &d.CreatedAt, &d.URL, &d.Secret); err != nil {
+			r.logger.Error("Failed to parse row", "error", err)
+			return deliveries, fmt.Errorf("ClaimDueDeliveries: failed to parse rows: %w", err)
+		}
+		deliveries = append(deliveries, d)
+	}
+	if err = rows.Err(); err != nil {
+		r.logger.Error("Error during rows iteration", "error", err)
+		return deliveries, fmt.Errorf("ClaimDueDeliveries: error during rows iteration: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// RecordAttempt сохраняет результат попытки доставки, обновляет состояние доставки и снимает аренду
+func (r *WebhookRepo) RecordAttempt(ctx context.Context, tx pgx.Tx, delivery *models.WebhookDelivery, attempt *models.WebhookAttempt, retryAfter time.Duration) error {
+	r.logger.Info("Executing query", "query", queryInsertAttempt, "delivery_id", delivery.ID, "attempt", attempt.Attempt)
+
+	_, err := tx.Exec(ctx, queryInsertAttempt, delivery.ID, attempt.Attempt, attempt.StatusCode, attempt.Error, attempt.DurationMs)
+	if err != nil {
+		r.logger.Error("Failed to execute query to insert attempt", "delivery_id", delivery.ID, "error", err)
+		return fmt.Errorf("RecordAttempt: %w", e.ErrFailedExecuteQuery)
+	}
+
+	_, err = tx.Exec(ctx, queryUpdateDelivery, delivery.ID, delivery.Status, delivery.Attempts,
+		attempt.StatusCode, attempt.Error, retryAfter.Seconds())
+	if err != nil {
+		r.logger.Error("Failed to execute query to update delivery", "delivery_id", delivery.ID, "error", err)
+		return fmt.Errorf("RecordAttempt: %w", e.ErrFailedExecuteQuery)
+	}
+
+	r.logger.Info("Delivery attempt recorded", "delivery_id", delivery.ID, "status", delivery.Status)
+	return nil
+}
+
+// ListDeliveries предоставляет историю доставок по подписке
+func (r *WebhookRepo) ListDeliveries(ctx context.Context, subscriptionID int64, limit, offset int) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+
+	r.logger.Info("Executing query", "query", queryListDeliveries, "subscription_id", subscriptionID)
+	rows, err := r.pool.Query(ctx, queryListDeliveries, subscriptionID, limit, offset)
+	if err != nil {
+		r.logger.Error("Failed to execute query to list deliveries", "subscription_id", subscriptionID, "error", err)
+		return deliveries, fmt.Errorf("ListDeliveries: %w", e.ErrFailedExecuteQuery)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var d models.WebhookDelivery
+		if err = rows.Scan(&d.ID, &d.SubscriptionID, &d.EventID, &d.EventType, &d.Payload, &d.Status, &d.Attempts,
+			&d.NextAttemptAt, &d.LastStatusCode, &d.LastError, &d.DeliveredAt, &d.CreatedAt); err != nil {
+			r.logger.Error("Failed to parse row", "error", err)
+			return deliveries, fmt.Errorf("ListDeliveries: failed to parse rows: %w", err)
+		}
+		deliveries = append(deliveries, d)
+	}
+	if err = rows.Err(); err != nil {
+		r.logger.Error("Error during rows iteration", "error", err)
+		return deliveries, fmt.Errorf("ListDeliveries: error during rows iteration: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// ListAttempts предоставляет попытки для набора доставок
+func (r *WebhookRepo) ListAttempts(ctx context.Context, deliveryIDs []int64) ([]models.WebhookAttempt, error) {
+	var attempts []models.WebhookAttempt
+
+	r.logger.Info("Executing query", "query", queryListAttempts, "deliveries", len(deliveryIDs))
+	rows, err := r.pool.Query(ctx, queryListAttempts, deliveryIDs)
+	if err != nil {
+		r.logger.Error("Failed to execute query to list attempts", "error", err)
+		return attempts, fmt.Errorf("ListAttempts: %w", e.ErrFailedExecuteQuery)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var a models.WebhookAttempt
+		if err = rows.Scan(&a.ID, &a.DeliveryID, &a.Attempt, &a.StatusCode, &a.Error, &a.DurationMs, &a.CreatedAt); err != nil {
+			r.logger.Error("Failed to parse row", "error", err)
+			return attempts, fmt.Errorf("ListAttempts: failed to parse rows: %w", err)
+		}
+		attempts = append(attempts, a)
+	}
+	if err = rows.Err(); err != nil {
+		r.logger.Error("Error during rows iteration", "error", err)
+		return attempts, fmt.Errorf("ListAttempts: error during rows iteration: %w", err)
+	}
+
+	return attempts, nil
+}
+
+// RetryDelivery возвращает доставку из dead-letter в очередь отправки с новым счетчиком попыток
+func (r *WebhookRepo) RetryDelivery(ctx context.Context, subscriptionID, deliveryID int64) error {
+	r.logger.Info("Executing query", "query", queryRetryDelivery, "delivery_id", deliveryID)
+
+	tag, err := r.pool.Exec(ctx, queryRetryDelivery, deliveryID, subscriptionID)
+	if err != nil {
+		r.logger.Error("Failed to execute query to retry delivery", "delivery_id", deliveryID, "error", err)
+		return fmt.Errorf("RetryDelivery: %w", e.ErrFailedExecuteQuery)
+	}
+	if tag.RowsAffected() == 0 {
+		return e.ErrWebhookNotFound
+	}
+
+	r.logger.Info("Delivery scheduled for retry", "delivery_id", deliveryID)
+	return nil
+}
+
+// collectSubscriptions считывает подписки из результата запроса
+func (r *WebhookRepo) collectSubscriptions(rows pgx.Rows, op string) ([]models.WebhookSubscription, error) {
+	var subs []models.WebhookSubscription
+	defer rows.Close()
+
+	for rows.Next() {
+		sub, err := scanSubscription(rows)
+		if err != nil {
+			r.logger.Error("Failed to parse row", "error", err)
+			return subs, fmt.Errorf("%s: failed to parse rows: %w", op, err)
+		}
+		subs = append(subs, sub)
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.Error("Error during rows iteration", "error", err)
+		return subs, fmt.Errorf("%s: error during rows iteration: %w", op, err)
+	}
+
+	return subs, nil
+}
+
+// scanSubscription считывает подписку из строки результата
+func scanSubscription(row pgx.Row) (models.WebhookSubscription, error) {
+	var sub models.WebhookSubscription
+	err := row.Scan(&sub.ID, &sub.Owner, &sub.URL, &sub.Secret, &sub.EventTypes, &sub.Global, &sub.Active, &sub.CreatedAt)
+	return sub, err
+}
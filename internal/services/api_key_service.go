@@ -0,0 +1,252 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"API-Avito-shop/internal/dto"
+	e "API-Avito-shop/internal/errors"
+	"API-Avito-shop/internal/models"
+	r "API-Avito-shop/internal/repositories"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// apiKeyPrefix отличает API-ключи магазина от других секретов, например при поиске утечек в репозиториях
+const apiKeyPrefix = "msk_"
+
+type APIKeyService interface {
+	CreateServiceAccount(ctx context.Context, admin string, createDTO *dto.CreateServiceAccount) (dto.ServiceAccount, error)
+	ListServiceAccounts(ctx context.Context) ([]dto.ServiceAccount, error)
+	CreateKey(ctx context.Context, admin, username string, createDTO *dto.CreateAPIKey) (dto.APIKeyCreated, error)
+	ListKeys(ctx context.Context, username string) ([]dto.APIKey, error)
+	RevokeKey(ctx context.Context, admin, username string, id int64) error
+	Authenticate(ctx context.Context, rawKey string) (*models.APIKey, error)
+}
+
+type DefaultAPIKeyService struct {
+	userRepo       r.UserRepository
+	apiKeyRepo     r.APIKeyRepository
+	outboxRepo     r.OutboxRepository
+	passwordHasher PasswordHasher
+	auditLog       AuditRecorder
+	txExecutor     TxExecutor
+	logger         *slog.Logger
+}
+
+func NewAPIKeyService(userRepo r.UserRepository, apiKeyRepo r.APIKeyRepository, outboxRepo r.OutboxRepository, passwordHasher PasswordHasher,
+	auditLog AuditRecorder, txHelper TxExecutor, logger *slog.Logger) *DefaultAPIKeyService {
+	return &DefaultAPIKeyService{
+		userRepo:       userRepo,
+		apiKeyRepo:     apiKeyRepo,
+		outboxRepo:     outboxRepo,
+		passwordHasher: passwordHasher,
+		auditLog:       auditLog,
+		txExecutor:     txHelper,
+		logger:         logger,
+	}
+}
+
+// CreateServiceAccount создает сервисную учетную запись. Пароль генерируется случайно и нигде не показывается,
+// поэтому учетная запись доступна только через API-ключи. Монеты сервисной учетной записи не начисляются.
+func (s *DefaultAPIKeyService) CreateServiceAccount(ctx context.Context, admin string, createDTO *dto.CreateServiceAccount) (dto.ServiceAccount, error) {
+	s.logger.Info("Starting to create service account", "admin", admin, "username", createDTO.UserName)
+
+	secret, err := randomToken(32)
+	if err != nil {
+		return dto.ServiceAccount{}, err
+	}
+	hashedPassword, err := s.passwordHasher.Hash(secret)
+	if err != nil {
+		return dto.ServiceAccount{}, err
+	}
+
+	err = s.txExecutor.RunWithTransaction(ctx, func(tx pgx.Tx) error {
+		if err := s.userRepo.CreateUserWithRole(ctx, tx, createDTO.UserName, hashedPassword, models.RoleService); err != nil {
+			return err
+		}
+
+		err := s.outboxRepo.AddEvent(ctx, tx, models.EventUserCreated, models.UserCreatedPayload{
+			Username: createDTO.UserName,
+			Role:     models.RoleService,
+		})
+		if err != nil {
+			return err
+		}
+
+		return s.auditLog.Record(ctx, tx, models.AuditEntry{Actor: admin, Action: models.AuditServiceAccountCreated, Target: createDTO.UserName})
+	})
+	if err != nil {
+		s.logger.Error("Failed to create service account", "username", createDTO.UserName, "error", err)
+		return dto.ServiceAccount{}, err
+	}
+
+	user, err := s.userRepo.GetUser(ctx, createDTO.UserName)
+	if err != nil {
+		return dto.ServiceAccount{}, err
+	}
+
+	s.logger.Info("Service account created", "admin", admin, "username", createDTO.UserName)
+	return dto.ServiceAccount{UserName: user.UserName, Balance: user.Balance}, nil
+}
+
+// ListServiceAccounts предоставляет список сервисных учетных записей
+func (s *DefaultAPIKeyService) ListServiceAccounts(ctx context.Context) ([]dto.ServiceAccount, error) {
+	users, err := s.userRepo.ListUsersByRole(ctx, models.RoleService)
+	if err != nil {
+		s.logger.Error("Failed to list service accounts", "error", err)
+		return nil, err
+	}
+
+	result := make([]dto.ServiceAccount, 0, len(users))
+	for _, user := range users {
+		result = append(result, dto.ServiceAccount{UserName: user.UserName, Balance: user.Balance})
+	}
+	return result, nil
+}
+
+// CreateKey выпускает API-ключ сервисной учетной записи. В базе хранится только хеш ключа.
+func (s *DefaultAPIKeyService) CreateKey(ctx context.Context, admin, username string, createDTO *dto.CreateAPIKey) (dto.APIKeyCreated, error) {
+	s.logger.Info("Starting to create api key", "admin", admin, "username", username, "scopes", createDTO.Scopes)
+
+	if err := s.requireServiceAccount(ctx, username); err != nil {
+		return dto.APIKeyCreated{}, err
+	}
+
+	prefix, err := randomToken(4)
+	if err != nil {
+		return dto.APIKeyCreated{}, err
+	}
+	secret, err := randomToken(32)
+	if err != nil {
+		return dto.APIKeyCreated{}, err
+	}
+	rawKey := apiKeyPrefix + prefix + "_" + secret
+
+	key := &models.APIKey{
+		Username:  username,
+		Name:      createDTO.Name,
+		Prefix:    apiKeyPrefix + prefix,
+		KeyHash:   hashToken(rawKey),
+		Scopes:    createDTO.Scopes,
+		CreatedBy: admin,
+	}
+	if createDTO.ExpiresInDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, createDTO.ExpiresInDays).UTC()
+		key.ExpiresAt = &expiresAt
+	}
+
+	if err = s.apiKeyRepo.CreateKey(ctx, key); err != nil {
+		s.logger.Error("Failed to create api key", "username", username, "error", err)
+		return dto.APIKeyCreated{}, err
+	}
+
+	s.auditLog.RecordStandalone(ctx, models.AuditEntry{
+		Actor:   admin,
+		Action:  models.AuditAPIKeyCreated,
+		Target:  username,
+		Details: auditDetails(map[string]any{"keyId": key.ID, "prefix": key.Prefix, "scopes": key.Scopes}),
+	})
+
+	s.logger.Info("API key created successfully", "admin", admin, "username", username, "key_id", key.ID)
+	return dto.APIKeyCreated{APIKey: toAPIKeyDTO(*key), Key: rawKey}, nil
+}
+
+// ListKeys предоставляет ключи сервисной учетной записи без секретной части
+func (s *DefaultAPIKeyService) ListKeys(ctx context.Context, username string) ([]dto.APIKey, error) {
+	if err := s.requireServiceAccount(ctx, username); err != nil {
+		return nil, err
+	}
+
+	keys, err := s.apiKeyRepo.ListKeys(ctx, username)
+	if err != nil {
+		s.logger.Error("Failed to list api keys", "username", username, "error", err)
+		return nil, err
+	}
+
+	result := make([]dto.APIKey, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, toAPIKeyDTO(key))
+	}
+	return result, nil
+}
+
+// RevokeKey отзывает API-ключ, дальнейшие запросы с ним отклоняются
+func (s *DefaultAPIKeyService) RevokeKey(ctx context.Context, admin, username string, id int64) error {
+	s.logger.Info("Starting to revoke api key", "admin", admin, "username", username, "key_id", id)
+
+	if err := s.apiKeyRepo.RevokeKey(ctx, username, id); err != nil {
+		s.logger.Error("Failed to revoke api key", "username", username, "key_id", id, "error", err)
+		return err
+	}
+
+	s.auditLog.RecordStandalone(ctx, models.AuditEntry{
+		Actor:   admin,
+		Action:  models.AuditAPIKeyRevoked,
+		Target:  username,
+		Details: auditDetails(map[string]any{"keyId": id}),
+	})
+
+	s.logger.Info("API key revoked successfully", "admin", admin, "username", username, "key_id", id)
+	return nil
+}
+
+// Authenticate проверяет API-ключ и возвращает его данные
+func (s *DefaultAPIKeyService) Authenticate(ctx context.Context, rawKey string) (*models.APIKey, error) {
+	if !strings.HasPrefix(rawKey, apiKeyPrefix) {
+		return nil, e.ErrInvalidAPIKey
+	}
+
+	key, err := s.apiKeyRepo.GetActiveKey(ctx, hashToken(rawKey))
+	if err != nil {
+		if errors.Is(err, e.ErrInvalidAPIKey) {
+			s.logger.Warn("Invalid api key used", "prefix", keyPrefix(rawKey))
+		}
+		return nil, err
+	}
+
+	if err = s.apiKeyRepo.TouchKey(ctx, key.ID); err != nil {
+		s.logger.Error("Failed to update api key usage", "key_id", key.ID, "error", err)
+	}
+
+	return key, nil
+}
+
+// requireServiceAccount проверяет, что пользователь является сервисной учетной записью
+func (s *DefaultAPIKeyService) requireServiceAccount(ctx context.Context, username string) error {
+	role, err := s.userRepo.GetRole(ctx, username)
+	if err != nil {
+		return err
+	}
+	if role != models.RoleService {
+		s.logger.Warn("API keys are available only for service accounts", "username", username)
+		return e.ErrInvalidUser
+	}
+	return nil
+}
+
+// keyPrefix возвращает открытую часть ключа для журналирования
+func keyPrefix(rawKey string) string {
+	if i := strings.LastIndex(rawKey, "_"); i > 0 {
+		return rawKey[:i]
+	}
+	return apiKeyPrefix
+}
+
+// toAPIKeyDTO преобразует модель ключа в DTO
+func toAPIKeyDTO(key models.APIKey) dto.APIKey {
+	return dto.APIKey{
+		ID:         key.ID,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     key.Scopes,
+		CreatedBy:  key.CreatedBy,
+		CreatedAt:  key.CreatedAt,
+		LastUsedAt: key.LastUsedAt,
+		ExpiresAt:  key.ExpiresAt,
+		RevokedAt:  key.RevokedAt,
+	}
+}
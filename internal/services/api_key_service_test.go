@@ -0,0 +1,137 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"API-Avito-shop/internal/dto"
+	e "API-Avito-shop/internal/errors"
+	"API-Avito-shop/internal/models"
+	r "API-Avito-shop/internal/repositories"
+)
+
+// fakeAPIKeyRepo хранит ключи в памяти по хешу, отозванные и истекшие ключи в нем не хранятся
+type fakeAPIKeyRepo struct {
+	r.APIKeyRepository
+	keys    map[string]*models.APIKey
+	lookups int
+	touched []int64
+}
+
+func (f *fakeAPIKeyRepo) CreateKey(_ context.Context, key *models.APIKey) error {
+	key.ID = int64(len(f.keys) + 1)
+	f.keys[key.KeyHash] = key
+	return nil
+}
+
+func (f *fakeAPIKeyRepo) GetActiveKey(_ context.Context, keyHash string) (*models.APIKey, error) {
+	f.lookups++
+	key, ok := f.keys[keyHash]
+	if !ok {
+		return nil, e.ErrInvalidAPIKey
+	}
+	return key, nil
+}
+
+func (f *fakeAPIKeyRepo) TouchKey(_ context.Context, id int64) error {
+	f.touched = append(f.touched, id)
+	return nil
+}
+
+func newTestAPIKeyService() (*DefaultAPIKeyService, *fakeAPIKeyRepo) {
+	userRepo := &fakeUserRepo{users: map[string]*models.User{
+		"crm":   {UserName: "crm", Role: models.RoleService},
+		"alice": {UserName: "alice", Role: models.RoleUser},
+	}}
+	apiKeyRepo := &fakeAPIKeyRepo{keys: make(map[string]*models.APIKey)}
+	return NewAPIKeyService(userRepo, apiKeyRepo, &fakeOutboxRepo{}, nil, &fakeAuditRecorder{}, fakeTxExecutor{}, testLogger()), apiKeyRepo
+}
+
+func TestAPIKeyAuthenticate(t *testing.T) {
+	service, repo := newTestAPIKeyService()
+	ctx := context.Background()
+
+	created, err := service.CreateKey(ctx, "admin", "crm", &dto.CreateAPIKey{Name: "sync", Scopes: []string{models.ScopeShopRead}})
+	if err != nil {
+		t.Fatalf("CreateKey() error = %v", err)
+	}
+	if !strings.HasPrefix(created.Key, created.Prefix+"_") || !strings.HasPrefix(created.Prefix, apiKeyPrefix) {
+		t.Errorf("CreateKey() key = %s, want it to start with prefix %s", created.Key, created.Prefix)
+	}
+	// В хранилище попадает только хеш ключа
+	for hash := range repo.keys {
+		if hash != hashToken(created.Key) {
+			t.Errorf("CreateKey() stored %s, want the hash of the key", hash)
+		}
+	}
+
+	key, err := service.Authenticate(ctx, created.Key)
+	if err != nil || key.Username != "crm" || !key.HasScope(models.ScopeShopRead) {
+		t.Fatalf("Authenticate() = %+v, %v, want key of crm with %s", key, err, models.ScopeShopRead)
+	}
+	if len(repo.touched) != 1 || repo.touched[0] != key.ID {
+		t.Errorf("Authenticate() touched keys %v, want [%d]", repo.touched, key.ID)
+	}
+
+	tests := []struct {
+		name   string
+		rawKey string
+		lookup bool
+	}{
+		{"changed secret", created.Key[:len(created.Key)-1] + "x", true},
+		{"prefix only", created.Prefix, true},
+		// Ключи без префикса магазина отклоняются без запроса к базе
+		{"foreign prefix", "ghp_" + strings.TrimPrefix(created.Key, apiKeyPrefix), false},
+		{"jwt", "eyJhbGciOiJIUzI1NiJ9.e30.sig", false},
+		{"empty", "", false},
+	}
+	for _, tt := range tests {
+		lookups := repo.lookups
+		if _, err := service.Authenticate(ctx, tt.rawKey); !errors.Is(err, e.ErrInvalidAPIKey) {
+			t.Errorf("%s: Authenticate() error = %v, want %v", tt.name, err, e.ErrInvalidAPIKey)
+		}
+		if got := repo.lookups > lookups; got != tt.lookup {
+			t.Errorf("%s: Authenticate() looked up the key = %v, want %v", tt.name, got, tt.lookup)
+		}
+	}
+}
+
+func TestAPIKeyOnlyForServiceAccounts(t *testing.T) {
+	service, repo := newTestAPIKeyService()
+
+	tests := []struct {
+		name     string
+		username string
+		want     error
+	}{
+		{"user", "alice", e.ErrInvalidUser},
+		{"missing", "bob", e.ErrInvalidUser},
+	}
+	for _, tt := range tests {
+		if _, err := service.CreateKey(context.Background(), "admin", tt.username, &dto.CreateAPIKey{Name: "key"}); !errors.Is(err, tt.want) {
+			t.Errorf("%s: CreateKey() error = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+	if len(repo.keys) != 0 {
+		t.Errorf("CreateKey() stored %d keys, want 0", len(repo.keys))
+	}
+}
+
+func TestKeyPrefix(t *testing.T) {
+	tests := []struct {
+		rawKey string
+		want   string
+	}{
+		{"msk_ab12cd34_secret", "msk_ab12cd34"},
+		{"msk_ab12cd34_sec_ret", "msk_ab12cd34_sec"},
+		{"msk_secret", "msk"},
+		{"secret", apiKeyPrefix},
+	}
+	for _, tt := range tests {
+		if got := keyPrefix(tt.rawKey); got != tt.want {
+			t.Errorf("keyPrefix(%s) = %s, want %s", tt.rawKey, got, tt.want)
+		}
+	}
+}
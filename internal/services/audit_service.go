@@ -0,0 +1,208 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"API-Avito-shop/internal/dto"
+	"API-Avito-shop/internal/models"
+	r "API-Avito-shop/internal/repositories"
+	"API-Avito-shop/internal/utils/requestmeta"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// auditBatchSize количество записей, читаемых за раз при выгрузке и проверке журнала
+const auditBatchSize = 1000
+
+// AuditRecorder записывает действия в журнал аудита
+type AuditRecorder interface {
+	Record(ctx context.Context, tx pgx.Tx, entry models.AuditEntry) error
+	RecordStandalone(ctx context.Context, entry models.AuditEntry)
+}
+
+type AuditService interface {
+	AuditRecorder
+	ListEntries(ctx context.Context, query *dto.AuditQuery, limit, offset int) ([]dto.AuditEntry, error)
+	ExportEntries(ctx context.Context, admin string, query *dto.AuditQuery, fn func(dto.AuditEntry) error) error
+	VerifyChain(ctx context.Context) (dto.AuditVerification, error)
+}
+
+// DefaultAuditService ведет журнал аудита в виде цепочки хешей
+type DefaultAuditService struct {
+	auditRepo  r.AuditRepository
+	txExecutor TxExecutor
+	chainKey   []byte
+	logger     *slog.Logger
+}
+
+func NewAuditService(auditRepo r.AuditRepository, txHelper TxExecutor, chainKey []byte, logger *slog.Logger) *DefaultAuditService {
+	return &DefaultAuditService{
+		auditRepo:  auditRepo,
+		txExecutor: txHelper,
+		chainKey:   chainKey,
+		logger:     logger,
+	}
+}
+
+// Record добавляет запись в журнал в рамках транзакции действия.
+// Запись не блокирует журнал, в цепочку хешей ее включает ChainSealer после фиксации транзакции.
+func (s *DefaultAuditService) Record(ctx context.Context, tx pgx.Tx, entry models.AuditEntry) error {
+	meta := requestmeta.FromContext(ctx)
+	entry.IP = meta.IP
+	entry.UserAgent = meta.UserAgent
+	entry.RequestID = meta.RequestID
+
+	return s.auditRepo.Insert(ctx, tx, &entry)
+}
+
+// RecordStandalone добавляет запись в отдельной транзакции для действий, выполняемых вне транзакции.
+// Ошибка записи не прерывает действие и только логируется.
+func (s *DefaultAuditService) RecordStandalone(ctx context.Context, entry models.AuditEntry) {
+	err := s.txExecutor.RunWithTransaction(ctx, func(tx pgx.Tx) error {
+		return s.Record(ctx, tx, entry)
+	})
+	if err != nil {
+		s.logger.Error("Failed to write audit entry", "action", entry.Action, "actor", entry.Actor, "error", err)
+	}
+}
+
+// ListEntries предоставляет записи журнала, начиная с последних
+func (s *DefaultAuditService) ListEntries(ctx context.Context, query *dto.AuditQuery, limit, offset int) ([]dto.AuditEntry, error) {
+	entries, err := s.auditRepo.ListEntries(ctx, toAuditFilter(query), limit, offset)
+	if err != nil {
+		s.logger.Error("Failed to list audit entries", "error", err)
+		return nil, err
+	}
+
+	result := make([]dto.AuditEntry, 0, len(entries))
+	for _, entry := range entries {
+		result = append(result, toAuditEntryDTO(entry))
+	}
+	return result, nil
+}
+
+// ExportEntries передает все подходящие записи в порядке добавления. Сама выгрузка фиксируется в журнале.
+func (s *DefaultAuditService) ExportEntries(ctx context.Context, admin string, query *dto.AuditQuery, fn func(dto.AuditEntry) error) error {
+	s.logger.Info("Starting audit export", "admin", admin)
+
+	filter := toAuditFilter(query)
+	details := auditDetails(map[string]any{
+		"actor":  filter.Actor,
+		"action": filter.Action,
+		"target": filter.Target,
+		"from":   filter.From,
+		"to":     filter.To,
+	})
+	s.RecordStandalone(ctx, models.AuditEntry{Actor: admin, Action: models.AuditLogExported, Details: details})
+
+	var afterID int64
+	for {
+		entries, err := s.auditRepo.ScanEntries(ctx, filter, afterID, auditBatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, entry := range entries {
+			if err = fn(toAuditEntryDTO(entry)); err != nil {
+				return err
+			}
+			afterID = entry.ID
+		}
+
+		if len(entries) < auditBatchSize {
+			return nil
+		}
+	}
+}
+
+// VerifyChain проверяет хеши записей, включенных в цепочку, и находит первую запись, нарушающую цепочку
+func (s *DefaultAuditService) VerifyChain(ctx context.Context) (dto.AuditVerification, error) {
+	s.logger.Info("Starting audit chain verification")
+
+	chain, err := s.auditRepo.VerifyChain(ctx, s.chainKey)
+	if err != nil {
+		return dto.AuditVerification{}, err
+	}
+
+	result := dto.AuditVerification{Valid: chain.Valid, Checked: chain.Checked, BrokenAt: chain.BrokenAt, Pending: chain.Pending}
+	if !result.Valid {
+		s.logger.Warn("Audit chain is broken", "entry_id", *result.BrokenAt)
+		return result, nil
+	}
+
+	s.logger.Info("Audit chain verified", "checked", result.Checked, "pending", result.Pending)
+	return result, nil
+}
+
+// auditDetails сериализует дополнительные сведения записи, пустые значения пропускаются
+func auditDetails(details map[string]any) string {
+	for key, value := range details {
+		switch v := value.(type) {
+		case string:
+			if v == "" {
+				delete(details, key)
+			}
+		case *time.Time:
+			if v == nil {
+				delete(details, key)
+			}
+		case *int:
+			if v == nil {
+				delete(details, key)
+			}
+		case nil:
+			delete(details, key)
+		}
+	}
+	if len(details) == 0 {
+		return ""
+	}
+
+	data, err := json.Marshal(details)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// toAuditFilter преобразует параметры запроса в фильтр журнала, границы периода приводятся к UTC
+func toAuditFilter(query *dto.AuditQuery) models.AuditFilter {
+	filter := models.AuditFilter{
+		Actor:  query.Actor,
+		Action: query.Action,
+		Target: query.Target,
+	}
+	if query.From != nil {
+		from := query.From.UTC()
+		filter.From = &from
+	}
+	if query.To != nil {
+		to := query.To.UTC()
+		filter.To = &to
+	}
+	return filter
+}
+
+// toAuditEntryDTO преобразует запись журнала в DTO
+func toAuditEntryDTO(entry models.AuditEntry) dto.AuditEntry {
+	result := dto.AuditEntry{
+		ID:        entry.ID,
+		Actor:     entry.Actor,
+		Action:    entry.Action,
+		Target:    entry.Target,
+		Amount:    entry.Amount,
+		IP:        entry.IP,
+		UserAgent: entry.UserAgent,
+		RequestID: entry.RequestID,
+		PrevHash:  entry.PrevHash,
+		Hash:      entry.Hash,
+		CreatedAt: entry.CreatedAt,
+	}
+	if entry.Details != "" {
+		result.Details = json.RawMessage(entry.Details)
+	}
+	return result
+}
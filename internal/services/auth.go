@@ -5,33 +5,50 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"time"
+
+	"API-Avito-shop/internal/utils/jwks"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
 type Token interface {
-	GenerateToken(ctx context.Context, userID string) (string, error)
-	ValidateToken(ctx context.Context, token string) (string, error)
+	GenerateToken(ctx context.Context, username string, version int) (string, error)
+	ValidateToken(ctx context.Context, token string) (*TokenClaims, error)
+}
+
+// TokenClaims представляет данные, извлеченные из проверенного токена
+type TokenClaims struct {
+	Username string
+	// Version сравнивается с users.token_version, смена пароля отзывает ранее выданные токены
+	Version int
 }
+
 type DefaultToken struct {
-	secretKey string
-	logger    *slog.Logger
+	keys   *jwks.KeySet
+	logger *slog.Logger
 }
 
-func NewToken(secretKey string, logger *slog.Logger) *DefaultToken {
+func NewToken(keys *jwks.KeySet, logger *slog.Logger) *DefaultToken {
 	return &DefaultToken{
-		secretKey: secretKey,
-		logger:    logger,
+		keys:   keys,
+		logger: logger,
 	}
 }
 
-// GenerateToken генерирует токен
-func (t *DefaultToken) GenerateToken(ctx context.Context, username string) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+// GenerateToken генерирует токен, подписанный активным ключом
+func (t *DefaultToken) GenerateToken(ctx context.Context, username string, version int) (string, error) {
+	key := t.keys.Active()
+	token := jwt.NewWithClaims(key.Method, jwt.MapClaims{
 		"username": username,
+		"ver":      version,
+		"iat":      time.Now().Unix(),
 	})
+	if key.ID != "" {
+		token.Header["kid"] = key.ID
+	}
 
-	tokenString, err := token.SignedString([]byte(t.secretKey))
+	tokenString, err := token.SignedString(key.SigningKey())
 	if err != nil {
 		t.logger.Error("Failed to generate token", "username", username, "error", err)
 		return "", err
@@ -41,33 +58,46 @@ func (t *DefaultToken) GenerateToken(ctx context.Context, username string) (stri
 	return tokenString, nil
 }
 
-// ValidateToken проверяет валидность токена
-func (t *DefaultToken) ValidateToken(ctx context.Context, tokenString string) (string, error) {
+// ValidateToken проверяет валидность токена ключом, указанным в заголовке kid.
+// Алгоритм токена должен совпадать с алгоритмом ключа.
+func (t *DefaultToken) ValidateToken(ctx context.Context, tokenString string) (*TokenClaims, error) {
 	parsedToken, err := jwt.Parse(tokenString, func(j *jwt.Token) (interface{}, error) {
-		if _, ok := j.Method.(*jwt.SigningMethodHMAC); !ok {
-			err := fmt.Errorf("unexpected signing method: %v", j.Header["alg"])
+		kid, _ := j.Header["kid"].(string)
+		key, err := t.keys.Lookup(kid)
+		if err != nil {
+			t.logger.Error("Unknown token signing key", "kid", kid)
+			return nil, err
+		}
+		if j.Method.Alg() != key.Method.Alg() {
+			err = fmt.Errorf("unexpected signing method: %v", j.Header["alg"])
 			t.logger.Error("Invalid token signing method", "token", tokenString, "error", err)
 			return nil, err
 		}
-		return []byte(t.secretKey), nil
+		return key.VerifyKey(), nil
 	})
 	if err != nil || !parsedToken.Valid {
 		t.logger.Error("Invalid token", "token", tokenString, "error", err)
-		return "", errors.New("invalid token")
+		return nil, errors.New("invalid token")
 	}
 
 	claims, ok := parsedToken.Claims.(jwt.MapClaims)
 	if !ok {
 		t.logger.Error("Invalid token claims", "token", tokenString)
-		return "", errors.New("invalid token claims")
+		return nil, errors.New("invalid token claims")
 	}
 
 	username, ok := claims["username"].(string)
 	if !ok {
 		t.logger.Error("Invalid user ID in token claims", "token", tokenString)
-		return "", errors.New("invalid user ID in token")
+		return nil, errors.New("invalid user ID in token")
+	}
+
+	// Токены, выданные до появления версии, считаются версией 0
+	var version int
+	if ver, ok := claims["ver"].(float64); ok {
+		version = int(ver)
 	}
 
 	t.logger.Info("Token validated successfully", "username", username)
-	return username, nil
+	return &TokenClaims{Username: username, Version: version}, nil
 }
@@ -0,0 +1,152 @@
+package services
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"API-Avito-shop/internal/utils/jwks"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// testKeySet создает набор с активным ключом Ed25519 "current", ключом RSA "rsa"
+// и открытой частью ключа "retired", закрытая часть которого возвращается для подписи
+func testKeySet(t *testing.T) (*jwks.KeySet, *rsa.PrivateKey, ed25519.PrivateKey) {
+	t.Helper()
+	dir := t.TempDir()
+	write := func(name, blockType string, der []byte, err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+		if err = os.WriteFile(filepath.Join(dir, name+".pem"), data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, current, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(current)
+	write("current", "PRIVATE KEY", der, err)
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	write("rsa", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey), nil)
+
+	retiredPublic, retired, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err = x509.MarshalPKIXPublicKey(retiredPublic)
+	write("retired", "PUBLIC KEY", der, err)
+
+	keys, err := jwks.LoadDir(dir, "current")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return keys, rsaKey, retired
+}
+
+// signTestToken подписывает токен пользователя alice заданными методом, kid и ключом
+func signTestToken(t *testing.T, method jwt.SigningMethod, kid string, key any) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, jwt.MapClaims{"username": "alice", "ver": 2, "iat": time.Now().Unix()})
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func TestTokenRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	keys, _, _ := testKeySet(t)
+	tokens := NewToken(keys, testLogger())
+
+	signed, err := tokens.GenerateToken(ctx, "alice", 3)
+	if err != nil {
+		t.Fatalf("GenerateToken() error = %v", err)
+	}
+	parsed, _, err := jwt.NewParser().ParseUnverified(signed, jwt.MapClaims{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Header["kid"] != "current" || parsed.Method.Alg() != "EdDSA" {
+		t.Errorf("token header = %v, want kid current and alg EdDSA", parsed.Header)
+	}
+
+	claims, err := tokens.ValidateToken(ctx, signed)
+	if err != nil || claims.Username != "alice" || claims.Version != 3 {
+		t.Errorf("ValidateToken() = %+v, %v, want alice version 3", claims, err)
+	}
+}
+
+func TestValidateTokenKeys(t *testing.T) {
+	ctx := context.Background()
+	keys, rsaKey, retired := testKeySet(t)
+	keys.AcceptHMAC("legacy-secret")
+	tokens := NewToken(keys, testLogger())
+
+	rsaPublicPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PUBLIC KEY", Bytes: x509.MarshalPKCS1PublicKey(&rsaKey.PublicKey)})
+	_, stranger, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		valid bool
+	}{
+		{"rsa key", signTestToken(t, jwt.SigningMethodRS256, "rsa", rsaKey), true},
+		// Токены ключа, выведенного из ротации, принимаются до истечения
+		{"retired key", signTestToken(t, jwt.SigningMethodEdDSA, "retired", retired), true},
+		{"legacy hmac without kid", signTestToken(t, jwt.SigningMethodHS256, "", []byte("legacy-secret")), true},
+		{"wrong hmac secret", signTestToken(t, jwt.SigningMethodHS256, "", []byte("guessed")), false},
+		{"unknown kid", signTestToken(t, jwt.SigningMethodEdDSA, "2030-01", stranger), false},
+		{"key of another kid", signTestToken(t, jwt.SigningMethodEdDSA, "current", stranger), false},
+		// Подмена алгоритма: HS256 с открытым ключом RSA в качестве секрета
+		{"hmac with rsa public key", signTestToken(t, jwt.SigningMethodHS256, "rsa", rsaPublicPEM), false},
+		{"rsa algorithm for ed25519 kid", signTestToken(t, jwt.SigningMethodRS256, "current", rsaKey), false},
+		{"hmac kid for asymmetric token", signTestToken(t, jwt.SigningMethodEdDSA, "", retired), false},
+		{"unsigned", signTestToken(t, jwt.SigningMethodNone, "current", jwt.UnsafeAllowNoneSignatureType), false},
+		{"garbage", "not.a.token", false},
+	}
+
+	for _, tt := range tests {
+		claims, err := tokens.ValidateToken(ctx, tt.token)
+		if tt.valid && (err != nil || claims.Username != "alice" || claims.Version != 2) {
+			t.Errorf("%s: ValidateToken() = %+v, %v, want alice version 2", tt.name, claims, err)
+		}
+		if !tt.valid && err == nil {
+			t.Errorf("%s: ValidateToken() accepted the token", tt.name)
+		}
+	}
+}
+
+func TestValidateTokenWithoutLegacySecret(t *testing.T) {
+	keys, _, _ := testKeySet(t)
+	tokens := NewToken(keys, testLogger())
+
+	// Без AcceptHMAC токены без kid не принимаются
+	token := signTestToken(t, jwt.SigningMethodHS256, "", []byte("legacy-secret"))
+	if _, err := tokens.ValidateToken(context.Background(), token); err == nil {
+		t.Error("ValidateToken() accepted an HMAC token without a legacy secret")
+	}
+}
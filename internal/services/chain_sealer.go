@@ -0,0 +1,77 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	r "API-Avito-shop/internal/repositories"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ChainSealer периодически включает в цепочку хешей строки, добавленные после последнего прохода.
+// Запись переводов, покупок и событий аудита не ждет цепочку, а хеши вычисляются в одном процессе.
+type ChainSealer struct {
+	name       string
+	chainRepo  r.ChainRepository
+	key        []byte
+	txExecutor TxExecutor
+	interval   time.Duration
+	batchSize  int
+	logger     *slog.Logger
+}
+
+func NewChainSealer(name string, chainRepo r.ChainRepository, key []byte, txHelper TxExecutor, interval time.Duration, batchSize int, logger *slog.Logger) *ChainSealer {
+	return &ChainSealer{
+		name:       name,
+		chainRepo:  chainRepo,
+		key:        key,
+		txExecutor: txHelper,
+		interval:   interval,
+		batchSize:  batchSize,
+		logger:     logger,
+	}
+}
+
+// Run запускает цикл дополнения цепочки до отмены контекста
+func (s *ChainSealer) Run(ctx context.Context) {
+	s.logger.Info("Chain sealer started", "chain", s.name, "interval", s.interval)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.drain(ctx)
+
+		select {
+		case <-ctx.Done():
+			s.logger.Info("Chain sealer stopped", "chain", s.name)
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// drain дополняет цепочку пачками, пока не останется строк без хеша
+func (s *ChainSealer) drain(ctx context.Context) {
+	for ctx.Err() == nil {
+		var sealed int
+
+		err := s.txExecutor.RunWithTransaction(ctx, func(tx pgx.Tx) error {
+			var err error
+			sealed, err = s.chainRepo.SealChain(ctx, tx, s.key, s.batchSize)
+			return err
+		})
+		if err != nil {
+			s.logger.Error("Failed to seal chain", "chain", s.name, "error", err)
+			return
+		}
+		if sealed > 0 {
+			s.logger.Info("Chain sealed", "chain", s.name, "rows", sealed)
+		}
+		if sealed < s.batchSize {
+			return
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"API-Avito-shop/internal/models"
+	r "API-Avito-shop/internal/repositories"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// CoinExpiryPolicy задает срок действия монет. Срок считается от времени первоначального начисления партии.
+type CoinExpiryPolicy struct {
+	Mode string
+	// Months срок действия для политики rolling
+	Months int
+}
+
+// ExpiresAt возвращает момент сгорания партии, начисленной в issuedAt; false, если монеты не сгорают.
+// Год начисления определяется в UTC, как и граница Cutoff.
+func (p CoinExpiryPolicy) ExpiresAt(issuedAt time.Time) (time.Time, bool) {
+	issuedAt = issuedAt.UTC()
+	switch p.Mode {
+	case models.ExpiryRolling:
+		return issuedAt.AddDate(0, p.Months, 0), true
+	case models.ExpiryYearEnd:
+		return time.Date(issuedAt.Year()+1, time.January, 1, 0, 0, 0, 0, time.UTC), true
+	default:
+		return time.Time{}, false
+	}
+}
+
+// Cutoff возвращает границу, партии начисленные раньше которой к моменту now сгорели; false, если монеты не сгорают
+func (p CoinExpiryPolicy) Cutoff(now time.Time) (time.Time, bool) {
+	switch p.Mode {
+	case models.ExpiryRolling:
+		return now.AddDate(0, -p.Months, 0), true
+	case models.ExpiryYearEnd:
+		return time.Date(now.Year(), time.January, 1, 0, 0, 0, 0, time.UTC), true
+	default:
+		return time.Time{}, false
+	}
+}
+
+// CoinExpiryJob периодически списывает монеты партий с истекшим сроком действия.
+// Сгорание уменьшает баланс, записывается в журнал сгорания и журнал аудита
+// и сообщается пользователю и подписчикам событий.
+type CoinExpiryJob struct {
+	coinLotRepo      r.CoinLotRepository
+	outboxRepo       r.OutboxRepository
+	notificationRepo r.NotificationRepository
+	auditLog         AuditRecorder
+	txExecutor       TxExecutor
+	policy           CoinExpiryPolicy
+	interval         time.Duration
+	batchSize        int
+	logger           *slog.Logger
+}
+
+func NewCoinExpiryJob(coinLotRepo r.CoinLotRepository, outboxRepo r.OutboxRepository, notificationRepo r.NotificationRepository, auditLog AuditRecorder, txHelper TxExecutor, policy CoinExpiryPolicy, interval time.Duration, batchSize int, logger *slog.Logger) *CoinExpiryJob {
+	return &CoinExpiryJob{
+		coinLotRepo:      coinLotRepo,
+		outboxRepo:       outboxRepo,
+		notificationRepo: notificationRepo,
+		auditLog:         auditLog,
+		txExecutor:       txHelper,
+		policy:           policy,
+		interval:         interval,
+		batchSize:        batchSize,
+		logger:           logger,
+	}
+}
+
+// Run запускает цикл сгорания монет до отмены контекста
+func (j *CoinExpiryJob) Run(ctx context.Context) {
+	j.logger.Info("Coin expiry job started", "policy", j.policy.Mode, "interval", j.interval)
+
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		j.expire(ctx)
+
+		select {
+		case <-ctx.Done():
+			j.logger.Info("Coin expiry job stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// expire списывает истекшие партии пачками пользователей, пока они не закончатся
+func (j *CoinExpiryJob) expire(ctx context.Context) {
+	cutoff, ok := j.policy.Cutoff(time.Now().UTC())
+	if !ok {
+		return
+	}
+
+	for ctx.Err() == nil {
+		var locked int
+
+		err := j.txExecutor.RunWithTransaction(ctx, func(tx pgx.Tx) error {
+			usernames, err := j.coinLotRepo.LockExpiringUsers(ctx, tx, cutoff, j.batchSize)
+			if err != nil {
+				return err
+			}
+			locked = len(usernames)
+			if locked == 0 {
+				return nil
+			}
+
+			expirations, err := j.coinLotRepo.ExpireLots(ctx, tx, usernames, cutoff)
+			if err != nil {
+				return err
+			}
+
+			for _, expiration := range expirations {
+				if err = j.notifyExpiration(ctx, tx, expiration); err != nil {
+					return err
+				}
+				j.logger.Info("Coins expired", "username", expiration.Username, "amount", expiration.Amount)
+			}
+			return nil
+		})
+		if err != nil {
+			j.logger.Error("Failed to expire coins", "error", err)
+			return
+		}
+		if locked < j.batchSize {
+			return
+		}
+	}
+}
+
+// notifyExpiration записывает сгорание в журнал аудита, публикует событие сгорания
+// и уведомляет пользователя о сгорании и новом балансе
+func (j *CoinExpiryJob) notifyExpiration(ctx context.Context, tx pgx.Tx, expiration models.CoinExpiration) error {
+	err := j.auditLog.Record(ctx, tx, models.AuditEntry{
+		Actor:   models.AuditActorSystem,
+		Action:  models.AuditCoinsExpired,
+		Target:  expiration.Username,
+		Amount:  &expiration.Amount,
+		Details: auditDetails(map[string]any{"policy": j.policy.Mode}),
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := j.outboxRepo.AddEvent(ctx, tx, models.EventCoinsExpired, models.CoinsExpiredPayload{
+		Username: expiration.Username,
+		Amount:   expiration.Amount,
+	}); err != nil {
+		return err
+	}
+
+	err = j.notificationRepo.Notify(ctx, tx, expiration.Username, models.NotificationCoinsExpired, models.CoinsExpiredData{Amount: expiration.Amount})
+	if err != nil {
+		return err
+	}
+
+	return j.notificationRepo.Notify(ctx, tx, expiration.Username, models.NotificationBalanceChanged, models.BalanceChangedData{Coins: expiration.Balance})
+}
@@ -0,0 +1,67 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"API-Avito-shop/internal/models"
+)
+
+func TestCoinExpiryPolicyExpiresAt(t *testing.T) {
+	moscow := time.FixedZone("MSK", 3*60*60)
+
+	tests := []struct {
+		name     string
+		policy   CoinExpiryPolicy
+		issuedAt time.Time
+		want     time.Time
+		expires  bool
+	}{
+		{"none", CoinExpiryPolicy{Mode: models.ExpiryNone}, time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), time.Time{}, false},
+		{"rolling", CoinExpiryPolicy{Mode: models.ExpiryRolling, Months: 12}, time.Date(2026, 3, 15, 10, 0, 0, 0, time.UTC),
+			time.Date(2027, 3, 15, 10, 0, 0, 0, time.UTC), true},
+		{"year end", CoinExpiryPolicy{Mode: models.ExpiryYearEnd}, time.Date(2026, 3, 15, 10, 0, 0, 0, time.UTC),
+			time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC), true},
+		{"year end on the last day", CoinExpiryPolicy{Mode: models.ExpiryYearEnd}, time.Date(2026, 12, 31, 23, 59, 0, 0, time.UTC),
+			time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC), true},
+		// Год начисления определяется в UTC: 1 января по Москве — еще 31 декабря UTC
+		{"year end in other zone", CoinExpiryPolicy{Mode: models.ExpiryYearEnd}, time.Date(2027, 1, 1, 1, 0, 0, 0, moscow),
+			time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC), true},
+	}
+
+	for _, tt := range tests {
+		got, ok := tt.policy.ExpiresAt(tt.issuedAt)
+		if ok != tt.expires || !got.Equal(tt.want) {
+			t.Errorf("%s: ExpiresAt() = %s, %v, want %s, %v", tt.name, got, ok, tt.want, tt.expires)
+		}
+		if ok && got.Location() != time.UTC {
+			t.Errorf("%s: ExpiresAt() is in %s, want UTC", tt.name, got.Location())
+		}
+	}
+}
+
+func TestCoinExpiryPolicyCutoffMatchesExpiresAt(t *testing.T) {
+	// Партия сгорела к моменту now тогда и только тогда, когда она начислена раньше границы
+	now := time.Date(2027, 3, 15, 12, 0, 0, 0, time.UTC)
+	policies := []CoinExpiryPolicy{
+		{Mode: models.ExpiryRolling, Months: 12},
+		{Mode: models.ExpiryYearEnd},
+	}
+	issued := []time.Time{
+		time.Date(2026, 3, 15, 11, 59, 0, 0, time.UTC),
+		time.Date(2026, 3, 15, 12, 1, 0, 0, time.UTC),
+		time.Date(2026, 12, 31, 23, 0, 0, 0, time.UTC),
+		time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	for _, policy := range policies {
+		cutoff, _ := policy.Cutoff(now)
+		for _, issuedAt := range issued {
+			expiresAt, _ := policy.ExpiresAt(issuedAt)
+			expired := !expiresAt.After(now)
+			if before := issuedAt.Before(cutoff); before != expired {
+				t.Errorf("%s: lot issued at %s expired = %v, but before cutoff %s = %v", policy.Mode, issuedAt, expired, cutoff, before)
+			}
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	e "API-Avito-shop/internal/errors"
+	"API-Avito-shop/internal/models"
+	r "API-Avito-shop/internal/repositories"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// fakeTxExecutor выполняет функцию без транзакции, для сервисов с поддельными репозиториями
+type fakeTxExecutor struct{}
+
+func (fakeTxExecutor) RunWithTransaction(_ context.Context, fn func(pgx.Tx) error) error {
+	return fn(nil)
+}
+
+// fakeAuditRecorder запоминает записи журнала аудита
+type fakeAuditRecorder struct {
+	mu      sync.Mutex
+	entries []models.AuditEntry
+}
+
+func (f *fakeAuditRecorder) Record(_ context.Context, _ pgx.Tx, entry models.AuditEntry) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.entries = append(f.entries, entry)
+	return nil
+}
+
+func (f *fakeAuditRecorder) RecordStandalone(ctx context.Context, entry models.AuditEntry) {
+	f.Record(ctx, nil, entry)
+}
+
+// actions возвращает действия записанных событий аудита по порядку
+func (f *fakeAuditRecorder) actions() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	result := make([]string, 0, len(f.entries))
+	for _, entry := range f.entries {
+		result = append(result, entry.Action)
+	}
+	return result
+}
+
+// fakeOutboxRepo запоминает добавленные события, остальные методы не используются
+type fakeOutboxRepo struct {
+	r.OutboxRepository
+	mu     sync.Mutex
+	events []fakeEvent
+}
+
+type fakeEvent struct {
+	eventType string
+	payload   any
+}
+
+func (f *fakeOutboxRepo) AddEvent(_ context.Context, _ pgx.Tx, eventType string, payload any) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.events = append(f.events, fakeEvent{eventType: eventType, payload: payload})
+	return nil
+}
+
+// fakeUserRepo хранит пользователей в памяти, остальные методы не используются
+type fakeUserRepo struct {
+	r.UserRepository
+	users map[string]*models.User
+	// stateLookups количество запросов версии токена
+	stateLookups int
+}
+
+func (f *fakeUserRepo) GetRole(_ context.Context, username string) (string, error) {
+	user, ok := f.users[username]
+	if !ok {
+		return "", e.ErrInvalidUser
+	}
+	return user.Role, nil
+}
+
+func (f *fakeUserRepo) GetUserForUpdate(_ context.Context, _ pgx.Tx, username string) (*models.User, error) {
+	user, ok := f.users[username]
+	if !ok {
+		return nil, e.ErrInvalidUser
+	}
+	copied := *user
+	return &copied, nil
+}
+
+func (f *fakeUserRepo) GetTokenState(_ context.Context, username string) (int, bool, error) {
+	f.stateLookups++
+	user, ok := f.users[username]
+	if !ok {
+		return 0, false, e.ErrInvalidUser
+	}
+	return user.TokenVersion, user.Disabled(), nil
+}
+
+// DisableUser, EnableUser и SoftDeleteUser повторяют запросы репозитория: отключение и удаление отзывают токены
+
+func (f *fakeUserRepo) DisableUser(_ context.Context, _ pgx.Tx, username, reason string) error {
+	user := f.users[username]
+	if user.DisabledAt == nil {
+		now := time.Now()
+		user.DisabledAt = &now
+	}
+	user.DisabledReason = &reason
+	user.TokenVersion++
+	return nil
+}
+
+func (f *fakeUserRepo) EnableUser(_ context.Context, _ pgx.Tx, username string) error {
+	user := f.users[username]
+	user.DisabledAt, user.DisabledReason = nil, nil
+	return nil
+}
+
+func (f *fakeUserRepo) SoftDeleteUser(_ context.Context, _ pgx.Tx, username string) error {
+	user := f.users[username]
+	now := time.Now()
+	user.DeletedAt = &now
+	if user.DisabledAt == nil {
+		user.DisabledAt = &now
+	}
+	user.TokenVersion++
+	return nil
+}
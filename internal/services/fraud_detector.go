@@ -0,0 +1,115 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	e "API-Avito-shop/internal/errors"
+	"API-Avito-shop/internal/models"
+	r "API-Avito-shop/internal/repositories"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type FraudDetector interface {
+	Evaluate(ctx context.Context, tx pgx.Tx, fromUser, toUser string, amount int) (models.FraudDecision, error)
+}
+
+// FraudPolicy задает пороги и действия правил обнаружения мошенничества
+type FraudPolicy struct {
+	Enabled bool
+	// Скорость: число и сумма переводов отправителя за окно
+	VelocityWindow       time.Duration
+	VelocityMaxTransfers int
+	VelocityMaxAmount    int
+	VelocityAction       string
+	// Стягивание: число разных новых учетных записей, переводивших одному получателю за окно
+	NewAccountAge      time.Duration
+	FanInWindow        time.Duration
+	FanInMaxNewSenders int
+	FanInAction        string
+	// Встречный перевод: получатель переводил отправителю за окно
+	RoundTripWindow time.Duration
+	RoundTripAction string
+}
+
+// DefaultFraudDetector проверяет переводы правилами скорости, стягивания монет с новых учетных записей
+// и встречных переводов
+type DefaultFraudDetector struct {
+	fraudRepo r.FraudRepository
+	policy    FraudPolicy
+	logger    *slog.Logger
+}
+
+func NewFraudDetector(fraudRepo r.FraudRepository, policy FraudPolicy, logger *slog.Logger) *DefaultFraudDetector {
+	return &DefaultFraudDetector{
+		fraudRepo: fraudRepo,
+		policy:    policy,
+		logger:    logger,
+	}
+}
+
+// Evaluate проверяет перевод правилами и возвращает самое строгое действие среди сработавших.
+// Возвращает ErrInvalidUser, если получатель не существует или отключен.
+func (d *DefaultFraudDetector) Evaluate(ctx context.Context, tx pgx.Tx, fromUser, toUser string, amount int) (models.FraudDecision, error) {
+	decision := models.FraudDecision{Action: models.FraudActionOff}
+	if !d.policy.Enabled {
+		return decision, nil
+	}
+
+	now := time.Now().UTC()
+	signals, err := d.fraudRepo.TransferSignals(ctx, tx, fromUser, toUser, models.FraudWindows{
+		Velocity:   now.Add(-d.policy.VelocityWindow),
+		FanIn:      now.Add(-d.policy.FanInWindow),
+		RoundTrip:  now.Add(-d.policy.RoundTripWindow),
+		NewAccount: now.Add(-d.policy.NewAccountAge),
+	})
+	if err != nil {
+		return decision, err
+	}
+	if !signals.RecipientActive {
+		return decision, e.ErrInvalidUser
+	}
+
+	apply := func(rule, action string) {
+		if action == models.FraudActionOff {
+			return
+		}
+		decision.Rules = append(decision.Rules, rule)
+		if fraudSeverity(action) > fraudSeverity(decision.Action) {
+			decision.Action = action
+		}
+	}
+
+	// Текущий перевод учитывается вместе с недавними
+	if signals.SenderTransfers+1 > d.policy.VelocityMaxTransfers || signals.SenderVolume+amount > d.policy.VelocityMaxAmount {
+		apply(models.FraudRuleVelocity, d.policy.VelocityAction)
+	}
+	if signals.SenderIsNew && signals.NewSenders+1 > d.policy.FanInMaxNewSenders {
+		apply(models.FraudRuleNewFanIn, d.policy.FanInAction)
+	}
+	if signals.ReverseTransfers > 0 {
+		apply(models.FraudRuleRoundTrip, d.policy.RoundTripAction)
+	}
+
+	if decision.Action != models.FraudActionOff {
+		d.logger.Warn("Transfer matched fraud rules", "from_user", fromUser, "to_user", toUser,
+			"amount", amount, "action", decision.Action, "rules", decision.Rules)
+	}
+	return decision, nil
+}
+
+// fraudSeverity возвращает строгость действия правила
+func fraudSeverity(action string) int {
+	switch action {
+	case models.FraudActionFlag:
+		return 1
+	case models.FraudActionHold:
+		return 2
+	case models.FraudActionBlock:
+		return 3
+	default:
+		return 0
+	}
+}
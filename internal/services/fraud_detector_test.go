@@ -0,0 +1,208 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"API-Avito-shop/internal/dto"
+	e "API-Avito-shop/internal/errors"
+	"API-Avito-shop/internal/models"
+	r "API-Avito-shop/internal/repositories"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func TestFraudSeverity(t *testing.T) {
+	tests := []struct {
+		action   string
+		severity int
+	}{
+		{models.FraudActionOff, 0},
+		{models.FraudActionFlag, 1},
+		{models.FraudActionHold, 2},
+		{models.FraudActionBlock, 3},
+		{"unknown", 0},
+		{"", 0},
+	}
+
+	for _, tt := range tests {
+		if got := fraudSeverity(tt.action); got != tt.severity {
+			t.Errorf("fraudSeverity(%q) = %d, want %d", tt.action, got, tt.severity)
+		}
+	}
+}
+
+// fakeFraudRepo возвращает заданные сведения о переводах, остальные методы не используются
+type fakeFraudRepo struct {
+	r.FraudRepository
+	signals models.TransferSignals
+}
+
+func (f *fakeFraudRepo) TransferSignals(context.Context, pgx.Tx, string, string, models.FraudWindows) (models.TransferSignals, error) {
+	return f.signals, nil
+}
+
+func TestFraudDetectorEvaluate(t *testing.T) {
+	policy := FraudPolicy{
+		Enabled:              true,
+		VelocityMaxTransfers: 3,
+		VelocityMaxAmount:    500,
+		VelocityAction:       models.FraudActionHold,
+		FanInMaxNewSenders:   2,
+		FanInAction:          models.FraudActionFlag,
+		RoundTripAction:      models.FraudActionBlock,
+	}
+
+	tests := []struct {
+		name    string
+		policy  func(p *FraudPolicy)
+		signals models.TransferSignals
+		amount  int
+		action  string
+		rules   []string
+		err     error
+	}{
+		{
+			name:    "clean transfer",
+			signals: models.TransferSignals{RecipientActive: true, SenderTransfers: 1, SenderVolume: 100},
+			amount:  100,
+			action:  models.FraudActionOff,
+		},
+		{
+			name:    "transfer count reaches the limit",
+			signals: models.TransferSignals{RecipientActive: true, SenderTransfers: 2},
+			amount:  10,
+			action:  models.FraudActionOff,
+		},
+		{
+			name:    "transfer count over the limit",
+			signals: models.TransferSignals{RecipientActive: true, SenderTransfers: 3},
+			amount:  10,
+			action:  models.FraudActionHold,
+			rules:   []string{models.FraudRuleVelocity},
+		},
+		{
+			name:    "current amount counts toward volume",
+			signals: models.TransferSignals{RecipientActive: true, SenderVolume: 450},
+			amount:  51,
+			action:  models.FraudActionHold,
+			rules:   []string{models.FraudRuleVelocity},
+		},
+		{
+			name:    "fan-in ignores old senders",
+			signals: models.TransferSignals{RecipientActive: true, NewSenders: 5},
+			amount:  10,
+			action:  models.FraudActionOff,
+		},
+		{
+			name:    "fan-in from new sender",
+			signals: models.TransferSignals{RecipientActive: true, SenderIsNew: true, NewSenders: 2},
+			amount:  10,
+			action:  models.FraudActionFlag,
+			rules:   []string{models.FraudRuleNewFanIn},
+		},
+		{
+			name:    "strictest action wins",
+			signals: models.TransferSignals{RecipientActive: true, SenderTransfers: 10, SenderIsNew: true, NewSenders: 2, ReverseTransfers: 1},
+			amount:  10,
+			action:  models.FraudActionBlock,
+			rules:   []string{models.FraudRuleVelocity, models.FraudRuleNewFanIn, models.FraudRuleRoundTrip},
+		},
+		{
+			name:    "disabled rule is not reported",
+			policy:  func(p *FraudPolicy) { p.RoundTripAction = models.FraudActionOff },
+			signals: models.TransferSignals{RecipientActive: true, ReverseTransfers: 1},
+			amount:  10,
+			action:  models.FraudActionOff,
+		},
+		{
+			name:    "rules disabled",
+			policy:  func(p *FraudPolicy) { p.Enabled = false },
+			signals: models.TransferSignals{SenderTransfers: 100},
+			amount:  10,
+			action:  models.FraudActionOff,
+		},
+		{
+			name:    "inactive recipient",
+			signals: models.TransferSignals{RecipientActive: false},
+			amount:  10,
+			action:  models.FraudActionOff,
+			err:     e.ErrInvalidUser,
+		},
+	}
+
+	for _, tt := range tests {
+		p := policy
+		if tt.policy != nil {
+			tt.policy(&p)
+		}
+		detector := NewFraudDetector(&fakeFraudRepo{signals: tt.signals}, p, testLogger())
+
+		decision, err := detector.Evaluate(context.Background(), nil, "alice", "bob", tt.amount)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%s: Evaluate() error = %v, want %v", tt.name, err, tt.err)
+			continue
+		}
+		if decision.Action != tt.action || !slices.Equal(decision.Rules, tt.rules) {
+			t.Errorf("%s: Evaluate() = %s %v, want %s %v", tt.name, decision.Action, decision.Rules, tt.action, tt.rules)
+		}
+	}
+}
+
+func TestSendCoinVelocityUnderConcurrency(t *testing.T) {
+	pool := testPool(t)
+	logger := testLogger()
+	ctx := testContext(t)
+
+	const maxTransfers, attempts = 3, 10
+	fraudRepo := r.NewFraudRepository(pool, logger)
+	detector := NewFraudDetector(fraudRepo, FraudPolicy{
+		Enabled:              true,
+		VelocityWindow:       time.Hour,
+		VelocityMaxTransfers: maxTransfers,
+		VelocityMaxAmount:    1_000_000,
+		VelocityAction:       models.FraudActionBlock,
+		FanInAction:          models.FraudActionOff,
+		RoundTripAction:      models.FraudActionOff,
+	}, logger)
+	txExecutor := NewTxExecutor(pool, logger)
+	auditService := NewAuditService(r.NewAuditRepository(pool, logger), txExecutor, testChainKey, logger)
+	service := NewTransactionService(r.NewUserRepository(pool, logger), r.NewTransactionRepository(pool, logger),
+		r.NewOutboxRepository(pool, logger), r.NewNotificationRepository(pool, logger), fraudRepo, r.NewCoinLotRepository(pool, logger),
+		detector, auditService, txExecutor, logger)
+
+	sender := newTestUser(t, pool, "velocity_sender")
+	recipient := newTestUser(t, pool, "velocity_recipient")
+
+	// Переводы одного отправителя сериализуются блокировкой его строки, поэтому правило видит все предыдущие
+	errs := runConcurrently(attempts, func(int) error {
+		_, err := service.SendCoin(ctx, sender, &dto.SendCoin{ToUser: recipient, Amount: 1})
+		return err
+	})
+
+	completed, blocked := 0, 0
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			completed++
+		case errors.Is(err, e.ErrTransferBlocked):
+			blocked++
+		default:
+			t.Errorf("SendCoin() unexpected error: %v", err)
+		}
+	}
+	if completed != maxTransfers || blocked != attempts-maxTransfers {
+		t.Errorf("completed %d and blocked %d transfers, want %d and %d", completed, blocked, maxTransfers, attempts-maxTransfers)
+	}
+
+	var stored int
+	if err := pool.QueryRow(ctx, `SELECT COUNT(*) FROM transactions WHERE from_username = $1`, sender).Scan(&stored); err != nil {
+		t.Fatal(err)
+	}
+	if stored != maxTransfers {
+		t.Errorf("stored %d transfers, want %d", stored, maxTransfers)
+	}
+}
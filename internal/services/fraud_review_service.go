@@ -0,0 +1,159 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+
+	"API-Avito-shop/internal/dto"
+	e "API-Avito-shop/internal/errors"
+	"API-Avito-shop/internal/models"
+	r "API-Avito-shop/internal/repositories"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type FraudReviewService interface {
+	ListReviews(ctx context.Context, query *dto.FraudReviewQuery, limit, offset int) ([]dto.FraudReview, error)
+	GetReview(ctx context.Context, id int64) (dto.FraudReview, error)
+	ApproveReview(ctx context.Context, admin string, id int64, note string) error
+	RejectReview(ctx context.Context, admin string, id int64, note string) error
+}
+
+type DefaultFraudReviewService struct {
+	fraudRepo          r.FraudRepository
+	transactionService TransactionService
+	auditLog           AuditRecorder
+	txExecutor         TxExecutor
+	logger             *slog.Logger
+}
+
+func NewFraudReviewService(fraudRepo r.FraudRepository, transactionService TransactionService, auditLog AuditRecorder, txHelper TxExecutor, logger *slog.Logger) *DefaultFraudReviewService {
+	return &DefaultFraudReviewService{
+		fraudRepo:          fraudRepo,
+		transactionService: transactionService,
+		auditLog:           auditLog,
+		txExecutor:         txHelper,
+		logger:             logger,
+	}
+}
+
+// ListReviews предоставляет очередь проверки переводов, начиная с последних
+func (s *DefaultFraudReviewService) ListReviews(ctx context.Context, query *dto.FraudReviewQuery, limit, offset int) ([]dto.FraudReview, error) {
+	reviews, err := s.fraudRepo.ListReviews(ctx, models.FraudReviewFilter{
+		Status: query.Status,
+		Action: query.Action,
+		User:   query.User,
+	}, limit, offset)
+	if err != nil {
+		s.logger.Error("Failed to list fraud reviews", "error", err)
+		return nil, err
+	}
+
+	result := make([]dto.FraudReview, 0, len(reviews))
+	for _, review := range reviews {
+		result = append(result, toFraudReviewDTO(&review))
+	}
+	return result, nil
+}
+
+// GetReview предоставляет перевод из очереди проверки
+func (s *DefaultFraudReviewService) GetReview(ctx context.Context, id int64) (dto.FraudReview, error) {
+	review, err := s.fraudRepo.GetReview(ctx, id)
+	if err != nil {
+		return dto.FraudReview{}, err
+	}
+	return toFraudReviewDTO(review), nil
+}
+
+// ApproveReview признает перевод законным. Задержанный перевод выполняется в момент одобрения,
+// поэтому одобрение не проходит, если у отправителя уже не хватает монет или получатель отключен.
+func (s *DefaultFraudReviewService) ApproveReview(ctx context.Context, admin string, id int64, note string) error {
+	s.logger.Info("Starting to approve fraud review", "admin", admin, "id", id)
+
+	err := s.resolve(ctx, admin, id, note, models.FraudReviewApproved, models.AuditFraudReviewApproved, func(tx pgx.Tx, review *models.FraudReview) error {
+		if review.Action != models.FraudActionHold {
+			return nil
+		}
+		return s.transactionService.CompleteTransfer(ctx, tx, review.FromUser, review.ToUser, review.Amount)
+	})
+	if err != nil {
+		s.logger.Error("Failed to approve fraud review", "id", id, "error", err)
+		return err
+	}
+
+	s.logger.Info("Fraud review approved", "admin", admin, "id", id)
+	return nil
+}
+
+// RejectReview подтверждает подозрение. Задержанный перевод отменяется, выполненный отмеченный
+// перевод остается в истории, при необходимости учетные записи отключаются отдельно.
+func (s *DefaultFraudReviewService) RejectReview(ctx context.Context, admin string, id int64, note string) error {
+	s.logger.Info("Starting to reject fraud review", "admin", admin, "id", id)
+
+	err := s.resolve(ctx, admin, id, note, models.FraudReviewRejected, models.AuditFraudReviewRejected, func(tx pgx.Tx, review *models.FraudReview) error {
+		return nil
+	})
+	if err != nil {
+		s.logger.Error("Failed to reject fraud review", "id", id, "error", err)
+		return err
+	}
+
+	s.logger.Info("Fraud review rejected", "admin", admin, "id", id)
+	return nil
+}
+
+// resolve блокирует перевод в очереди, проверяет, что решение еще не принято, и сохраняет решение
+// вместе с действием fn в одной транзакции
+func (s *DefaultFraudReviewService) resolve(ctx context.Context, admin string, id int64, note, status, auditAction string, fn func(tx pgx.Tx, review *models.FraudReview) error) error {
+	return s.txExecutor.RunWithTransaction(ctx, func(tx pgx.Tx) error {
+		review, err := s.fraudRepo.GetReviewForUpdate(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if review.Status != models.FraudReviewPending {
+			return e.ErrReviewResolved
+		}
+
+		if err = fn(tx, review); err != nil {
+			return err
+		}
+		if err = s.fraudRepo.ResolveReview(ctx, tx, id, status, admin, note); err != nil {
+			return err
+		}
+
+		return s.auditLog.Record(ctx, tx, models.AuditEntry{
+			Actor:  admin,
+			Action: auditAction,
+			Target: review.FromUser,
+			Amount: &review.Amount,
+			Details: auditDetails(map[string]any{
+				"review": review.ID,
+				"to":     review.ToUser,
+				"action": review.Action,
+				"note":   note,
+			}),
+		})
+	})
+}
+
+// toFraudReviewDTO преобразует перевод из очереди проверки в DTO
+func toFraudReviewDTO(review *models.FraudReview) dto.FraudReview {
+	result := dto.FraudReview{
+		ID:         review.ID,
+		FromUser:   review.FromUser,
+		ToUser:     review.ToUser,
+		Amount:     review.Amount,
+		Action:     review.Action,
+		Rules:      review.Rules,
+		Status:     review.Status,
+		CreatedAt:  review.CreatedAt,
+		ReviewedAt: review.ReviewedAt,
+	}
+	if review.ReviewedBy != nil {
+		result.ReviewedBy = *review.ReviewedBy
+	}
+	if review.ReviewNote != nil {
+		result.Note = *review.ReviewNote
+	}
+	return result
+}
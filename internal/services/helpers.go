@@ -0,0 +1,84 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"time"
+
+	e "API-Avito-shop/internal/errors"
+	"API-Avito-shop/internal/models"
+	r "API-Avito-shop/internal/repositories"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// maxPeriodDays наибольшая длина периода выписок и отчетов
+const maxPeriodDays = 366
+
+// randomToken генерирует криптографически стойкую случайную строку в hex-кодировке
+func randomToken(size int) (string, error) {
+	buf := make([]byte, size)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate random token: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// hashToken вычисляет хеш случайного токена для хранения и поиска.
+// Токены имеют высокую энтропию, поэтому медленный хеш паролей не нужен.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// exponentialBackoff вычисляет задержку перед повторной попыткой с экспоненциальным ростом
+func exponentialBackoff(base, limit time.Duration, attempts int) time.Duration {
+	delay := base
+	for i := 0; i < attempts && delay < limit; i++ {
+		delay *= 2
+	}
+	return min(delay, limit)
+}
+
+// datePeriod возвращает границы периода [from, to) по датам запроса, обе даты включаются.
+// По умолчанию период начинается с первого числа текущего месяца и заканчивается сегодняшним днем.
+func datePeriod(fromDate, toDate *time.Time, now time.Time) (time.Time, time.Time, error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	from := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, time.UTC)
+	if fromDate != nil {
+		from = fromDate.UTC()
+	}
+	last := today
+	if toDate != nil {
+		last = toDate.UTC()
+	}
+
+	if last.Before(from) || last.Sub(from) >= maxPeriodDays*24*time.Hour {
+		return time.Time{}, time.Time{}, e.ErrInvalidPeriod
+	}
+
+	return from, last.AddDate(0, 0, 1), nil
+}
+
+// lockUsers блокирует строки пользователей до конца транзакции в порядке имен,
+// чтобы встречные операции одних и тех же пользователей не приводили к взаимной блокировке
+func lockUsers(ctx context.Context, tx pgx.Tx, userRepo r.UserRepository, usernames ...string) (map[string]*models.User, error) {
+	ordered := slices.Clone(usernames)
+	slices.Sort(ordered)
+	ordered = slices.Compact(ordered)
+
+	users := make(map[string]*models.User, len(ordered))
+	for _, username := range ordered {
+		user, err := userRepo.GetUserForUpdate(ctx, tx, username)
+		if err != nil {
+			return nil, err
+		}
+		users[username] = user
+	}
+	return users, nil
+}
@@ -0,0 +1,46 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	e "API-Avito-shop/internal/errors"
+)
+
+func TestDatePeriod(t *testing.T) {
+	date := func(year int, month time.Month, day int) *time.Time {
+		d := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+		return &d
+	}
+	now := time.Date(2026, 10, 19, 15, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		from, to *time.Time
+		wantFrom time.Time
+		wantTo   time.Time
+		err      error
+	}{
+		{"current month by default", nil, nil, *date(2026, 10, 1), *date(2026, 10, 20), nil},
+		{"from only", date(2026, 9, 15), nil, *date(2026, 9, 15), *date(2026, 10, 20), nil},
+		{"to only", nil, date(2026, 10, 10), *date(2026, 10, 1), *date(2026, 10, 11), nil},
+		{"single day", date(2026, 5, 5), date(2026, 5, 5), *date(2026, 5, 5), *date(2026, 5, 6), nil},
+		{"to includes the last day", date(2026, 1, 1), date(2026, 1, 31), *date(2026, 1, 1), *date(2026, 2, 1), nil},
+		{"longest period", date(2025, 1, 1), date(2026, 1, 1), *date(2025, 1, 1), *date(2026, 1, 2), nil},
+		{"too long", date(2025, 1, 1), date(2026, 1, 2), time.Time{}, time.Time{}, e.ErrInvalidPeriod},
+		{"reversed", date(2026, 5, 6), date(2026, 5, 5), time.Time{}, time.Time{}, e.ErrInvalidPeriod},
+		{"to before default from", nil, date(2026, 9, 30), time.Time{}, time.Time{}, e.ErrInvalidPeriod},
+	}
+
+	for _, tt := range tests {
+		from, to, err := datePeriod(tt.from, tt.to, now)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%s: datePeriod() error = %v, want %v", tt.name, err, tt.err)
+			continue
+		}
+		if !from.Equal(tt.wantFrom) || !to.Equal(tt.wantTo) {
+			t.Errorf("%s: datePeriod() = [%s, %s), want [%s, %s)", tt.name, from, to, tt.wantFrom, tt.wantTo)
+		}
+	}
+}
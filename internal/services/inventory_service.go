@@ -0,0 +1,396 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"API-Avito-shop/internal/dto"
+	e "API-Avito-shop/internal/errors"
+	"API-Avito-shop/internal/models"
+	r "API-Avito-shop/internal/repositories"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type InventoryService interface {
+	GiveItems(ctx context.Context, username string, give *dto.GiveItems) error
+	ListTransfers(ctx context.Context, username string, limit, offset int) ([]dto.ItemTransfer, error)
+	ProposeTrade(ctx context.Context, username string, propose *dto.ProposeTrade) (dto.Trade, error)
+	ListTrades(ctx context.Context, username string, query *dto.TradeQuery, limit, offset int) ([]dto.Trade, error)
+	GetTrade(ctx context.Context, username string, id int64) (dto.Trade, error)
+	AcceptTrade(ctx context.Context, username string, id int64) error
+	DeclineTrade(ctx context.Context, username string, id int64) error
+	CancelTrade(ctx context.Context, username string, id int64) error
+}
+
+type DefaultInventoryService struct {
+	inventoryRepo    r.InventoryRepository
+	userRepo         r.UserRepository
+	shopRepo         r.ShopRepository
+	notificationRepo r.NotificationRepository
+	auditLog         AuditRecorder
+	txExecutor       TxExecutor
+	logger           *slog.Logger
+}
+
+func NewInventoryService(inventoryRepo r.InventoryRepository, userRepo r.UserRepository, shopRepo r.ShopRepository, notificationRepo r.NotificationRepository, auditLog AuditRecorder, txHelper TxExecutor, logger *slog.Logger) *DefaultInventoryService {
+	return &DefaultInventoryService{
+		inventoryRepo:    inventoryRepo,
+		userRepo:         userRepo,
+		shopRepo:         shopRepo,
+		notificationRepo: notificationRepo,
+		auditLog:         auditLog,
+		txExecutor:       txHelper,
+		logger:           logger,
+	}
+}
+
+// GiveItems передает экземпляры товара из инвентаря пользователя другому пользователю
+func (s *DefaultInventoryService) GiveItems(ctx context.Context, username string, give *dto.GiveItems) error {
+	s.logger.Info("Starting to give items", "from_user", username, "to_user", give.ToUser, "item", give.Item)
+
+	if give.ToUser == username {
+		s.logger.Warn("Sender matches recipient", "from_user", username)
+		return e.ErrInvalidUser
+	}
+	quantity := max(give.Quantity, 1)
+
+	err := s.txExecutor.RunWithTransaction(ctx, func(tx pgx.Tx) error {
+		if err := s.lockParticipants(ctx, tx, username, give.ToUser); err != nil {
+			return err
+		}
+		if err := s.inventoryRepo.MoveItems(ctx, tx, username, give.ToUser, give.Item, quantity, nil); err != nil {
+			return err
+		}
+
+		err := s.notificationRepo.Notify(ctx, tx, give.ToUser, models.NotificationItemsReceived, models.ItemsReceivedData{
+			FromUser: username,
+			Item:     give.Item,
+			Quantity: quantity,
+		})
+		if err != nil {
+			return err
+		}
+
+		return s.auditLog.Record(ctx, tx, models.AuditEntry{
+			Actor:   username,
+			Action:  models.AuditItemsGiven,
+			Target:  give.ToUser,
+			Amount:  &quantity,
+			Details: auditDetails(map[string]any{"item": give.Item}),
+		})
+	})
+	if err != nil {
+		s.logger.Error("Failed to give items", "from_user", username, "to_user", give.ToUser, "error", err)
+		return err
+	}
+
+	s.logger.Info("Items given successfully", "from_user", username, "to_user", give.ToUser, "item", give.Item)
+	return nil
+}
+
+// ListTransfers предоставляет переданные и полученные пользователем товары, начиная с последних
+func (s *DefaultInventoryService) ListTransfers(ctx context.Context, username string, limit, offset int) ([]dto.ItemTransfer, error) {
+	transfers, err := s.inventoryRepo.ListTransfers(ctx, username, limit, offset)
+	if err != nil {
+		s.logger.Error("Failed to list item transfers", "username", username, "error", err)
+		return nil, err
+	}
+
+	result := make([]dto.ItemTransfer, 0, len(transfers))
+	for _, transfer := range transfers {
+		result = append(result, dto.ItemTransfer{
+			FromUser:  transfer.FromUser,
+			ToUser:    transfer.ToUser,
+			Item:      transfer.Item,
+			Quantity:  transfer.Quantity,
+			TradeID:   transfer.TradeID,
+			CreatedAt: transfer.CreatedAt,
+		})
+	}
+	return result, nil
+}
+
+// ProposeTrade создает предложение обмена. Товары не резервируются: наличие у инициатора проверяется сейчас,
+// а у обеих сторон — еще раз в момент принятия.
+func (s *DefaultInventoryService) ProposeTrade(ctx context.Context, username string, propose *dto.ProposeTrade) (dto.Trade, error) {
+	s.logger.Info("Starting to propose trade", "proposer", username, "recipient", propose.ToUser)
+
+	if propose.ToUser == username {
+		s.logger.Warn("Proposer matches recipient", "proposer", username)
+		return dto.Trade{}, e.ErrInvalidUser
+	}
+
+	trade := models.Trade{
+		Proposer:  username,
+		Recipient: propose.ToUser,
+		Message:   propose.Message,
+		Offer:     toItemQuantities(propose.Offer),
+		Request:   toItemQuantities(propose.Request),
+	}
+	for _, item := range append(trade.Offer, trade.Request...) {
+		if err := checkItem(ctx, s.shopRepo, item.Item); err != nil {
+			return dto.Trade{}, err
+		}
+	}
+
+	err := s.txExecutor.RunWithTransaction(ctx, func(tx pgx.Tx) error {
+		if err := s.lockParticipants(ctx, tx, username, trade.Recipient); err != nil {
+			return err
+		}
+
+		inventory, err := s.shopRepo.GetPurchases(ctx, tx, username)
+		if err != nil {
+			return err
+		}
+		if !hasItems(inventory, trade.Offer) {
+			return e.ErrNotEnoughItems
+		}
+
+		if _, err = s.inventoryRepo.CreateTrade(ctx, tx, &trade); err != nil {
+			return err
+		}
+
+		err = s.notificationRepo.Notify(ctx, tx, trade.Recipient, models.NotificationTradeProposed, models.TradeProposedData{
+			TradeID:  trade.ID,
+			FromUser: username,
+		})
+		if err != nil {
+			return err
+		}
+
+		return s.auditTrade(ctx, tx, username, trade.Recipient, models.AuditTradeProposed, &trade)
+	})
+	if err != nil {
+		s.logger.Error("Failed to propose trade", "proposer", username, "recipient", propose.ToUser, "error", err)
+		return dto.Trade{}, err
+	}
+
+	s.logger.Info("Trade proposed", "proposer", username, "recipient", propose.ToUser, "id", trade.ID)
+	return toTradeDTO(&trade), nil
+}
+
+// ListTrades предоставляет обмены, в которых участвует пользователь, начиная с последних
+func (s *DefaultInventoryService) ListTrades(ctx context.Context, username string, query *dto.TradeQuery, limit, offset int) ([]dto.Trade, error) {
+	trades, err := s.inventoryRepo.ListTrades(ctx, models.TradeFilter{Username: username, Status: query.Status}, limit, offset)
+	if err != nil {
+		s.logger.Error("Failed to list trades", "username", username, "error", err)
+		return nil, err
+	}
+
+	result := make([]dto.Trade, 0, len(trades))
+	for _, trade := range trades {
+		result = append(result, toTradeDTO(&trade))
+	}
+	return result, nil
+}
+
+// GetTrade предоставляет обмен. Для пользователей, не участвующих в обмене, он не существует.
+func (s *DefaultInventoryService) GetTrade(ctx context.Context, username string, id int64) (dto.Trade, error) {
+	trade, err := s.inventoryRepo.GetTrade(ctx, id)
+	if err != nil {
+		return dto.Trade{}, err
+	}
+	if trade.Proposer != username && trade.Recipient != username {
+		return dto.Trade{}, e.ErrTradeNotFound
+	}
+	return toTradeDTO(trade), nil
+}
+
+// AcceptTrade принимает обмен от имени получателя: экземпляры товаров обеих сторон меняют владельцев
+// в одной транзакции, если у одной из сторон товаров уже не хватает, обмен не выполняется.
+func (s *DefaultInventoryService) AcceptTrade(ctx context.Context, username string, id int64) error {
+	s.logger.Info("Starting to accept trade", "username", username, "id", id)
+
+	err := s.txExecutor.RunWithTransaction(ctx, func(tx pgx.Tx) error {
+		trade, err := s.pendingTrade(ctx, tx, id, username, true)
+		if err != nil {
+			return err
+		}
+		if err = s.lockParticipants(ctx, tx, trade.Proposer, trade.Recipient); err != nil {
+			return err
+		}
+
+		for _, item := range trade.Offer {
+			if err = s.inventoryRepo.MoveItems(ctx, tx, trade.Proposer, trade.Recipient, item.Item, item.Quantity, &trade.ID); err != nil {
+				return err
+			}
+		}
+		for _, item := range trade.Request {
+			if err = s.inventoryRepo.MoveItems(ctx, tx, trade.Recipient, trade.Proposer, item.Item, item.Quantity, &trade.ID); err != nil {
+				return err
+			}
+		}
+
+		if err = s.resolveTrade(ctx, tx, trade, models.TradeAccepted); err != nil {
+			return err
+		}
+
+		return s.auditTrade(ctx, tx, username, trade.Proposer, models.AuditTradeAccepted, trade)
+	})
+	if err != nil {
+		s.logger.Error("Failed to accept trade", "id", id, "error", err)
+		return err
+	}
+
+	s.logger.Info("Trade accepted", "username", username, "id", id)
+	return nil
+}
+
+// DeclineTrade отклоняет обмен от имени получателя
+func (s *DefaultInventoryService) DeclineTrade(ctx context.Context, username string, id int64) error {
+	return s.closeTrade(ctx, username, id, false, models.TradeDeclined, models.AuditTradeDeclined)
+}
+
+// CancelTrade отменяет обмен от имени инициатора
+func (s *DefaultInventoryService) CancelTrade(ctx context.Context, username string, id int64) error {
+	return s.closeTrade(ctx, username, id, true, models.TradeCancelled, models.AuditTradeCancelled)
+}
+
+// closeTrade завершает ожидающий обмен без передачи товаров. Отменить обмен может только инициатор,
+// отклонить — только получатель. Решение записывается в журнал аудита как action.
+func (s *DefaultInventoryService) closeTrade(ctx context.Context, username string, id int64, byProposer bool, status, action string) error {
+	s.logger.Info("Starting to close trade", "username", username, "id", id, "status", status)
+
+	err := s.txExecutor.RunWithTransaction(ctx, func(tx pgx.Tx) error {
+		trade, err := s.pendingTrade(ctx, tx, id, username, !byProposer)
+		if err != nil {
+			return err
+		}
+		if err = s.resolveTrade(ctx, tx, trade, status); err != nil {
+			return err
+		}
+
+		counterparty := trade.Proposer
+		if byProposer {
+			counterparty = trade.Recipient
+		}
+		return s.auditTrade(ctx, tx, username, counterparty, action, trade)
+	})
+	if err != nil {
+		s.logger.Error("Failed to close trade", "id", id, "status", status, "error", err)
+		return err
+	}
+
+	s.logger.Info("Trade closed", "username", username, "id", id, "status", status)
+	return nil
+}
+
+// pendingTrade блокирует обмен и проверяет, что он ожидает решения и что пользователь является
+// получателем (asRecipient) или инициатором. Чужой обмен считается несуществующим.
+func (s *DefaultInventoryService) pendingTrade(ctx context.Context, tx pgx.Tx, id int64, username string, asRecipient bool) (*models.Trade, error) {
+	trade, err := s.inventoryRepo.GetTradeForUpdate(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	participant := trade.Proposer
+	if asRecipient {
+		participant = trade.Recipient
+	}
+	if participant != username {
+		if trade.Proposer == username || trade.Recipient == username {
+			return nil, e.ErrTradeForbidden
+		}
+		return nil, e.ErrTradeNotFound
+	}
+	if trade.Status != models.TradePending {
+		return nil, e.ErrTradeResolved
+	}
+
+	return trade, nil
+}
+
+// resolveTrade сохраняет итоговый статус обмена и уведомляет второго участника
+func (s *DefaultInventoryService) resolveTrade(ctx context.Context, tx pgx.Tx, trade *models.Trade, status string) error {
+	if err := s.inventoryRepo.ResolveTrade(ctx, tx, trade.ID, status, time.Now().UTC()); err != nil {
+		return err
+	}
+
+	notify := trade.Proposer
+	if status == models.TradeCancelled {
+		notify = trade.Recipient
+	}
+	return s.notificationRepo.Notify(ctx, tx, notify, models.NotificationTradeResolved, models.TradeResolvedData{
+		TradeID: trade.ID,
+		Status:  status,
+	})
+}
+
+// lockParticipants блокирует учетные записи участников передачи в порядке имен, чтобы встречные передачи
+// и обмены не приводили к взаимной блокировке. Отключенные, удаленные и сервисные учетные записи не допускаются.
+func (s *DefaultInventoryService) lockParticipants(ctx context.Context, tx pgx.Tx, first, second string) error {
+	users, err := lockUsers(ctx, tx, s.userRepo, first, second)
+	if err != nil {
+		return err
+	}
+
+	for _, user := range users {
+		if user.Role != models.RoleUser || user.Disabled() || user.Deleted() {
+			s.logger.Warn("User cannot take part in item transfers", "username", user.UserName, "role", user.Role)
+			return e.ErrInvalidUser
+		}
+	}
+	return nil
+}
+
+// auditTrade записывает в журнал аудита действие участника с обменом и его состав
+func (s *DefaultInventoryService) auditTrade(ctx context.Context, tx pgx.Tx, actor, target, action string, trade *models.Trade) error {
+	tradeDTO := toTradeDTO(trade)
+	return s.auditLog.Record(ctx, tx, models.AuditEntry{
+		Actor:  actor,
+		Action: action,
+		Target: target,
+		Details: auditDetails(map[string]any{
+			"trade":   trade.ID,
+			"offer":   tradeDTO.Offer,
+			"request": tradeDTO.Request,
+		}),
+	})
+}
+
+// hasItems проверяет, что в инвентаре достаточно экземпляров каждого товара
+func hasItems(inventory []dto.Item, items []models.ItemQuantity) bool {
+	owned := make(map[string]int, len(inventory))
+	for _, item := range inventory {
+		owned[item.Type] = item.Quantity
+	}
+	for _, item := range items {
+		if owned[item.Item] < item.Quantity {
+			return false
+		}
+	}
+	return true
+}
+
+// toItemQuantities преобразует состав стороны обмена из DTO
+func toItemQuantities(items []dto.TradeItem) []models.ItemQuantity {
+	result := make([]models.ItemQuantity, 0, len(items))
+	for _, item := range items {
+		result = append(result, models.ItemQuantity{Item: item.Item, Quantity: item.Quantity})
+	}
+	return result
+}
+
+// toTradeDTO преобразует обмен в DTO
+func toTradeDTO(trade *models.Trade) dto.Trade {
+	result := dto.Trade{
+		ID:         trade.ID,
+		Proposer:   trade.Proposer,
+		Recipient:  trade.Recipient,
+		Message:    trade.Message,
+		Status:     trade.Status,
+		Offer:      make([]dto.TradeItem, 0, len(trade.Offer)),
+		Request:    make([]dto.TradeItem, 0, len(trade.Request)),
+		CreatedAt:  trade.CreatedAt,
+		ResolvedAt: trade.ResolvedAt,
+	}
+	for _, item := range trade.Offer {
+		result.Offer = append(result.Offer, dto.TradeItem{Item: item.Item, Quantity: item.Quantity})
+	}
+	for _, item := range trade.Request {
+		result.Request = append(result.Request, dto.TradeItem{Item: item.Item, Quantity: item.Quantity})
+	}
+	return result
+}
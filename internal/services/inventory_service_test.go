@@ -0,0 +1,154 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"API-Avito-shop/internal/dto"
+	e "API-Avito-shop/internal/errors"
+	"API-Avito-shop/internal/models"
+	r "API-Avito-shop/internal/repositories"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func TestHasItems(t *testing.T) {
+	inventory := []dto.Item{{Type: "cup", Quantity: 2}, {Type: "pen", Quantity: 1}}
+
+	tests := []struct {
+		name  string
+		items []models.ItemQuantity
+		want  bool
+	}{
+		{"nothing", nil, true},
+		{"exact quantity", []models.ItemQuantity{{Item: "cup", Quantity: 2}}, true},
+		{"less than owned", []models.ItemQuantity{{Item: "cup", Quantity: 1}, {Item: "pen", Quantity: 1}}, true},
+		{"more than owned", []models.ItemQuantity{{Item: "cup", Quantity: 3}}, false},
+		{"not owned", []models.ItemQuantity{{Item: "socks", Quantity: 1}}, false},
+		{"one of several missing", []models.ItemQuantity{{Item: "cup", Quantity: 1}, {Item: "pen", Quantity: 2}}, false},
+	}
+
+	for _, tt := range tests {
+		if got := hasItems(inventory, tt.items); got != tt.want {
+			t.Errorf("%s: hasItems() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// newTestInventoryService собирает сервис инвентаря поверх тестовой базы
+func newTestInventoryService(pool *pgxpool.Pool) *DefaultInventoryService {
+	logger := testLogger()
+	txExecutor := NewTxExecutor(pool, logger)
+	auditService := NewAuditService(r.NewAuditRepository(pool, logger), txExecutor, testChainKey, logger)
+	return NewInventoryService(r.NewInventoryRepository(pool, logger), r.NewUserRepository(pool, logger), r.NewShopRepository(pool, logger),
+		r.NewNotificationRepository(pool, logger), auditService, txExecutor, logger)
+}
+
+// countItems возвращает число экземпляров товара в инвентаре пользователя
+func countItems(t *testing.T, pool *pgxpool.Pool, username, item string) int {
+	t.Helper()
+
+	ctx := testContext(t)
+	var count int
+	err := NewTxExecutor(pool, testLogger()).RunWithTransaction(ctx, func(tx pgx.Tx) error {
+		inventory, err := r.NewShopRepository(pool, testLogger()).GetPurchases(ctx, tx, username)
+		for _, owned := range inventory {
+			if owned.Type == item {
+				count = owned.Quantity
+			}
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatalf("get inventory of %s: %v", username, err)
+	}
+	return count
+}
+
+func TestAcceptTradeUnderConcurrency(t *testing.T) {
+	pool := testPool(t)
+	ctx := testContext(t)
+	shopService, _ := newTestShopService(pool)
+	service := newTestInventoryService(pool)
+
+	buy := func(t *testing.T, username, item string) {
+		t.Helper()
+		if err := shopService.BuyProduct(ctx, item, username, ""); err != nil {
+			t.Fatalf("BuyProduct(%s, %s) error = %v", item, username, err)
+		}
+	}
+	propose := func(t *testing.T, proposer, recipient string) int64 {
+		t.Helper()
+		trade, err := service.ProposeTrade(ctx, proposer, &dto.ProposeTrade{
+			ToUser:  recipient,
+			Offer:   []dto.TradeItem{{Item: "cup", Quantity: 1}},
+			Request: []dto.TradeItem{{Item: "pen", Quantity: 1}},
+		})
+		if err != nil {
+			t.Fatalf("ProposeTrade(%s, %s) error = %v", proposer, recipient, err)
+		}
+		return trade.ID
+	}
+
+	t.Run("same item in two trades", func(t *testing.T) {
+		proposer := newTestUser(t, pool, "trade_proposer")
+		recipients := []string{newTestUser(t, pool, "trade_recipient"), newTestUser(t, pool, "trade_recipient")}
+		buy(t, proposer, "cup")
+		ids := make([]int64, len(recipients))
+		for i, recipient := range recipients {
+			buy(t, recipient, "pen")
+			ids[i] = propose(t, proposer, recipient)
+		}
+
+		// Оба обмена отдают одну и ту же кружку: выполниться может только один
+		errs := runConcurrently(len(recipients), func(i int) error {
+			return service.AcceptTrade(ctx, recipients[i], ids[i])
+		})
+
+		accepted, cups := 0, 0
+		for i, err := range errs {
+			switch {
+			case err == nil:
+				accepted++
+			case !errors.Is(err, e.ErrNotEnoughItems):
+				t.Errorf("AcceptTrade() unexpected error: %v", err)
+			}
+			cups += countItems(t, pool, recipients[i], "cup")
+		}
+		if accepted != 1 {
+			t.Errorf("accepted %d trades, want 1", accepted)
+		}
+		if left := countItems(t, pool, proposer, "cup"); cups != 1 || left != 0 {
+			t.Errorf("recipients own %d cups and proposer %d, want 1 and 0", cups, left)
+		}
+	})
+
+	t.Run("same trade accepted twice", func(t *testing.T) {
+		proposer := newTestUser(t, pool, "trade_proposer")
+		recipient := newTestUser(t, pool, "trade_recipient")
+		buy(t, proposer, "cup")
+		buy(t, recipient, "pen")
+		id := propose(t, proposer, recipient)
+
+		errs := runConcurrently(2, func(int) error {
+			return service.AcceptTrade(ctx, recipient, id)
+		})
+
+		accepted := 0
+		for _, err := range errs {
+			switch {
+			case err == nil:
+				accepted++
+			case !errors.Is(err, e.ErrTradeResolved):
+				t.Errorf("AcceptTrade() unexpected error: %v", err)
+			}
+		}
+		if accepted != 1 {
+			t.Errorf("accepted %d times, want 1", accepted)
+		}
+		if got := countItems(t, pool, proposer, "pen"); got != 1 {
+			t.Errorf("proposer owns %d pens, want 1", got)
+		}
+	})
+}
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"API-Avito-shop/internal/models"
+	r "API-Avito-shop/internal/repositories"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// LeaderboardRefresher периодически добавляет новые переводы и покупки к дневным итогам рейтингов.
+// Обрабатываются только строки после сохраненной позиции, поэтому обновление не перечитывает всю историю.
+// Позиция — номер строки в цепочке хешей: номера выдаются после фиксации в порядке возрастания,
+// поэтому строка с меньшим номером не может появиться после уже учтенной.
+type LeaderboardRefresher struct {
+	leaderboardRepo r.LeaderboardRepository
+	txExecutor      TxExecutor
+	interval        time.Duration
+	batchSize       int
+	logger          *slog.Logger
+}
+
+func NewLeaderboardRefresher(leaderboardRepo r.LeaderboardRepository, txHelper TxExecutor, interval time.Duration, batchSize int, logger *slog.Logger) *LeaderboardRefresher {
+	return &LeaderboardRefresher{
+		leaderboardRepo: leaderboardRepo,
+		txExecutor:      txHelper,
+		interval:        interval,
+		batchSize:       batchSize,
+		logger:          logger,
+	}
+}
+
+// Run запускает цикл обновления итогов до отмены контекста
+func (l *LeaderboardRefresher) Run(ctx context.Context) {
+	l.logger.Info("Leaderboard refresher started", "interval", l.interval)
+
+	ticker := time.NewTicker(l.interval)
+	defer ticker.Stop()
+
+	for {
+		l.drain(ctx, models.LeaderboardSourceTransactions, l.leaderboardRepo.ApplyTransactions)
+		l.drain(ctx, models.LeaderboardSourcePurchases, l.leaderboardRepo.ApplyPurchases)
+
+		select {
+		case <-ctx.Done():
+			l.logger.Info("Leaderboard refresher stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// drain добавляет к итогам новые строки источника пачками, пока они не закончатся
+func (l *LeaderboardRefresher) drain(ctx context.Context, source string, apply func(context.Context, pgx.Tx, int64, int) (int64, int, error)) {
+	for ctx.Err() == nil {
+		var processed int
+
+		err := l.txExecutor.RunWithTransaction(ctx, func(tx pgx.Tx) error {
+			lastSeq, err := l.leaderboardRepo.LockWatermark(ctx, tx, source)
+			if err != nil {
+				return err
+			}
+
+			lastSeq, processed, err = apply(ctx, tx, lastSeq, l.batchSize)
+			if err != nil {
+				return err
+			}
+
+			return l.leaderboardRepo.SaveWatermark(ctx, tx, source, lastSeq)
+		})
+		if err != nil {
+			l.logger.Error("Failed to refresh leaderboard", "source", source, "error", err)
+			return
+		}
+		if processed > 0 {
+			l.logger.Info("Leaderboard refreshed", "source", source, "rows", processed)
+		}
+		if processed < l.batchSize {
+			return
+		}
+	}
+}
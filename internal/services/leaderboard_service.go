@@ -0,0 +1,93 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"API-Avito-shop/internal/dto"
+	"API-Avito-shop/internal/models"
+	r "API-Avito-shop/internal/repositories"
+)
+
+// defaultLeaderboardLimit количество мест в рейтинге по умолчанию
+const defaultLeaderboardLimit = 10
+
+type LeaderboardService interface {
+	GetLeaderboard(ctx context.Context, query *dto.LeaderboardQuery) (dto.Leaderboard, error)
+}
+
+type DefaultLeaderboardService struct {
+	leaderboardRepo r.LeaderboardRepository
+	logger          *slog.Logger
+}
+
+func NewLeaderboardService(leaderboardRepo r.LeaderboardRepository, logger *slog.Logger) *DefaultLeaderboardService {
+	return &DefaultLeaderboardService{
+		leaderboardRepo: leaderboardRepo,
+		logger:          logger,
+	}
+}
+
+// GetLeaderboard предоставляет рейтинги получателей, дарителей и покупателей за неделю, месяц или все время.
+// Рейтинги строятся по дневным итогам, которые обновляет LeaderboardRefresher.
+func (s *DefaultLeaderboardService) GetLeaderboard(ctx context.Context, query *dto.LeaderboardQuery) (dto.Leaderboard, error) {
+	period := query.Period
+	if period == "" {
+		period = models.PeriodMonth
+	}
+	limit := query.Limit
+	if limit == 0 {
+		limit = defaultLeaderboardLimit
+	}
+	date := time.Now().UTC()
+	if query.Date != nil {
+		date = query.Date.UTC()
+	}
+
+	result := dto.Leaderboard{Period: period}
+	result.From, result.To = periodBounds(period, date)
+
+	var err error
+	for _, board := range []struct {
+		name  string
+		field *[]dto.LeaderboardEntry
+	}{
+		{models.LeaderboardReceivers, &result.TopReceivers},
+		{models.LeaderboardGivers, &result.TopGivers},
+		{models.LeaderboardSpenders, &result.TopSpenders},
+	} {
+		*board.field, err = s.leaderboardRepo.TopUsers(ctx, board.name, result.From, result.To, limit)
+		if err != nil {
+			s.logger.Error("Failed to get leaderboard", "board", board.name, "period", period, "error", err)
+			return dto.Leaderboard{}, err
+		}
+	}
+
+	result.UpdatedAt, err = s.leaderboardRepo.LastRefresh(ctx)
+	if err != nil {
+		return dto.Leaderboard{}, err
+	}
+
+	return result, nil
+}
+
+// periodBounds возвращает границы [from, to) недели (с понедельника) или календарного месяца, содержащих date.
+// Для всего времени границы не задаются.
+func periodBounds(period string, date time.Time) (*time.Time, *time.Time) {
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+
+	var from, to time.Time
+	switch period {
+	case models.PeriodWeek:
+		from = day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+		to = from.AddDate(0, 0, 7)
+	case models.PeriodMonth:
+		from = time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, time.UTC)
+		to = from.AddDate(0, 1, 0)
+	default:
+		return nil, nil
+	}
+
+	return &from, &to
+}
@@ -0,0 +1,48 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"API-Avito-shop/internal/models"
+)
+
+func TestPeriodBounds(t *testing.T) {
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name     string
+		period   string
+		date     time.Time
+		from, to time.Time
+	}{
+		{"week from monday", models.PeriodWeek, date(2026, 10, 19), date(2026, 10, 19), date(2026, 10, 26)},
+		{"week from sunday", models.PeriodWeek, date(2026, 10, 25), date(2026, 10, 19), date(2026, 10, 26)},
+		{"week across months", models.PeriodWeek, date(2026, 11, 1), date(2026, 10, 26), date(2026, 11, 2)},
+		{"week across years", models.PeriodWeek, date(2027, 1, 1), date(2026, 12, 28), date(2027, 1, 4)},
+		{"week ignores time of day", models.PeriodWeek, time.Date(2026, 10, 21, 23, 59, 59, 0, time.UTC), date(2026, 10, 19), date(2026, 10, 26)},
+		{"month", models.PeriodMonth, date(2026, 10, 19), date(2026, 10, 1), date(2026, 11, 1)},
+		{"month on first day", models.PeriodMonth, date(2026, 10, 1), date(2026, 10, 1), date(2026, 11, 1)},
+		{"december", models.PeriodMonth, date(2026, 12, 31), date(2026, 12, 1), date(2027, 1, 1)},
+		{"leap february", models.PeriodMonth, date(2028, 2, 29), date(2028, 2, 1), date(2028, 3, 1)},
+	}
+
+	for _, tt := range tests {
+		from, to := periodBounds(tt.period, tt.date)
+		if from == nil || to == nil {
+			t.Errorf("%s: periodBounds() returned no bounds", tt.name)
+			continue
+		}
+		if !from.Equal(tt.from) || !to.Equal(tt.to) {
+			t.Errorf("%s: periodBounds() = [%s, %s), want [%s, %s)", tt.name, from, to, tt.from, tt.to)
+		}
+	}
+}
+
+func TestPeriodBoundsAllTime(t *testing.T) {
+	if from, to := periodBounds(models.PeriodAll, time.Now()); from != nil || to != nil {
+		t.Errorf("periodBounds(all) = %v, %v, want no bounds", from, to)
+	}
+}
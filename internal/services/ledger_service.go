@@ -0,0 +1,60 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+
+	"API-Avito-shop/internal/dto"
+	r "API-Avito-shop/internal/repositories"
+)
+
+type LedgerService interface {
+	VerifyLedger(ctx context.Context) (dto.LedgerVerification, error)
+}
+
+// DefaultLedgerService проверяет неизменность истории переводов и покупок
+type DefaultLedgerService struct {
+	transactionRepo r.TransactionRepository
+	shopRepo        r.ShopRepository
+	chainKey        []byte
+	logger          *slog.Logger
+}
+
+func NewLedgerService(transactionRepo r.TransactionRepository, shopRepo r.ShopRepository, chainKey []byte, logger *slog.Logger) *DefaultLedgerService {
+	return &DefaultLedgerService{
+		transactionRepo: transactionRepo,
+		shopRepo:        shopRepo,
+		chainKey:        chainKey,
+		logger:          logger,
+	}
+}
+
+// VerifyLedger проходит цепочки хешей транзакций и покупок и сообщает первую поврежденную строку каждой таблицы
+func (s *DefaultLedgerService) VerifyLedger(ctx context.Context) (dto.LedgerVerification, error) {
+	s.logger.Info("Starting ledger verification")
+
+	var (
+		result dto.LedgerVerification
+		err    error
+	)
+
+	result.Transactions, err = s.transactionRepo.VerifyChain(ctx, s.chainKey)
+	if err != nil {
+		return result, err
+	}
+
+	result.Purchases, err = s.shopRepo.VerifyChain(ctx, s.chainKey)
+	if err != nil {
+		return result, err
+	}
+
+	result.Valid = result.Transactions.Valid && result.Purchases.Valid
+	if !result.Valid {
+		s.logger.Warn("Ledger chain is broken", "transactions_broken_at", result.Transactions.BrokenAt, "purchases_broken_at", result.Purchases.BrokenAt)
+		return result, nil
+	}
+
+	s.logger.Info("Ledger verified", "transactions", result.Transactions.Checked, "purchases", result.Purchases.Checked,
+		"pending", result.Transactions.Pending+result.Purchases.Pending)
+	return result, nil
+}
@@ -0,0 +1,164 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	e "API-Avito-shop/internal/errors"
+	"API-Avito-shop/internal/models"
+	r "API-Avito-shop/internal/repositories"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type LoginGuard interface {
+	CheckLocked(ctx context.Context, username, clientIP string) error
+	RegisterFailure(ctx context.Context, username, clientIP, reason string) error
+	RegisterSuccess(ctx context.Context, username string) error
+	Unlock(ctx context.Context, admin, username, clientIP string) error
+}
+
+// LockoutPolicy задает пороги и длительность блокировок входа
+type LockoutPolicy struct {
+	MaxUserFailures int
+	MaxIPFailures   int
+	FailureWindow   time.Duration
+	BaseDuration    time.Duration
+	MaxDuration     time.Duration
+}
+
+// DefaultLoginGuard защищает вход от перебора паролей, блокируя учетные записи и IP-адреса
+type DefaultLoginGuard struct {
+	loginAttemptRepo r.LoginAttemptRepository
+	outboxRepo       r.OutboxRepository
+	auditLog         AuditRecorder
+	txExecutor       TxExecutor
+	policy           LockoutPolicy
+	logger           *slog.Logger
+}
+
+func NewLoginGuard(loginAttemptRepo r.LoginAttemptRepository, outboxRepo r.OutboxRepository, auditLog AuditRecorder, txHelper TxExecutor, policy LockoutPolicy, logger *slog.Logger) *DefaultLoginGuard {
+	return &DefaultLoginGuard{
+		loginAttemptRepo: loginAttemptRepo,
+		outboxRepo:       outboxRepo,
+		auditLog:         auditLog,
+		txExecutor:       txHelper,
+		policy:           policy,
+		logger:           logger,
+	}
+}
+
+// CheckLocked возвращает LockoutError, если заблокирован IP-адрес или учетная запись
+func (g *DefaultLoginGuard) CheckLocked(ctx context.Context, username, clientIP string) error {
+	for _, target := range []struct{ scope, key string }{
+		{r.LoginScopeIP, clientIP},
+		{r.LoginScopeUser, username},
+	} {
+		remaining, err := g.loginAttemptRepo.GetLockRemaining(ctx, target.scope, target.key)
+		if err != nil {
+			return err
+		}
+		if remaining > 0 {
+			g.logger.Warn("Login attempt while locked", "scope", target.scope, "username", username, "client_ip", clientIP)
+			g.auditLog.RecordStandalone(ctx, models.AuditEntry{
+				Actor:   username,
+				Action:  models.AuditLoginFailed,
+				Target:  username,
+				Details: auditDetails(map[string]any{"reason": "locked", "scope": target.scope}),
+			})
+			return &e.LockoutError{RetryAfter: remaining}
+		}
+	}
+
+	return nil
+}
+
+// RegisterFailure учитывает неудачную попытку, блокирует вход при превышении порога
+// и фиксирует попытку в журнале аудита с указанной причиной
+func (g *DefaultLoginGuard) RegisterFailure(ctx context.Context, username, clientIP, reason string) error {
+	return g.txExecutor.RunWithTransaction(ctx, func(tx pgx.Tx) error {
+		if err := g.registerFailure(ctx, tx, r.LoginScopeUser, username, g.policy.MaxUserFailures); err != nil {
+			return err
+		}
+		if err := g.registerFailure(ctx, tx, r.LoginScopeIP, clientIP, g.policy.MaxIPFailures); err != nil {
+			return err
+		}
+
+		return g.auditLog.Record(ctx, tx, models.AuditEntry{
+			Actor:   username,
+			Action:  models.AuditLoginFailed,
+			Target:  username,
+			Details: auditDetails(map[string]any{"reason": reason}),
+		})
+	})
+}
+
+// RegisterSuccess сбрасывает счетчик неудач учетной записи после успешного входа
+func (g *DefaultLoginGuard) RegisterSuccess(ctx context.Context, username string) error {
+	return g.loginAttemptRepo.Reset(ctx, r.LoginScopeUser, username)
+}
+
+// Unlock снимает блокировку учетной записи по запросу администратора. Если указан clientIP,
+// снимается и блокировка этого адреса: иначе пользователь не сможет войти с него до ее окончания.
+func (g *DefaultLoginGuard) Unlock(ctx context.Context, admin, username, clientIP string) error {
+	g.logger.Info("Starting to unlock account", "admin", admin, "username", username, "client_ip", clientIP)
+
+	if err := g.loginAttemptRepo.Reset(ctx, r.LoginScopeUser, username); err != nil {
+		g.logger.Error("Failed to unlock account", "username", username, "error", err)
+		return err
+	}
+	if clientIP != "" {
+		if err := g.loginAttemptRepo.Reset(ctx, r.LoginScopeIP, clientIP); err != nil {
+			g.logger.Error("Failed to unlock ip", "client_ip", clientIP, "error", err)
+			return err
+		}
+	}
+
+	err := g.txExecutor.RunWithTransaction(ctx, func(tx pgx.Tx) error {
+		err := g.outboxRepo.AddEvent(ctx, tx, models.EventAccountUnlocked, models.AccountUnlockedPayload{
+			Username: username,
+			Admin:    admin,
+			IP:       clientIP,
+		})
+		if err != nil {
+			return err
+		}
+
+		entry := models.AuditEntry{Actor: admin, Action: models.AuditUserUnlocked, Target: username}
+		if clientIP != "" {
+			entry.Details = auditDetails(map[string]any{"ip": clientIP})
+		}
+		return g.auditLog.Record(ctx, tx, entry)
+	})
+	if err != nil {
+		g.logger.Error("Failed to record unlock event", "username", username, "error", err)
+		return err
+	}
+
+	g.logger.Info("Account unlocked successfully", "admin", admin, "username", username)
+	return nil
+}
+
+// registerFailure увеличивает счетчик для области и при достижении порога блокирует вход
+// с экспоненциально растущей длительностью
+func (g *DefaultLoginGuard) registerFailure(ctx context.Context, tx pgx.Tx, scope, key string, maxFailures int) error {
+	failures, lockouts, err := g.loginAttemptRepo.RegisterFailure(ctx, tx, scope, key, g.policy.FailureWindow)
+	if err != nil {
+		return err
+	}
+	if failures < maxFailures {
+		return nil
+	}
+
+	duration := exponentialBackoff(g.policy.BaseDuration, g.policy.MaxDuration, lockouts)
+	if err = g.loginAttemptRepo.Lock(ctx, tx, scope, key, duration); err != nil {
+		return err
+	}
+
+	return g.outboxRepo.AddEvent(ctx, tx, models.EventAccountLocked, models.AccountLockedPayload{
+		Scope:       scope,
+		Key:         key,
+		LockedUntil: time.Now().Add(duration).UTC(),
+	})
+}
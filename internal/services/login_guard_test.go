@@ -0,0 +1,155 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	e "API-Avito-shop/internal/errors"
+	"API-Avito-shop/internal/models"
+	r "API-Avito-shop/internal/repositories"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func TestExponentialBackoff(t *testing.T) {
+	tests := []struct {
+		name     string
+		base     time.Duration
+		limit    time.Duration
+		attempts int
+		want     time.Duration
+	}{
+		{"first lockout", time.Minute, time.Hour, 0, time.Minute},
+		{"second lockout", time.Minute, time.Hour, 1, 2 * time.Minute},
+		{"third lockout", time.Minute, time.Hour, 2, 4 * time.Minute},
+		{"reaches the limit", time.Minute, time.Hour, 6, time.Hour},
+		// Большое число блокировок не переполняет длительность
+		{"many lockouts", time.Minute, time.Hour, 1000, time.Hour},
+		{"base over the limit", 2 * time.Hour, time.Hour, 0, time.Hour},
+	}
+
+	for _, tt := range tests {
+		if got := exponentialBackoff(tt.base, tt.limit, tt.attempts); got != tt.want {
+			t.Errorf("%s: exponentialBackoff(%s, %s, %d) = %s, want %s", tt.name, tt.base, tt.limit, tt.attempts, got, tt.want)
+		}
+	}
+}
+
+// fakeLoginAttemptRepo хранит счетчики неудач в памяти, блокировка действует до Reset
+type fakeLoginAttemptRepo struct {
+	failures map[string]int
+	lockouts map[string]int
+	locks    map[string]time.Duration
+}
+
+func newFakeLoginAttemptRepo() *fakeLoginAttemptRepo {
+	return &fakeLoginAttemptRepo{failures: map[string]int{}, lockouts: map[string]int{}, locks: map[string]time.Duration{}}
+}
+
+func (f *fakeLoginAttemptRepo) GetLockRemaining(_ context.Context, scope, key string) (time.Duration, error) {
+	return f.locks[scope+":"+key], nil
+}
+
+func (f *fakeLoginAttemptRepo) RegisterFailure(_ context.Context, _ pgx.Tx, scope, key string, _ time.Duration) (int, int, error) {
+	f.failures[scope+":"+key]++
+	return f.failures[scope+":"+key], f.lockouts[scope+":"+key], nil
+}
+
+func (f *fakeLoginAttemptRepo) Lock(_ context.Context, _ pgx.Tx, scope, key string, duration time.Duration) error {
+	f.failures[scope+":"+key] = 0
+	f.lockouts[scope+":"+key]++
+	f.locks[scope+":"+key] = duration
+	return nil
+}
+
+func (f *fakeLoginAttemptRepo) Reset(_ context.Context, scope, key string) error {
+	delete(f.failures, scope+":"+key)
+	delete(f.lockouts, scope+":"+key)
+	delete(f.locks, scope+":"+key)
+	return nil
+}
+
+func newTestLoginGuard() (*DefaultLoginGuard, *fakeLoginAttemptRepo, *fakeOutboxRepo) {
+	attempts := newFakeLoginAttemptRepo()
+	outbox := &fakeOutboxRepo{}
+	guard := NewLoginGuard(attempts, outbox, &fakeAuditRecorder{}, fakeTxExecutor{}, LockoutPolicy{
+		MaxUserFailures: 3,
+		MaxIPFailures:   5,
+		FailureWindow:   15 * time.Minute,
+		BaseDuration:    time.Minute,
+		MaxDuration:     10 * time.Minute,
+	}, testLogger())
+	return guard, attempts, outbox
+}
+
+func TestLoginGuardLocksAccount(t *testing.T) {
+	ctx := context.Background()
+	guard, attempts, outbox := newTestLoginGuard()
+
+	fail := func(times int, username, ip string) {
+		t.Helper()
+		for i := 0; i < times; i++ {
+			if err := guard.RegisterFailure(ctx, username, ip, "invalid_password"); err != nil {
+				t.Fatalf("RegisterFailure() error = %v", err)
+			}
+		}
+	}
+
+	fail(2, "alice", "10.0.0.1")
+	if err := guard.CheckLocked(ctx, "alice", "10.0.0.1"); err != nil {
+		t.Errorf("CheckLocked() below the threshold = %v, want nil", err)
+	}
+
+	fail(1, "alice", "10.0.0.1")
+	var lockout *e.LockoutError
+	if err := guard.CheckLocked(ctx, "alice", "10.0.0.2"); !errors.As(err, &lockout) || lockout.RetryAfter != time.Minute {
+		t.Errorf("CheckLocked() after 3 failures = %v, want lockout for 1m", err)
+	}
+	if len(outbox.events) != 1 || outbox.events[0].eventType != models.EventAccountLocked ||
+		outbox.events[0].payload.(models.AccountLockedPayload).Scope != r.LoginScopeUser {
+		t.Errorf("events = %+v, want one AccountLocked for the user", outbox.events)
+	}
+
+	// Каждая следующая блокировка вдвое длиннее предыдущей, но не длиннее MaxDuration
+	for _, want := range []time.Duration{2 * time.Minute, 4 * time.Minute, 8 * time.Minute, 10 * time.Minute, 10 * time.Minute} {
+		fail(3, "alice", "10.0.0.3")
+		if got := attempts.locks[r.LoginScopeUser+":alice"]; got != want {
+			t.Errorf("lockout %d lasts %s, want %s", attempts.lockouts[r.LoginScopeUser+":alice"], got, want)
+		}
+	}
+
+	if err := guard.RegisterSuccess(ctx, "alice"); err != nil {
+		t.Fatal(err)
+	}
+	if err := guard.CheckLocked(ctx, "alice", "10.0.0.4"); err != nil {
+		t.Errorf("CheckLocked() after reset = %v, want nil", err)
+	}
+}
+
+func TestLoginGuardLocksIP(t *testing.T) {
+	ctx := context.Background()
+	guard, _, _ := newTestLoginGuard()
+
+	// Перебор разных учетных записей с одного адреса блокирует адрес, но не учетные записи
+	for _, username := range []string{"u1", "u2", "u3", "u4", "u5"} {
+		if err := guard.RegisterFailure(ctx, username, "10.0.0.1", "invalid_password"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := guard.CheckLocked(ctx, "u1", "10.0.0.1"); !errors.Is(err, e.ErrAccountLocked) {
+		t.Errorf("CheckLocked() from the address = %v, want ErrAccountLocked", err)
+	}
+	if err := guard.CheckLocked(ctx, "u1", "10.0.0.2"); err != nil {
+		t.Errorf("CheckLocked() from another address = %v, want nil", err)
+	}
+
+	// Разблокировка с адресом снимает и блокировку адреса
+	if err := guard.Unlock(ctx, "admin", "u1", "10.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := guard.CheckLocked(ctx, "u1", "10.0.0.1"); err != nil {
+		t.Errorf("CheckLocked() after unlock = %v, want nil", err)
+	}
+}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"log/slog"
+	"sync"
+
+	"API-Avito-shop/internal/models"
+)
+
+// NotificationHub рассылает уведомления подключенным к этому экземпляру клиентам
+type NotificationHub struct {
+	mu          sync.RWMutex
+	subscribers map[string]map[chan models.Notification]struct{}
+	closed      bool
+	bufferSize  int
+	logger      *slog.Logger
+}
+
+func NewNotificationHub(bufferSize int, logger *slog.Logger) *NotificationHub {
+	return &NotificationHub{
+		subscribers: make(map[string]map[chan models.Notification]struct{}),
+		bufferSize:  bufferSize,
+		logger:      logger,
+	}
+}
+
+// Subscribe регистрирует получателя уведомлений пользователя и возвращает функцию отписки
+func (h *NotificationHub) Subscribe(username string) (<-chan models.Notification, func()) {
+	ch := make(chan models.Notification, h.bufferSize)
+
+	h.mu.Lock()
+	if h.closed {
+		h.mu.Unlock()
+		close(ch)
+		return ch, func() {}
+	}
+	if h.subscribers[username] == nil {
+		h.subscribers[username] = make(map[chan models.Notification]struct{})
+	}
+	h.subscribers[username][ch] = struct{}{}
+	h.mu.Unlock()
+
+	h.logger.Info("Notification subscriber connected", "username", username)
+
+	unsubscribe := func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+
+		if _, ok := h.subscribers[username][ch]; !ok {
+			return
+		}
+		delete(h.subscribers[username], ch)
+		if len(h.subscribers[username]) == 0 {
+			delete(h.subscribers, username)
+		}
+		close(ch)
+
+		h.logger.Info("Notification subscriber disconnected", "username", username)
+	}
+
+	return ch, unsubscribe
+}
+
+// Dispatch передает уведомление всем подписчикам пользователя, медленные получатели пропускают сообщения
+func (h *NotificationHub) Dispatch(notification models.Notification) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for ch := range h.subscribers[notification.Username] {
+		select {
+		case ch <- notification:
+		default:
+			h.logger.Warn("Notification dropped for slow subscriber", "username", notification.Username, "type", notification.Type)
+		}
+	}
+}
+
+// Close отключает всех подписчиков, используется при остановке сервера
+func (h *NotificationHub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, channels := range h.subscribers {
+		for ch := range channels {
+			close(ch)
+		}
+	}
+	h.subscribers = make(map[string]map[chan models.Notification]struct{})
+	h.closed = true
+}
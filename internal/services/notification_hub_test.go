@@ -0,0 +1,98 @@
+package services
+
+import (
+	"testing"
+
+	"API-Avito-shop/internal/models"
+)
+
+// drainNotifications возвращает уведомления, уже находящиеся в канале, и сообщает, закрыт ли он
+func drainNotifications(ch <-chan models.Notification) ([]models.Notification, bool) {
+	var result []models.Notification
+	for {
+		select {
+		case notification, ok := <-ch:
+			if !ok {
+				return result, true
+			}
+			result = append(result, notification)
+		default:
+			return result, false
+		}
+	}
+}
+
+func TestNotificationHubDispatch(t *testing.T) {
+	hub := NewNotificationHub(4, testLogger())
+	alice1, unsubscribe1 := hub.Subscribe("alice")
+	alice2, unsubscribe2 := hub.Subscribe("alice")
+	bob, unsubscribeBob := hub.Subscribe("bob")
+	defer unsubscribe1()
+	defer unsubscribe2()
+	defer unsubscribeBob()
+
+	hub.Dispatch(models.Notification{Username: "alice", Type: models.NotificationBalanceChanged})
+	hub.Dispatch(models.Notification{Username: "carol", Type: models.NotificationBalanceChanged})
+
+	// Уведомление получают все подключения пользователя и только они
+	for name, ch := range map[string]<-chan models.Notification{"alice 1": alice1, "alice 2": alice2} {
+		if got, _ := drainNotifications(ch); len(got) != 1 || got[0].Username != "alice" {
+			t.Errorf("%s received %v, want one notification for alice", name, got)
+		}
+	}
+	if got, _ := drainNotifications(bob); len(got) != 0 {
+		t.Errorf("bob received %v, want nothing", got)
+	}
+}
+
+func TestNotificationHubSlowSubscriber(t *testing.T) {
+	const bufferSize = 2
+	hub := NewNotificationHub(bufferSize, testLogger())
+	ch, unsubscribe := hub.Subscribe("alice")
+	defer unsubscribe()
+
+	// Переполненный буфер не блокирует отправителя, лишние уведомления отбрасываются
+	for i := 0; i < bufferSize+3; i++ {
+		hub.Dispatch(models.Notification{Username: "alice", Type: models.NotificationBalanceChanged})
+	}
+	if got, _ := drainNotifications(ch); len(got) != bufferSize {
+		t.Errorf("received %d notifications, want %d", len(got), bufferSize)
+	}
+}
+
+func TestNotificationHubUnsubscribe(t *testing.T) {
+	hub := NewNotificationHub(1, testLogger())
+	ch, unsubscribe := hub.Subscribe("alice")
+	other, unsubscribeOther := hub.Subscribe("alice")
+	defer unsubscribeOther()
+
+	unsubscribe()
+	unsubscribe()
+	if _, closed := drainNotifications(ch); !closed {
+		t.Error("channel is open after unsubscribe")
+	}
+
+	hub.Dispatch(models.Notification{Username: "alice"})
+	if got, closed := drainNotifications(other); closed || len(got) != 1 {
+		t.Errorf("remaining subscriber received %v (closed %v), want one notification", got, closed)
+	}
+}
+
+func TestNotificationHubClose(t *testing.T) {
+	hub := NewNotificationHub(1, testLogger())
+	ch, unsubscribe := hub.Subscribe("alice")
+
+	hub.Close()
+	if _, closed := drainNotifications(ch); !closed {
+		t.Error("channel is open after Close")
+	}
+	// Отписка после остановки не закрывает канал повторно
+	unsubscribe()
+
+	late, unsubscribeLate := hub.Subscribe("alice")
+	defer unsubscribeLate()
+	if _, closed := drainNotifications(late); !closed {
+		t.Error("subscription after Close is open")
+	}
+	hub.Dispatch(models.Notification{Username: "alice"})
+}
@@ -0,0 +1,48 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	r "API-Avito-shop/internal/repositories"
+)
+
+// NotificationListener получает уведомления через Postgres LISTEN и передает их в локальный хаб,
+// благодаря чему клиенты получают события независимо от того, какая реплика их обработала
+type NotificationListener struct {
+	notificationRepo r.NotificationRepository
+	hub              *NotificationHub
+	reconnectDelay   time.Duration
+	logger           *slog.Logger
+}
+
+func NewNotificationListener(notificationRepo r.NotificationRepository, hub *NotificationHub, reconnectDelay time.Duration, logger *slog.Logger) *NotificationListener {
+	return &NotificationListener{
+		notificationRepo: notificationRepo,
+		hub:              hub,
+		reconnectDelay:   reconnectDelay,
+		logger:           logger,
+	}
+}
+
+// Run слушает канал уведомлений, переподключаясь при ошибках, до отмены контекста
+func (l *NotificationListener) Run(ctx context.Context) {
+	l.logger.Info("Notification listener started")
+
+	for {
+		err := l.notificationRepo.Listen(ctx, l.hub.Dispatch)
+		if ctx.Err() != nil {
+			l.logger.Info("Notification listener stopped")
+			return
+		}
+		l.logger.Error("Notification listener disconnected", "retry_after", l.reconnectDelay, "error", err)
+
+		select {
+		case <-ctx.Done():
+			l.logger.Info("Notification listener stopped")
+			return
+		case <-time.After(l.reconnectDelay):
+		}
+	}
+}
@@ -0,0 +1,273 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	e "API-Avito-shop/internal/errors"
+	"API-Avito-shop/internal/models"
+	"API-Avito-shop/internal/oidc"
+	r "API-Avito-shop/internal/repositories"
+	"API-Avito-shop/internal/utils/validation"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type IdentityProvider interface {
+	AuthCodeURL(ctx context.Context, state, nonce, codeVerifier string) (string, error)
+	Exchange(ctx context.Context, code, codeVerifier, nonce string) (oidc.Claims, error)
+	Issuer() string
+}
+
+type OIDCService interface {
+	BeginLogin(ctx context.Context) (string, string, error)
+	BeginLink(ctx context.Context, username string) (string, string, error)
+	CompleteLogin(ctx context.Context, state, code string) (*models.User, error)
+}
+
+// IdentityMapping задает правила получения username из claims провайдера
+type IdentityMapping struct {
+	// UsernameClaim имя claim с username, например preferred_username или email
+	UsernameClaim string
+	// StripDomain отбрасывает часть после @, если claim содержит адрес почты из AllowedDomains
+	StripDomain bool
+	// AllowedDomains домены почты, из адресов которых допускается получать username
+	AllowedDomains []string
+}
+
+type DefaultOIDCService struct {
+	provider       IdentityProvider
+	oidcRepo       r.OIDCRepository
+	userRepo       r.UserRepository
+	outboxRepo     r.OutboxRepository
+	auditLog       AuditRecorder
+	txExecutor     TxExecutor
+	passwordHasher PasswordHasher
+	mapping        IdentityMapping
+	stateTTL       time.Duration
+	logger         *slog.Logger
+}
+
+func NewOIDCService(provider IdentityProvider, oidcRepo r.OIDCRepository, userRepo r.UserRepository, outboxRepo r.OutboxRepository, auditLog AuditRecorder, txHelper TxExecutor, passwordHasher PasswordHasher, mapping IdentityMapping, stateTTL time.Duration, logger *slog.Logger) *DefaultOIDCService {
+	return &DefaultOIDCService{
+		provider:       provider,
+		oidcRepo:       oidcRepo,
+		userRepo:       userRepo,
+		outboxRepo:     outboxRepo,
+		auditLog:       auditLog,
+		txExecutor:     txHelper,
+		passwordHasher: passwordHasher,
+		mapping:        mapping,
+		stateTTL:       stateTTL,
+		logger:         logger,
+	}
+}
+
+// BeginLogin сохраняет state, nonce и PKCE verifier и возвращает state и адрес страницы входа провайдера
+func (s *DefaultOIDCService) BeginLogin(ctx context.Context) (string, string, error) {
+	return s.begin(ctx, "")
+}
+
+// BeginLink начинает вход, по завершении которого учетная запись провайдера связывается с пользователем username
+func (s *DefaultOIDCService) BeginLink(ctx context.Context, username string) (string, string, error) {
+	s.logger.Info("Starting SSO identity linking", "username", username)
+	return s.begin(ctx, username)
+}
+
+func (s *DefaultOIDCService) begin(ctx context.Context, linkUsername string) (string, string, error) {
+	state, err := randomToken(16)
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err := randomToken(16)
+	if err != nil {
+		return "", "", err
+	}
+	codeVerifier, err := randomToken(32)
+	if err != nil {
+		return "", "", err
+	}
+
+	if err = s.oidcRepo.SaveState(ctx, state, nonce, codeVerifier, linkUsername, s.stateTTL); err != nil {
+		return "", "", err
+	}
+
+	authURL, err := s.provider.AuthCodeURL(ctx, state, nonce, codeVerifier)
+	if err != nil {
+		s.logger.Error("Failed to build authorization url", "error", err)
+		return "", "", err
+	}
+
+	return state, authURL, nil
+}
+
+// CompleteLogin обменивает код на ID token и возвращает связанного пользователя.
+// При первом входе пользователь создается, существующие пользователи связываются только через BeginLink.
+func (s *DefaultOIDCService) CompleteLogin(ctx context.Context, state, code string) (*models.User, error) {
+	loginState, err := s.oidcRepo.ConsumeState(ctx, state)
+	if err != nil {
+		return nil, err
+	}
+
+	claims, err := s.provider.Exchange(ctx, code, loginState.CodeVerifier, loginState.Nonce)
+	if err != nil {
+		s.logger.Warn("Failed to complete OIDC login", "error", err)
+		return nil, err
+	}
+
+	issuer, subject := s.provider.Issuer(), claims.Subject()
+	s.logger.Info("Starting SSO login", "issuer", issuer, "subject", subject)
+
+	var user *models.User
+	err = s.txExecutor.RunWithTransaction(ctx, func(tx pgx.Tx) error {
+		var username string
+		if loginState.LinkUsername != "" {
+			username, err = s.linkUser(ctx, tx, issuer, subject, loginState.LinkUsername)
+		} else {
+			username, err = s.resolveUser(ctx, tx, issuer, subject, claims)
+		}
+		if err != nil {
+			return err
+		}
+
+		user, err = s.userRepo.GetUserForUpdate(ctx, tx, username)
+		if err != nil {
+			return err
+		}
+		if user.Disabled() {
+			s.logger.Warn("Disabled user tried to sign in via SSO", "username", username)
+			return e.ErrUserDisabled
+		}
+
+		return s.auditLog.Record(ctx, tx, models.AuditEntry{
+			Actor:   username,
+			Action:  models.AuditLogin,
+			Target:  username,
+			Details: auditDetails(map[string]any{"method": "oidc", "issuer": issuer}),
+		})
+	})
+	if err != nil {
+		s.logger.Error("Failed to resolve SSO user", "issuer", issuer, "subject", subject, "error", err)
+		return nil, err
+	}
+
+	s.logger.Info("SSO login completed", "username", user.UserName)
+	return user, nil
+}
+
+// resolveUser возвращает пользователя, связанного с учетной записью провайдера, создавая или связывая его при первом входе
+func (s *DefaultOIDCService) resolveUser(ctx context.Context, tx pgx.Tx, issuer, subject string, claims oidc.Claims) (string, error) {
+	username, err := s.oidcRepo.GetIdentityUser(ctx, tx, issuer, subject)
+	if err == nil {
+		return username, nil
+	}
+	if !errors.Is(err, e.ErrInvalidUser) {
+		return "", err
+	}
+
+	username, err = s.provisionUser(ctx, tx, claims)
+	if err != nil {
+		return "", err
+	}
+	return username, s.oidcRepo.LinkIdentity(ctx, tx, issuer, subject, username)
+}
+
+// linkUser связывает учетную запись провайдера с пользователем, который начал связывание, войдя в магазин
+func (s *DefaultOIDCService) linkUser(ctx context.Context, tx pgx.Tx, issuer, subject, username string) (string, error) {
+	linked, err := s.oidcRepo.GetIdentityUser(ctx, tx, issuer, subject)
+	if err == nil {
+		if linked != username {
+			s.logger.Warn("SSO identity is already linked to another user", "username", username)
+			return "", e.ErrIdentityConflict
+		}
+		return username, nil
+	}
+	if !errors.Is(err, e.ErrInvalidUser) {
+		return "", err
+	}
+
+	if err = s.oidcRepo.LinkIdentity(ctx, tx, issuer, subject, username); err != nil {
+		return "", err
+	}
+	s.logger.Info("Linked SSO identity to user", "username", username)
+
+	return username, s.auditLog.Record(ctx, tx, models.AuditEntry{
+		Actor:   username,
+		Action:  models.AuditIdentityLinked,
+		Target:  username,
+		Details: auditDetails(map[string]any{"issuer": issuer}),
+	})
+}
+
+// provisionUser создает пользователя для новой учетной записи провайдера.
+// Пароль генерируется случайно, поэтому вход по паролю для такого пользователя невозможен до его смены.
+func (s *DefaultOIDCService) provisionUser(ctx context.Context, tx pgx.Tx, claims oidc.Claims) (string, error) {
+	username, err := s.mapUsername(claims)
+	if err != nil {
+		return "", err
+	}
+
+	secret, err := randomToken(32)
+	if err != nil {
+		return "", err
+	}
+	hashedPassword, err := s.passwordHasher.Hash(secret)
+	if err != nil {
+		return "", err
+	}
+
+	_, created, err := s.userRepo.GetOrCreateUser(ctx, tx, username, hashedPassword)
+	if err != nil {
+		return "", err
+	}
+
+	if !created {
+		s.logger.Warn("SSO username is taken by local account", "username", username)
+		return "", e.ErrIdentityConflict
+	}
+
+	return username, s.outboxRepo.AddEvent(ctx, tx, models.EventUserCreated, models.UserCreatedPayload{
+		Username: username,
+		Role:     models.RoleUser,
+	})
+}
+
+// mapUsername получает username из claim, удаляя недопустимые символы.
+// Адрес почты принимается только подтвержденным провайдером, домен отбрасывается только для AllowedDomains.
+func (s *DefaultOIDCService) mapUsername(claims oidc.Claims) (string, error) {
+	value := claims.String(s.mapping.UsernameClaim)
+	if local, domain, found := strings.Cut(value, "@"); found {
+		if !strings.EqualFold(value, claims.String("email")) || !claims.Bool("email_verified") {
+			s.logger.Warn("SSO email is not verified", "claim", s.mapping.UsernameClaim, "value", value)
+			return "", e.ErrIdentityUnverified
+		}
+		if s.mapping.StripDomain {
+			if !slices.ContainsFunc(s.mapping.AllowedDomains, func(allowed string) bool {
+				return strings.EqualFold(allowed, domain)
+			}) {
+				s.logger.Warn("SSO email domain is not allowed", "domain", domain)
+				return "", e.ErrIdentityMapping
+			}
+			value = local
+		}
+	}
+
+	username := strings.Map(func(r rune) rune {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return r
+		}
+		return -1
+	}, value)
+
+	if !validation.IsValidUsername(username) {
+		s.logger.Warn("SSO claim cannot be mapped to username", "claim", s.mapping.UsernameClaim, "value", value)
+		return "", e.ErrIdentityMapping
+	}
+
+	return username, nil
+}
@@ -0,0 +1,111 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"API-Avito-shop/internal/events"
+	r "API-Avito-shop/internal/repositories"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// OutboxRelay периодически забирает события из outbox и передает их издателю
+type OutboxRelay struct {
+	outboxRepo    r.OutboxRepository
+	publisher     events.Publisher
+	txExecutor    TxExecutor
+	interval      time.Duration
+	batchSize     int
+	lease         time.Duration
+	retryDelay    time.Duration
+	maxRetryDelay time.Duration
+	logger        *slog.Logger
+}
+
+func NewOutboxRelay(outboxRepo r.OutboxRepository, publisher events.Publisher, txHelper TxExecutor, interval time.Duration, batchSize int, lease, retryDelay, maxRetryDelay time.Duration, logger *slog.Logger) *OutboxRelay {
+	return &OutboxRelay{
+		outboxRepo:    outboxRepo,
+		publisher:     publisher,
+		txExecutor:    txHelper,
+		interval:      interval,
+		batchSize:     batchSize,
+		lease:         lease,
+		retryDelay:    retryDelay,
+		maxRetryDelay: maxRetryDelay,
+		logger:        logger,
+	}
+}
+
+// Run запускает цикл доставки событий до отмены контекста
+func (o *OutboxRelay) Run(ctx context.Context) {
+	o.logger.Info("Outbox relay started", "interval", o.interval)
+
+	ticker := time.NewTicker(o.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			o.logger.Info("Outbox relay stopped")
+			return
+		case <-ticker.C:
+			o.drain(ctx)
+		}
+	}
+}
+
+// drain отправляет события пачками, пока в outbox остаются готовые к отправке
+func (o *OutboxRelay) drain(ctx context.Context) {
+	for ctx.Err() == nil {
+		pending, err := o.outboxRepo.HasPending(ctx)
+		if err != nil || !pending {
+			return
+		}
+
+		processed, err := o.relayBatch(ctx)
+		if err != nil {
+			o.logger.Error("Failed to relay outbox batch", "error", err)
+			return
+		}
+		if processed < o.batchSize {
+			return
+		}
+	}
+}
+
+// relayBatch захватывает пачку событий, публикует их вне транзакции и отмечает результат
+// в отдельной короткой транзакции
+func (o *OutboxRelay) relayBatch(ctx context.Context) (int, error) {
+	pending, err := o.outboxRepo.ClaimPending(ctx, o.batchSize, o.lease)
+	if err != nil {
+		return 0, err
+	}
+
+	failures := make([]error, len(pending))
+	for i, event := range pending {
+		failures[i] = o.publisher.Publish(ctx, event)
+	}
+
+	err = o.txExecutor.RunWithTransaction(ctx, func(tx pgx.Tx) error {
+		for i, event := range pending {
+			if failures[i] == nil {
+				if err := o.outboxRepo.MarkPublished(ctx, tx, event.ID); err != nil {
+					return err
+				}
+				continue
+			}
+
+			delay := exponentialBackoff(o.retryDelay, o.maxRetryDelay, event.Attempts)
+			o.logger.Warn("Failed to publish event", "event_id", event.ID, "event_type", event.Type, "retry_after", delay, "error", failures[i])
+			if err := o.outboxRepo.MarkFailed(ctx, tx, event.ID, failures[i].Error(), delay); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	return len(pending), err
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"errors"
+	"log/slog"
+
+	e "API-Avito-shop/internal/errors"
+	"API-Avito-shop/internal/utils/password"
+)
+
+type PasswordHasher interface {
+	Hash(plain string) (string, error)
+	Verify(plain, encoded string) (bool, bool, error)
+}
+
+// DefaultPasswordHasher хеширует пароли текущим алгоритмом и проверяет хеши всех поддерживаемых алгоритмов
+type DefaultPasswordHasher struct {
+	current    password.Algorithm
+	algorithms []password.Algorithm
+	logger     *slog.Logger
+}
+
+// NewPasswordHasher создает хешер. Алгоритм хеша определяется по префиксу,
+// поэтому legacy должен содержать все алгоритмы, хеши которых могут храниться в базе.
+func NewPasswordHasher(current password.Algorithm, legacy []password.Algorithm, logger *slog.Logger) *DefaultPasswordHasher {
+	return &DefaultPasswordHasher{
+		current:    current,
+		algorithms: append([]password.Algorithm{current}, legacy...),
+		logger:     logger,
+	}
+}
+
+// Hash хеширует пароль текущим алгоритмом. Пароль длиннее password.MaxBytes байт
+// отклоняется ошибкой политики паролей.
+func (h *DefaultPasswordHasher) Hash(plain string) (string, error) {
+	if len(plain) > password.MaxBytes {
+		return "", &e.PolicyError{Violations: []string{"password is too long"}}
+	}
+
+	hash, err := h.current.Hash(plain)
+	if err != nil {
+		h.logger.Error("Failed to hash password", "error", err)
+		return "", err
+	}
+	return hash, nil
+}
+
+// Verify проверяет пароль и сообщает, нужно ли перехешировать его текущим алгоритмом.
+// Пароль длиннее password.MaxBytes байт не мог быть сохранен и считается неверным,
+// иначе bcrypt принял бы любой пароль с теми же первыми 72 байтами. Поврежденный хеш или хеш нераспознанного формата
+// (например, у учетной записи без пароля) также означает неверный пароль, а не ошибку сервера.
+func (h *DefaultPasswordHasher) Verify(plain, encoded string) (bool, bool, error) {
+	if len(plain) > password.MaxBytes {
+		return false, false, nil
+	}
+
+	for _, algorithm := range h.algorithms {
+		if !algorithm.Identifies(encoded) {
+			continue
+		}
+
+		ok, err := algorithm.Verify(plain, encoded)
+		if errors.Is(err, password.ErrUnknownHash) {
+			h.logger.Warn("Malformed password hash", "error", err)
+			return false, false, nil
+		}
+		if err != nil {
+			h.logger.Error("Failed to verify password hash", "error", err)
+			return false, false, err
+		}
+		if !ok {
+			return false, false, nil
+		}
+		return true, h.current.NeedsRehash(encoded), nil
+	}
+
+	h.logger.Warn("Unknown password hash format")
+	return false, false, nil
+}
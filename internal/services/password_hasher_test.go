@@ -0,0 +1,49 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"API-Avito-shop/internal/utils/password"
+)
+
+func TestPasswordHasherVerify(t *testing.T) {
+	current := password.Argon2id{Memory: 64, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+	legacy := password.Bcrypt{Cost: 4}
+	hasher := NewPasswordHasher(current, []password.Algorithm{legacy}, testLogger())
+
+	argon2Hash, err := current.Hash("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bcryptHash, err := legacy.Hash("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		password string
+		encoded  string
+		ok       bool
+		rehash   bool
+	}{
+		{"current algorithm", "correct horse", argon2Hash, true, false},
+		{"legacy algorithm is rehashed", "correct horse", bcryptHash, true, true},
+		{"wrong password", "wrong horse", argon2Hash, false, false},
+		{"too long password", strings.Repeat("a", password.MaxBytes+1), argon2Hash, false, false},
+		// Поврежденный или неизвестный хеш означает неверный пароль, а не ошибку сервера
+		{"no password", "correct horse", "", false, false},
+		{"unknown format", "correct horse", "$scrypt$whatever", false, false},
+		{"zero iterations", "correct horse", strings.Replace(argon2Hash, "t=1", "t=0", 1), false, false},
+		{"wrong version", "correct horse", strings.Replace(argon2Hash, "v=19", "v=16", 1), false, false},
+		{"broken bcrypt", "correct horse", "$2a$10$short", false, false},
+	}
+
+	for _, tt := range tests {
+		ok, rehash, err := hasher.Verify(tt.password, tt.encoded)
+		if err != nil || ok != tt.ok || rehash != tt.rehash {
+			t.Errorf("%s: Verify() = %v, %v, %v, want %v, %v, nil", tt.name, ok, rehash, err, tt.ok, tt.rehash)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"API-Avito-shop/internal/dto"
+	e "API-Avito-shop/internal/errors"
+	"API-Avito-shop/internal/models"
+	r "API-Avito-shop/internal/repositories"
+	"API-Avito-shop/internal/utils/password"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type PasswordService interface {
+	ChangePassword(ctx context.Context, username string, changeDTO *dto.ChangePassword, clientIP string) (int, error)
+	CreateResetToken(ctx context.Context, admin, username string) (dto.PasswordResetToken, error)
+	ResetPassword(ctx context.Context, resetDTO *dto.ResetPassword) error
+}
+
+type DefaultPasswordService struct {
+	userRepo          r.UserRepository
+	passwordResetRepo r.PasswordResetRepository
+	outboxRepo        r.OutboxRepository
+	loginGuard        LoginGuard
+	auditLog          AuditRecorder
+	txExecutor        TxExecutor
+	passwordHasher    PasswordHasher
+	policy            password.Policy
+	resetTokenTTL     time.Duration
+	logger            *slog.Logger
+}
+
+func NewPasswordService(userRepo r.UserRepository, passwordResetRepo r.PasswordResetRepository, outboxRepo r.OutboxRepository, loginGuard LoginGuard, auditLog AuditRecorder, txHelper TxExecutor, passwordHasher PasswordHasher, policy password.Policy, resetTokenTTL time.Duration, logger *slog.Logger) *DefaultPasswordService {
+	return &DefaultPasswordService{
+		userRepo:          userRepo,
+		passwordResetRepo: passwordResetRepo,
+		outboxRepo:        outboxRepo,
+		loginGuard:        loginGuard,
+		auditLog:          auditLog,
+		txExecutor:        txHelper,
+		passwordHasher:    passwordHasher,
+		policy:            policy,
+		resetTokenTTL:     resetTokenTTL,
+		logger:            logger,
+	}
+}
+
+// ChangePassword меняет пароль после проверки текущего и возвращает новую версию токенов.
+// Неверный текущий пароль учитывается как неудачная попытка входа.
+func (s *DefaultPasswordService) ChangePassword(ctx context.Context, username string, changeDTO *dto.ChangePassword, clientIP string) (int, error) {
+	s.logger.Info("Starting to change password", "username", username)
+
+	if err := s.loginGuard.CheckLocked(ctx, username, clientIP); err != nil {
+		return 0, err
+	}
+
+	if err := s.policy.Validate(changeDTO.NewPassword, username); err != nil {
+		s.logger.Warn("New password rejected by policy", "username", username, "error", err)
+		return 0, err
+	}
+
+	hashedPassword, err := s.passwordHasher.Hash(changeDTO.NewPassword)
+	if err != nil {
+		return 0, err
+	}
+
+	var version int
+	err = s.txExecutor.RunWithTransaction(ctx, func(tx pgx.Tx) error {
+		user, err := s.userRepo.GetUserForUpdate(ctx, tx, username)
+		if err != nil {
+			return err
+		}
+
+		ok, _, err := s.passwordHasher.Verify(changeDTO.CurrentPassword, user.Password)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return e.ErrInvalidPass
+		}
+		if changeDTO.CurrentPassword == changeDTO.NewPassword {
+			return &e.PolicyError{Violations: []string{"new password must differ from the current one"}}
+		}
+
+		version, err = s.userRepo.UpdatePassword(ctx, tx, username, hashedPassword)
+		if err != nil {
+			return err
+		}
+
+		err = s.outboxRepo.AddEvent(ctx, tx, models.EventPasswordChanged, models.PasswordChangedPayload{
+			Username: username,
+		})
+		if err != nil {
+			return err
+		}
+
+		return s.auditLog.Record(ctx, tx, models.AuditEntry{Actor: username, Action: models.AuditPasswordChanged, Target: username})
+	})
+	if err != nil {
+		if errors.Is(err, e.ErrInvalidPass) {
+			s.logger.Warn("Incorrect current password", "username", username, "client_ip", clientIP)
+			if err := s.loginGuard.RegisterFailure(ctx, username, clientIP, "invalid_current_password"); err != nil {
+				s.logger.Error("Failed to register login failure", "username", username, "error", err)
+			}
+			return 0, e.ErrInvalidPass
+		}
+		s.logger.Error("Failed to change password", "username", username, "error", err)
+		return 0, err
+	}
+
+	s.logger.Info("Password changed successfully", "username", username)
+	return version, nil
+}
+
+// CreateResetToken выдает одноразовый токен сброса пароля. В базе хранится только хеш токена.
+func (s *DefaultPasswordService) CreateResetToken(ctx context.Context, admin, username string) (dto.PasswordResetToken, error) {
+	s.logger.Info("Starting to create password reset token", "admin", admin, "username", username)
+
+	token, err := randomToken(32)
+	if err != nil {
+		s.logger.Error("Failed to generate reset token", "error", err)
+		return dto.PasswordResetToken{}, err
+	}
+
+	err = s.txExecutor.RunWithTransaction(ctx, func(tx pgx.Tx) error {
+		if _, err := s.userRepo.GetUserForUpdate(ctx, tx, username); err != nil {
+			return err
+		}
+		if err := s.passwordResetRepo.CreateToken(ctx, tx, username, hashToken(token), admin, s.resetTokenTTL); err != nil {
+			return err
+		}
+
+		return s.auditLog.Record(ctx, tx, models.AuditEntry{Actor: admin, Action: models.AuditPasswordResetIssued, Target: username})
+	})
+	if err != nil {
+		s.logger.Error("Failed to create password reset token", "username", username, "error", err)
+		return dto.PasswordResetToken{}, err
+	}
+
+	s.logger.Info("Password reset token created", "admin", admin, "username", username)
+	return dto.PasswordResetToken{
+		Username:  username,
+		Token:     token,
+		ExpiresAt: time.Now().Add(s.resetTokenTTL).UTC(),
+	}, nil
+}
+
+// ResetPassword устанавливает новый пароль по токену сброса и снимает блокировку входа
+func (s *DefaultPasswordService) ResetPassword(ctx context.Context, resetDTO *dto.ResetPassword) error {
+	s.logger.Info("Starting to reset password")
+
+	hashedPassword, err := s.passwordHasher.Hash(resetDTO.NewPassword)
+	if err != nil {
+		return err
+	}
+
+	var username string
+	err = s.txExecutor.RunWithTransaction(ctx, func(tx pgx.Tx) error {
+		username, err = s.passwordResetRepo.ConsumeToken(ctx, tx, hashToken(resetDTO.Token))
+		if err != nil {
+			return err
+		}
+
+		// Политика проверяется внутри транзакции: при отказе токен остается неиспользованным
+		if err = s.policy.Validate(resetDTO.NewPassword, username); err != nil {
+			return err
+		}
+
+		if _, err = s.userRepo.UpdatePassword(ctx, tx, username, hashedPassword); err != nil {
+			return err
+		}
+
+		err = s.outboxRepo.AddEvent(ctx, tx, models.EventPasswordChanged, models.PasswordChangedPayload{
+			Username: username,
+			Reset:    true,
+		})
+		if err != nil {
+			return err
+		}
+
+		return s.auditLog.Record(ctx, tx, models.AuditEntry{Actor: username, Action: models.AuditPasswordReset, Target: username})
+	})
+	if err != nil {
+		s.logger.Error("Failed to reset password", "error", err)
+		return err
+	}
+
+	if err = s.loginGuard.RegisterSuccess(ctx, username); err != nil {
+		s.logger.Error("Failed to reset login failures", "username", username, "error", err)
+	}
+
+	s.logger.Info("Password reset successfully", "username", username)
+	return nil
+}
@@ -0,0 +1,127 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	r "API-Avito-shop/internal/repositories"
+	"API-Avito-shop/internal/utils/password"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// testChainKey ключ цепочек хешей в тестах с базой данных
+var testChainKey = []byte("0123456789abcdef0123456789abcdef")
+
+var testUserSeq atomic.Int64
+
+// testPool подключается к базе TEST_DATABASE_URL с примененными миграциями.
+// Без переменной окружения тест пропускается.
+func testPool(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	pool, err := pgxpool.New(context.Background(), url)
+	if err != nil {
+		t.Fatalf("connect to test database: %v", err)
+	}
+	t.Cleanup(pool.Close)
+	return pool
+}
+
+// testLogger возвращает логгер, не выводящий записи
+func testLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// newTestUser создает пользователя со стартовым балансом и уникальным именем с префиксом prefix
+func newTestUser(t *testing.T, pool *pgxpool.Pool, prefix string) string {
+	t.Helper()
+
+	username := fmt.Sprintf("%s_%d_%d", prefix, time.Now().UnixNano(), testUserSeq.Add(1))
+	userRepo := r.NewUserRepository(pool, testLogger())
+	ctx := testContext(t)
+	err := NewTxExecutor(pool, testLogger()).RunWithTransaction(ctx, func(tx pgx.Tx) error {
+		_, _, err := userRepo.GetOrCreateUser(ctx, tx, username, "test")
+		return err
+	})
+	if err != nil {
+		t.Fatalf("create user %s: %v", username, err)
+	}
+	return username
+}
+
+// newTestShopService собирает сервис покупок и сервис промокодов поверх тестовой базы
+func newTestShopService(pool *pgxpool.Pool) (*DefaultShopService, *DefaultPromoCodeService) {
+	logger := testLogger()
+	txExecutor := NewTxExecutor(pool, logger)
+	auditService := NewAuditService(r.NewAuditRepository(pool, logger), txExecutor, testChainKey, logger)
+	userRepo := r.NewUserRepository(pool, logger)
+	shopRepo := r.NewShopRepository(pool, logger)
+
+	pricingService := NewPricingService(r.NewPricingRepository(pool, logger), shopRepo, auditService, txExecutor, logger)
+	promoCodeService := NewPromoCodeService(r.NewPromoCodeRepository(pool, logger), userRepo, shopRepo, auditService, txExecutor, logger)
+	shopService := NewShopService(userRepo, shopRepo, r.NewCoinLotRepository(pool, logger), r.NewInventoryRepository(pool, logger),
+		pricingService, promoCodeService, r.NewOutboxRepository(pool, logger), r.NewNotificationRepository(pool, logger),
+		auditService, txExecutor, logger)
+	return shopService, promoCodeService
+}
+
+// newTestUserService собирает сервис пользователей поверх тестовой базы с политикой сгорания монет expiryPolicy
+func newTestUserService(pool *pgxpool.Pool, expiryPolicy CoinExpiryPolicy) *DefaultUserService {
+	logger := testLogger()
+	txExecutor := NewTxExecutor(pool, logger)
+	auditService := NewAuditService(r.NewAuditRepository(pool, logger), txExecutor, testChainKey, logger)
+	return NewUserService(r.NewUserRepository(pool, logger), r.NewShopRepository(pool, logger), r.NewTransactionRepository(pool, logger),
+		r.NewOutboxRepository(pool, logger), r.NewCoinLotRepository(pool, logger), nil, auditService, txExecutor, nil,
+		password.Policy{}, expiryPolicy, time.Minute, logger)
+}
+
+// newTestTransactionService собирает сервис переводов поверх тестовой базы без антифрод-правил
+func newTestTransactionService(pool *pgxpool.Pool) *DefaultTransactionService {
+	logger := testLogger()
+	txExecutor := NewTxExecutor(pool, logger)
+	auditService := NewAuditService(r.NewAuditRepository(pool, logger), txExecutor, testChainKey, logger)
+	fraudRepo := r.NewFraudRepository(pool, logger)
+	return NewTransactionService(r.NewUserRepository(pool, logger), r.NewTransactionRepository(pool, logger),
+		r.NewOutboxRepository(pool, logger), r.NewNotificationRepository(pool, logger), fraudRepo, r.NewCoinLotRepository(pool, logger),
+		NewFraudDetector(fraudRepo, FraudPolicy{}, logger), auditService, txExecutor, logger)
+}
+
+// testContext возвращает контекст с ограничением времени, отменяемый по окончании теста
+func testContext(t *testing.T) context.Context {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	t.Cleanup(cancel)
+	return ctx
+}
+
+// runConcurrently запускает n вызовов fn одновременно и возвращает их ошибки
+func runConcurrently(n int, fn func(i int) error) []error {
+	errs := make([]error, n)
+	start := make(chan struct{})
+
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			errs[i] = fn(i)
+		}()
+	}
+	close(start)
+	wg.Wait()
+	return errs
+}
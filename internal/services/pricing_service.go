@@ -0,0 +1,399 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"API-Avito-shop/internal/dto"
+	e "API-Avito-shop/internal/errors"
+	"API-Avito-shop/internal/models"
+	r "API-Avito-shop/internal/repositories"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type PricingService interface {
+	EffectivePrice(ctx context.Context, tx pgx.Tx, item string, at time.Time) (*models.EffectivePrice, error)
+	Catalog(ctx context.Context) ([]dto.CatalogItem, error)
+	ListPriceChanges(ctx context.Context, item string) ([]dto.PriceChange, error)
+	SchedulePrice(ctx context.Context, admin, item string, schedule *dto.SchedulePrice) (dto.PriceChange, error)
+	CancelPriceChange(ctx context.Context, admin, item string, id int64) error
+	ListPromotions(ctx context.Context, query *dto.PromotionQuery, limit, offset int) ([]dto.Promotion, error)
+	CreatePromotion(ctx context.Context, admin string, create *dto.CreatePromotion) (dto.Promotion, error)
+	CancelPromotion(ctx context.Context, admin string, id int64) error
+}
+
+type DefaultPricingService struct {
+	pricingRepo r.PricingRepository
+	shopRepo    r.ShopRepository
+	auditLog    AuditRecorder
+	txExecutor  TxExecutor
+	logger      *slog.Logger
+}
+
+func NewPricingService(pricingRepo r.PricingRepository, shopRepo r.ShopRepository, auditLog AuditRecorder, txHelper TxExecutor, logger *slog.Logger) *DefaultPricingService {
+	return &DefaultPricingService{
+		pricingRepo: pricingRepo,
+		shopRepo:    shopRepo,
+		auditLog:    auditLog,
+		txExecutor:  txHelper,
+		logger:      logger,
+	}
+}
+
+// EffectivePrice вычисляет цену товара в момент at: базовую цену по истории и лучшую из действующих акций.
+// Акции не суммируются, применяется та, что дает наименьшую цену.
+func (s *DefaultPricingService) EffectivePrice(ctx context.Context, tx pgx.Tx, item string, at time.Time) (*models.EffectivePrice, error) {
+	products, err := s.pricingRepo.BasePrices(ctx, tx, item, at)
+	if err != nil {
+		return nil, err
+	}
+	if len(products) == 0 {
+		return nil, e.ErrItemNotFound
+	}
+
+	promotions, err := s.pricingRepo.ActivePromotions(ctx, tx, item, at)
+	if err != nil {
+		return nil, err
+	}
+
+	price := bestPrice(products[0], promotions)
+	return &price, nil
+}
+
+// Catalog предоставляет товары с текущими ценами с учетом акций
+func (s *DefaultPricingService) Catalog(ctx context.Context) ([]dto.CatalogItem, error) {
+	now := time.Now().UTC()
+	catalog := make([]dto.CatalogItem, 0)
+
+	err := s.txExecutor.RunWithTransaction(ctx, func(tx pgx.Tx) error {
+		products, err := s.pricingRepo.BasePrices(ctx, tx, "", now)
+		if err != nil {
+			return err
+		}
+		promotions, err := s.pricingRepo.ActivePromotions(ctx, tx, "", now)
+		if err != nil {
+			return err
+		}
+
+		for _, product := range products {
+			price := bestPrice(product, promotions)
+			catalogItem := dto.CatalogItem{Item: price.Item, Price: price.Price, BasePrice: price.BasePrice}
+			if price.Promotion != nil {
+				catalogItem.Promotion = price.Promotion.Name
+				catalogItem.PromotionEndsAt = &price.Promotion.EndsAt
+			}
+			catalog = append(catalog, catalogItem)
+		}
+		return nil
+	})
+	if err != nil {
+		s.logger.Error("Failed to get catalog", "error", err)
+		return nil, err
+	}
+
+	return catalog, nil
+}
+
+// ListPriceChanges предоставляет историю и запланированные изменения цены товара, начиная с последних
+func (s *DefaultPricingService) ListPriceChanges(ctx context.Context, item string) ([]dto.PriceChange, error) {
+	if err := checkItem(ctx, s.shopRepo, item); err != nil {
+		return nil, err
+	}
+
+	changes, err := s.pricingRepo.ListPriceChanges(ctx, item)
+	if err != nil {
+		s.logger.Error("Failed to list price changes", "item", item, "error", err)
+		return nil, err
+	}
+
+	// Изменения отсортированы от последних, поэтому действующая цена — первая не из будущего
+	now := time.Now().UTC()
+	result := make([]dto.PriceChange, 0, len(changes))
+	active := false
+	for _, change := range changes {
+		status := models.PriceSuperseded
+		switch {
+		case change.EffectiveFrom.After(now):
+			status = models.PriceScheduled
+		case !active:
+			status = models.PriceActive
+			active = true
+		}
+		result = append(result, toPriceChangeDTO(&change, status))
+	}
+	return result, nil
+}
+
+// SchedulePrice сохраняет новую цену товара, действующую с указанного момента или сразу
+func (s *DefaultPricingService) SchedulePrice(ctx context.Context, admin, item string, schedule *dto.SchedulePrice) (dto.PriceChange, error) {
+	s.logger.Info("Starting to schedule price change", "admin", admin, "item", item)
+
+	now := time.Now().UTC()
+	change := models.PriceChange{Item: item, Price: schedule.Price, EffectiveFrom: now, CreatedBy: &admin, CreatedAt: now}
+	if schedule.EffectiveFrom != nil {
+		if schedule.EffectiveFrom.Before(now) {
+			return dto.PriceChange{}, e.ErrPriceChangeInPast
+		}
+		change.EffectiveFrom = schedule.EffectiveFrom.UTC()
+	}
+
+	if err := checkItem(ctx, s.shopRepo, item); err != nil {
+		return dto.PriceChange{}, err
+	}
+
+	err := s.txExecutor.RunWithTransaction(ctx, func(tx pgx.Tx) error {
+		id, err := s.pricingRepo.CreatePriceChange(ctx, tx, &change)
+		if err != nil {
+			return err
+		}
+		change.ID = id
+
+		return s.auditLog.Record(ctx, tx, models.AuditEntry{
+			Actor:   admin,
+			Action:  models.AuditPriceScheduled,
+			Target:  item,
+			Amount:  &change.Price,
+			Details: auditDetails(map[string]any{"id": id, "effectiveFrom": change.EffectiveFrom}),
+		})
+	})
+	if err != nil {
+		s.logger.Error("Failed to schedule price change", "item", item, "error", err)
+		return dto.PriceChange{}, err
+	}
+
+	status := models.PriceActive
+	if change.EffectiveFrom.After(now) {
+		status = models.PriceScheduled
+	}
+
+	s.logger.Info("Price change scheduled", "admin", admin, "item", item, "id", change.ID)
+	return toPriceChangeDTO(&change, status), nil
+}
+
+// CancelPriceChange удаляет изменение цены, которое еще не вступило в силу
+func (s *DefaultPricingService) CancelPriceChange(ctx context.Context, admin, item string, id int64) error {
+	s.logger.Info("Starting to cancel price change", "admin", admin, "item", item, "id", id)
+
+	err := s.txExecutor.RunWithTransaction(ctx, func(tx pgx.Tx) error {
+		change, err := s.pricingRepo.DeletePriceChange(ctx, tx, item, id, time.Now().UTC())
+		if err != nil {
+			return err
+		}
+
+		return s.auditLog.Record(ctx, tx, models.AuditEntry{
+			Actor:   admin,
+			Action:  models.AuditPriceChangeCancelled,
+			Target:  item,
+			Amount:  &change.Price,
+			Details: auditDetails(map[string]any{"id": id, "effectiveFrom": change.EffectiveFrom}),
+		})
+	})
+	if err != nil {
+		s.logger.Error("Failed to cancel price change", "id", id, "error", err)
+		return err
+	}
+
+	s.logger.Info("Price change cancelled", "admin", admin, "item", item, "id", id)
+	return nil
+}
+
+// ListPromotions предоставляет акции с заданным статусом, начиная с последних
+func (s *DefaultPricingService) ListPromotions(ctx context.Context, query *dto.PromotionQuery, limit, offset int) ([]dto.Promotion, error) {
+	now := time.Now().UTC()
+
+	promotions, err := s.pricingRepo.ListPromotions(ctx, query.Status, now, limit, offset)
+	if err != nil {
+		s.logger.Error("Failed to list promotions", "error", err)
+		return nil, err
+	}
+
+	result := make([]dto.Promotion, 0, len(promotions))
+	for _, promotion := range promotions {
+		result = append(result, toPromotionDTO(&promotion, now))
+	}
+	return result, nil
+}
+
+// CreatePromotion создает акцию на товар или на весь каталог
+func (s *DefaultPricingService) CreatePromotion(ctx context.Context, admin string, create *dto.CreatePromotion) (dto.Promotion, error) {
+	s.logger.Info("Starting to create promotion", "admin", admin, "name", create.Name)
+
+	now := time.Now().UTC()
+	promotion := models.Promotion{
+		Name:      create.Name,
+		Kind:      create.Kind,
+		Value:     create.Value,
+		StartsAt:  now,
+		EndsAt:    create.EndsAt.UTC(),
+		CreatedBy: admin,
+		CreatedAt: now,
+	}
+	if create.StartsAt != nil {
+		promotion.StartsAt = create.StartsAt.UTC()
+	}
+	if create.Kind == models.PromotionPercent && create.Value >= 100 {
+		return dto.Promotion{}, e.ErrInvalidPromotion
+	}
+	if !promotion.EndsAt.After(promotion.StartsAt) || !promotion.EndsAt.After(now) {
+		return dto.Promotion{}, e.ErrInvalidPromotion
+	}
+
+	if create.Item != "" {
+		if err := checkItem(ctx, s.shopRepo, create.Item); err != nil {
+			return dto.Promotion{}, err
+		}
+		promotion.Item = &create.Item
+	}
+
+	err := s.txExecutor.RunWithTransaction(ctx, func(tx pgx.Tx) error {
+		if promotion.Kind == models.PromotionFixed {
+			if err := s.checkFixedDiscount(ctx, tx, &promotion); err != nil {
+				return err
+			}
+		}
+
+		id, err := s.pricingRepo.CreatePromotion(ctx, tx, &promotion)
+		if err != nil {
+			return err
+		}
+		promotion.ID = id
+
+		return s.auditLog.Record(ctx, tx, models.AuditEntry{
+			Actor:  admin,
+			Action: models.AuditPromotionCreated,
+			Target: create.Item,
+			Amount: &promotion.Value,
+			Details: auditDetails(map[string]any{
+				"id":       id,
+				"name":     promotion.Name,
+				"kind":     promotion.Kind,
+				"startsAt": promotion.StartsAt,
+				"endsAt":   promotion.EndsAt,
+			}),
+		})
+	})
+	if err != nil {
+		s.logger.Error("Failed to create promotion", "name", create.Name, "error", err)
+		return dto.Promotion{}, err
+	}
+
+	s.logger.Info("Promotion created", "admin", admin, "id", promotion.ID)
+	return toPromotionDTO(&promotion, now), nil
+}
+
+// CancelPromotion отменяет акцию, которая еще не завершилась. Покупки по акции остаются без изменений.
+func (s *DefaultPricingService) CancelPromotion(ctx context.Context, admin string, id int64) error {
+	s.logger.Info("Starting to cancel promotion", "admin", admin, "id", id)
+
+	err := s.txExecutor.RunWithTransaction(ctx, func(tx pgx.Tx) error {
+		promotion, err := s.pricingRepo.CancelPromotion(ctx, tx, id, time.Now().UTC())
+		if err != nil {
+			return err
+		}
+
+		var item string
+		if promotion.Item != nil {
+			item = *promotion.Item
+		}
+		return s.auditLog.Record(ctx, tx, models.AuditEntry{
+			Actor:   admin,
+			Action:  models.AuditPromotionCancelled,
+			Target:  item,
+			Details: auditDetails(map[string]any{"id": id, "name": promotion.Name}),
+		})
+	})
+	if err != nil {
+		s.logger.Error("Failed to cancel promotion", "id", id, "error", err)
+		return err
+	}
+
+	s.logger.Info("Promotion cancelled", "admin", admin, "id", id)
+	return nil
+}
+
+// checkFixedDiscount проверяет, что фиксированная скидка меньше базовой цены каждого товара акции на момент ее начала.
+// Если цена позже снизится, скидка ограничивается минимальной ценой.
+func (s *DefaultPricingService) checkFixedDiscount(ctx context.Context, tx pgx.Tx, promotion *models.Promotion) error {
+	var item string
+	if promotion.Item != nil {
+		item = *promotion.Item
+	}
+
+	products, err := s.pricingRepo.BasePrices(ctx, tx, item, promotion.StartsAt)
+	if err != nil {
+		return err
+	}
+	for _, product := range products {
+		if promotion.Value >= product.Price {
+			s.logger.Warn("Fixed discount covers the whole price", "item", product.Item, "price", product.Price, "value", promotion.Value)
+			return e.ErrInvalidPromotion
+		}
+	}
+	return nil
+}
+
+// checkItem проверяет, что товар есть в каталоге
+func checkItem(ctx context.Context, shopRepo r.ShopRepository, item string) error {
+	if _, err := shopRepo.GetItem(ctx, item); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return e.ErrItemNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// bestPrice применяет к базовой цене товара лучшую из подходящих акций
+func bestPrice(product models.Product, promotions []models.Promotion) models.EffectivePrice {
+	price := models.EffectivePrice{Item: product.Item, BasePrice: product.Price, Price: product.Price}
+	for i := range promotions {
+		promotion := &promotions[i]
+		if promotion.Item != nil && *promotion.Item != product.Item {
+			continue
+		}
+		if discounted := promotion.Apply(product.Price); discounted < price.Price {
+			price.Price = discounted
+			price.Promotion = promotion
+		}
+	}
+	return price
+}
+
+// toPriceChangeDTO преобразует изменение цены в DTO
+func toPriceChangeDTO(change *models.PriceChange, status string) dto.PriceChange {
+	result := dto.PriceChange{
+		ID:            change.ID,
+		Item:          change.Item,
+		Price:         change.Price,
+		EffectiveFrom: change.EffectiveFrom,
+		Status:        status,
+		CreatedAt:     change.CreatedAt,
+	}
+	if change.CreatedBy != nil {
+		result.CreatedBy = *change.CreatedBy
+	}
+	return result
+}
+
+// toPromotionDTO преобразует акцию в DTO со статусом на момент now
+func toPromotionDTO(promotion *models.Promotion, now time.Time) dto.Promotion {
+	result := dto.Promotion{
+		ID:          promotion.ID,
+		Name:        promotion.Name,
+		Kind:        promotion.Kind,
+		Value:       promotion.Value,
+		StartsAt:    promotion.StartsAt,
+		EndsAt:      promotion.EndsAt,
+		Status:      promotion.Status(now),
+		CreatedBy:   promotion.CreatedBy,
+		CreatedAt:   promotion.CreatedAt,
+		CancelledAt: promotion.CancelledAt,
+	}
+	if promotion.Item != nil {
+		result.Item = *promotion.Item
+	}
+	return result
+}
@@ -0,0 +1,52 @@
+package services
+
+import (
+	"testing"
+
+	"API-Avito-shop/internal/models"
+)
+
+func TestBestPrice(t *testing.T) {
+	cup := "cup"
+	socks := "socks"
+	catalogPercent := models.Promotion{ID: 1, Kind: models.PromotionPercent, Value: 10}
+	cupFixed := models.Promotion{ID: 2, Item: &cup, Kind: models.PromotionFixed, Value: 30}
+	cupPercent := models.Promotion{ID: 3, Item: &cup, Kind: models.PromotionPercent, Value: 50}
+	socksFixed := models.Promotion{ID: 4, Item: &socks, Kind: models.PromotionFixed, Value: 5}
+	catalogFixed := models.Promotion{ID: 5, Kind: models.PromotionFixed, Value: 1000}
+	cupSmallPercent := models.Promotion{ID: 6, Item: &cup, Kind: models.PromotionPercent, Value: 5}
+
+	tests := []struct {
+		name       string
+		product    models.Product
+		promotions []models.Promotion
+		price      int
+		promotion  int64
+	}{
+		{"no promotions", models.Product{Item: "cup", Price: 20}, nil, 20, 0},
+		{"catalog promotion", models.Product{Item: "cup", Price: 200}, []models.Promotion{catalogPercent}, 180, 1},
+		{"other item promotion is ignored", models.Product{Item: "cup", Price: 200}, []models.Promotion{socksFixed}, 200, 0},
+		{"item promotion beats catalog", models.Product{Item: "cup", Price: 200}, []models.Promotion{catalogPercent, cupFixed}, 170, 2},
+		{"catalog promotion beats item", models.Product{Item: "cup", Price: 200}, []models.Promotion{cupSmallPercent, catalogPercent}, 180, 1},
+		{"best of item promotions", models.Product{Item: "cup", Price: 200}, []models.Promotion{cupFixed, cupPercent}, 100, 3},
+		{"promotions do not stack", models.Product{Item: "cup", Price: 200}, []models.Promotion{catalogPercent, cupFixed, cupPercent}, 100, 3},
+		{"first promotion wins a tie", models.Product{Item: "cup", Price: 60}, []models.Promotion{cupFixed, cupPercent}, 30, 2},
+		// Скидка больше цены, например после снижения базовой цены, не делает товар бесплатным
+		{"discount over price", models.Product{Item: "cup", Price: 20}, []models.Promotion{catalogFixed}, models.MinPrice, 5},
+	}
+
+	for _, tt := range tests {
+		got := bestPrice(tt.product, tt.promotions)
+		if got.Item != tt.product.Item || got.BasePrice != tt.product.Price || got.Price != tt.price {
+			t.Errorf("%s: bestPrice() = %s %d/%d, want %s %d/%d", tt.name, got.Item, got.Price, got.BasePrice,
+				tt.product.Item, tt.price, tt.product.Price)
+		}
+		var promotion int64
+		if got.Promotion != nil {
+			promotion = got.Promotion.ID
+		}
+		if promotion != tt.promotion {
+			t.Errorf("%s: bestPrice() applied promotion %d, want %d", tt.name, promotion, tt.promotion)
+		}
+	}
+}
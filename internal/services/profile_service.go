@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"API-Avito-shop/internal/dto"
+	"API-Avito-shop/internal/models"
+	r "API-Avito-shop/internal/repositories"
+)
+
+type ProfileService interface {
+	GetProfile(ctx context.Context, username string) (dto.Profile, error)
+	UpdateProfile(ctx context.Context, username string, updateDTO *dto.UpdateProfile) (dto.Profile, error)
+	SearchDirectory(ctx context.Context, username, query string, limit, offset int) ([]dto.DirectoryUser, error)
+	RecentRecipients(ctx context.Context, username string, limit, offset int) ([]dto.RecentRecipient, error)
+}
+
+type DefaultProfileService struct {
+	profileRepo r.ProfileRepository
+	logger      *slog.Logger
+}
+
+func NewProfileService(profileRepo r.ProfileRepository, logger *slog.Logger) *DefaultProfileService {
+	return &DefaultProfileService{
+		profileRepo: profileRepo,
+		logger:      logger,
+	}
+}
+
+// GetProfile предоставляет профиль пользователя
+func (s *DefaultProfileService) GetProfile(ctx context.Context, username string) (dto.Profile, error) {
+	profile, err := s.profileRepo.GetProfile(ctx, username)
+	if err != nil {
+		return dto.Profile{}, err
+	}
+
+	return toProfileDTO(profile), nil
+}
+
+// UpdateProfile заменяет профиль пользователя, пробелы по краям полей отбрасываются
+func (s *DefaultProfileService) UpdateProfile(ctx context.Context, username string, updateDTO *dto.UpdateProfile) (dto.Profile, error) {
+	s.logger.Info("Starting to update profile", "username", username)
+
+	profile := &models.Profile{
+		Username:    username,
+		DisplayName: strings.TrimSpace(updateDTO.DisplayName),
+		Department:  strings.TrimSpace(updateDTO.Department),
+		Team:        strings.TrimSpace(updateDTO.Team),
+		AvatarURL:   strings.TrimSpace(updateDTO.AvatarURL),
+		Bio:         strings.TrimSpace(updateDTO.Bio),
+	}
+
+	if err := s.profileRepo.SaveProfile(ctx, profile); err != nil {
+		s.logger.Error("Failed to update profile", "username", username, "error", err)
+		return dto.Profile{}, err
+	}
+
+	s.logger.Info("Profile updated successfully", "username", username)
+	return toProfileDTO(profile), nil
+}
+
+// SearchDirectory ищет получателей перевода по логину и отображаемому имени, сам пользователь не показывается
+func (s *DefaultProfileService) SearchDirectory(ctx context.Context, username, query string, limit, offset int) ([]dto.DirectoryUser, error) {
+	users, err := s.profileRepo.SearchDirectory(ctx, strings.TrimSpace(query), username, limit, offset)
+	if err != nil {
+		s.logger.Error("Failed to search directory", "username", username, "error", err)
+		return nil, err
+	}
+
+	return users, nil
+}
+
+// RecentRecipients предоставляет недавних получателей переводов пользователя
+func (s *DefaultProfileService) RecentRecipients(ctx context.Context, username string, limit, offset int) ([]dto.RecentRecipient, error) {
+	recipients, err := s.profileRepo.RecentRecipients(ctx, username, limit, offset)
+	if err != nil {
+		s.logger.Error("Failed to get recent recipients", "username", username, "error", err)
+		return nil, err
+	}
+
+	return recipients, nil
+}
+
+// toProfileDTO преобразует модель профиля в DTO
+func toProfileDTO(profile *models.Profile) dto.Profile {
+	return dto.Profile{
+		UserName:    profile.Username,
+		DisplayName: profile.DisplayName,
+		Department:  profile.Department,
+		Team:        profile.Team,
+		AvatarURL:   profile.AvatarURL,
+		Bio:         profile.Bio,
+		UpdatedAt:   profile.UpdatedAt,
+	}
+}
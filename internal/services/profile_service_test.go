@@ -0,0 +1,254 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"API-Avito-shop/internal/dto"
+	e "API-Avito-shop/internal/errors"
+	"API-Avito-shop/internal/models"
+	r "API-Avito-shop/internal/repositories"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// fakeProfileRepo запоминает сохраненный профиль и параметры поиска, остальные методы не используются
+type fakeProfileRepo struct {
+	r.ProfileRepository
+	saved   *models.Profile
+	err     error
+	query   string
+	exclude string
+}
+
+func (f *fakeProfileRepo) SaveProfile(_ context.Context, profile *models.Profile) error {
+	if f.err != nil {
+		return f.err
+	}
+	updatedAt := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+	profile.UpdatedAt = &updatedAt
+	f.saved = profile
+	return nil
+}
+
+func (f *fakeProfileRepo) SearchDirectory(_ context.Context, query, exclude string, _, _ int) ([]dto.DirectoryUser, error) {
+	f.query, f.exclude = query, exclude
+	return []dto.DirectoryUser{}, f.err
+}
+
+func TestUpdateProfile(t *testing.T) {
+	repo := &fakeProfileRepo{}
+	service := NewProfileService(repo, testLogger())
+
+	got, err := service.UpdateProfile(context.Background(), "alice", &dto.UpdateProfile{
+		DisplayName: "  Алиса Смирнова ",
+		Department:  "Маркетинг\t",
+		AvatarURL:   " https://cdn.example.com/alice.png",
+		Bio:         "\n",
+	})
+	if err != nil {
+		t.Fatalf("UpdateProfile() error = %v", err)
+	}
+
+	// Пробелы по краям отбрасываются, незаполненные поля очищаются
+	want := dto.Profile{
+		UserName:    "alice",
+		DisplayName: "Алиса Смирнова",
+		Department:  "Маркетинг",
+		AvatarURL:   "https://cdn.example.com/alice.png",
+		UpdatedAt:   repo.saved.UpdatedAt,
+	}
+	if got != want {
+		t.Errorf("UpdateProfile() = %+v, want %+v", got, want)
+	}
+	if repo.saved.Username != "alice" || repo.saved.DisplayName != want.DisplayName || repo.saved.Team != "" || repo.saved.Bio != "" {
+		t.Errorf("UpdateProfile() saved %+v, want trimmed profile of alice", repo.saved)
+	}
+
+	repo.err = e.ErrDisplayNameTaken
+	if _, err = service.UpdateProfile(context.Background(), "bob", &dto.UpdateProfile{DisplayName: "Алиса Смирнова"}); !errors.Is(err, e.ErrDisplayNameTaken) {
+		t.Errorf("UpdateProfile() error = %v, want %v", err, e.ErrDisplayNameTaken)
+	}
+}
+
+func TestProfiles(t *testing.T) {
+	pool := testPool(t)
+	ctx := testContext(t)
+	service := NewProfileService(r.NewProfileRepository(pool, testLogger()), testLogger())
+	alice := newTestUser(t, pool, "profile")
+	bob := newTestUser(t, pool, "profile")
+
+	// Пользователь без заполненного профиля получает пустой профиль
+	profile, err := service.GetProfile(ctx, alice)
+	if err != nil || profile != (dto.Profile{UserName: alice}) {
+		t.Errorf("GetProfile() = %+v, %v, want empty profile of %s", profile, err, alice)
+	}
+	if _, err = service.GetProfile(ctx, alice+"_missing"); !errors.Is(err, e.ErrInvalidUser) {
+		t.Errorf("GetProfile() of missing user error = %v, want %v", err, e.ErrInvalidUser)
+	}
+
+	displayName := fmt.Sprintf("Боб Петров %d", time.Now().UnixNano())
+	if _, err = service.UpdateProfile(ctx, bob, &dto.UpdateProfile{DisplayName: displayName, Team: "Платежи"}); err != nil {
+		t.Fatalf("UpdateProfile() error = %v", err)
+	}
+	profile, err = service.GetProfile(ctx, bob)
+	if err != nil || profile.DisplayName != displayName || profile.Team != "Платежи" || profile.UpdatedAt == nil {
+		t.Errorf("GetProfile() = %+v, %v, want saved profile", profile, err)
+	}
+
+	// Отображаемое имя уникально без учета регистра, свое имя можно сохранить повторно
+	if _, err = service.UpdateProfile(ctx, alice, &dto.UpdateProfile{DisplayName: strings.ToUpper(displayName)}); !errors.Is(err, e.ErrDisplayNameTaken) {
+		t.Errorf("UpdateProfile() with taken name error = %v, want %v", err, e.ErrDisplayNameTaken)
+	}
+	if _, err = service.UpdateProfile(ctx, bob, &dto.UpdateProfile{DisplayName: displayName}); err != nil {
+		t.Errorf("UpdateProfile() with own name error = %v", err)
+	}
+
+	// История переводов показывает отображаемые имена
+	if _, err = newTestTransactionService(pool).SendCoin(ctx, alice, &dto.SendCoin{ToUser: bob, Amount: 10}); err != nil {
+		t.Fatalf("SendCoin() error = %v", err)
+	}
+	userService := newTestUserService(pool, CoinExpiryPolicy{Mode: models.ExpiryNone})
+	sent, err := userService.UserInfo(ctx, alice)
+	if err != nil {
+		t.Fatalf("UserInfo() error = %v", err)
+	}
+	if want := []dto.SentCoin{{ToUser: bob, ToDisplayName: displayName, Amount: 10}}; len(sent.CoinHistory.Sent) != 1 || sent.CoinHistory.Sent[0] != want[0] {
+		t.Errorf("UserInfo().CoinHistory.Sent = %+v, want %+v", sent.CoinHistory.Sent, want)
+	}
+	received, err := userService.UserInfo(ctx, bob)
+	if err != nil {
+		t.Fatalf("UserInfo() error = %v", err)
+	}
+	if want := []dto.ReceivedCoin{{FromUser: alice, Amount: 10}}; len(received.CoinHistory.Received) != 1 || received.CoinHistory.Received[0] != want[0] {
+		t.Errorf("UserInfo().CoinHistory.Received = %+v, want %+v", received.CoinHistory.Received, want)
+	}
+}
+
+func TestSearchDirectoryQuery(t *testing.T) {
+	repo := &fakeProfileRepo{}
+	service := NewProfileService(repo, testLogger())
+
+	// Пробелы вокруг строки поиска из поля автодополнения не участвуют в поиске, сам пользователь исключается
+	if _, err := service.SearchDirectory(context.Background(), "alice", "  bo ", 20, 0); err != nil {
+		t.Fatalf("SearchDirectory() error = %v", err)
+	}
+	if repo.query != "bo" || repo.exclude != "alice" {
+		t.Errorf("SearchDirectory() searched %q excluding %q, want %q excluding %q", repo.query, repo.exclude, "bo", "alice")
+	}
+}
+
+func TestSearchDirectory(t *testing.T) {
+	pool := testPool(t)
+	ctx := testContext(t)
+	logger := testLogger()
+	service := NewProfileService(r.NewProfileRepository(pool, logger), logger)
+	userRepo := r.NewUserRepository(pool, logger)
+
+	prefix := fmt.Sprintf("dir%x", time.Now().UnixNano())
+	self := newTestUser(t, pool, prefix)
+	first := newTestUser(t, pool, prefix)
+	second := newTestUser(t, pool, prefix)
+	disabled := newTestUser(t, pool, prefix)
+	named := newTestUser(t, pool, "named")
+	serviceAccount := prefix + "_svc"
+	err := NewTxExecutor(pool, logger).RunWithTransaction(ctx, func(tx pgx.Tx) error {
+		if err := userRepo.DisableUser(ctx, tx, disabled, "test"); err != nil {
+			return err
+		}
+		return userRepo.CreateUserWithRole(ctx, tx, serviceAccount, "test", models.RoleService)
+	})
+	if err != nil {
+		t.Fatalf("prepare users: %v", err)
+	}
+	if _, err = service.UpdateProfile(ctx, named, &dto.UpdateProfile{DisplayName: "Карина " + prefix}); err != nil {
+		t.Fatalf("UpdateProfile() error = %v", err)
+	}
+
+	users, err := service.SearchDirectory(ctx, self, prefix, 50, 0)
+	if err != nil {
+		t.Fatalf("SearchDirectory() error = %v", err)
+	}
+	found := make(map[string]int, len(users))
+	for i, user := range users {
+		found[user.UserName] = i
+	}
+
+	// Совпадения по началу логина идут первыми, затем совпадения по отображаемому имени
+	if len(users) < 3 || !slices.Contains([]string{first, second}, users[0].UserName) || !slices.Contains([]string{first, second}, users[1].UserName) {
+		t.Fatalf("SearchDirectory() = %+v, want %s and %s first", users, first, second)
+	}
+	if i, ok := found[named]; !ok || users[i].DisplayName != "Карина "+prefix {
+		t.Errorf("SearchDirectory() did not find %s by display name", named)
+	}
+	for _, excluded := range []string{self, disabled, serviceAccount} {
+		if _, ok := found[excluded]; ok {
+			t.Errorf("SearchDirectory() returned %s, want it excluded", excluded)
+		}
+	}
+
+	// Страницы не пересекаются и идут в том же порядке
+	for offset := 0; offset < 2; offset++ {
+		page, err := service.SearchDirectory(ctx, self, prefix, 1, offset)
+		if err != nil || len(page) != 1 || page[0].UserName != users[offset].UserName {
+			t.Errorf("SearchDirectory(offset %d) = %+v, %v, want %s", offset, page, err, users[offset].UserName)
+		}
+	}
+}
+
+func TestRecentRecipients(t *testing.T) {
+	pool := testPool(t)
+	ctx := testContext(t)
+	logger := testLogger()
+	service := NewProfileService(r.NewProfileRepository(pool, logger), logger)
+	transactions := newTestTransactionService(pool)
+
+	sender := newTestUser(t, pool, "recent")
+	bob := newTestUser(t, pool, "recent")
+	carol := newTestUser(t, pool, "recent")
+	dave := newTestUser(t, pool, "recent")
+	for _, recipient := range []string{bob, carol, dave, bob} {
+		if _, err := transactions.SendCoin(ctx, sender, &dto.SendCoin{ToUser: recipient, Amount: 1}); err != nil {
+			t.Fatalf("SendCoin() error = %v", err)
+		}
+	}
+	// Переводы другим отправителем не попадают в список
+	if _, err := transactions.SendCoin(ctx, carol, &dto.SendCoin{ToUser: sender, Amount: 1}); err != nil {
+		t.Fatalf("SendCoin() error = %v", err)
+	}
+	err := NewTxExecutor(pool, logger).RunWithTransaction(ctx, func(tx pgx.Tx) error {
+		return r.NewUserRepository(pool, logger).DisableUser(ctx, tx, dave, "test")
+	})
+	if err != nil {
+		t.Fatalf("disable user: %v", err)
+	}
+
+	recipients, err := service.RecentRecipients(ctx, sender, 10, 0)
+	if err != nil {
+		t.Fatalf("RecentRecipients() error = %v", err)
+	}
+
+	// Последний получатель первый, отключенные учетные записи не предлагаются
+	want := []struct {
+		username  string
+		transfers int
+	}{{bob, 2}, {carol, 1}}
+	if len(recipients) != len(want) {
+		t.Fatalf("RecentRecipients() = %+v, want %v", recipients, want)
+	}
+	for i, w := range want {
+		if recipients[i].UserName != w.username || recipients[i].Transfers != w.transfers || recipients[i].LastSentAt == nil {
+			t.Errorf("RecentRecipients()[%d] = %+v, want %s with %d transfers", i, recipients[i], w.username, w.transfers)
+		}
+	}
+
+	page, err := service.RecentRecipients(ctx, sender, 1, 1)
+	if err != nil || len(page) != 1 || page[0].UserName != carol {
+		t.Errorf("RecentRecipients(limit 1, offset 1) = %+v, %v, want %s", page, err, carol)
+	}
+}
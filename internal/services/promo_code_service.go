@@ -0,0 +1,274 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"API-Avito-shop/internal/dto"
+	e "API-Avito-shop/internal/errors"
+	"API-Avito-shop/internal/models"
+	r "API-Avito-shop/internal/repositories"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type PromoCodeService interface {
+	Redeem(ctx context.Context, tx pgx.Tx, code, username, item string, price int) (*models.PromoRedemption, error)
+	ListCodes(ctx context.Context, query *dto.PromoCodeQuery, limit, offset int) ([]dto.PromoCode, error)
+	GetCode(ctx context.Context, id int64) (dto.PromoCode, error)
+	ListRedemptions(ctx context.Context, id int64, limit, offset int) ([]dto.PromoRedemption, error)
+	CreateCode(ctx context.Context, admin string, create *dto.CreatePromoCode) (dto.PromoCode, error)
+	DisableCode(ctx context.Context, admin string, id int64) error
+}
+
+type DefaultPromoCodeService struct {
+	promoCodeRepo r.PromoCodeRepository
+	userRepo      r.UserRepository
+	shopRepo      r.ShopRepository
+	auditLog      AuditRecorder
+	txExecutor    TxExecutor
+	logger        *slog.Logger
+}
+
+func NewPromoCodeService(promoCodeRepo r.PromoCodeRepository, userRepo r.UserRepository, shopRepo r.ShopRepository, auditLog AuditRecorder, txHelper TxExecutor, logger *slog.Logger) *DefaultPromoCodeService {
+	return &DefaultPromoCodeService{
+		promoCodeRepo: promoCodeRepo,
+		userRepo:      userRepo,
+		shopRepo:      shopRepo,
+		auditLog:      auditLog,
+		txExecutor:    txHelper,
+		logger:        logger,
+	}
+}
+
+// Redeem погашает промокод при покупке товара по цене price в транзакции покупки.
+// Код блокируется до конца транзакции, поэтому одновременные покупки не превышают лимиты погашений.
+// Код, закрепленный за другим пользователем, считается несуществующим.
+func (s *DefaultPromoCodeService) Redeem(ctx context.Context, tx pgx.Tx, code, username, item string, price int) (*models.PromoRedemption, error) {
+	promoCode, err := s.promoCodeRepo.GetByCodeForUpdate(ctx, tx, normalizePromoCode(code))
+	if err != nil {
+		return nil, err
+	}
+	if promoCode.Username != nil && *promoCode.Username != username {
+		return nil, e.ErrPromoCodeNotFound
+	}
+
+	switch promoCode.Status(time.Now().UTC()) {
+	case models.PromoCodeDisabled:
+		return nil, e.ErrPromoCodeNotFound
+	case models.PromoCodeExpired:
+		return nil, e.ErrPromoCodeExpired
+	case models.PromoCodeExhausted:
+		return nil, e.ErrPromoCodeExhausted
+	}
+	if !promoCode.AppliesTo(item) {
+		return nil, e.ErrPromoCodeNotApplicable
+	}
+
+	redeemed, err := s.promoCodeRepo.CountUserRedemptions(ctx, tx, promoCode.ID, username)
+	if err != nil {
+		return nil, err
+	}
+	if redeemed >= promoCode.PerUserLimit {
+		return nil, e.ErrPromoCodeUserLimit
+	}
+
+	discounted := promoCode.Apply(price)
+	redemption := models.PromoRedemption{
+		CodeID:   promoCode.ID,
+		Username: username,
+		Item:     item,
+		Price:    discounted,
+		Discount: price - discounted,
+	}
+	if err = s.promoCodeRepo.Redeem(ctx, tx, &redemption); err != nil {
+		return nil, err
+	}
+
+	return &redemption, nil
+}
+
+// ListCodes предоставляет промокоды с заданным статусом, начиная с последних
+func (s *DefaultPromoCodeService) ListCodes(ctx context.Context, query *dto.PromoCodeQuery, limit, offset int) ([]dto.PromoCode, error) {
+	now := time.Now().UTC()
+
+	codes, err := s.promoCodeRepo.List(ctx, query.Status, now, limit, offset)
+	if err != nil {
+		s.logger.Error("Failed to list promo codes", "error", err)
+		return nil, err
+	}
+
+	result := make([]dto.PromoCode, 0, len(codes))
+	for _, code := range codes {
+		result = append(result, toPromoCodeDTO(&code, now))
+	}
+	return result, nil
+}
+
+// GetCode предоставляет промокод
+func (s *DefaultPromoCodeService) GetCode(ctx context.Context, id int64) (dto.PromoCode, error) {
+	code, err := s.promoCodeRepo.Get(ctx, id)
+	if err != nil {
+		return dto.PromoCode{}, err
+	}
+	return toPromoCodeDTO(code, time.Now().UTC()), nil
+}
+
+// ListRedemptions предоставляет погашения промокода, начиная с последних
+func (s *DefaultPromoCodeService) ListRedemptions(ctx context.Context, id int64, limit, offset int) ([]dto.PromoRedemption, error) {
+	if _, err := s.promoCodeRepo.Get(ctx, id); err != nil {
+		return nil, err
+	}
+
+	redemptions, err := s.promoCodeRepo.ListRedemptions(ctx, id, limit, offset)
+	if err != nil {
+		s.logger.Error("Failed to list promo redemptions", "id", id, "error", err)
+		return nil, err
+	}
+
+	result := make([]dto.PromoRedemption, 0, len(redemptions))
+	for _, redemption := range redemptions {
+		result = append(result, dto.PromoRedemption{
+			UserName:  redemption.Username,
+			Item:      redemption.Item,
+			Price:     redemption.Price,
+			Discount:  redemption.Discount,
+			CreatedAt: redemption.CreatedAt,
+		})
+	}
+	return result, nil
+}
+
+// CreateCode создает промокод. Без заданного кода генерируется случайный.
+func (s *DefaultPromoCodeService) CreateCode(ctx context.Context, admin string, create *dto.CreatePromoCode) (dto.PromoCode, error) {
+	s.logger.Info("Starting to create promo code", "admin", admin)
+
+	now := time.Now().UTC()
+	if create.Kind == models.PromotionPercent && create.Value > 100 {
+		return dto.PromoCode{}, e.ErrInvalidPromoCode
+	}
+	if create.ExpiresAt != nil && !create.ExpiresAt.After(now) {
+		return dto.PromoCode{}, e.ErrInvalidPromoCode
+	}
+
+	code := models.PromoCode{
+		Code:           normalizePromoCode(create.Code),
+		Description:    create.Description,
+		Kind:           create.Kind,
+		Value:          create.Value,
+		MaxRedemptions: create.MaxRedemptions,
+		PerUserLimit:   max(create.PerUserLimit, 1),
+		CreatedBy:      admin,
+	}
+	if create.ExpiresAt != nil {
+		expiresAt := create.ExpiresAt.UTC()
+		code.ExpiresAt = &expiresAt
+	}
+	if code.Code == "" {
+		token, err := randomToken(5)
+		if err != nil {
+			return dto.PromoCode{}, err
+		}
+		code.Code = strings.ToUpper(token)
+	}
+
+	for _, item := range create.Items {
+		if err := checkItem(ctx, s.shopRepo, item); err != nil {
+			return dto.PromoCode{}, err
+		}
+		code.Items = append(code.Items, item)
+	}
+	if create.User != "" {
+		if _, err := s.userRepo.GetUser(ctx, create.User); err != nil {
+			return dto.PromoCode{}, err
+		}
+		code.Username = &create.User
+	}
+
+	err := s.txExecutor.RunWithTransaction(ctx, func(tx pgx.Tx) error {
+		id, err := s.promoCodeRepo.Create(ctx, tx, &code)
+		if err != nil {
+			return err
+		}
+		code.ID = id
+
+		return s.auditLog.Record(ctx, tx, models.AuditEntry{
+			Actor:  admin,
+			Action: models.AuditPromoCodeCreated,
+			Target: create.User,
+			Amount: &code.Value,
+			Details: auditDetails(map[string]any{
+				"id":             id,
+				"code":           code.Code,
+				"kind":           code.Kind,
+				"items":          strings.Join(code.Items, ","),
+				"maxRedemptions": code.MaxRedemptions,
+				"perUserLimit":   code.PerUserLimit,
+				"expiresAt":      code.ExpiresAt,
+			}),
+		})
+	})
+	if err != nil {
+		s.logger.Error("Failed to create promo code", "error", err)
+		return dto.PromoCode{}, err
+	}
+
+	s.logger.Info("Promo code created", "admin", admin, "id", code.ID)
+	return toPromoCodeDTO(&code, now), nil
+}
+
+// DisableCode отключает промокод, погашения по нему остаются в силе
+func (s *DefaultPromoCodeService) DisableCode(ctx context.Context, admin string, id int64) error {
+	s.logger.Info("Starting to disable promo code", "admin", admin, "id", id)
+
+	err := s.txExecutor.RunWithTransaction(ctx, func(tx pgx.Tx) error {
+		code, err := s.promoCodeRepo.Disable(ctx, tx, id, time.Now().UTC())
+		if err != nil {
+			return err
+		}
+
+		return s.auditLog.Record(ctx, tx, models.AuditEntry{
+			Actor:   admin,
+			Action:  models.AuditPromoCodeDisabled,
+			Details: auditDetails(map[string]any{"id": id, "code": code.Code, "redemptions": code.Redemptions}),
+		})
+	})
+	if err != nil {
+		s.logger.Error("Failed to disable promo code", "id", id, "error", err)
+		return err
+	}
+
+	s.logger.Info("Promo code disabled", "admin", admin, "id", id)
+	return nil
+}
+
+// normalizePromoCode приводит промокод к виду, в котором он хранится
+func normalizePromoCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// toPromoCodeDTO преобразует промокод в DTO со статусом на момент now
+func toPromoCodeDTO(code *models.PromoCode, now time.Time) dto.PromoCode {
+	result := dto.PromoCode{
+		ID:             code.ID,
+		Code:           code.Code,
+		Description:    code.Description,
+		Kind:           code.Kind,
+		Value:          code.Value,
+		Items:          code.Items,
+		MaxRedemptions: code.MaxRedemptions,
+		PerUserLimit:   code.PerUserLimit,
+		Redemptions:    code.Redemptions,
+		ExpiresAt:      code.ExpiresAt,
+		Status:         code.Status(now),
+		CreatedBy:      code.CreatedBy,
+		CreatedAt:      code.CreatedAt,
+		DisabledAt:     code.DisabledAt,
+	}
+	if code.Username != nil {
+		result.User = *code.Username
+	}
+	return result
+}
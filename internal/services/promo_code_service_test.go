@@ -0,0 +1,86 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"API-Avito-shop/internal/dto"
+	e "API-Avito-shop/internal/errors"
+	"API-Avito-shop/internal/models"
+)
+
+func TestPromoCodeRedemptionLimitsUnderConcurrency(t *testing.T) {
+	pool := testPool(t)
+	ctx := testContext(t)
+	shopService, promoCodeService := newTestShopService(pool)
+
+	const maxRedemptions, perUserLimit, attempts = 3, 2, 10
+	limit := maxRedemptions
+
+	tests := []struct {
+		name      string
+		create    dto.CreatePromoCode
+		sameBuyer bool
+		want      int
+		err       error
+	}{
+		{
+			name:   "total limit",
+			create: dto.CreatePromoCode{Kind: models.PromotionPercent, Value: 50, MaxRedemptions: &limit},
+			want:   maxRedemptions,
+			err:    e.ErrPromoCodeExhausted,
+		},
+		{
+			name:      "per user limit",
+			create:    dto.CreatePromoCode{Kind: models.PromotionPercent, Value: 50, PerUserLimit: perUserLimit},
+			sameBuyer: true,
+			want:      perUserLimit,
+			err:       e.ErrPromoCodeUserLimit,
+		},
+	}
+
+	for _, tt := range tests {
+		admin := newTestUser(t, pool, "promo_admin")
+		code, err := promoCodeService.CreateCode(ctx, admin, &tt.create)
+		if err != nil {
+			t.Fatalf("%s: CreateCode() error = %v", tt.name, err)
+		}
+
+		buyers := make([]string, attempts)
+		for i := range buyers {
+			if i == 0 || !tt.sameBuyer {
+				buyers[i] = newTestUser(t, pool, "promo_buyer")
+			} else {
+				buyers[i] = buyers[0]
+			}
+		}
+
+		// Код блокируется в транзакции покупки, поэтому лимит не превышается при одновременных покупках
+		errs := runConcurrently(attempts, func(i int) error {
+			return shopService.BuyProduct(ctx, "cup", buyers[i], code.Code)
+		})
+
+		redeemed, rejected := 0, 0
+		for _, err := range errs {
+			switch {
+			case err == nil:
+				redeemed++
+			case errors.Is(err, tt.err):
+				rejected++
+			default:
+				t.Errorf("%s: BuyProduct() unexpected error: %v", tt.name, err)
+			}
+		}
+		if redeemed != tt.want || rejected != attempts-tt.want {
+			t.Errorf("%s: redeemed %d and rejected %d purchases, want %d and %d", tt.name, redeemed, rejected, tt.want, attempts-tt.want)
+		}
+
+		var stored int
+		if err := pool.QueryRow(ctx, `SELECT COUNT(*) FROM promo_redemptions WHERE code_id = $1`, code.ID).Scan(&stored); err != nil {
+			t.Fatal(err)
+		}
+		if stored != tt.want {
+			t.Errorf("%s: stored %d redemptions, want %d", tt.name, stored, tt.want)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"API-Avito-shop/internal/dto"
+	r "API-Avito-shop/internal/repositories"
+)
+
+const (
+	reportTopItemsLimit = 10
+	reportDormantLimit  = 50
+)
+
+type ReportService interface {
+	EconomyReport(ctx context.Context, query *dto.EconomyReportQuery) (dto.EconomyReport, error)
+}
+
+type DefaultReportService struct {
+	reportRepo r.ReportRepository
+	logger     *slog.Logger
+}
+
+func NewReportService(reportRepo r.ReportRepository, logger *slog.Logger) *DefaultReportService {
+	return &DefaultReportService{
+		reportRepo: reportRepo,
+		logger:     logger,
+	}
+}
+
+// EconomyReport строит отчет о состоянии экономики монет за период. Показатели оборота
+// и распределение балансов отражают состояние на момент построения отчета.
+func (s *DefaultReportService) EconomyReport(ctx context.Context, query *dto.EconomyReportQuery) (dto.EconomyReport, error) {
+	now := time.Now().UTC()
+	from, to, err := datePeriod(query.From, query.To, now)
+	if err != nil {
+		return dto.EconomyReport{}, err
+	}
+
+	totals, transfers, err := s.reportRepo.EconomyTotals(ctx, from, to)
+	if err != nil {
+		s.logger.Error("Failed to get economy totals", "error", err)
+		return dto.EconomyReport{}, err
+	}
+
+	topItems, err := s.reportRepo.TopItems(ctx, from, to, reportTopItemsLimit)
+	if err != nil {
+		s.logger.Error("Failed to get top items", "error", err)
+		return dto.EconomyReport{}, err
+	}
+
+	dormant, err := s.reportRepo.DormantAccounts(ctx, from, to, reportDormantLimit)
+	if err != nil {
+		s.logger.Error("Failed to get dormant accounts", "error", err)
+		return dto.EconomyReport{}, err
+	}
+
+	distribution, err := s.reportRepo.BalanceDistribution(ctx)
+	if err != nil {
+		s.logger.Error("Failed to get balance distribution", "error", err)
+		return dto.EconomyReport{}, err
+	}
+
+	totals.NetIssuance = totals.Minted - totals.SpentInShop - totals.Expired
+	if totals.Circulation > 0 {
+		transfers.Velocity = float64(transfers.Volume) / float64(totals.Circulation)
+	}
+	transfers.AvgDailyVolume = float64(transfers.Volume) / to.Sub(from).Hours() * 24
+
+	return dto.EconomyReport{
+		From:                from,
+		To:                  to.AddDate(0, 0, -1),
+		GeneratedAt:         now,
+		Totals:              totals,
+		Transfers:           transfers,
+		TopItems:            topItems,
+		Dormant:             dormant,
+		BalanceDistribution: distribution,
+	}, nil
+}
@@ -3,60 +3,210 @@ package services
 import (
 	"context"
 	"log/slog"
+	"time"
 
+	e "API-Avito-shop/internal/errors"
+	"API-Avito-shop/internal/models"
 	r "API-Avito-shop/internal/repositories"
 
 	"github.com/jackc/pgx/v5"
 )
 
 type ShopService interface {
-	BuyProduct(ctx context.Context, item string, username string) error
+	BuyProduct(ctx context.Context, item, username, code string) error
+	GiftProduct(ctx context.Context, item, username, code string, gift models.Gift) error
 }
 
 type DefaultShopService struct {
-	userRepo   r.UserRepository
-	shopRepo   r.ShopRepository
-	txExecutor TxExecutor
-	logger     *slog.Logger
+	userRepo         r.UserRepository
+	shopRepo         r.ShopRepository
+	coinLotRepo      r.CoinLotRepository
+	inventoryRepo    r.InventoryRepository
+	pricingService   PricingService
+	promoCodeService PromoCodeService
+	outboxRepo       r.OutboxRepository
+	notificationRepo r.NotificationRepository
+	auditLog         AuditRecorder
+	txExecutor       TxExecutor
+	logger           *slog.Logger
 }
 
-func NewShopService(userRepo r.UserRepository, shopRepo r.ShopRepository, txHelper TxExecutor, logger *slog.Logger) *DefaultShopService {
+func NewShopService(userRepo r.UserRepository, shopRepo r.ShopRepository, coinLotRepo r.CoinLotRepository, inventoryRepo r.InventoryRepository, pricingService PricingService, promoCodeService PromoCodeService, outboxRepo r.OutboxRepository, notificationRepo r.NotificationRepository, auditLog AuditRecorder, txHelper TxExecutor, logger *slog.Logger) *DefaultShopService {
 	return &DefaultShopService{
-		userRepo:   userRepo,
-		shopRepo:   shopRepo,
-		txExecutor: txHelper,
-		logger:     logger,
+		userRepo:         userRepo,
+		shopRepo:         shopRepo,
+		coinLotRepo:      coinLotRepo,
+		inventoryRepo:    inventoryRepo,
+		pricingService:   pricingService,
+		promoCodeService: promoCodeService,
+		outboxRepo:       outboxRepo,
+		notificationRepo: notificationRepo,
+		auditLog:         auditLog,
+		txExecutor:       txHelper,
+		logger:           logger,
 	}
 }
 
-// BuyProduct позволяет купить товар
-func (s *DefaultShopService) BuyProduct(ctx context.Context, item string, username string) error {
+// BuyProduct позволяет купить товар по цене, действующей в момент покупки с учетом акций.
+// Непустой промокод погашается в той же транзакции, скидка по нему применяется к цене с учетом акции.
+func (s *DefaultShopService) BuyProduct(ctx context.Context, item, username, code string) error {
 	s.logger.Info("Starting to buy item", "item", item)
 
+	if err := s.purchase(ctx, item, username, code, nil); err != nil {
+		s.logger.Error("Failed to buy item", "item", item, "error", err)
+		return err
+	}
+
+	s.logger.Info("Purchase completed successfully", "username", username, "item", item)
+	return nil
+}
+
+// GiftProduct позволяет купить товар в подарок: монеты списываются с покупателя, товар попадает
+// в инвентарь получателя. Подарки получают только действующие пользователи с ролью user, себе подарки не отправляются.
+func (s *DefaultShopService) GiftProduct(ctx context.Context, item, username, code string, gift models.Gift) error {
+	s.logger.Info("Starting to buy gift", "item", item, "to_user", gift.ToUser)
+
+	if gift.ToUser == username {
+		s.logger.Warn("Buyer matches gift recipient", "username", username)
+		return e.ErrInvalidUser
+	}
+
+	if err := s.purchase(ctx, item, username, code, &gift); err != nil {
+		s.logger.Error("Failed to buy gift", "item", item, "to_user", gift.ToUser, "error", err)
+		return err
+	}
+
+	s.logger.Info("Gift purchased successfully", "username", username, "item", item, "to_user", gift.ToUser)
+	return nil
+}
+
+// purchase списывает монеты покупателя, сохраняет покупку и добавляет экземпляр товара в инвентарь владельца,
+// для подарка также уведомляет получателя
+func (s *DefaultShopService) purchase(ctx context.Context, item, username, code string, gift *models.Gift) error {
 	existingItem, err := s.shopRepo.GetItem(ctx, item)
 	if err != nil {
 		s.logger.Error("Failed to find item", "item", item, "error", err)
 		return err
 	}
 
-	err = s.txExecutor.RunWithTransaction(ctx, func(tx pgx.Tx) error {
-		if err = s.userRepo.SubtractCoins(ctx, tx, username, existingItem.Price); err != nil {
+	return s.txExecutor.RunWithTransaction(ctx, func(tx pgx.Tx) error {
+		// Получатель проверяется под блокировкой, чтобы его не отключили до зачисления подарка
+		if gift != nil {
+			if err := s.lockRecipient(ctx, tx, username, gift.ToUser); err != nil {
+				return err
+			}
+		}
+
+		price, err := s.pricingService.EffectivePrice(ctx, tx, existingItem.Item, time.Now().UTC())
+		if err != nil {
+			return err
+		}
+
+		var redemption *models.PromoRedemption
+		if code != "" {
+			if redemption, err = s.promoCodeService.Redeem(ctx, tx, code, username, item, price.Price); err != nil {
+				return err
+			}
+			price.Price = redemption.Price
+		}
+
+		if err = s.userRepo.SubtractCoins(ctx, tx, username, price.Price); err != nil {
+			return err
+		}
+		if _, err = s.coinLotRepo.Consume(ctx, tx, username, price.Price); err != nil {
 			return err
 		}
 		s.logger.Info("Payment for item made", "username", username, "item", item)
 
-		if err = s.shopRepo.AddPurchase(ctx, tx, item, username, existingItem.Price); err != nil {
+		purchaseID, err := s.shopRepo.AddPurchase(ctx, tx, item, username, price.Price, gift)
+		if err != nil {
+			return err
+		}
+
+		var giftTo string
+		owner := username
+		if gift != nil {
+			giftTo, owner = gift.ToUser, gift.ToUser
+		}
+		if err = s.inventoryRepo.AddItem(ctx, tx, purchaseID, item, owner); err != nil {
+			return err
+		}
+
+		if err = s.outboxRepo.AddEvent(ctx, tx, models.EventItemPurchased, models.ItemPurchasedPayload{
+			Username: username,
+			Item:     item,
+			Price:    price.Price,
+			GiftTo:   giftTo,
+		}); err != nil {
+			return err
+		}
+
+		err = s.notificationRepo.Notify(ctx, tx, username, models.NotificationPurchaseCompleted, models.PurchaseCompletedData{
+			Item:   item,
+			Price:  price.Price,
+			ToUser: giftTo,
+		})
+		if err != nil {
+			return err
+		}
+		if gift != nil {
+			err = s.notificationRepo.Notify(ctx, tx, gift.ToUser, models.NotificationGiftReceived, models.GiftReceivedData{
+				FromUser: username,
+				Item:     item,
+				Message:  gift.Message,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		balance, err := s.userRepo.GetBalance(ctx, tx, username)
+		if err != nil {
+			return err
+		}
+		err = s.notificationRepo.Notify(ctx, tx, username, models.NotificationBalanceChanged, models.BalanceChangedData{Coins: balance})
+		if err != nil {
 			return err
 		}
 
-		return nil
+		action := models.AuditItemPurchased
+		if gift != nil {
+			action = models.AuditItemGifted
+		}
+		var promotion, discount any
+		if price.Promotion != nil {
+			promotion = price.Promotion.ID
+		}
+		if redemption != nil {
+			discount = redemption.Discount
+		}
+		return s.auditLog.Record(ctx, tx, models.AuditEntry{
+			Actor:  username,
+			Action: action,
+			Target: item,
+			Amount: &price.Price,
+			Details: auditDetails(map[string]any{
+				"basePrice":     price.BasePrice,
+				"promotion":     promotion,
+				"promoCode":     normalizePromoCode(code),
+				"promoDiscount": discount,
+				"giftTo":        giftTo,
+			}),
+		})
 	})
+}
 
+// lockRecipient блокирует покупателя и получателя подарка и проверяет, что получатель может получать подарки
+func (s *DefaultShopService) lockRecipient(ctx context.Context, tx pgx.Tx, username, toUser string) error {
+	users, err := lockUsers(ctx, tx, s.userRepo, username, toUser)
 	if err != nil {
-		s.logger.Error("Failed to buy item", "item", item, "error", err)
 		return err
 	}
 
-	s.logger.Info("Purchase completed successfully", "username", username, "item", item)
+	recipient := users[toUser]
+	if recipient.Role != models.RoleUser || recipient.Disabled() || recipient.Deleted() {
+		s.logger.Warn("Gift recipient cannot receive gifts", "to_user", toUser, "role", recipient.Role)
+		return e.ErrInvalidUser
+	}
 	return nil
 }
@@ -0,0 +1,44 @@
+package services
+
+import (
+	"testing"
+
+	"API-Avito-shop/internal/models"
+)
+
+func TestGiftVisibleInBothHistories(t *testing.T) {
+	pool := testPool(t)
+	ctx := testContext(t)
+	shopService, _ := newTestShopService(pool)
+	userService := newTestUserService(pool, CoinExpiryPolicy{Mode: models.ExpiryNone})
+
+	sender := newTestUser(t, pool, "gift_sender")
+	recipient := newTestUser(t, pool, "gift_recipient")
+	if err := shopService.GiftProduct(ctx, "cup", sender, "", models.Gift{ToUser: recipient, Message: "Спасибо!"}); err != nil {
+		t.Fatalf("GiftProduct() error = %v", err)
+	}
+
+	senderInfo, err := userService.UserInfo(ctx, sender)
+	if err != nil {
+		t.Fatalf("UserInfo(%s) error = %v", sender, err)
+	}
+	sent := senderInfo.Gifts.Sent
+	if len(sent) != 1 || sent[0].ToUser != recipient || sent[0].Item != "cup" || sent[0].Message != "Спасибо!" {
+		t.Errorf("sender gifts = %+v, want cup sent to %s", senderInfo.Gifts, recipient)
+	}
+	if len(senderInfo.Inventory) != 0 {
+		t.Errorf("sender inventory = %v, want empty", senderInfo.Inventory)
+	}
+
+	recipientInfo, err := userService.UserInfo(ctx, recipient)
+	if err != nil {
+		t.Fatalf("UserInfo(%s) error = %v", recipient, err)
+	}
+	received := recipientInfo.Gifts.Received
+	if len(received) != 1 || received[0].FromUser != sender || received[0].Item != "cup" || received[0].Message != "Спасибо!" {
+		t.Errorf("recipient gifts = %+v, want cup received from %s", recipientInfo.Gifts, sender)
+	}
+	if len(recipientInfo.Inventory) != 1 || recipientInfo.Inventory[0].Type != "cup" {
+		t.Errorf("recipient inventory = %v, want one cup", recipientInfo.Inventory)
+	}
+}
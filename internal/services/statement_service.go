@@ -0,0 +1,119 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"time"
+
+	"API-Avito-shop/internal/dto"
+	"API-Avito-shop/internal/models"
+	r "API-Avito-shop/internal/repositories"
+)
+
+type StatementService interface {
+	Statement(ctx context.Context, username string, query *dto.StatementQuery) (dto.Statement, error)
+}
+
+type DefaultStatementService struct {
+	statementRepo r.StatementRepository
+	logger        *slog.Logger
+}
+
+func NewStatementService(statementRepo r.StatementRepository, logger *slog.Logger) *DefaultStatementService {
+	return &DefaultStatementService{
+		statementRepo: statementRepo,
+		logger:        logger,
+	}
+}
+
+// Statement строит выписку за период: баланс на начало, операции с остатком после каждой и итоги по видам операций.
+// Баланс на начало периода вычисляется от текущего баланса за вычетом всех движений после начала периода.
+func (s *DefaultStatementService) Statement(ctx context.Context, username string, query *dto.StatementQuery) (dto.Statement, error) {
+	from, to, err := datePeriod(query.From, query.To, time.Now().UTC())
+	if err != nil {
+		return dto.Statement{}, err
+	}
+
+	balance, movements, err := s.statementRepo.MovementsSince(ctx, username, from)
+	if err != nil {
+		s.logger.Error("Failed to get movements", "username", username, "error", err)
+		return dto.Statement{}, err
+	}
+
+	opening := balance
+	for _, movement := range movements {
+		opening -= movement.Amount
+	}
+
+	statement := dto.Statement{
+		UserName:       username,
+		From:           from,
+		To:             to.AddDate(0, 0, -1),
+		OpeningBalance: opening,
+		Entries:        make([]dto.StatementEntry, 0),
+		Summary:        dto.StatementSummary{ByItem: make([]dto.StatementItemTotal, 0)},
+	}
+
+	running := opening
+	items := make(map[string]*dto.StatementItemTotal)
+	for _, movement := range movements {
+		if !movement.CreatedAt.Before(to) {
+			break
+		}
+		running += movement.Amount
+
+		entry := dto.StatementEntry{Date: movement.CreatedAt, Type: movement.Kind, Amount: movement.Amount, Balance: running}
+		switch movement.Kind {
+		case models.MovementTransferIn:
+			entry.Counterparty = movement.Counterparty
+			addStatementTotal(&statement.Summary.TransfersIn, movement.Amount)
+		case models.MovementTransferOut:
+			entry.Counterparty = movement.Counterparty
+			addStatementTotal(&statement.Summary.TransfersOut, -movement.Amount)
+		case models.MovementGrant:
+			entry.Reason = movement.Counterparty
+			addStatementTotal(&statement.Summary.Grants, movement.Amount)
+		case models.MovementExpiry:
+			addStatementTotal(&statement.Summary.Expired, -movement.Amount)
+		case models.MovementPurchase:
+			entry.Item = movement.Counterparty
+			entry.Counterparty = movement.GiftTo
+			addStatementTotal(&statement.Summary.Purchases, -movement.Amount)
+			item, ok := items[movement.Counterparty]
+			if !ok {
+				item = &dto.StatementItemTotal{Item: movement.Counterparty}
+				items[movement.Counterparty] = item
+			}
+			item.Quantity++
+			item.Amount -= movement.Amount
+		}
+
+		if movement.Amount > 0 {
+			statement.TotalCredits += movement.Amount
+		} else {
+			statement.TotalDebits -= movement.Amount
+		}
+		statement.Entries = append(statement.Entries, entry)
+	}
+	statement.ClosingBalance = running
+
+	for _, item := range items {
+		statement.Summary.ByItem = append(statement.Summary.ByItem, *item)
+	}
+	sort.Slice(statement.Summary.ByItem, func(i, j int) bool {
+		a, b := statement.Summary.ByItem[i], statement.Summary.ByItem[j]
+		if a.Amount != b.Amount {
+			return a.Amount > b.Amount
+		}
+		return a.Item < b.Item
+	})
+
+	return statement, nil
+}
+
+// addStatementTotal учитывает операцию в итогах
+func addStatementTotal(total *dto.StatementTotal, amount int) {
+	total.Count++
+	total.Amount += amount
+}
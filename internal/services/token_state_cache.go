@@ -0,0 +1,65 @@
+package services
+
+import (
+	"sync"
+	"time"
+)
+
+// tokenStateCacheLimit количество записей, после которого при добавлении удаляются устаревшие
+const tokenStateCacheLimit = 10000
+
+// tokenStateCache хранит в памяти процесса версии токенов, недавно подтвержденные базой данных.
+// Кешируются только действующие версии, поэтому новый токен после смены пароля сразу проверяется по базе,
+// а отзыв старых токенов и отключение учетной записи вступают в силу не позже чем через ttl.
+type tokenStateCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]tokenStateEntry
+	now     func() time.Time
+}
+
+type tokenStateEntry struct {
+	version   int
+	expiresAt time.Time
+}
+
+func newTokenStateCache(ttl time.Duration) *tokenStateCache {
+	return &tokenStateCache{
+		ttl:     ttl,
+		entries: make(map[string]tokenStateEntry),
+		now:     time.Now,
+	}
+}
+
+// valid сообщает, подтверждена ли версия токена пользователя в течение ttl
+func (c *tokenStateCache) valid(username string, version int) bool {
+	if c.ttl <= 0 {
+		return false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[username]
+	return ok && entry.version == version && c.now().Before(entry.expiresAt)
+}
+
+// store запоминает действующую версию токена пользователя
+func (c *tokenStateCache) store(username string, version int) {
+	if c.ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	if len(c.entries) >= tokenStateCacheLimit {
+		for key, entry := range c.entries {
+			if !now.Before(entry.expiresAt) {
+				delete(c.entries, key)
+			}
+		}
+	}
+	c.entries[username] = tokenStateEntry{version: version, expiresAt: now.Add(c.ttl)}
+}
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// newTestTokenStateCache возвращает кеш с управляемыми часами
+func newTestTokenStateCache(ttl time.Duration) (*tokenStateCache, func(time.Duration)) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache := newTokenStateCache(ttl)
+	cache.now = func() time.Time { return now }
+	return cache, func(d time.Duration) { now = now.Add(d) }
+}
+
+func TestTokenStateCache(t *testing.T) {
+	cache, advance := newTestTokenStateCache(time.Minute)
+
+	if cache.valid("alice", 1) {
+		t.Error("valid() before store = true, want false")
+	}
+
+	cache.store("alice", 1)
+	tests := []struct {
+		name     string
+		username string
+		version  int
+		want     bool
+	}{
+		{"stored version", "alice", 1, true},
+		// Новый токен после смены пароля не подтверждается кешем
+		{"newer version", "alice", 2, false},
+		{"older version", "alice", 0, false},
+		{"other user", "bob", 1, false},
+	}
+	for _, tt := range tests {
+		if got := cache.valid(tt.username, tt.version); got != tt.want {
+			t.Errorf("%s: valid(%s, %d) = %v, want %v", tt.name, tt.username, tt.version, got, tt.want)
+		}
+	}
+
+	advance(time.Minute - time.Second)
+	if !cache.valid("alice", 1) {
+		t.Error("valid() within ttl = false, want true")
+	}
+	// По истечении ttl версия снова проверяется по базе, отзыв вступает в силу
+	advance(time.Second)
+	if cache.valid("alice", 1) {
+		t.Error("valid() after ttl = true, want false")
+	}
+
+	cache.store("alice", 1)
+	cache.store("alice", 2)
+	if cache.valid("alice", 1) || !cache.valid("alice", 2) {
+		t.Error("store() did not replace the cached version")
+	}
+}
+
+func TestTokenStateCacheDisabled(t *testing.T) {
+	cache, _ := newTestTokenStateCache(0)
+
+	cache.store("alice", 1)
+	if cache.valid("alice", 1) {
+		t.Error("valid() with zero ttl = true, want false")
+	}
+	if len(cache.entries) != 0 {
+		t.Errorf("cache with zero ttl holds %d entries, want 0", len(cache.entries))
+	}
+}
+
+func TestTokenStateCacheEviction(t *testing.T) {
+	cache, advance := newTestTokenStateCache(time.Minute)
+
+	for i := 0; i < tokenStateCacheLimit; i++ {
+		cache.store(fmt.Sprintf("user%d", i), 1)
+	}
+	advance(time.Minute)
+	cache.store("fresh", 1)
+
+	// При переполнении устаревшие записи удаляются
+	if len(cache.entries) != 1 || !cache.valid("fresh", 1) {
+		t.Errorf("cache holds %d entries after eviction, want only the fresh one", len(cache.entries))
+	}
+}
@@ -2,67 +2,272 @@ package services
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 
 	"API-Avito-shop/internal/dto"
 	e "API-Avito-shop/internal/errors"
+	"API-Avito-shop/internal/models"
 	r "API-Avito-shop/internal/repositories"
 
 	"github.com/jackc/pgx/v5"
 )
 
 type TransactionService interface {
-	SendCoin(ctx context.Context, username string, sendCoinDTO *dto.SendCoin) error
+	SendCoin(ctx context.Context, username string, sendCoinDTO *dto.SendCoin) (dto.SendCoinResult, error)
+	CompleteTransfer(ctx context.Context, tx pgx.Tx, fromUser, toUser string, amount int) error
 }
 
 type DefaultTransactionService struct {
-	userRepo        r.UserRepository
-	transactionRepo r.TransactionRepository
-	txExecutor      TxExecutor
-	logger          *slog.Logger
+	userRepo         r.UserRepository
+	transactionRepo  r.TransactionRepository
+	outboxRepo       r.OutboxRepository
+	notificationRepo r.NotificationRepository
+	fraudRepo        r.FraudRepository
+	coinLotRepo      r.CoinLotRepository
+	fraudDetector    FraudDetector
+	auditLog         AuditRecorder
+	txExecutor       TxExecutor
+	logger           *slog.Logger
 }
 
-func NewTransactionService(userRepo r.UserRepository, transactionRepo r.TransactionRepository, txHelper TxExecutor, logger *slog.Logger) *DefaultTransactionService {
+func NewTransactionService(userRepo r.UserRepository, transactionRepo r.TransactionRepository, outboxRepo r.OutboxRepository, notificationRepo r.NotificationRepository, fraudRepo r.FraudRepository, coinLotRepo r.CoinLotRepository, fraudDetector FraudDetector, auditLog AuditRecorder, txHelper TxExecutor, logger *slog.Logger) *DefaultTransactionService {
 	return &DefaultTransactionService{
-		userRepo:        userRepo,
-		transactionRepo: transactionRepo,
-		txExecutor:      txHelper,
-		logger:          logger,
+		userRepo:         userRepo,
+		transactionRepo:  transactionRepo,
+		outboxRepo:       outboxRepo,
+		notificationRepo: notificationRepo,
+		fraudRepo:        fraudRepo,
+		coinLotRepo:      coinLotRepo,
+		fraudDetector:    fraudDetector,
+		auditLog:         auditLog,
+		txExecutor:       txHelper,
+		logger:           logger,
 	}
 }
 
-// SendCoin производит транзакцию монет между пользователями
-func (s *DefaultTransactionService) SendCoin(ctx context.Context, username string, sendCoinDTO *dto.SendCoin) error {
+// SendCoin производит транзакцию монет между пользователями после проверки правилами обнаружения мошенничества.
+// Отмеченный перевод выполняется и попадает в очередь проверки, задержанный ждет решения администратора
+// без списания монет, заблокированный отклоняется с ErrTransferBlocked.
+func (s *DefaultTransactionService) SendCoin(ctx context.Context, username string, sendCoinDTO *dto.SendCoin) (dto.SendCoinResult, error) {
 	s.logger.Info("Starting to send coins from user to user", "from_user", username, "to_user", sendCoinDTO.ToUser)
 
+	result := dto.SendCoinResult{Status: models.TransferCompleted}
+	var decision models.FraudDecision
 	err := s.txExecutor.RunWithTransaction(ctx, func(tx pgx.Tx) error {
 		if sendCoinDTO.ToUser == username {
 			s.logger.Warn("Sender matches recipient", "from_user", username, "to_user", sendCoinDTO.ToUser)
 			return e.ErrInvalidUser
 		}
 
-		if err := s.userRepo.SubtractCoins(ctx, tx, username, sendCoinDTO.Amount); err != nil {
+		// Правила считают недавние переводы отправителя и получателя, поэтому оба блокируются до проверки,
+		// иначе параллельные переводы не видят друг друга и обходят пороги
+		if _, err := lockUsers(ctx, tx, s.userRepo, username, sendCoinDTO.ToUser); err != nil {
 			return err
 		}
-		s.logger.Info("Coins left the user", "from_user", username)
 
-		if err := s.userRepo.AddCoins(ctx, tx, sendCoinDTO.ToUser, sendCoinDTO.Amount); err != nil {
+		var err error
+		decision, err = s.fraudDetector.Evaluate(ctx, tx, username, sendCoinDTO.ToUser, sendCoinDTO.Amount)
+		if err != nil {
 			return err
 		}
-		s.logger.Info("Coins reached the user", "from_user", username)
 
-		if err := s.transactionRepo.TransferCoin(ctx, tx, username, sendCoinDTO.ToUser, sendCoinDTO.Amount); err != nil {
+		switch decision.Action {
+		case models.FraudActionBlock:
+			return e.ErrTransferBlocked
+		case models.FraudActionHold:
+			result.Status = models.TransferHeld
+			result.ReviewID, err = s.holdTransfer(ctx, tx, username, sendCoinDTO, decision)
 			return err
+		case models.FraudActionFlag:
+			return s.flagTransfer(ctx, tx, username, sendCoinDTO, decision)
 		}
 
-		return nil
+		return s.CompleteTransfer(ctx, tx, username, sendCoinDTO.ToUser, sendCoinDTO.Amount)
 	})
 
 	if err != nil {
+		if errors.Is(err, e.ErrTransferBlocked) {
+			s.recordBlockedTransfer(ctx, username, sendCoinDTO, decision)
+		}
 		s.logger.Error("Failed to send coins", "from_user", username, "to_user", sendCoinDTO.ToUser, "error", err)
+		return result, err
+	}
+
+	s.logger.Info("Coins sent successfully", "from_user", username, "to_user", sendCoinDTO.ToUser, "status", result.Status)
+	return result, nil
+}
+
+// CompleteTransfer переводит монеты в рамках транзакции: меняет балансы, сохраняет перевод,
+// публикует событие, уведомляет участников и записывает перевод в журнал аудита.
+// Баланс проверяется под блокировкой, так как задержанный перевод выполняется позже и монеты для него не резервируются.
+func (s *DefaultTransactionService) CompleteTransfer(ctx context.Context, tx pgx.Tx, fromUser, toUser string, amount int) error {
+	users, err := lockUsers(ctx, tx, s.userRepo, fromUser, toUser)
+	if err != nil {
+		return err
+	}
+	if users[fromUser].Balance < amount {
+		s.logger.Warn("Not enough coins to complete transfer", "from_user", fromUser, "amount", amount)
+		return e.ErrNotEnoughCoins
+	}
+
+	if err = s.userRepo.SubtractCoins(ctx, tx, fromUser, amount); err != nil {
 		return err
 	}
+	lots, err := s.coinLotRepo.Consume(ctx, tx, fromUser, amount)
+	if err != nil {
+		return err
+	}
+	s.logger.Info("Coins left the user", "from_user", fromUser)
+
+	if err = s.userRepo.AddCoins(ctx, tx, toUser, amount); err != nil {
+		return err
+	}
+	// Монеты переходят получателю со временем первоначального начисления, срок действия не продлевается
+	if err = s.coinLotRepo.Credit(ctx, tx, toUser, lots); err != nil {
+		return err
+	}
+	s.logger.Info("Coins reached the user", "from_user", fromUser)
+
+	if err = s.transactionRepo.TransferCoin(ctx, tx, fromUser, toUser, amount); err != nil {
+		return err
+	}
+
+	if err = s.outboxRepo.AddEvent(ctx, tx, models.EventCoinsSent, models.CoinsSentPayload{
+		FromUser: fromUser,
+		ToUser:   toUser,
+		Amount:   amount,
+	}); err != nil {
+		return err
+	}
+
+	if err = s.notifyTransfer(ctx, tx, fromUser, toUser, amount); err != nil {
+		return err
+	}
+
+	return s.auditLog.Record(ctx, tx, models.AuditEntry{
+		Actor:  fromUser,
+		Action: models.AuditCoinsSent,
+		Target: toUser,
+		Amount: &amount,
+	})
+}
+
+// flagTransfer выполняет перевод и добавляет его в очередь проверки
+func (s *DefaultTransactionService) flagTransfer(ctx context.Context, tx pgx.Tx, username string, sendCoinDTO *dto.SendCoin, decision models.FraudDecision) error {
+	id, err := s.fraudRepo.CreateReview(ctx, tx, &models.FraudReview{
+		FromUser: username,
+		ToUser:   sendCoinDTO.ToUser,
+		Amount:   sendCoinDTO.Amount,
+		Action:   decision.Action,
+		Rules:    decision.Rules,
+		Status:   models.FraudReviewPending,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err = s.CompleteTransfer(ctx, tx, username, sendCoinDTO.ToUser, sendCoinDTO.Amount); err != nil {
+		return err
+	}
+
+	return s.auditLog.Record(ctx, tx, models.AuditEntry{
+		Actor:   username,
+		Action:  models.AuditTransferFlagged,
+		Target:  sendCoinDTO.ToUser,
+		Amount:  &sendCoinDTO.Amount,
+		Details: auditDetails(map[string]any{"review": id, "rules": decision.Rules}),
+	})
+}
+
+// holdTransfer добавляет перевод в очередь проверки без списания монет.
+// Баланс проверяется сразу, чтобы не задерживать заведомо невыполнимый перевод, и повторно при одобрении.
+func (s *DefaultTransactionService) holdTransfer(ctx context.Context, tx pgx.Tx, username string, sendCoinDTO *dto.SendCoin, decision models.FraudDecision) (int64, error) {
+	balance, err := s.userRepo.GetBalance(ctx, tx, username)
+	if err != nil {
+		return 0, err
+	}
+	if balance < sendCoinDTO.Amount {
+		return 0, e.ErrNotEnoughCoins
+	}
+
+	id, err := s.fraudRepo.CreateReview(ctx, tx, &models.FraudReview{
+		FromUser: username,
+		ToUser:   sendCoinDTO.ToUser,
+		Amount:   sendCoinDTO.Amount,
+		Action:   decision.Action,
+		Rules:    decision.Rules,
+		Status:   models.FraudReviewPending,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return id, s.auditLog.Record(ctx, tx, models.AuditEntry{
+		Actor:   username,
+		Action:  models.AuditTransferHeld,
+		Target:  sendCoinDTO.ToUser,
+		Amount:  &sendCoinDTO.Amount,
+		Details: auditDetails(map[string]any{"review": id, "rules": decision.Rules}),
+	})
+}
+
+// recordBlockedTransfer сохраняет заблокированный перевод в очереди проверки в отдельной транзакции,
+// так как транзакция перевода откатывается. Ошибка записи только логируется.
+func (s *DefaultTransactionService) recordBlockedTransfer(ctx context.Context, username string, sendCoinDTO *dto.SendCoin, decision models.FraudDecision) {
+	err := s.txExecutor.RunWithTransaction(ctx, func(tx pgx.Tx) error {
+		id, err := s.fraudRepo.CreateReview(ctx, tx, &models.FraudReview{
+			FromUser: username,
+			ToUser:   sendCoinDTO.ToUser,
+			Amount:   sendCoinDTO.Amount,
+			Action:   decision.Action,
+			Rules:    decision.Rules,
+			Status:   models.FraudReviewBlocked,
+		})
+		if err != nil {
+			return err
+		}
+
+		return s.auditLog.Record(ctx, tx, models.AuditEntry{
+			Actor:   username,
+			Action:  models.AuditTransferBlocked,
+			Target:  sendCoinDTO.ToUser,
+			Amount:  &sendCoinDTO.Amount,
+			Details: auditDetails(map[string]any{"review": id, "rules": decision.Rules}),
+		})
+	})
+	if err != nil {
+		s.logger.Error("Failed to record blocked transfer", "from_user", username, "to_user", sendCoinDTO.ToUser, "error", err)
+	}
+}
+
+// notifyTransfer уведомляет отправителя и получателя о переводе и новом балансе
+func (s *DefaultTransactionService) notifyTransfer(ctx context.Context, tx pgx.Tx, fromUser, toUser string, amount int) error {
+	err := s.notificationRepo.Notify(ctx, tx, fromUser, models.NotificationCoinsSent, models.CoinsSentData{
+		ToUser: toUser,
+		Amount: amount,
+	})
+	if err != nil {
+		return err
+	}
+
+	err = s.notificationRepo.Notify(ctx, tx, toUser, models.NotificationCoinsReceived, models.CoinsReceivedData{
+		FromUser: fromUser,
+		Amount:   amount,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, name := range []string{fromUser, toUser} {
+		balance, err := s.userRepo.GetBalance(ctx, tx, name)
+		if err != nil {
+			return err
+		}
+		if err = s.notificationRepo.Notify(ctx, tx, name, models.NotificationBalanceChanged, models.BalanceChangedData{Coins: balance}); err != nil {
+			return err
+		}
+	}
 
-	s.logger.Info("Coins sent successfully", "from_user", username, "to_user", sendCoinDTO.ToUser)
 	return nil
 }
@@ -0,0 +1,339 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"API-Avito-shop/internal/dto"
+	e "API-Avito-shop/internal/errors"
+	"API-Avito-shop/internal/models"
+	r "API-Avito-shop/internal/repositories"
+	"API-Avito-shop/internal/utils/totp"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// recoveryCodesCount количество кодов восстановления, выдаваемых за раз
+const recoveryCodesCount = 10
+
+type TwoFactorService interface {
+	Enroll(ctx context.Context, username string) (dto.TOTPEnrollment, error)
+	Confirm(ctx context.Context, username, code string) ([]string, int, error)
+	Disable(ctx context.Context, username, code string) (int, error)
+	RegenerateRecoveryCodes(ctx context.Context, username, code string) ([]string, error)
+	IsEnabled(ctx context.Context, username string) (bool, error)
+	BeginLogin(ctx context.Context, username string) (string, error)
+	CompleteLogin(ctx context.Context, loginDTO *dto.TwoFactorLogin, clientIP string) (*models.User, error)
+}
+
+// TwoFactorPolicy задает параметры двухфакторной аутентификации
+type TwoFactorPolicy struct {
+	Issuer       string
+	ChallengeTTL time.Duration
+	MaxAttempts  int
+}
+
+type DefaultTwoFactorService struct {
+	twoFactorRepo r.TwoFactorRepository
+	userRepo      r.UserRepository
+	outboxRepo    r.OutboxRepository
+	loginGuard    LoginGuard
+	auditLog      AuditRecorder
+	txExecutor    TxExecutor
+	policy        TwoFactorPolicy
+	logger        *slog.Logger
+}
+
+func NewTwoFactorService(twoFactorRepo r.TwoFactorRepository, userRepo r.UserRepository, outboxRepo r.OutboxRepository, loginGuard LoginGuard, auditLog AuditRecorder, txHelper TxExecutor, policy TwoFactorPolicy, logger *slog.Logger) *DefaultTwoFactorService {
+	return &DefaultTwoFactorService{
+		twoFactorRepo: twoFactorRepo,
+		userRepo:      userRepo,
+		outboxRepo:    outboxRepo,
+		loginGuard:    loginGuard,
+		auditLog:      auditLog,
+		txExecutor:    txHelper,
+		policy:        policy,
+		logger:        logger,
+	}
+}
+
+// Enroll создает новый секрет. Двухфакторная аутентификация включается только после подтверждения кодом.
+func (s *DefaultTwoFactorService) Enroll(ctx context.Context, username string) (dto.TOTPEnrollment, error) {
+	s.logger.Info("Starting two-factor enrollment", "username", username)
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return dto.TOTPEnrollment{}, err
+	}
+
+	if err = s.twoFactorRepo.SaveSecret(ctx, username, secret); err != nil {
+		s.logger.Error("Failed to save totp secret", "username", username, "error", err)
+		return dto.TOTPEnrollment{}, err
+	}
+
+	return dto.TOTPEnrollment{
+		Secret:     secret,
+		OTPAuthURI: totp.URI(s.policy.Issuer, username, secret),
+	}, nil
+}
+
+// Confirm включает двухфакторную аутентификацию и выдает коды восстановления.
+// Ранее выданные токены отзываются, возвращается новая версия токенов.
+func (s *DefaultTwoFactorService) Confirm(ctx context.Context, username, code string) ([]string, int, error) {
+	s.logger.Info("Starting two-factor confirmation", "username", username)
+
+	t, err := s.twoFactorRepo.GetTOTP(ctx, username)
+	if err != nil {
+		return nil, 0, err
+	}
+	if t.Enabled() {
+		return nil, 0, e.ErrTwoFactorEnabled
+	}
+
+	step, ok := totp.Validate(t.Secret, code, time.Now(), 1)
+	if !ok {
+		s.logger.Warn("Invalid two-factor confirmation code", "username", username)
+		return nil, 0, e.ErrInvalidTwoFactor
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var version int
+	err = s.txExecutor.RunWithTransaction(ctx, func(tx pgx.Tx) error {
+		if err := s.twoFactorRepo.Confirm(ctx, tx, username, step); err != nil {
+			return err
+		}
+		if err := s.twoFactorRepo.ReplaceRecoveryCodes(ctx, tx, username, hashes); err != nil {
+			return err
+		}
+
+		version, err = s.userRepo.BumpTokenVersion(ctx, tx, username)
+		if err != nil {
+			return err
+		}
+
+		if err := s.outboxRepo.AddEvent(ctx, tx, models.EventTwoFactorEnabled, models.TwoFactorPayload{Username: username}); err != nil {
+			return err
+		}
+
+		return s.auditLog.Record(ctx, tx, models.AuditEntry{Actor: username, Action: models.AuditTwoFactorEnabled, Target: username})
+	})
+	if err != nil {
+		s.logger.Error("Failed to confirm two-factor authentication", "username", username, "error", err)
+		return nil, 0, err
+	}
+
+	s.logger.Info("Two-factor authentication enabled", "username", username)
+	return codes, version, nil
+}
+
+// Disable отключает двухфакторную аутентификацию после проверки кода.
+// Ранее выданные токены отзываются, возвращается новая версия токенов.
+func (s *DefaultTwoFactorService) Disable(ctx context.Context, username, code string) (int, error) {
+	s.logger.Info("Starting to disable two-factor authentication", "username", username)
+
+	if err := s.verifyCode(ctx, username, code); err != nil {
+		return 0, err
+	}
+
+	var version int
+	err := s.txExecutor.RunWithTransaction(ctx, func(tx pgx.Tx) error {
+		if err := s.twoFactorRepo.Delete(ctx, tx, username); err != nil {
+			return err
+		}
+
+		var err error
+		version, err = s.userRepo.BumpTokenVersion(ctx, tx, username)
+		if err != nil {
+			return err
+		}
+
+		if err := s.outboxRepo.AddEvent(ctx, tx, models.EventTwoFactorDisabled, models.TwoFactorPayload{Username: username}); err != nil {
+			return err
+		}
+
+		return s.auditLog.Record(ctx, tx, models.AuditEntry{Actor: username, Action: models.AuditTwoFactorDisabled, Target: username})
+	})
+	if err != nil {
+		s.logger.Error("Failed to disable two-factor authentication", "username", username, "error", err)
+		return 0, err
+	}
+
+	s.logger.Info("Two-factor authentication disabled", "username", username)
+	return version, nil
+}
+
+// RegenerateRecoveryCodes заменяет коды восстановления после проверки кода
+func (s *DefaultTwoFactorService) RegenerateRecoveryCodes(ctx context.Context, username, code string) ([]string, error) {
+	if err := s.verifyCode(ctx, username, code); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.txExecutor.RunWithTransaction(ctx, func(tx pgx.Tx) error {
+		return s.twoFactorRepo.ReplaceRecoveryCodes(ctx, tx, username, hashes)
+	})
+	if err != nil {
+		s.logger.Error("Failed to regenerate recovery codes", "username", username, "error", err)
+		return nil, err
+	}
+
+	s.logger.Info("Recovery codes regenerated", "username", username)
+	return codes, nil
+}
+
+// IsEnabled сообщает, включена ли двухфакторная аутентификация
+func (s *DefaultTwoFactorService) IsEnabled(ctx context.Context, username string) (bool, error) {
+	t, err := s.twoFactorRepo.GetTOTP(ctx, username)
+	if err != nil {
+		if errors.Is(err, e.ErrTwoFactorNotEnabled) {
+			return false, nil
+		}
+		return false, err
+	}
+	return t.Enabled(), nil
+}
+
+// BeginLogin создает вход, ожидающий второй фактор, если он включен у пользователя.
+// Возвращает пустую строку, если второй фактор не требуется.
+func (s *DefaultTwoFactorService) BeginLogin(ctx context.Context, username string) (string, error) {
+	enabled, err := s.IsEnabled(ctx, username)
+	if err != nil || !enabled {
+		return "", err
+	}
+
+	challenge, err := randomToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	if err = s.twoFactorRepo.CreateChallenge(ctx, hashToken(challenge), username, s.policy.ChallengeTTL); err != nil {
+		return "", err
+	}
+
+	s.logger.Info("Two-factor challenge created", "username", username)
+	return challenge, nil
+}
+
+// CompleteLogin проверяет второй фактор и возвращает пользователя.
+// Неверные коды учитываются как неудачные попытки входа.
+func (s *DefaultTwoFactorService) CompleteLogin(ctx context.Context, loginDTO *dto.TwoFactorLogin, clientIP string) (*models.User, error) {
+	challengeHash := hashToken(loginDTO.ChallengeToken)
+
+	username, err := s.twoFactorRepo.GetChallenge(ctx, challengeHash, s.policy.MaxAttempts)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.loginGuard.CheckLocked(ctx, username, clientIP); err != nil {
+		return nil, err
+	}
+
+	if err = s.verifyCode(ctx, username, loginDTO.Code); err != nil {
+		if errors.Is(err, e.ErrInvalidTwoFactor) {
+			if err := s.twoFactorRepo.FailChallenge(ctx, challengeHash); err != nil {
+				s.logger.Error("Failed to register challenge failure", "username", username, "error", err)
+			}
+			if err := s.loginGuard.RegisterFailure(ctx, username, clientIP, "invalid_second_factor"); err != nil {
+				s.logger.Error("Failed to register login failure", "username", username, "error", err)
+			}
+		}
+		return nil, err
+	}
+
+	if err = s.twoFactorRepo.DeleteChallenge(ctx, challengeHash); err != nil {
+		return nil, err
+	}
+
+	// Учетная запись могла быть отключена, пока пользователь вводил код
+	user, err := s.userRepo.GetUser(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if user.Disabled() {
+		s.logger.Warn("Disabled user tried to complete two-factor login", "username", username)
+		return nil, e.ErrUserDisabled
+	}
+
+	if err = s.loginGuard.RegisterSuccess(ctx, username); err != nil {
+		s.logger.Error("Failed to reset login failures", "username", username, "error", err)
+	}
+
+	s.auditLog.RecordStandalone(ctx, models.AuditEntry{
+		Actor:   username,
+		Action:  models.AuditLogin,
+		Target:  username,
+		Details: auditDetails(map[string]any{"method": "totp"}),
+	})
+
+	s.logger.Info("Two-factor login completed", "username", username)
+	return user, nil
+}
+
+// verifyCode принимает код приложения-аутентификатора или неиспользованный код восстановления.
+// Код приложения нельзя использовать повторно.
+func (s *DefaultTwoFactorService) verifyCode(ctx context.Context, username, code string) error {
+	t, err := s.twoFactorRepo.GetTOTP(ctx, username)
+	if err != nil {
+		return err
+	}
+	if !t.Enabled() {
+		return e.ErrTwoFactorNotEnabled
+	}
+
+	code = strings.TrimSpace(code)
+	if step, ok := totp.Validate(t.Secret, code, time.Now(), 1); ok {
+		fresh, err := s.twoFactorRepo.UseStep(ctx, username, step)
+		if err != nil {
+			return err
+		}
+		if !fresh {
+			s.logger.Warn("Two-factor code reused", "username", username)
+			return e.ErrInvalidTwoFactor
+		}
+		return nil
+	}
+
+	used, err := s.twoFactorRepo.UseRecoveryCode(ctx, username, hashToken(normalizeRecoveryCode(code)))
+	if err != nil {
+		return err
+	}
+	if !used {
+		s.logger.Warn("Invalid two-factor code", "username", username)
+		return e.ErrInvalidTwoFactor
+	}
+	return nil
+}
+
+// generateRecoveryCodes создает коды восстановления вида xxxxx-xxxxx и их хеши
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, recoveryCodesCount)
+	hashes := make([]string, 0, recoveryCodesCount)
+
+	for range recoveryCodesCount {
+		raw, err := randomToken(5)
+		if err != nil {
+			return nil, nil, err
+		}
+		code := raw[:5] + "-" + raw[5:]
+		codes = append(codes, code)
+		hashes = append(hashes, hashToken(normalizeRecoveryCode(code)))
+	}
+
+	return codes, hashes, nil
+}
+
+// normalizeRecoveryCode приводит код к виду без дефисов и в нижнем регистре
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.ReplaceAll(code, "-", ""))
+}
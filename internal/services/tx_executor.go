@@ -28,7 +28,7 @@ func NewTxExecutor(pool *pgxpool.Pool, logger *slog.Logger) *DefaultTxExecutor {
 func (t *DefaultTxExecutor) RunWithTransaction(ctx context.Context, fn func(tx pgx.Tx) error) (err error) {
 	tx, err := t.pool.Begin(ctx)
 	if err != nil {
-		t.logger.Error("failed to start transaction", err)
+		t.logger.Error("failed to start transaction", "error", err)
 		return fmt.Errorf("failed to start transaction: %w", err)
 	}
 
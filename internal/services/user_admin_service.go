@@ -0,0 +1,224 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+
+	"API-Avito-shop/internal/dto"
+	e "API-Avito-shop/internal/errors"
+	"API-Avito-shop/internal/models"
+	r "API-Avito-shop/internal/repositories"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type UserManagementService interface {
+	SearchUsers(ctx context.Context, query *dto.UserSearchQuery, limit, offset int) ([]dto.AdminUser, error)
+	GetUserDetails(ctx context.Context, username string) (dto.AdminUserDetails, error)
+	DisableUser(ctx context.Context, admin, username, reason string) error
+	EnableUser(ctx context.Context, admin, username string) error
+	DeleteUser(ctx context.Context, admin, username string) error
+}
+
+type DefaultUserManagementService struct {
+	userRepo    r.UserRepository
+	apiKeyRepo  r.APIKeyRepository
+	webhookRepo r.WebhookRepository
+	outboxRepo  r.OutboxRepository
+	userService UserService
+	auditLog    AuditRecorder
+	txExecutor  TxExecutor
+	logger      *slog.Logger
+}
+
+func NewUserManagementService(userRepo r.UserRepository, apiKeyRepo r.APIKeyRepository, webhookRepo r.WebhookRepository, outboxRepo r.OutboxRepository, userService UserService, auditLog AuditRecorder, txHelper TxExecutor, logger *slog.Logger) *DefaultUserManagementService {
+	return &DefaultUserManagementService{
+		userRepo:    userRepo,
+		apiKeyRepo:  apiKeyRepo,
+		webhookRepo: webhookRepo,
+		outboxRepo:  outboxRepo,
+		userService: userService,
+		auditLog:    auditLog,
+		txExecutor:  txHelper,
+		logger:      logger,
+	}
+}
+
+// SearchUsers предоставляет список пользователей по подстроке имени, роли и статусу
+func (s *DefaultUserManagementService) SearchUsers(ctx context.Context, query *dto.UserSearchQuery, limit, offset int) ([]dto.AdminUser, error) {
+	users, err := s.userRepo.SearchUsers(ctx, models.UserFilter{
+		Query:  query.Query,
+		Role:   query.Role,
+		Status: query.Status,
+	}, limit, offset)
+	if err != nil {
+		s.logger.Error("Failed to search users", "error", err)
+		return nil, err
+	}
+
+	result := make([]dto.AdminUser, 0, len(users))
+	for _, user := range users {
+		result = append(result, toAdminUserDTO(&user))
+	}
+	return result, nil
+}
+
+// GetUserDetails предоставляет профиль пользователя, баланс, инвентарь и историю переводов
+func (s *DefaultUserManagementService) GetUserDetails(ctx context.Context, username string) (dto.AdminUserDetails, error) {
+	user, err := s.userRepo.GetUser(ctx, username)
+	if err != nil {
+		return dto.AdminUserDetails{}, err
+	}
+
+	info, err := s.userService.UserInfo(ctx, username)
+	if err != nil {
+		return dto.AdminUserDetails{}, err
+	}
+
+	details := dto.AdminUserDetails{
+		AdminUser:   toAdminUserDTO(user),
+		Inventory:   info.Inventory,
+		CoinHistory: info.CoinHistory,
+		Gifts:       info.Gifts,
+	}
+	details.Balance = info.Coins
+
+	return details, nil
+}
+
+// DisableUser отключает учетную запись: вход, запросы с выданными токенами и API-ключами
+// и получение монет запрещаются до включения администратором
+func (s *DefaultUserManagementService) DisableUser(ctx context.Context, admin, username, reason string) error {
+	s.logger.Info("Starting to disable user", "admin", admin, "username", username)
+
+	err := s.changeStatus(ctx, admin, username, func(tx pgx.Tx, user *models.User) error {
+		if err := s.userRepo.DisableUser(ctx, tx, username, reason); err != nil {
+			return err
+		}
+		if err := s.outboxRepo.AddEvent(ctx, tx, models.EventUserDisabled, models.UserStatusPayload{Username: username, Admin: admin, Reason: reason}); err != nil {
+			return err
+		}
+
+		return s.auditLog.Record(ctx, tx, models.AuditEntry{
+			Actor:   admin,
+			Action:  models.AuditUserDisabled,
+			Target:  username,
+			Details: auditDetails(map[string]any{"reason": reason}),
+		})
+	})
+	if err != nil {
+		s.logger.Error("Failed to disable user", "username", username, "error", err)
+		return err
+	}
+
+	s.logger.Info("User disabled successfully", "admin", admin, "username", username)
+	return nil
+}
+
+// EnableUser снова разрешает вход отключенной учетной записи. Удаленные учетные записи не включаются.
+func (s *DefaultUserManagementService) EnableUser(ctx context.Context, admin, username string) error {
+	s.logger.Info("Starting to enable user", "admin", admin, "username", username)
+
+	err := s.changeStatus(ctx, admin, username, func(tx pgx.Tx, user *models.User) error {
+		if !user.Disabled() {
+			return nil
+		}
+
+		if err := s.userRepo.EnableUser(ctx, tx, username); err != nil {
+			return err
+		}
+		if err := s.outboxRepo.AddEvent(ctx, tx, models.EventUserEnabled, models.UserStatusPayload{Username: username, Admin: admin}); err != nil {
+			return err
+		}
+
+		return s.auditLog.Record(ctx, tx, models.AuditEntry{Actor: admin, Action: models.AuditUserEnabled, Target: username})
+	})
+	if err != nil {
+		s.logger.Error("Failed to enable user", "username", username, "error", err)
+		return err
+	}
+
+	s.logger.Info("User enabled successfully", "admin", admin, "username", username)
+	return nil
+}
+
+// DeleteUser выполняет мягкое удаление: строка пользователя сохраняется, так как на нее ссылаются
+// переводы и покупки, а учетная запись отключается, ее API-ключи отзываются и подписки на вебхуки отключаются
+func (s *DefaultUserManagementService) DeleteUser(ctx context.Context, admin, username string) error {
+	s.logger.Info("Starting to delete user", "admin", admin, "username", username)
+
+	err := s.changeStatus(ctx, admin, username, func(tx pgx.Tx, user *models.User) error {
+		if err := s.userRepo.SoftDeleteUser(ctx, tx, username); err != nil {
+			return err
+		}
+		if err := s.apiKeyRepo.RevokeAllKeys(ctx, tx, username); err != nil {
+			return err
+		}
+		if err := s.webhookRepo.DeactivateOwnerSubscriptions(ctx, tx, username); err != nil {
+			return err
+		}
+		if err := s.outboxRepo.AddEvent(ctx, tx, models.EventUserDeleted, models.UserStatusPayload{Username: username, Admin: admin}); err != nil {
+			return err
+		}
+
+		return s.auditLog.Record(ctx, tx, models.AuditEntry{
+			Actor:   admin,
+			Action:  models.AuditUserDeleted,
+			Target:  username,
+			Details: auditDetails(map[string]any{"balance": user.Balance}),
+		})
+	})
+	if err != nil {
+		s.logger.Error("Failed to delete user", "username", username, "error", err)
+		return err
+	}
+
+	s.logger.Info("User deleted successfully", "admin", admin, "username", username)
+	return nil
+}
+
+// changeStatus блокирует строку пользователя и выполняет изменение статуса в транзакции.
+// Администратор не может изменить статус своей учетной записи, удаленные учетные записи не изменяются.
+func (s *DefaultUserManagementService) changeStatus(ctx context.Context, admin, username string, fn func(tx pgx.Tx, user *models.User) error) error {
+	if admin == username {
+		s.logger.Warn("Admin tried to change own account status", "admin", admin)
+		return e.ErrForbidden
+	}
+
+	return s.txExecutor.RunWithTransaction(ctx, func(tx pgx.Tx) error {
+		user, err := s.userRepo.GetUserForUpdate(ctx, tx, username)
+		if err != nil {
+			return err
+		}
+		if user.Deleted() {
+			return e.ErrUserDeleted
+		}
+
+		return fn(tx, user)
+	})
+}
+
+// toAdminUserDTO преобразует модель пользователя в DTO для администратора
+func toAdminUserDTO(user *models.User) dto.AdminUser {
+	result := dto.AdminUser{
+		UserName:   user.UserName,
+		Role:       user.Role,
+		Status:     models.UserStatusActive,
+		Balance:    user.Balance,
+		CreatedAt:  user.CreatedAt,
+		DisabledAt: user.DisabledAt,
+		DeletedAt:  user.DeletedAt,
+	}
+	if user.DisabledReason != nil {
+		result.DisabledReason = *user.DisabledReason
+	}
+
+	switch {
+	case user.Deleted():
+		result.Status = models.UserStatusDeleted
+	case user.Disabled():
+		result.Status = models.UserStatusDisabled
+	}
+
+	return result
+}
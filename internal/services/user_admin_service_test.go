@@ -0,0 +1,195 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	e "API-Avito-shop/internal/errors"
+	"API-Avito-shop/internal/models"
+	r "API-Avito-shop/internal/repositories"
+	"API-Avito-shop/internal/utils/password"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// fakeRevoker отмечает отзыв ключей и отключение вебхуков удаленной учетной записи
+type fakeRevoker struct {
+	r.APIKeyRepository
+	r.WebhookRepository
+	revoked []string
+}
+
+func (f *fakeRevoker) RevokeAllKeys(_ context.Context, _ pgx.Tx, username string) error {
+	f.revoked = append(f.revoked, "keys:"+username)
+	return nil
+}
+
+func (f *fakeRevoker) DeactivateOwnerSubscriptions(_ context.Context, _ pgx.Tx, owner string) error {
+	f.revoked = append(f.revoked, "webhooks:"+owner)
+	return nil
+}
+
+// newTestUserManagement собирает сервисы пользователей поверх репозитория в памяти
+// и возвращает функцию, переводящую часы кеша версий токенов
+func newTestUserManagement(users ...*models.User) (*DefaultUserManagementService, *DefaultUserService, *fakeUserRepo, *fakeRevoker, *fakeAuditRecorder, func(time.Duration)) {
+	userRepo := &fakeUserRepo{users: make(map[string]*models.User)}
+	for _, user := range users {
+		userRepo.users[user.UserName] = user
+	}
+	revoker := &fakeRevoker{}
+	auditLog := &fakeAuditRecorder{}
+
+	userService := NewUserService(userRepo, nil, nil, &fakeOutboxRepo{}, nil, nil, auditLog, fakeTxExecutor{}, nil,
+		password.Policy{}, CoinExpiryPolicy{}, time.Minute, testLogger())
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	userService.tokenStates.now = func() time.Time { return now }
+
+	management := NewUserManagementService(userRepo, revoker, revoker, &fakeOutboxRepo{}, userService, auditLog, fakeTxExecutor{}, testLogger())
+	return management, userService, userRepo, revoker, auditLog, func(d time.Duration) { now = now.Add(d) }
+}
+
+func TestCheckTokenVersion(t *testing.T) {
+	disabledAt := time.Now()
+	_, userService, userRepo, _, _, _ := newTestUserManagement(
+		&models.User{UserName: "alice", TokenVersion: 3},
+		&models.User{UserName: "bob", TokenVersion: 1, DisabledAt: &disabledAt},
+	)
+
+	tests := []struct {
+		name     string
+		username string
+		version  int
+		want     error
+	}{
+		{"current version", "alice", 3, nil},
+		{"version before password change", "alice", 2, e.ErrTokenRevoked},
+		{"version from the future", "alice", 4, e.ErrTokenRevoked},
+		{"disabled user", "bob", 1, e.ErrUserDisabled},
+		// Токен пользователя, которого больше нет, считается отозванным
+		{"unknown user", "carol", 1, e.ErrTokenRevoked},
+	}
+	for _, tt := range tests {
+		if err := userService.CheckTokenVersion(context.Background(), tt.username, tt.version); !errors.Is(err, tt.want) {
+			t.Errorf("%s: CheckTokenVersion(%s, %d) error = %v, want %v", tt.name, tt.username, tt.version, err, tt.want)
+		}
+	}
+
+	// Подтвержденная версия берется из кеша, отклоненные версии не кешируются
+	lookups := userRepo.stateLookups
+	userService.CheckTokenVersion(context.Background(), "alice", 3)
+	userService.CheckTokenVersion(context.Background(), "alice", 2)
+	if got := userRepo.stateLookups - lookups; got != 1 {
+		t.Errorf("CheckTokenVersion() queried token state %d times, want 1", got)
+	}
+}
+
+func TestDisableRevokesTokens(t *testing.T) {
+	management, userService, _, _, auditLog, advance := newTestUserManagement(
+		&models.User{UserName: "admin", Role: models.RoleAdmin},
+		&models.User{UserName: "alice", TokenVersion: 1},
+	)
+	ctx := context.Background()
+
+	if err := userService.CheckTokenVersion(ctx, "alice", 1); err != nil {
+		t.Fatalf("CheckTokenVersion() before disable error = %v", err)
+	}
+	if err := management.DisableUser(ctx, "admin", "alice", "fraud"); err != nil {
+		t.Fatalf("DisableUser() error = %v", err)
+	}
+
+	// Отключение вступает в силу не позже чем через ttl кеша
+	advance(time.Minute - time.Second)
+	if err := userService.CheckTokenVersion(ctx, "alice", 1); err != nil {
+		t.Errorf("CheckTokenVersion() within ttl error = %v, want cached nil", err)
+	}
+	advance(time.Second)
+	if err := userService.CheckTokenVersion(ctx, "alice", 1); !errors.Is(err, e.ErrUserDisabled) {
+		t.Errorf("CheckTokenVersion() after ttl error = %v, want %v", err, e.ErrUserDisabled)
+	}
+
+	// После включения токены, выданные до отключения, не действуют
+	if err := management.EnableUser(ctx, "admin", "alice"); err != nil {
+		t.Fatalf("EnableUser() error = %v", err)
+	}
+	if err := userService.CheckTokenVersion(ctx, "alice", 1); !errors.Is(err, e.ErrTokenRevoked) {
+		t.Errorf("CheckTokenVersion() after enable error = %v, want %v", err, e.ErrTokenRevoked)
+	}
+	if err := userService.CheckTokenVersion(ctx, "alice", 2); err != nil {
+		t.Errorf("CheckTokenVersion() of a new token error = %v, want nil", err)
+	}
+
+	want := []string{models.AuditUserDisabled, models.AuditUserEnabled}
+	if got := auditLog.actions(); !slices.Equal(got, want) {
+		t.Errorf("audit actions = %v, want %v", got, want)
+	}
+}
+
+func TestChangeStatus(t *testing.T) {
+	deletedAt := time.Now()
+	management, _, userRepo, revoker, auditLog, _ := newTestUserManagement(
+		&models.User{UserName: "admin", Role: models.RoleAdmin},
+		&models.User{UserName: "alice"},
+		&models.User{UserName: "bob", DeletedAt: &deletedAt, DisabledAt: &deletedAt},
+		&models.User{UserName: "crm", Role: models.RoleService},
+	)
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		change func() error
+		want   error
+	}{
+		{"disable self", func() error { return management.DisableUser(ctx, "admin", "admin", "") }, e.ErrForbidden},
+		{"delete self", func() error { return management.DeleteUser(ctx, "admin", "admin") }, e.ErrForbidden},
+		{"enable deleted", func() error { return management.EnableUser(ctx, "admin", "bob") }, e.ErrUserDeleted},
+		{"disable deleted", func() error { return management.DisableUser(ctx, "admin", "bob", "") }, e.ErrUserDeleted},
+		{"disable unknown", func() error { return management.DisableUser(ctx, "admin", "carol", "") }, e.ErrInvalidUser},
+		// Включение активной учетной записи ничего не меняет и не попадает в аудит
+		{"enable active", func() error { return management.EnableUser(ctx, "admin", "alice") }, nil},
+	}
+	for _, tt := range tests {
+		if err := tt.change(); !errors.Is(err, tt.want) {
+			t.Errorf("%s: error = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+	if got := auditLog.actions(); len(got) != 0 {
+		t.Errorf("audit actions = %v, want none", got)
+	}
+
+	if err := management.DeleteUser(ctx, "admin", "crm"); err != nil {
+		t.Fatalf("DeleteUser() error = %v", err)
+	}
+	crm := userRepo.users["crm"]
+	if !crm.Deleted() || !crm.Disabled() || crm.TokenVersion != 1 {
+		t.Errorf("DeleteUser() left %+v, want deleted, disabled and tokens revoked", crm)
+	}
+	if want := []string{"keys:crm", "webhooks:crm"}; !slices.Equal(revoker.revoked, want) {
+		t.Errorf("DeleteUser() revoked %v, want %v", revoker.revoked, want)
+	}
+}
+
+func TestToAdminUserDTO(t *testing.T) {
+	at := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	reason := "fraud"
+
+	tests := []struct {
+		name   string
+		user   models.User
+		status string
+		reason string
+	}{
+		{"active", models.User{UserName: "alice"}, models.UserStatusActive, ""},
+		{"disabled", models.User{UserName: "alice", DisabledAt: &at, DisabledReason: &reason}, models.UserStatusDisabled, "fraud"},
+		// Удаленная учетная запись также отключена, статус удаления важнее
+		{"deleted", models.User{UserName: "alice", DisabledAt: &at, DeletedAt: &at}, models.UserStatusDeleted, ""},
+	}
+	for _, tt := range tests {
+		got := toAdminUserDTO(&tt.user)
+		if got.Status != tt.status || got.DisabledReason != tt.reason {
+			t.Errorf("%s: toAdminUserDTO() status = %s, reason = %q, want %s, %q", tt.name, got.Status, got.DisabledReason, tt.status, tt.reason)
+		}
+	}
+}
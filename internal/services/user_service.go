@@ -2,62 +2,162 @@ package services
 
 import (
 	"context"
+	"errors"
 	"log/slog"
+	"time"
 
 	"API-Avito-shop/internal/dto"
 	e "API-Avito-shop/internal/errors"
+	"API-Avito-shop/internal/models"
 	r "API-Avito-shop/internal/repositories"
+	"API-Avito-shop/internal/utils/password"
 
 	"github.com/jackc/pgx/v5"
-	"golang.org/x/crypto/bcrypt"
 )
 
 type UserService interface {
-	AuthUser(ctx context.Context, userAuthDTO *dto.UserAuth) error
+	AuthUser(ctx context.Context, userAuthDTO *dto.UserAuth, clientIP string) (*models.User, error)
 	UserInfo(ctx context.Context, username string) (dto.InfoResponse, error)
+	IsAdmin(ctx context.Context, username string) (bool, error)
+	CheckTokenVersion(ctx context.Context, username string, version int) error
 }
 
 type DefaultUserService struct {
 	userRepo        r.UserRepository
 	shopRepo        r.ShopRepository
 	transactionRepo r.TransactionRepository
+	outboxRepo      r.OutboxRepository
+	coinLotRepo     r.CoinLotRepository
+	loginGuard      LoginGuard
+	auditLog        AuditRecorder
 	txExecutor      TxExecutor
+	passwordHasher  PasswordHasher
+	passwordPolicy  password.Policy
+	expiryPolicy    CoinExpiryPolicy
+	tokenStates     *tokenStateCache
 	logger          *slog.Logger
 }
 
-func NewUserService(userRepo r.UserRepository, shopRepo r.ShopRepository, transactionRepo r.TransactionRepository, txHelper TxExecutor, logger *slog.Logger) *DefaultUserService {
+func NewUserService(userRepo r.UserRepository, shopRepo r.ShopRepository, transactionRepo r.TransactionRepository, outboxRepo r.OutboxRepository, coinLotRepo r.CoinLotRepository, loginGuard LoginGuard, auditLog AuditRecorder, txHelper TxExecutor, passwordHasher PasswordHasher, passwordPolicy password.Policy, expiryPolicy CoinExpiryPolicy, tokenStateTTL time.Duration, logger *slog.Logger) *DefaultUserService {
 	return &DefaultUserService{
 		userRepo:        userRepo,
 		shopRepo:        shopRepo,
 		transactionRepo: transactionRepo,
+		outboxRepo:      outboxRepo,
+		coinLotRepo:     coinLotRepo,
+		loginGuard:      loginGuard,
+		auditLog:        auditLog,
 		txExecutor:      txHelper,
+		passwordHasher:  passwordHasher,
+		passwordPolicy:  passwordPolicy,
+		expiryPolicy:    expiryPolicy,
+		tokenStates:     newTokenStateCache(tokenStateTTL),
 		logger:          logger,
 	}
 }
 
-// AuthUser выполняет авторизацию пользователя или создает его, если первый раз
-func (s *DefaultUserService) AuthUser(ctx context.Context, userAuthDTO *dto.UserAuth) error {
+// AuthUser выполняет авторизацию пользователя или создает его, если первый раз.
+// Пароль нового пользователя проверяется политикой паролей.
+// Неудачные попытки учитываются по учетной записи и IP-адресу клиента.
+func (s *DefaultUserService) AuthUser(ctx context.Context, userAuthDTO *dto.UserAuth, clientIP string) (*models.User, error) {
 	s.logger.Info("Start of user authorization", "username", userAuthDTO.UserName)
 
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(userAuthDTO.Password), bcrypt.DefaultCost)
-	if err != nil {
-		s.logger.Error("Failed to hash password", "error", err)
-		return err
+	if err := s.loginGuard.CheckLocked(ctx, userAuthDTO.UserName, clientIP); err != nil {
+		return nil, err
 	}
 
-	storedPassword, err := s.userRepo.GetOrCreateUser(ctx, userAuthDTO.UserName, string(hashedPassword))
+	user, err := s.userRepo.GetUser(ctx, userAuthDTO.UserName)
+	if errors.Is(err, e.ErrInvalidUser) {
+		user, err = s.createUser(ctx, userAuthDTO)
+	}
 	if err != nil {
 		s.logger.Error("Failed to get or create user", "error", err)
-		return err
+		return nil, err
+	}
+
+	ok, rehash, err := s.passwordHasher.Verify(userAuthDTO.Password, user.Password)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		s.logger.Warn("Incorrect password", "username", userAuthDTO.UserName, "client_ip", clientIP)
+		if err = s.loginGuard.RegisterFailure(ctx, userAuthDTO.UserName, clientIP, "invalid_password"); err != nil {
+			s.logger.Error("Failed to register login failure", "username", userAuthDTO.UserName, "error", err)
+		}
+		return nil, e.ErrInvalidPass
 	}
 
-	if err = bcrypt.CompareHashAndPassword([]byte(storedPassword), []byte(userAuthDTO.Password)); err != nil {
-		s.logger.Warn("Incorrect password", "username", userAuthDTO.UserName)
-		return e.ErrInvalidPass
+	// Пароль проверяется до статуса учетной записи, чтобы не раскрывать статус без знания пароля
+	if user.Disabled() {
+		s.logger.Warn("Disabled user tried to sign in", "username", userAuthDTO.UserName, "client_ip", clientIP)
+		return nil, e.ErrUserDisabled
 	}
 
-	s.logger.Info("User successfully created")
-	return nil
+	if rehash {
+		s.rehashPassword(ctx, user, userAuthDTO.Password)
+	}
+
+	if err = s.loginGuard.RegisterSuccess(ctx, userAuthDTO.UserName); err != nil {
+		s.logger.Error("Failed to reset login failures", "username", userAuthDTO.UserName, "error", err)
+	}
+	s.auditLog.RecordStandalone(ctx, models.AuditEntry{
+		Actor:   userAuthDTO.UserName,
+		Action:  models.AuditLogin,
+		Target:  userAuthDTO.UserName,
+		Details: auditDetails(map[string]any{"method": "password"}),
+	})
+
+	s.logger.Info("User successfully authorized", "username", userAuthDTO.UserName)
+	return user, nil
+}
+
+// createUser создает пользователя при первом входе. Если пользователь был создан
+// параллельным запросом, возвращается существующая запись.
+func (s *DefaultUserService) createUser(ctx context.Context, userAuthDTO *dto.UserAuth) (*models.User, error) {
+	if err := s.passwordPolicy.Validate(userAuthDTO.Password, userAuthDTO.UserName); err != nil {
+		return nil, err
+	}
+
+	hashedPassword, err := s.passwordHasher.Hash(userAuthDTO.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	var user *models.User
+	err = s.txExecutor.RunWithTransaction(ctx, func(tx pgx.Tx) error {
+		var created bool
+		user, created, err = s.userRepo.GetOrCreateUser(ctx, tx, userAuthDTO.UserName, hashedPassword)
+		if err != nil {
+			return err
+		}
+
+		if created {
+			return s.outboxRepo.AddEvent(ctx, tx, models.EventUserCreated, models.UserCreatedPayload{
+				Username: userAuthDTO.UserName,
+				Role:     models.RoleUser,
+			})
+		}
+
+		return nil
+	})
+
+	return user, err
+}
+
+// rehashPassword обновляет хеш пароля до текущего алгоритма и параметров.
+// Ошибка не прерывает вход: хеш будет обновлен при следующей попытке.
+func (s *DefaultUserService) rehashPassword(ctx context.Context, user *models.User, plain string) {
+	hashedPassword, err := s.passwordHasher.Hash(plain)
+	if err != nil {
+		return
+	}
+
+	if err = s.userRepo.RehashPassword(ctx, user.UserName, user.Password, hashedPassword); err != nil {
+		s.logger.Error("Failed to upgrade password hash", "username", user.UserName, "error", err)
+		return
+	}
+
+	s.logger.Info("Password hash upgraded", "username", user.UserName)
 }
 
 // UserInfo предоставляет информацию о пользователе: текущий баланс, приобретенные товары и историю транзакций
@@ -90,6 +190,25 @@ func (s *DefaultUserService) UserInfo(ctx context.Context, username string) (dto
 			return err
 		}
 
+		userData.Gifts.Received, err = s.shopRepo.ReceivedGifts(ctx, tx, username)
+		if err != nil {
+			return err
+		}
+
+		userData.Gifts.Sent, err = s.shopRepo.SentGifts(ctx, tx, username)
+		if err != nil {
+			return err
+		}
+
+		if _, ok := s.expiryPolicy.Cutoff(time.Now()); !ok {
+			return nil
+		}
+		lots, err := s.coinLotRepo.ActiveLots(ctx, tx, username)
+		if err != nil {
+			return err
+		}
+		userData.ExpiringCoins = s.expiringCoins(lots)
+
 		return nil
 	})
 
@@ -101,3 +220,64 @@ func (s *DefaultUserService) UserInfo(ctx context.Context, username string) (dto
 	s.logger.Info("User information retrieved successfully", "username", username)
 	return userData, nil
 }
+
+// expiringCoins группирует непотраченные партии по дню сгорания в порядке сгорания
+func (s *DefaultUserService) expiringCoins(lots []models.CoinLot) []dto.ExpiringCoins {
+	var result []dto.ExpiringCoins
+	for _, lot := range lots {
+		expiresAt, ok := s.expiryPolicy.ExpiresAt(lot.IssuedAt)
+		if !ok {
+			continue
+		}
+
+		// Партии списываются в порядке начисления, поэтому дни сгорания идут по возрастанию
+		last := len(result) - 1
+		if last >= 0 && result[last].ExpiresAt.Truncate(24*time.Hour).Equal(expiresAt.Truncate(24*time.Hour)) {
+			result[last].Amount += lot.Amount
+			continue
+		}
+		result = append(result, dto.ExpiringCoins{Amount: lot.Amount, ExpiresAt: expiresAt})
+	}
+	return result
+}
+
+// IsAdmin проверяет, является ли пользователь администратором
+func (s *DefaultUserService) IsAdmin(ctx context.Context, username string) (bool, error) {
+	role, err := s.userRepo.GetRole(ctx, username)
+	if err != nil {
+		s.logger.Error("Failed to get user role", "username", username, "error", err)
+		return false, err
+	}
+
+	return role == models.RoleAdmin, nil
+}
+
+// CheckTokenVersion возвращает ErrTokenRevoked, если токен выдан до последней смены пароля,
+// и ErrUserDisabled, если учетная запись отключена. Действующие версии кешируются на время tokenStateTTL.
+func (s *DefaultUserService) CheckTokenVersion(ctx context.Context, username string, version int) error {
+	if s.tokenStates.valid(username, version) {
+		return nil
+	}
+
+	current, disabled, err := s.userRepo.GetTokenState(ctx, username)
+	if err != nil {
+		if errors.Is(err, e.ErrInvalidUser) {
+			return e.ErrTokenRevoked
+		}
+		s.logger.Error("Failed to get token version", "username", username, "error", err)
+		return err
+	}
+
+	if disabled {
+		s.logger.Warn("Disabled user token used", "username", username)
+		return e.ErrUserDisabled
+	}
+
+	if version != current {
+		s.logger.Warn("Revoked token used", "username", username, "token_version", version, "current_version", current)
+		return e.ErrTokenRevoked
+	}
+
+	s.tokenStates.store(username, current)
+	return nil
+}
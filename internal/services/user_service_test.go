@@ -0,0 +1,115 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"API-Avito-shop/internal/dto"
+	"API-Avito-shop/internal/models"
+)
+
+func TestExpiringCoins(t *testing.T) {
+	at := func(month time.Month, day, hour int) time.Time {
+		return time.Date(2026, month, day, hour, 0, 0, 0, time.UTC)
+	}
+	yearEnd := time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		policy CoinExpiryPolicy
+		lots   []models.CoinLot
+		want   []dto.ExpiringCoins
+	}{
+		{
+			name:   "no expiry",
+			policy: CoinExpiryPolicy{Mode: models.ExpiryNone},
+			lots:   []models.CoinLot{{Amount: 100, IssuedAt: at(1, 1, 0)}},
+			want:   nil,
+		},
+		{
+			name:   "no lots",
+			policy: CoinExpiryPolicy{Mode: models.ExpiryRolling, Months: 1},
+			want:   nil,
+		},
+		{
+			name:   "same day is merged",
+			policy: CoinExpiryPolicy{Mode: models.ExpiryRolling, Months: 1},
+			lots: []models.CoinLot{
+				{Amount: 100, IssuedAt: at(3, 1, 9)},
+				{Amount: 50, IssuedAt: at(3, 1, 18)},
+			},
+			want: []dto.ExpiringCoins{{Amount: 150, ExpiresAt: at(4, 1, 9)}},
+		},
+		{
+			name:   "different days stay apart",
+			policy: CoinExpiryPolicy{Mode: models.ExpiryRolling, Months: 1},
+			lots: []models.CoinLot{
+				{Amount: 100, IssuedAt: at(3, 1, 9)},
+				{Amount: 50, IssuedAt: at(3, 2, 9)},
+				{Amount: 25, IssuedAt: at(3, 2, 23)},
+			},
+			want: []dto.ExpiringCoins{
+				{Amount: 100, ExpiresAt: at(4, 1, 9)},
+				{Amount: 75, ExpiresAt: at(4, 2, 9)},
+			},
+		},
+		{
+			name:   "year end merges the whole year",
+			policy: CoinExpiryPolicy{Mode: models.ExpiryYearEnd},
+			lots: []models.CoinLot{
+				{Amount: 1000, IssuedAt: at(1, 10, 0)},
+				{Amount: 30, IssuedAt: at(6, 1, 0)},
+				{Amount: 20, IssuedAt: at(12, 31, 23)},
+			},
+			want: []dto.ExpiringCoins{{Amount: 1050, ExpiresAt: yearEnd}},
+		},
+	}
+
+	for _, tt := range tests {
+		s := &DefaultUserService{expiryPolicy: tt.policy}
+		got := s.expiringCoins(tt.lots)
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: expiringCoins() = %v, want %v", tt.name, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i].Amount != tt.want[i].Amount || !got[i].ExpiresAt.Equal(tt.want[i].ExpiresAt) {
+				t.Errorf("%s: expiringCoins()[%d] = %v, want %v", tt.name, i, got[i], tt.want[i])
+			}
+		}
+	}
+}
+
+func TestUserInfoExpiringCoins(t *testing.T) {
+	pool := testPool(t)
+	ctx := testContext(t)
+	username := newTestUser(t, pool, "expiry")
+
+	tests := []struct {
+		name    string
+		policy  CoinExpiryPolicy
+		expires bool
+	}{
+		{"no expiry", CoinExpiryPolicy{Mode: models.ExpiryNone}, false},
+		{"year end", CoinExpiryPolicy{Mode: models.ExpiryYearEnd}, true},
+	}
+
+	for _, tt := range tests {
+		info, err := newTestUserService(pool, tt.policy).UserInfo(ctx, username)
+		if err != nil {
+			t.Fatalf("%s: UserInfo() error = %v", tt.name, err)
+		}
+		if !tt.expires {
+			if info.ExpiringCoins != nil {
+				t.Errorf("%s: UserInfo().ExpiringCoins = %v, want none", tt.name, info.ExpiringCoins)
+			}
+			continue
+		}
+
+		// Стартовый баланс начислен одной партией и сгорает в конце текущего года
+		want, _ := tt.policy.ExpiresAt(time.Now())
+		if len(info.ExpiringCoins) != 1 || info.ExpiringCoins[0].Amount != info.Coins || !info.ExpiringCoins[0].ExpiresAt.Equal(want) {
+			t.Errorf("%s: UserInfo().ExpiringCoins = %v, want %d coins at %s", tt.name, info.ExpiringCoins, info.Coins, want)
+		}
+	}
+}
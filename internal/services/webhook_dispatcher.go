@@ -0,0 +1,223 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"syscall"
+	"time"
+
+	"API-Avito-shop/internal/models"
+	r "API-Avito-shop/internal/repositories"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Заголовки исходящих webhook-запросов
+const (
+	WebhookSignatureHeader = "X-Webhook-Signature"
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookDeliveryHeader  = "X-Webhook-Delivery"
+)
+
+// WebhookDispatcher отправляет события подписчикам с подписью и повторными попытками
+type WebhookDispatcher struct {
+	webhookRepo   r.WebhookRepository
+	txExecutor    TxExecutor
+	client        *http.Client
+	interval      time.Duration
+	batchSize     int
+	maxAttempts   int
+	retryDelay    time.Duration
+	maxRetryDelay time.Duration
+	logger        *slog.Logger
+}
+
+func NewWebhookDispatcher(webhookRepo r.WebhookRepository, txHelper TxExecutor, timeout, interval time.Duration, batchSize, maxAttempts int, retryDelay, maxRetryDelay time.Duration, allowPrivateTargets bool, logger *slog.Logger) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		webhookRepo:   webhookRepo,
+		txExecutor:    txHelper,
+		client:        newWebhookClient(timeout, allowPrivateTargets),
+		interval:      interval,
+		batchSize:     batchSize,
+		maxAttempts:   maxAttempts,
+		retryDelay:    retryDelay,
+		maxRetryDelay: maxRetryDelay,
+		logger:        logger,
+	}
+}
+
+// Run запускает цикл доставки webhook до отмены контекста
+func (d *WebhookDispatcher) Run(ctx context.Context) {
+	d.logger.Info("Webhook dispatcher started", "interval", d.interval)
+
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			d.logger.Info("Webhook dispatcher stopped")
+			return
+		case <-ticker.C:
+			if err := d.dispatchBatch(ctx); err != nil {
+				d.logger.Error("Failed to dispatch webhook batch", "error", err)
+			}
+		}
+	}
+}
+
+// dispatchBatch захватывает пачку доставок, время которых наступило, отправляет их вне транзакции
+// и сохраняет результат каждой попытки в отдельной короткой транзакции
+func (d *WebhookDispatcher) dispatchBatch(ctx context.Context) error {
+	due, err := d.webhookRepo.HasDueDeliveries(ctx)
+	if err != nil || !due {
+		return err
+	}
+
+	// Аренда покрывает последовательную отправку всей пачки с запасом на сохранение результатов
+	lease := time.Duration(d.batchSize)*d.client.Timeout + d.interval
+	deliveries, err := d.webhookRepo.ClaimDueDeliveries(ctx, d.batchSize, lease)
+	if err != nil {
+		return err
+	}
+
+	for i := range deliveries {
+		delivery := &deliveries[i]
+		attempt := d.send(ctx, delivery)
+
+		delivery.Attempts++
+		var retryAfter time.Duration
+		switch {
+		case attempt.Error == nil:
+			delivery.Status = models.DeliveryStatusDelivered
+		case delivery.Attempts >= d.maxAttempts:
+			delivery.Status = models.DeliveryStatusDead
+			d.logger.Warn("Webhook delivery moved to dead-letter", "delivery_id", delivery.ID, "attempts", delivery.Attempts)
+		default:
+			retryAfter = exponentialBackoff(d.retryDelay, d.maxRetryDelay, delivery.Attempts-1)
+		}
+
+		err = d.txExecutor.RunWithTransaction(ctx, func(tx pgx.Tx) error {
+			return d.webhookRepo.RecordAttempt(ctx, tx, delivery, attempt, retryAfter)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// send выполняет одну попытку доставки и возвращает ее результат
+func (d *WebhookDispatcher) send(ctx context.Context, delivery *models.WebhookDelivery) *models.WebhookAttempt {
+	attempt := &models.WebhookAttempt{DeliveryID: delivery.ID, Attempt: delivery.Attempts + 1}
+	fail := func(err error) *models.WebhookAttempt {
+		msg := err.Error()
+		attempt.Error = &msg
+		return attempt
+	}
+
+	timestamp := time.Now().Unix()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return fail(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookEventHeader, delivery.EventType)
+	req.Header.Set(WebhookDeliveryHeader, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(WebhookSignatureHeader, SignWebhookPayload(delivery.Secret, timestamp, delivery.Payload))
+
+	start := time.Now()
+	resp, err := d.client.Do(req)
+	attempt.DurationMs = int(time.Since(start).Milliseconds())
+	if err != nil {
+		d.logger.Warn("Webhook delivery failed", "delivery_id", delivery.ID, "error", err)
+		return fail(err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	attempt.StatusCode = &resp.StatusCode
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		d.logger.Warn("Webhook endpoint rejected delivery", "delivery_id", delivery.ID, "status", resp.StatusCode)
+		return fail(fmt.Errorf("unexpected status code %d", resp.StatusCode))
+	}
+
+	d.logger.Info("Webhook delivered", "delivery_id", delivery.ID, "event_type", delivery.EventType)
+	return attempt
+}
+
+// errForbiddenWebhookTarget возвращается при попытке соединения с внутренним адресом
+var errForbiddenWebhookTarget = errors.New("webhook target address is not allowed")
+
+// forbiddenWebhookPrefixes диапазоны, не являющиеся внутренними по net.IP, но недоступные извне
+var forbiddenWebhookPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+}
+
+// newWebhookClient создает HTTP-клиент, который проверяет адрес каждого соединения, в том числе после
+// перенаправлений и повторного разрешения имени. Прокси не используется, иначе проверялся бы его адрес.
+func newWebhookClient(timeout time.Duration, allowPrivateTargets bool) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivateTargets {
+		dialer.Control = webhookDialControl
+	}
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: &http.Transport{DialContext: dialer.DialContext, TLSHandshakeTimeout: timeout},
+	}
+}
+
+// webhookDialControl запрещает соединения с loopback, частными, link-local (включая адрес метаданных
+// облака 169.254.169.254) и зарезервированными адресами
+func webhookDialControl(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", errForbiddenWebhookTarget, address)
+	}
+	if !isPublicAddr(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s", errForbiddenWebhookTarget, address)
+	}
+	return nil
+}
+
+// isPublicAddr сообщает, является ли адрес публичным адресом unicast
+func isPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() || addr.IsLoopback() || addr.IsLinkLocalUnicast() {
+		return false
+	}
+	for _, prefix := range forbiddenWebhookPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// SignWebhookPayload формирует подпись HMAC-SHA256 вида "t=<unix>,v1=<hex>".
+// Подписывается строка "<unix>.<body>", что защищает от повторной отправки старых запросов.
+func SignWebhookPayload(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return fmt.Sprintf("t=%d,v1=%s", timestamp, hex.EncodeToString(mac.Sum(nil)))
+}
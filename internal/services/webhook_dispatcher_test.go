@@ -0,0 +1,70 @@
+package services
+
+import (
+	"errors"
+	"net/netip"
+	"testing"
+)
+
+func TestIsPublicAddr(t *testing.T) {
+	tests := []struct {
+		addr   string
+		public bool
+	}{
+		{"93.184.216.34", true},
+		{"8.8.8.8", true},
+		{"2606:4700:4700::1111", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"fd00::1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"0.0.0.0", false},
+		{"::", false},
+		{"100.64.0.1", false},
+		{"192.0.0.8", false},
+		{"198.18.0.1", false},
+		{"240.0.0.1", false},
+		{"255.255.255.255", false},
+		{"224.0.0.1", false},
+		{"ff02::1", false},
+		{"64:ff9b::a9fe:a9fe", false},
+		// IPv4-mapped адреса проверяются как IPv4
+		{"::ffff:127.0.0.1", false},
+		{"::ffff:169.254.169.254", false},
+		{"::ffff:93.184.216.34", true},
+	}
+
+	for _, tt := range tests {
+		if got := isPublicAddr(netip.MustParseAddr(tt.addr)); got != tt.public {
+			t.Errorf("isPublicAddr(%s) = %v, want %v", tt.addr, got, tt.public)
+		}
+	}
+}
+
+func TestWebhookDialControl(t *testing.T) {
+	tests := []struct {
+		address string
+		allowed bool
+	}{
+		{"93.184.216.34:443", true},
+		{"[2606:4700:4700::1111]:443", true},
+		{"127.0.0.1:8080", false},
+		{"[::1]:80", false},
+		{"169.254.169.254:80", false},
+		{"localhost:80", false},
+	}
+
+	for _, tt := range tests {
+		err := webhookDialControl("tcp", tt.address, nil)
+		if tt.allowed && err != nil {
+			t.Errorf("webhookDialControl(%s) = %v, want nil", tt.address, err)
+		}
+		if !tt.allowed && !errors.Is(err, errForbiddenWebhookTarget) {
+			t.Errorf("webhookDialControl(%s) = %v, want errForbiddenWebhookTarget", tt.address, err)
+		}
+	}
+}
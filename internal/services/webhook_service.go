@@ -0,0 +1,264 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+
+	"API-Avito-shop/internal/dto"
+	e "API-Avito-shop/internal/errors"
+	"API-Avito-shop/internal/events"
+	"API-Avito-shop/internal/models"
+	r "API-Avito-shop/internal/repositories"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type WebhookService interface {
+	CreateWebhook(ctx context.Context, username string, createDTO *dto.CreateWebhook) (dto.WebhookCreated, error)
+	ListWebhooks(ctx context.Context, username string) ([]dto.Webhook, error)
+	DeleteWebhook(ctx context.Context, username string, id int64) error
+	ListDeliveries(ctx context.Context, username string, id int64, limit, offset int) ([]dto.WebhookDelivery, error)
+	RetryDelivery(ctx context.Context, username string, id, deliveryID int64) error
+}
+
+// DefaultWebhookService управляет подписками и ставит события в очередь доставки
+type DefaultWebhookService struct {
+	userRepo    r.UserRepository
+	webhookRepo r.WebhookRepository
+	txExecutor  TxExecutor
+	logger      *slog.Logger
+}
+
+func NewWebhookService(userRepo r.UserRepository, webhookRepo r.WebhookRepository, txHelper TxExecutor, logger *slog.Logger) *DefaultWebhookService {
+	return &DefaultWebhookService{
+		userRepo:    userRepo,
+		webhookRepo: webhookRepo,
+		txExecutor:  txHelper,
+		logger:      logger,
+	}
+}
+
+// CreateWebhook регистрирует подписку, глобальные подписки доступны только администраторам
+func (s *DefaultWebhookService) CreateWebhook(ctx context.Context, username string, createDTO *dto.CreateWebhook) (dto.WebhookCreated, error) {
+	s.logger.Info("Starting to create webhook", "username", username, "url", createDTO.URL)
+
+	if createDTO.Global {
+		if err := s.requireAdmin(ctx, username); err != nil {
+			return dto.WebhookCreated{}, err
+		}
+	}
+
+	secret, err := randomToken(32)
+	if err != nil {
+		s.logger.Error("Failed to generate webhook secret", "error", err)
+		return dto.WebhookCreated{}, err
+	}
+
+	sub := models.WebhookSubscription{
+		Owner:      username,
+		URL:        createDTO.URL,
+		Secret:     "whsec_" + secret,
+		EventTypes: createDTO.EventTypes,
+		Global:     createDTO.Global,
+	}
+	if err = s.webhookRepo.CreateSubscription(ctx, &sub); err != nil {
+		s.logger.Error("Failed to create webhook", "username", username, "error", err)
+		return dto.WebhookCreated{}, err
+	}
+
+	s.logger.Info("Webhook created successfully", "username", username, "webhook_id", sub.ID)
+	return dto.WebhookCreated{Webhook: toWebhookDTO(sub), Secret: sub.Secret}, nil
+}
+
+// ListWebhooks предоставляет подписки пользователя
+func (s *DefaultWebhookService) ListWebhooks(ctx context.Context, username string) ([]dto.Webhook, error) {
+	subs, err := s.webhookRepo.ListSubscriptions(ctx, username)
+	if err != nil {
+		s.logger.Error("Failed to list webhooks", "username", username, "error", err)
+		return nil, err
+	}
+
+	webhooks := make([]dto.Webhook, 0, len(subs))
+	for _, sub := range subs {
+		webhooks = append(webhooks, toWebhookDTO(sub))
+	}
+	return webhooks, nil
+}
+
+// DeleteWebhook удаляет подписку
+func (s *DefaultWebhookService) DeleteWebhook(ctx context.Context, username string, id int64) error {
+	if _, err := s.authorizedSubscription(ctx, username, id); err != nil {
+		return err
+	}
+
+	if err := s.webhookRepo.DeleteSubscription(ctx, id); err != nil {
+		s.logger.Error("Failed to delete webhook", "webhook_id", id, "error", err)
+		return err
+	}
+
+	s.logger.Info("Webhook deleted successfully", "username", username, "webhook_id", id)
+	return nil
+}
+
+// ListDeliveries предоставляет историю доставок подписки вместе с попытками
+func (s *DefaultWebhookService) ListDeliveries(ctx context.Context, username string, id int64, limit, offset int) ([]dto.WebhookDelivery, error) {
+	if _, err := s.authorizedSubscription(ctx, username, id); err != nil {
+		return nil, err
+	}
+
+	deliveries, err := s.webhookRepo.ListDeliveries(ctx, id, limit, offset)
+	if err != nil {
+		s.logger.Error("Failed to list deliveries", "webhook_id", id, "error", err)
+		return nil, err
+	}
+
+	ids := make([]int64, 0, len(deliveries))
+	for _, d := range deliveries {
+		ids = append(ids, d.ID)
+	}
+
+	attempts, err := s.webhookRepo.ListAttempts(ctx, ids)
+	if err != nil {
+		s.logger.Error("Failed to list delivery attempts", "webhook_id", id, "error", err)
+		return nil, err
+	}
+
+	attemptsByDelivery := make(map[int64][]dto.WebhookAttempt, len(deliveries))
+	for _, a := range attempts {
+		attemptsByDelivery[a.DeliveryID] = append(attemptsByDelivery[a.DeliveryID], dto.WebhookAttempt{
+			Attempt:    a.Attempt,
+			StatusCode: a.StatusCode,
+			Error:      a.Error,
+			DurationMs: a.DurationMs,
+			CreatedAt:  a.CreatedAt,
+		})
+	}
+
+	result := make([]dto.WebhookDelivery, 0, len(deliveries))
+	for _, d := range deliveries {
+		delivery := dto.WebhookDelivery{
+			ID:             d.ID,
+			EventID:        d.EventID,
+			EventType:      d.EventType,
+			Status:         d.Status,
+			Attempts:       d.Attempts,
+			LastStatusCode: d.LastStatusCode,
+			LastError:      d.LastError,
+			DeliveredAt:    d.DeliveredAt,
+			CreatedAt:      d.CreatedAt,
+			AttemptLog:     attemptsByDelivery[d.ID],
+		}
+		if d.Status == models.DeliveryStatusPending {
+			delivery.NextAttemptAt = &d.NextAttemptAt
+		}
+		result = append(result, delivery)
+	}
+
+	return result, nil
+}
+
+// RetryDelivery повторно ставит в очередь доставку из dead-letter
+func (s *DefaultWebhookService) RetryDelivery(ctx context.Context, username string, id, deliveryID int64) error {
+	if _, err := s.authorizedSubscription(ctx, username, id); err != nil {
+		return err
+	}
+
+	if err := s.webhookRepo.RetryDelivery(ctx, id, deliveryID); err != nil {
+		s.logger.Error("Failed to retry delivery", "webhook_id", id, "delivery_id", deliveryID, "error", err)
+		return err
+	}
+
+	return nil
+}
+
+// Publish ставит событие в очередь доставки всем подходящим подписчикам.
+// Пользовательские подписки получают только события, в которых участвует владелец подписки.
+func (s *DefaultWebhookService) Publish(ctx context.Context, event models.Event) error {
+	payload, err := json.Marshal(events.NewEnvelope(event))
+	if err != nil {
+		return err
+	}
+
+	participants := eventParticipants(event)
+
+	return s.txExecutor.RunWithTransaction(ctx, func(tx pgx.Tx) error {
+		subs, err := s.webhookRepo.ActiveSubscriptions(ctx, tx, event.Type)
+		if err != nil {
+			return err
+		}
+
+		for _, sub := range subs {
+			if !sub.Global && !participants[sub.Owner] {
+				continue
+			}
+			if err = s.webhookRepo.EnqueueDelivery(ctx, tx, sub.ID, event, payload); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// authorizedSubscription возвращает подписку, если пользователь ее владелец или администратор
+func (s *DefaultWebhookService) authorizedSubscription(ctx context.Context, username string, id int64) (*models.WebhookSubscription, error) {
+	sub, err := s.webhookRepo.GetSubscription(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if sub.Owner != username {
+		if err = s.requireAdmin(ctx, username); err != nil {
+			// Не раскрываем существование чужих подписок
+			return nil, e.ErrWebhookNotFound
+		}
+	}
+
+	return sub, nil
+}
+
+// requireAdmin проверяет, что пользователь является администратором
+func (s *DefaultWebhookService) requireAdmin(ctx context.Context, username string) error {
+	role, err := s.userRepo.GetRole(ctx, username)
+	if err != nil {
+		return err
+	}
+	if role != models.RoleAdmin {
+		s.logger.Warn("Admin permission required", "username", username)
+		return e.ErrForbidden
+	}
+	return nil
+}
+
+// eventParticipants извлекает пользователей, затронутых событием
+func eventParticipants(event models.Event) map[string]bool {
+	var payload struct {
+		FromUser string `json:"fromUser"`
+		ToUser   string `json:"toUser"`
+		Username string `json:"username"`
+	}
+	participants := make(map[string]bool)
+	if err := json.Unmarshal(event.Payload, &payload); err != nil {
+		return participants
+	}
+
+	for _, name := range []string{payload.FromUser, payload.ToUser, payload.Username} {
+		if name != "" {
+			participants[name] = true
+		}
+	}
+	return participants
+}
+
+// toWebhookDTO преобразует модель подписки в DTO
+func toWebhookDTO(sub models.WebhookSubscription) dto.Webhook {
+	return dto.Webhook{
+		ID:         sub.ID,
+		URL:        sub.URL,
+		EventTypes: sub.EventTypes,
+		Global:     sub.Global,
+		Active:     sub.Active,
+		CreatedAt:  sub.CreatedAt,
+	}
+}
@@ -0,0 +1,40 @@
+// Package hashchain вычисляет хеши строк цепочек, защищающих журналы от изменения.
+// Хеш вычисляется HMAC с ключом, который хранится вне базы данных, поэтому пересчитать цепочку
+// после правки строк можно только зная ключ.
+package hashchain
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+	"time"
+)
+
+// timeLayout формат времени в хешах: UTC с точностью до микросекунд, как хранит PostgreSQL
+const timeLayout = "2006-01-02T15:04:05.000000Z"
+
+// Hash вычисляет HMAC-SHA256 строки цепочки от хеша предыдущей строки и полей строки.
+// Каждое значение предваряется своей длиной, поэтому разные наборы полей не дают одинаковых данных.
+func Hash(key []byte, prevHash string, fields ...string) string {
+	mac := hmac.New(sha256.New, key)
+	writeField(mac, prevHash)
+	for _, field := range fields {
+		writeField(mac, field)
+	}
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Time приводит время к виду, используемому в хешах
+func Time(t time.Time) string {
+	return t.UTC().Format(timeLayout)
+}
+
+// writeField записывает длину значения и само значение
+func writeField(w io.Writer, field string) {
+	var size [8]byte
+	binary.BigEndian.PutUint64(size[:], uint64(len(field)))
+	_, _ = w.Write(size[:])
+	_, _ = w.Write([]byte(field))
+}
@@ -0,0 +1,63 @@
+package hashchain
+
+import (
+	"testing"
+	"time"
+)
+
+var testKey = []byte("0123456789abcdef0123456789abcdef")
+
+func TestHashKnownValue(t *testing.T) {
+	// Значение зафиксировано: изменение формата сделает недействительными уже запечатанные цепочки
+	const want = "5890bbc6603062f185fa754747227713db297abc82500f241a1992114a487599"
+
+	got := Hash(testKey, "", "alice", "bob", "100", "2025-01-02T03:04:05.000006Z")
+	if got != want {
+		t.Errorf("Hash() = %s, want %s", got, want)
+	}
+}
+
+func TestHashDistinguishesInputs(t *testing.T) {
+	base := Hash(testKey, "prev", "ab", "c")
+
+	tests := []struct {
+		name string
+		hash string
+	}{
+		{"field boundary moved", Hash(testKey, "prev", "a", "bc")},
+		{"fields joined", Hash(testKey, "prev", "abc")},
+		{"extra empty field", Hash(testKey, "prev", "ab", "c", "")},
+		{"prev hash moved into fields", Hash(testKey, "", "prev", "ab", "c")},
+		{"other prev hash", Hash(testKey, "prev2", "ab", "c")},
+		{"other key", Hash([]byte("fedcba9876543210fedcba9876543210"), "prev", "ab", "c")},
+	}
+
+	for _, tt := range tests {
+		if tt.hash == base {
+			t.Errorf("%s: hash matches the original row", tt.name)
+		}
+	}
+	if again := Hash(testKey, "prev", "ab", "c"); again != base {
+		t.Errorf("Hash() is not deterministic: %s != %s", again, base)
+	}
+}
+
+func TestTime(t *testing.T) {
+	moscow := time.FixedZone("MSK", 3*60*60)
+
+	tests := []struct {
+		name string
+		at   time.Time
+		want string
+	}{
+		{"utc", time.Date(2025, 1, 2, 3, 4, 5, 6000, time.UTC), "2025-01-02T03:04:05.000006Z"},
+		{"other zone", time.Date(2025, 1, 2, 6, 4, 5, 0, moscow), "2025-01-02T03:04:05.000000Z"},
+		{"nanoseconds dropped", time.Date(2025, 1, 2, 3, 4, 5, 6789, time.UTC), "2025-01-02T03:04:05.000006Z"},
+	}
+
+	for _, tt := range tests {
+		if got := Time(tt.at); got != tt.want {
+			t.Errorf("%s: Time() = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package jwks
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ErrUnknownKey возвращается, если токен подписан неизвестным ключом
+var ErrUnknownKey = errors.New("unknown signing key")
+
+// Key представляет ключ подписи токенов. Ключ без закрытой части используется только для проверки.
+type Key struct {
+	ID         string
+	Method     jwt.SigningMethod
+	signingKey any
+	verifyKey  any
+}
+
+// VerifyKey возвращает ключ для проверки подписи
+func (k *Key) VerifyKey() any {
+	return k.verifyKey
+}
+
+// SigningKey возвращает ключ для подписи или nil, если ключ только для проверки
+func (k *Key) SigningKey() any {
+	return k.signingKey
+}
+
+// KeySet хранит активный ключ подписи и все ключи, принимаемые при проверке
+type KeySet struct {
+	active *Key
+	keys   map[string]*Key
+}
+
+// NewHMAC создает набор из одного симметричного ключа HS256 без идентификатора
+func NewHMAC(secret string) *KeySet {
+	key := &Key{Method: jwt.SigningMethodHS256, signingKey: []byte(secret), verifyKey: []byte(secret)}
+	return &KeySet{active: key, keys: map[string]*Key{"": key}}
+}
+
+// LoadDir загружает ключи из PEM-файлов каталога, идентификатором ключа (kid) служит имя файла без .pem.
+// Закрытые ключи RSA и Ed25519 могут подписывать токены, открытые ключи (PUBLIC KEY) оставляются
+// для проверки токенов, подписанных выведенными из ротации ключами.
+func LoadDir(dir, activeID string) (*KeySet, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+
+	set := &KeySet{keys: make(map[string]*Key)}
+	for _, path := range paths {
+		id := strings.TrimSuffix(filepath.Base(path), ".pem")
+		key, err := loadKey(path, id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load key %s: %w", id, err)
+		}
+		set.keys[id] = key
+	}
+
+	active, ok := set.keys[activeID]
+	if !ok {
+		return nil, fmt.Errorf("active key %q not found in %s", activeID, dir)
+	}
+	if active.signingKey == nil {
+		return nil, fmt.Errorf("active key %q has no private part", activeID)
+	}
+	set.active = active
+
+	return set, nil
+}
+
+// AcceptHMAC добавляет симметричный ключ HS256 только для проверки токенов без kid,
+// выданных до перехода на асимметричную подпись
+func (s *KeySet) AcceptHMAC(secret string) {
+	s.keys[""] = &Key{Method: jwt.SigningMethodHS256, verifyKey: []byte(secret)}
+}
+
+// Active возвращает ключ, которым подписываются новые токены
+func (s *KeySet) Active() *Key {
+	return s.active
+}
+
+// Lookup ищет ключ проверки по kid
+func (s *KeySet) Lookup(id string) (*Key, error) {
+	key, ok := s.keys[id]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	return key, nil
+}
+
+// Document возвращает открытые ключи набора в формате JWK Set. Симметричные ключи не публикуются.
+func (s *KeySet) Document() Document {
+	doc := Document{Keys: []JWK{}}
+	for _, key := range s.keys {
+		if jwk, ok := toJWK(key); ok {
+			doc.Keys = append(doc.Keys, jwk)
+		}
+	}
+	sort.Slice(doc.Keys, func(i, j int) bool { return doc.Keys[i].KeyID < doc.Keys[j].KeyID })
+	return doc
+}
+
+// loadKey разбирает PEM-файл с закрытым или открытым ключом
+func loadKey(path, id string) (*Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	var parsed any
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block type %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		return &Key{ID: id, Method: jwt.SigningMethodRS256, signingKey: k, verifyKey: &k.PublicKey}, nil
+	case *rsa.PublicKey:
+		return &Key{ID: id, Method: jwt.SigningMethodRS256, verifyKey: k}, nil
+	case ed25519.PrivateKey:
+		return &Key{ID: id, Method: jwt.SigningMethodEdDSA, signingKey: k, verifyKey: k.Public()}, nil
+	case ed25519.PublicKey:
+		return &Key{ID: id, Method: jwt.SigningMethodEdDSA, verifyKey: k}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %T, expected RSA or Ed25519", parsed)
+	}
+}
@@ -0,0 +1,160 @@
+package jwks
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// writePEM сохраняет блок PEM в файл каталога dir
+func writePEM(t *testing.T, dir, name, blockType string, der []byte) {
+	t.Helper()
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	if err := os.WriteFile(filepath.Join(dir, name), data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// testKeyDir создает каталог с активным ключом Ed25519, ключом RSA и открытым ключом RSA, выведенным из ротации
+func testKeyDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(edKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writePEM(t, dir, "2025-07.pem", "PRIVATE KEY", der)
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writePEM(t, dir, "2025-01.pem", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey))
+
+	retired, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err = x509.MarshalPKIXPublicKey(&retired.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writePEM(t, dir, "2024-07.pem", "PUBLIC KEY", der)
+
+	// Файлы без расширения .pem не загружаются
+	if err := os.WriteFile(filepath.Join(dir, "README"), []byte("not a key"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestLoadDir(t *testing.T) {
+	dir := testKeyDir(t)
+
+	set, err := LoadDir(dir, "2025-07")
+	if err != nil {
+		t.Fatalf("LoadDir() error = %v", err)
+	}
+	if active := set.Active(); active.ID != "2025-07" || active.Method != jwt.SigningMethodEdDSA || active.SigningKey() == nil {
+		t.Errorf("Active() = %s %s, want 2025-07 EdDSA with a private key", active.ID, active.Method.Alg())
+	}
+
+	tests := []struct {
+		id      string
+		method  jwt.SigningMethod
+		signing bool
+	}{
+		{"2025-07", jwt.SigningMethodEdDSA, true},
+		{"2025-01", jwt.SigningMethodRS256, true},
+		{"2024-07", jwt.SigningMethodRS256, false},
+	}
+	for _, tt := range tests {
+		key, err := set.Lookup(tt.id)
+		if err != nil {
+			t.Errorf("Lookup(%s) error = %v", tt.id, err)
+			continue
+		}
+		if key.Method != tt.method || (key.SigningKey() != nil) != tt.signing || key.VerifyKey() == nil {
+			t.Errorf("Lookup(%s) = %s signing %v, want %s signing %v", tt.id, key.Method.Alg(), key.SigningKey() != nil, tt.method.Alg(), tt.signing)
+		}
+	}
+
+	for _, id := range []string{"", "2025-07.pem", "README", "2026-01"} {
+		if _, err := set.Lookup(id); !errors.Is(err, ErrUnknownKey) {
+			t.Errorf("Lookup(%q) error = %v, want ErrUnknownKey", id, err)
+		}
+	}
+}
+
+func TestLoadDirErrors(t *testing.T) {
+	dir := testKeyDir(t)
+
+	if _, err := LoadDir(dir, "2026-01"); err == nil {
+		t.Error("LoadDir() with a missing active key succeeded")
+	}
+	// Открытым ключом нельзя подписывать токены
+	if _, err := LoadDir(dir, "2024-07"); err == nil {
+		t.Error("LoadDir() with a public active key succeeded")
+	}
+
+	broken := t.TempDir()
+	if err := os.WriteFile(filepath.Join(broken, "bad.pem"), []byte("garbage"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadDir(broken, "bad"); err == nil {
+		t.Error("LoadDir() with a file without PEM blocks succeeded")
+	}
+
+	unsupported := t.TempDir()
+	writePEM(t, unsupported, "cert.pem", "CERTIFICATE", []byte("x"))
+	if _, err := LoadDir(unsupported, "cert"); err == nil {
+		t.Error("LoadDir() with an unsupported block type succeeded")
+	}
+}
+
+func TestDocument(t *testing.T) {
+	set, err := LoadDir(testKeyDir(t), "2025-07")
+	if err != nil {
+		t.Fatal(err)
+	}
+	set.AcceptHMAC("legacy-secret")
+
+	// Симметричный ключ не публикуется, ключи упорядочены по kid
+	doc := set.Document()
+	want := []struct{ kid, kty, alg string }{
+		{"2024-07", "RSA", "RS256"},
+		{"2025-01", "RSA", "RS256"},
+		{"2025-07", "OKP", "EdDSA"},
+	}
+	if len(doc.Keys) != len(want) {
+		t.Fatalf("Document() has %d keys, want %d", len(doc.Keys), len(want))
+	}
+	for i, jwk := range doc.Keys {
+		if jwk.KeyID != want[i].kid || jwk.KeyType != want[i].kty || jwk.Algorithm != want[i].alg || jwk.Use != "sig" {
+			t.Errorf("Document().Keys[%d] = %s %s %s, want %s %s %s", i, jwk.KeyID, jwk.KeyType, jwk.Algorithm, want[i].kid, want[i].kty, want[i].alg)
+		}
+	}
+	if rsaKey := doc.Keys[0]; rsaKey.N == "" || rsaKey.E != "AQAB" || rsaKey.X != "" {
+		t.Errorf("RSA JWK = %+v, want n and e=AQAB", rsaKey)
+	}
+	if edKey := doc.Keys[2]; edKey.Curve != "Ed25519" || len(edKey.X) != 43 || edKey.N != "" {
+		t.Errorf("Ed25519 JWK = %+v, want crv Ed25519 and a 32-byte x", edKey)
+	}
+
+	if keys := NewHMAC("secret").Document().Keys; keys == nil || len(keys) != 0 {
+		t.Errorf("HMAC Document().Keys = %v, want an empty list", keys)
+	}
+}
@@ -0,0 +1,112 @@
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+const argon2idPrefix = "$argon2id$"
+
+// Пределы параметров Argon2id, принимаемых из сохраненных хешей
+const (
+	// MaxArgon2Memory наибольший объем памяти в KiB (1 GiB)
+	MaxArgon2Memory = 1 << 20
+	// MaxArgon2Iterations наибольшее число итераций
+	MaxArgon2Iterations = 100
+
+	minArgon2KeyLength = 16
+)
+
+// Argon2id хеширует пароли алгоритмом Argon2id.
+// Хеш кодируется в формате PHC: $argon2id$v=19$m=<KiB>,t=<итерации>,p=<потоки>$<соль>$<ключ>
+type Argon2id struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// argon2Params представляет параметры, разобранные из закодированного хеша
+type argon2Params struct {
+	memory      uint32
+	iterations  uint32
+	parallelism uint8
+	salt        []byte
+	key         []byte
+}
+
+func (a Argon2id) Hash(password string) (string, error) {
+	salt := make([]byte, a.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	key := argon2.IDKey([]byte(password), salt, a.Iterations, a.Memory, a.Parallelism, a.KeyLength)
+
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s", argon2idPrefix, argon2.Version, a.Memory, a.Iterations, a.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+func (a Argon2id) Verify(password, encoded string) (bool, error) {
+	params, err := parseArgon2id(encoded)
+	if err != nil {
+		return false, err
+	}
+
+	key := argon2.IDKey([]byte(password), params.salt, params.iterations, params.memory, params.parallelism, uint32(len(params.key)))
+	return subtle.ConstantTimeCompare(key, params.key) == 1, nil
+}
+
+func (a Argon2id) Identifies(encoded string) bool {
+	return strings.HasPrefix(encoded, argon2idPrefix)
+}
+
+func (a Argon2id) NeedsRehash(encoded string) bool {
+	params, err := parseArgon2id(encoded)
+	if err != nil {
+		return true
+	}
+	return params.memory != a.Memory || params.iterations != a.Iterations || params.parallelism != a.Parallelism ||
+		uint32(len(params.salt)) != a.SaltLength || uint32(len(params.key)) != a.KeyLength
+}
+
+// parseArgon2id разбирает хеш в формате PHC. Поврежденный хеш и параметры вне допустимых пределов
+// возвращают ErrUnknownHash: нулевые t и p вызывают панику в argon2.IDKey, а большое m — выделение памяти
+// по значению из базы.
+func parseArgon2id(encoded string) (*argon2Params, error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return nil, ErrUnknownHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return nil, fmt.Errorf("%w: unsupported argon2 version %s", ErrUnknownHash, parts[2])
+	}
+
+	var params argon2Params
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.memory, &params.iterations, &params.parallelism); err != nil {
+		return nil, fmt.Errorf("%w: invalid argon2 parameters: %v", ErrUnknownHash, err)
+	}
+	if params.parallelism == 0 || params.iterations == 0 || params.iterations > MaxArgon2Iterations ||
+		params.memory < 8*uint32(params.parallelism) || params.memory > MaxArgon2Memory {
+		return nil, fmt.Errorf("%w: argon2 parameters out of range: %s", ErrUnknownHash, parts[3])
+	}
+
+	var err error
+	if params.salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return nil, fmt.Errorf("%w: invalid argon2 salt: %v", ErrUnknownHash, err)
+	}
+	// Пустой ключ совпал бы с ключом любого пароля
+	if params.key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil || len(params.key) < minArgon2KeyLength {
+		return nil, fmt.Errorf("%w: invalid argon2 key", ErrUnknownHash)
+	}
+
+	return &params, nil
+}
@@ -0,0 +1,89 @@
+package password
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// testArgon2id использует минимальные параметры, чтобы тесты выполнялись быстро
+var testArgon2id = Argon2id{Memory: 64, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+func TestArgon2idVerify(t *testing.T) {
+	encoded, err := testArgon2id.Hash("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		password string
+		want     bool
+	}{
+		{"correct horse", true},
+		{"correct horse ", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		got, err := testArgon2id.Verify(tt.password, encoded)
+		if err != nil || got != tt.want {
+			t.Errorf("Verify(%q) = %v, %v, want %v, nil", tt.password, got, err, tt.want)
+		}
+	}
+}
+
+func TestArgon2idVerifyMalformed(t *testing.T) {
+	encoded, err := testArgon2id.Hash("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(encoded, "$")
+	with := func(index int, value string) string {
+		changed := append([]string(nil), parts...)
+		changed[index] = value
+		return strings.Join(changed, "$")
+	}
+
+	tests := []struct {
+		name    string
+		encoded string
+	}{
+		{"too few parts", "$argon2id$v=19$m=64,t=1,p=1$c2FsdA"},
+		{"other algorithm", with(1, "argon2i")},
+		{"unsupported version", with(2, "v=16")},
+		{"garbled version", with(2, "version")},
+		{"garbled parameters", with(3, "m=64;t=1;p=1")},
+		{"zero iterations", with(3, "m=64,t=0,p=1")},
+		{"zero parallelism", with(3, "m=64,t=1,p=0")},
+		{"memory below 8 per thread", with(3, "m=8,t=1,p=2")},
+		{"memory too large", with(3, "m=4194304,t=1,p=1")},
+		{"too many iterations", with(3, "m=64,t=1000000,p=1")},
+		{"parallelism overflow", with(3, "m=64,t=1,p=256")},
+		{"invalid salt", with(4, "!!!")},
+		{"invalid key", with(5, "!!!")},
+		// С пустым ключом хеш совпал бы с любым паролем
+		{"empty key", with(5, "")},
+		{"short key", with(5, "c2hvcnQ")},
+	}
+
+	for _, tt := range tests {
+		ok, err := testArgon2id.Verify("correct horse", tt.encoded)
+		if ok || !errors.Is(err, ErrUnknownHash) {
+			t.Errorf("%s: Verify() = %v, %v, want false, ErrUnknownHash", tt.name, ok, err)
+		}
+		if !testArgon2id.NeedsRehash(tt.encoded) {
+			t.Errorf("%s: NeedsRehash() = false, want true", tt.name)
+		}
+	}
+}
+
+func TestBcryptVerifyMalformed(t *testing.T) {
+	tests := []string{"$2a$", "$2a$10$short", "$2a$99$abcdefghijklmnopqrstuuabcdefghijklmnopqrstuvwxyz01234"}
+
+	for _, encoded := range tests {
+		ok, err := (Bcrypt{}).Verify("password", encoded)
+		if ok || !errors.Is(err, ErrUnknownHash) {
+			t.Errorf("Verify(%q) = %v, %v, want false, ErrUnknownHash", encoded, ok, err)
+		}
+	}
+}
@@ -0,0 +1,22 @@
+package password
+
+import "errors"
+
+// ErrUnknownHash возвращается, если формат сохраненного хеша не распознан
+var ErrUnknownHash = errors.New("unknown password hash format")
+
+// MaxBytes наибольшая длина пароля в байтах: bcrypt учитывает только первые 72 байта,
+// а общее ограничение позволяет переключать алгоритмы без потери паролей
+const MaxBytes = 72
+
+// Algorithm представляет алгоритм хеширования паролей с закодированными в хеше параметрами
+type Algorithm interface {
+	// Hash возвращает хеш пароля в формате с префиксом алгоритма
+	Hash(password string) (string, error)
+	// Verify сравнивает пароль с хешем этого алгоритма
+	Verify(password, encoded string) (bool, error)
+	// Identifies сообщает, создан ли хеш этим алгоритмом
+	Identifies(encoded string) bool
+	// NeedsRehash сообщает, отличаются ли алгоритм или параметры хеша от текущих
+	NeedsRehash(encoded string) bool
+}
@@ -0,0 +1,91 @@
+package password
+
+import (
+	_ "embed"
+	"strings"
+	"unicode"
+
+	e "API-Avito-shop/internal/errors"
+)
+
+//go:embed breached.txt
+var breachedList string
+
+// breached содержит пароли из локального списка утечек в нижнем регистре
+var breached = parseBreachedList(breachedList)
+
+// Policy задает требования к новым паролям
+type Policy struct {
+	MinLength      int
+	MaxLength      int
+	RequireUpper   bool
+	RequireLower   bool
+	RequireDigit   bool
+	RequireSpecial bool
+	CheckBreached  bool
+}
+
+// Validate проверяет пароль на соответствие политике и возвращает PolicyError со всеми нарушениями
+func (p Policy) Validate(password, username string) error {
+	var violations []string
+
+	length := len([]rune(password))
+	if length < p.MinLength {
+		violations = append(violations, "password is too short")
+	}
+	if (p.MaxLength > 0 && length > p.MaxLength) || len(password) > MaxBytes {
+		violations = append(violations, "password is too long")
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSpecial bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			hasSpecial = true
+		}
+	}
+	if p.RequireUpper && !hasUpper {
+		violations = append(violations, "password must contain an uppercase letter")
+	}
+	if p.RequireLower && !hasLower {
+		violations = append(violations, "password must contain a lowercase letter")
+	}
+	if p.RequireDigit && !hasDigit {
+		violations = append(violations, "password must contain a digit")
+	}
+	if p.RequireSpecial && !hasSpecial {
+		violations = append(violations, "password must contain a special character")
+	}
+
+	lower := strings.ToLower(password)
+	if username != "" && strings.Contains(lower, strings.ToLower(username)) {
+		violations = append(violations, "password must not contain the username")
+	}
+	if p.CheckBreached && breached[lower] {
+		violations = append(violations, "password is too common and appears in breach lists")
+	}
+
+	if len(violations) > 0 {
+		return &e.PolicyError{Violations: violations}
+	}
+	return nil
+}
+
+// parseBreachedList разбирает встроенный список, пропуская пустые строки и комментарии
+func parseBreachedList(list string) map[string]bool {
+	result := make(map[string]bool)
+	for _, line := range strings.Split(list, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		result[strings.ToLower(line)] = true
+	}
+	return result
+}
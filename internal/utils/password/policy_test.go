@@ -0,0 +1,62 @@
+package password
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	e "API-Avito-shop/internal/errors"
+)
+
+func TestPolicyValidate(t *testing.T) {
+	strict := Policy{MinLength: 8, MaxLength: 64, RequireUpper: true, RequireLower: true, RequireDigit: true, RequireSpecial: true, CheckBreached: true}
+
+	tests := []struct {
+		name       string
+		policy     Policy
+		password   string
+		username   string
+		violations []string
+	}{
+		{"satisfies everything", strict, "Tr0ub4dor&3", "alice", nil},
+		{"too short", strict, "Aa1!", "alice", []string{"password is too short"}},
+		// Длина считается в символах, а не в байтах
+		{"short in characters", strict, "Пар0ль!", "alice", []string{"password is too short"}},
+		{"too long", strict, "Aa1!" + strings.Repeat("x", 61), "alice", []string{"password is too long"}},
+		{"over bcrypt byte limit", Policy{}, strings.Repeat("я", 37), "alice", []string{"password is too long"}},
+		{"missing classes", strict, "zxvbnmlk", "alice", []string{
+			"password must contain an uppercase letter",
+			"password must contain a digit",
+			"password must contain a special character",
+		}},
+		{"space is special", Policy{RequireSpecial: true}, "correct horse", "alice", nil},
+		{"contains username", Policy{}, "my-ALICE-password", "alice", []string{"password must not contain the username"}},
+		{"no username", Policy{}, "anything", "", nil},
+		{"breached in other case", Policy{CheckBreached: true}, "PassWord1", "alice", []string{"password is too common and appears in breach lists"}},
+		{"breach check disabled", Policy{}, "password1", "alice", nil},
+	}
+
+	for _, tt := range tests {
+		err := tt.policy.Validate(tt.password, tt.username)
+		if tt.violations == nil {
+			if err != nil {
+				t.Errorf("%s: Validate() = %v, want nil", tt.name, err)
+			}
+			continue
+		}
+
+		var policyErr *e.PolicyError
+		if !errors.As(err, &policyErr) || !slices.Equal(policyErr.Violations, tt.violations) {
+			t.Errorf("%s: Validate() = %v, want violations %q", tt.name, err, tt.violations)
+		}
+	}
+}
+
+func TestParseBreachedList(t *testing.T) {
+	got := parseBreachedList("# comment\n\nPassword\n  qwerty  \r\n")
+
+	if len(got) != 2 || !got["password"] || !got["qwerty"] {
+		t.Errorf("parseBreachedList() = %v, want password and qwerty", got)
+	}
+}
@@ -0,0 +1,146 @@
+// Package pdf формирует простые текстовые PDF-документы: моноширинный шрифт Courier,
+// формат A4 и автоматический перенос на новую страницу. Встроенные шрифты PDF не содержат
+// кириллицы, поэтому символы вне ASCII заменяются на '?'.
+package pdf
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	pageWidth  = 595
+	pageHeight = 842
+	margin     = 40
+	fontSize   = 9
+	leading    = 12
+	// LineWidth количество символов, помещающихся в строку
+	LineWidth    = (pageWidth - 2*margin) * 10 / (fontSize * 6)
+	linesPerPage = (pageHeight - 2*margin) / leading
+)
+
+// Document представляет текстовый документ, разбитый на страницы
+type Document struct {
+	pages [][]string
+}
+
+// New создает пустой документ
+func New() *Document {
+	return &Document{}
+}
+
+// Line добавляет строку, слишком длинная строка обрезается. Длина считается в символах:
+// каждый символ, в том числе замененный на '?', занимает одну позицию.
+func (d *Document) Line(text string) {
+	if len(d.pages) == 0 || len(d.pages[len(d.pages)-1]) == linesPerPage {
+		d.pages = append(d.pages, make([]string, 0, linesPerPage))
+	}
+	if utf8.RuneCountInString(text) > LineWidth {
+		text = string([]rune(text)[:LineWidth])
+	}
+	d.pages[len(d.pages)-1] = append(d.pages[len(d.pages)-1], text)
+}
+
+// Linef добавляет отформатированную строку
+func (d *Document) Linef(format string, args ...any) {
+	d.Line(fmt.Sprintf(format, args...))
+}
+
+// WriteTo записывает документ в формате PDF
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	pages := d.pages
+	if len(pages) == 0 {
+		pages = [][]string{{}}
+	}
+
+	cw := &countingWriter{w: bufio.NewWriter(w)}
+	// Объекты: 1 — каталог, 2 — дерево страниц, 3 — шрифт, далее страница и ее содержимое
+	offsets := make([]int64, 0, 3+2*len(pages))
+	object := func(body string) {
+		offsets = append(offsets, cw.n)
+		fmt.Fprintf(cw, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	fmt.Fprint(cw, "%PDF-1.4\n")
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 4+2*i)
+	}
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>")
+
+	for i, lines := range pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>",
+			pageWidth, pageHeight, 5+2*i))
+
+		content := pageContent(lines)
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content))
+	}
+
+	xref := cw.n
+	fmt.Fprintf(cw, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(cw, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(cw, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	if cw.err != nil {
+		return cw.n, cw.err
+	}
+	return cw.n, cw.w.Flush()
+}
+
+// pageContent формирует поток команд, выводящих строки страницы сверху вниз
+func pageContent(lines []string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "BT /F1 %d Tf %d TL %d %d Td", fontSize, leading, margin, pageHeight-margin-fontSize)
+	for i, line := range lines {
+		if i > 0 {
+			b.WriteString(" T*")
+		}
+		b.WriteString(" (")
+		b.WriteString(escape(line))
+		b.WriteString(") Tj")
+	}
+	b.WriteString(" ET")
+	return b.String()
+}
+
+// escape экранирует служебные символы строки PDF и заменяет символы вне ASCII
+func escape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 0x20 || r > 0x7e:
+			b.WriteByte('?')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// countingWriter считает записанные байты для таблицы перекрестных ссылок
+type countingWriter struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	c.err = err
+	return n, err
+}
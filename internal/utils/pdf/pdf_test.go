@@ -0,0 +1,116 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestEscape(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"plain", "Balance: 1000", "Balance: 1000"},
+		{"parentheses", "gift (cup)", `gift \(cup\)`},
+		{"backslash", `a\b`, `a\\b`},
+		{"unbalanced parenthesis", "close)", `close\)`},
+		{"cyrillic", "Баланс", "??????"},
+		{"control characters", "a\tb\nc\r", "a?b?c?"},
+		{"delete", "a\x7fb", "a?b"},
+		{"emoji", "ok 👍", "ok ?"},
+		{"invalid utf-8", "a\xffb", "a?b"},
+	}
+
+	for _, tt := range tests {
+		if got := escape(tt.in); got != tt.want {
+			t.Errorf("%s: escape(%q) = %q, want %q", tt.name, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestLineTruncation(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"short", "short line", "short line"},
+		{"exact width", strings.Repeat("a", LineWidth), strings.Repeat("a", LineWidth)},
+		{"ascii", strings.Repeat("a", LineWidth+5), strings.Repeat("a", LineWidth)},
+		// Кириллица занимает два байта на символ, но одну позицию в строке документа
+		{"cyrillic fits", strings.Repeat("я", LineWidth), strings.Repeat("я", LineWidth)},
+		{"cyrillic", strings.Repeat("я", LineWidth+1), strings.Repeat("я", LineWidth)},
+		{"mixed", "x" + strings.Repeat("ж", LineWidth), "x" + strings.Repeat("ж", LineWidth-1)},
+	}
+
+	for _, tt := range tests {
+		doc := New()
+		doc.Line(tt.in)
+		if got := doc.pages[0][0]; got != tt.want {
+			t.Errorf("%s: Line() stored %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLinePagination(t *testing.T) {
+	doc := New()
+	for i := 0; i < linesPerPage+1; i++ {
+		doc.Linef("line %d", i)
+	}
+
+	if len(doc.pages) != 2 {
+		t.Fatalf("got %d pages, want 2", len(doc.pages))
+	}
+	if len(doc.pages[0]) != linesPerPage || len(doc.pages[1]) != 1 {
+		t.Errorf("pages hold %d and %d lines, want %d and 1", len(doc.pages[0]), len(doc.pages[1]), linesPerPage)
+	}
+}
+
+func TestWriteToCrossReferences(t *testing.T) {
+	doc := New()
+	for i := 0; i < linesPerPage+1; i++ {
+		doc.Linef("line (%d) Ж", i)
+	}
+
+	var buf bytes.Buffer
+	n, err := doc.WriteTo(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := buf.Bytes()
+	if n != int64(len(out)) {
+		t.Errorf("WriteTo() = %d, wrote %d bytes", n, len(out))
+	}
+	if !bytes.HasPrefix(out, []byte("%PDF-1.4\n")) || !bytes.HasSuffix(out, []byte("%%EOF\n")) {
+		t.Fatalf("document is missing the PDF header or trailer")
+	}
+	if !bytes.Contains(out, []byte("/Count 2")) {
+		t.Errorf("page tree does not count 2 pages")
+	}
+
+	// Каждая запись таблицы перекрестных ссылок указывает на начало своего объекта
+	match := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(out)
+	if match == nil {
+		t.Fatal("startxref not found")
+	}
+	xref, _ := strconv.Atoi(string(match[1]))
+	if !bytes.HasPrefix(out[xref:], []byte("xref\n")) {
+		t.Fatalf("startxref %d does not point to the xref table", xref)
+	}
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(out[xref:], -1)
+	if len(entries) != 3+2*2 {
+		t.Fatalf("got %d xref entries, want %d", len(entries), 3+2*2)
+	}
+	for i, entry := range entries {
+		offset, _ := strconv.Atoi(string(entry[1]))
+		want := fmt.Sprintf("%d 0 obj\n", i+1)
+		if !bytes.HasPrefix(out[offset:], []byte(want)) {
+			t.Errorf("xref entry %d points to %q, want %q", i+1, out[offset:min(offset+len(want), len(out))], want)
+		}
+	}
+}
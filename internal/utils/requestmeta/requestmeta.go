@@ -0,0 +1,23 @@
+package requestmeta
+
+import "context"
+
+// Meta описывает источник запроса для журнала аудита
+type Meta struct {
+	IP        string
+	UserAgent string
+	RequestID string
+}
+
+type contextKey struct{}
+
+// WithMeta сохраняет данные о запросе в контексте
+func WithMeta(ctx context.Context, meta Meta) context.Context {
+	return context.WithValue(ctx, contextKey{}, meta)
+}
+
+// FromContext возвращает данные о запросе или пустую структуру для фоновых операций
+func FromContext(ctx context.Context) Meta {
+	meta, _ := ctx.Value(contextKey{}).(Meta)
+	return meta
+}
@@ -22,9 +22,15 @@ func init() {
 	}
 }
 
+// usernamePattern задает допустимый формат username
+var usernamePattern = regexp.MustCompile("^[a-zA-Z][a-zA-Z0-9]{5,14}$")
+
 // validateUsername функция валидации username
 func validateUsername(fl validator.FieldLevel) bool {
-	pattern := "^[a-zA-Z][a-zA-Z0-9]{5,14}$"
-	match, _ := regexp.MatchString(pattern, fl.Field().String())
-	return match
+	return IsValidUsername(fl.Field().String())
+}
+
+// IsValidUsername проверяет username вне привязки запроса
+func IsValidUsername(username string) bool {
+	return usernamePattern.MatchString(username)
 }
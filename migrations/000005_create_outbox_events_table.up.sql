@@ -0,0 +1,16 @@
+-- Создание таблицы исходящих доменных событий (transactional outbox). Публикация выполняется вне транзакции,
+-- срок аренды locked_until защищает событие от повторного захвата
+CREATE TABLE IF NOT EXISTS outbox_events (
+    id BIGSERIAL PRIMARY KEY,
+    event_type TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    locked_until TIMESTAMP,
+    published_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Добавление частичного индекса для быстрой выборки неопубликованных событий
+CREATE INDEX IF NOT EXISTS idx_outbox_pending ON outbox_events(next_attempt_at, id) WHERE published_at IS NULL;
\ No newline at end of file
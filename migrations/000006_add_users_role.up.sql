@@ -0,0 +1,2 @@
+-- Добавление роли пользователя (назначается администратором через БД)
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role TEXT NOT NULL DEFAULT 'user' CHECK (role IN ('user', 'admin'));
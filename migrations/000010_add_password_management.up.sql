@@ -0,0 +1,17 @@
+-- Добавление версии токенов пользователя, увеличивается при смене пароля и делает выданные токены недействительными
+ALTER TABLE users ADD COLUMN IF NOT EXISTS token_version INT NOT NULL DEFAULT 0;
+
+-- Создание таблицы одноразовых токенов сброса пароля
+CREATE TABLE IF NOT EXISTS password_reset_tokens (
+    id BIGSERIAL PRIMARY KEY,
+    username TEXT NOT NULL,
+    token_hash TEXT NOT NULL UNIQUE,
+    created_by TEXT NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (username) REFERENCES users(username) ON DELETE CASCADE
+);
+
+-- Добавление индекса для быстрого поиска токенов пользователя
+CREATE INDEX IF NOT EXISTS idx_password_reset_tokens_username ON password_reset_tokens(username);
@@ -0,0 +1,23 @@
+-- Создание таблицы незавершенных входов через OIDC: state, nonce и PKCE verifier живут до callback,
+-- username задан, если пользователь подтвердил связывание учетной записи провайдера
+CREATE TABLE IF NOT EXISTS oidc_login_states (
+    state TEXT PRIMARY KEY,
+    nonce TEXT NOT NULL,
+    code_verifier TEXT NOT NULL,
+    username TEXT REFERENCES users(username) ON DELETE CASCADE,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Создание таблицы связей пользователей с внешними учетными записями провайдера
+CREATE TABLE IF NOT EXISTS user_identities (
+    issuer TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    username TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (issuer, subject),
+    FOREIGN KEY (username) REFERENCES users(username) ON DELETE CASCADE
+);
+
+-- Добавление индекса для быстрого поиска связей пользователя
+CREATE INDEX IF NOT EXISTS idx_user_identities_username ON user_identities(username);
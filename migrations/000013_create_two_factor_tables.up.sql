@@ -0,0 +1,32 @@
+-- Создание таблицы TOTP-секретов пользователей, двухфакторная аутентификация включена после подтверждения
+CREATE TABLE IF NOT EXISTS user_totp (
+    username TEXT PRIMARY KEY,
+    secret TEXT NOT NULL,
+    confirmed_at TIMESTAMP,
+    last_used_step BIGINT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (username) REFERENCES users(username) ON DELETE CASCADE
+);
+
+-- Создание таблицы одноразовых кодов восстановления, хранятся только хеши
+CREATE TABLE IF NOT EXISTS recovery_codes (
+    id BIGSERIAL PRIMARY KEY,
+    username TEXT NOT NULL,
+    code_hash TEXT NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (username) REFERENCES users(username) ON DELETE CASCADE
+);
+
+-- Добавление индекса для быстрого поиска кодов пользователя
+CREATE INDEX IF NOT EXISTS idx_recovery_codes_username ON recovery_codes(username);
+
+-- Создание таблицы незавершенных входов, ожидающих второй фактор
+CREATE TABLE IF NOT EXISTS login_challenges (
+    token_hash TEXT PRIMARY KEY,
+    username TEXT NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (username) REFERENCES users(username) ON DELETE CASCADE
+);
@@ -0,0 +1,49 @@
+-- Создание журнала аудита действий, влияющих на безопасность и движение монет.
+-- Записи добавляются без хеша, а фоновый процесс включает их в цепочку в порядке фиксации: присваивает номер,
+-- хеш предыдущей записи и HMAC с ключом вне базы данных, поэтому изменение или удаление записи обнаруживается проверкой.
+CREATE TABLE IF NOT EXISTS audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    actor TEXT NOT NULL,
+    action TEXT NOT NULL,
+    target TEXT NOT NULL DEFAULT '',
+    amount INT,
+    details TEXT NOT NULL DEFAULT '',
+    ip TEXT NOT NULL DEFAULT '',
+    user_agent TEXT NOT NULL DEFAULT '',
+    request_id TEXT NOT NULL DEFAULT '',
+    chain_seq BIGINT UNIQUE,
+    prev_hash TEXT,
+    hash TEXT UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Добавление индексов для фильтрации журнала
+CREATE INDEX IF NOT EXISTS idx_audit_log_actor ON audit_log(actor, id);
+CREATE INDEX IF NOT EXISTS idx_audit_log_target ON audit_log(target, id);
+CREATE INDEX IF NOT EXISTS idx_audit_log_action ON audit_log(action, id);
+CREATE INDEX IF NOT EXISTS idx_audit_log_created_at ON audit_log(created_at);
+
+-- Добавление индекса для выборки записей, еще не включенных в цепочку
+CREATE INDEX IF NOT EXISTS idx_audit_log_unsealed ON audit_log(id) WHERE chain_seq IS NULL;
+
+-- Создание функции, запрещающей изменение журнала. Разрешено только однократное включение записи в цепочку
+-- без изменения ее содержимого.
+CREATE OR REPLACE FUNCTION audit_log_append_only() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'UPDATE' AND OLD.chain_seq IS NULL AND NEW.chain_seq IS NOT NULL
+        AND NEW.id = OLD.id AND NEW.actor = OLD.actor AND NEW.action = OLD.action AND NEW.target = OLD.target
+        AND NEW.amount IS NOT DISTINCT FROM OLD.amount AND NEW.details = OLD.details AND NEW.ip = OLD.ip
+        AND NEW.user_agent = OLD.user_agent AND NEW.request_id = OLD.request_id AND NEW.created_at = OLD.created_at THEN
+        RETURN NEW;
+    END IF;
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+-- Запрет изменения и удаления записей журнала
+CREATE TRIGGER audit_log_no_update BEFORE UPDATE OR DELETE ON audit_log
+    FOR EACH ROW EXECUTE FUNCTION audit_log_append_only();
+
+-- Запрет очистки журнала
+CREATE TRIGGER audit_log_no_truncate BEFORE TRUNCATE ON audit_log
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_log_append_only();
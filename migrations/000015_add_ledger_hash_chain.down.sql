@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_purchases_unsealed;
+DROP INDEX IF EXISTS idx_transactions_unsealed;
+ALTER TABLE purchases ALTER COLUMN created_at TYPE TIMESTAMP USING created_at AT TIME ZONE 'UTC';
+ALTER TABLE transactions ALTER COLUMN created_at TYPE TIMESTAMP USING created_at AT TIME ZONE 'UTC';
+ALTER TABLE purchases DROP COLUMN IF EXISTS chained, DROP COLUMN IF EXISTS hash, DROP COLUMN IF EXISTS prev_hash, DROP COLUMN IF EXISTS chain_seq;
+ALTER TABLE transactions DROP COLUMN IF EXISTS chained, DROP COLUMN IF EXISTS hash, DROP COLUMN IF EXISTS prev_hash, DROP COLUMN IF EXISTS chain_seq;
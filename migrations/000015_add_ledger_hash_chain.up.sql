@@ -0,0 +1,26 @@
+-- Добавление цепочки хешей в таблицу транзакций: номер строки в цепочке, хеш предыдущей строки и хеш строки.
+-- Строки добавляются без хеша и включаются в цепочку фоновым процессом в порядке фиксации.
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS chain_seq BIGINT UNIQUE;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS prev_hash TEXT;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS hash TEXT;
+
+-- Добавление цепочки хешей в таблицу покупок
+ALTER TABLE purchases ADD COLUMN IF NOT EXISTS chain_seq BIGINT UNIQUE;
+ALTER TABLE purchases ADD COLUMN IF NOT EXISTS prev_hash TEXT;
+ALTER TABLE purchases ADD COLUMN IF NOT EXISTS hash TEXT;
+
+-- Существующие строки не включаются в цепочку: хеш, вычисленный задним числом, подтвердил бы и уже
+-- внесенные в них изменения. Такие строки отмечаются как не покрытые цепочкой, новые строки покрываются.
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS chained BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE transactions ALTER COLUMN chained SET DEFAULT TRUE;
+ALTER TABLE purchases ADD COLUMN IF NOT EXISTS chained BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE purchases ALTER COLUMN chained SET DEFAULT TRUE;
+
+-- Время создания хранится с часовым поясом, чтобы хеш не зависел от часового пояса сессии.
+-- Существующие значения считаются записанными в UTC, как в часовом поясе сервера базы данных по умолчанию.
+ALTER TABLE transactions ALTER COLUMN created_at TYPE TIMESTAMPTZ USING created_at AT TIME ZONE 'UTC';
+ALTER TABLE purchases ALTER COLUMN created_at TYPE TIMESTAMPTZ USING created_at AT TIME ZONE 'UTC';
+
+-- Добавление индексов для выборки строк, еще не включенных в цепочку
+CREATE INDEX IF NOT EXISTS idx_transactions_unsealed ON transactions(id) WHERE chain_seq IS NULL AND chained;
+CREATE INDEX IF NOT EXISTS idx_purchases_unsealed ON purchases(id) WHERE chain_seq IS NULL AND chained;
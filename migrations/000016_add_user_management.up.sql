@@ -0,0 +1,12 @@
+-- Добавление времени создания пользователя
+ALTER TABLE users ADD COLUMN IF NOT EXISTS created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP;
+
+-- Добавление отключения учетной записи администратором
+ALTER TABLE users ADD COLUMN IF NOT EXISTS disabled_at TIMESTAMP;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS disabled_reason TEXT;
+
+-- Добавление мягкого удаления: строка остается, чтобы не нарушать ссылки из истории переводов и покупок
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+
+-- Удаленная учетная запись всегда отключена
+ALTER TABLE users ADD CONSTRAINT users_deleted_disabled_check CHECK (deleted_at IS NULL OR disabled_at IS NOT NULL);
@@ -0,0 +1,14 @@
+-- Создание таблицы профилей пользователей, строка создается при первом изменении профиля
+CREATE TABLE IF NOT EXISTS user_profiles (
+    username TEXT PRIMARY KEY,
+    display_name TEXT NOT NULL DEFAULT '',
+    department TEXT NOT NULL DEFAULT '',
+    team TEXT NOT NULL DEFAULT '',
+    avatar_url TEXT NOT NULL DEFAULT '',
+    bio TEXT NOT NULL DEFAULT '',
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (username) REFERENCES users(username) ON DELETE CASCADE
+);
+
+-- Отображаемые имена уникальны без учета регистра, чтобы коллегу нельзя было выдать за другого
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_profiles_display_name ON user_profiles (LOWER(display_name)) WHERE display_name <> '';
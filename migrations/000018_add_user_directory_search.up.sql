@@ -0,0 +1,9 @@
+-- Подключение расширения для поиска по триграммам
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- Добавление триграммных индексов для поиска по логину и отображаемому имени
+CREATE INDEX IF NOT EXISTS idx_users_username_trgm ON users USING GIN (username gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_user_profiles_display_name_trgm ON user_profiles USING GIN (display_name gin_trgm_ops);
+
+-- Добавление индекса для списка недавних получателей
+CREATE INDEX IF NOT EXISTS idx_transactions_from_created ON transactions(from_username, created_at DESC);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS leaderboard_watermarks CASCADE;
+DROP TABLE IF EXISTS leaderboard_daily CASCADE;
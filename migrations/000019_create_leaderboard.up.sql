@@ -0,0 +1,41 @@
+-- Создание таблицы дневных итогов пользователей для рейтингов: получено и отдано в переводах, потрачено в магазине
+CREATE TABLE IF NOT EXISTS leaderboard_daily (
+    username TEXT NOT NULL,
+    day DATE NOT NULL,
+    received BIGINT NOT NULL DEFAULT 0,
+    given BIGINT NOT NULL DEFAULT 0,
+    spent BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (username, day),
+    FOREIGN KEY (username) REFERENCES users(username) ON DELETE CASCADE
+);
+
+-- Добавление индекса для выборки итогов за период
+CREATE INDEX IF NOT EXISTS idx_leaderboard_daily_day ON leaderboard_daily(day);
+
+-- Создание таблицы позиций обработки: номер в цепочке хешей последней учтенной строки каждой исходной таблицы.
+-- Номера выдаются в порядке фиксации, поэтому строка с меньшим номером не может появиться после уже учтенной.
+CREATE TABLE IF NOT EXISTS leaderboard_watermarks (
+    source TEXT PRIMARY KEY,
+    last_seq BIGINT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Добавление начальных позиций, строки цепочки учитываются при первом обновлении
+INSERT INTO leaderboard_watermarks (source) VALUES ('transactions'), ('purchases') ON CONFLICT DO NOTHING;
+
+-- Строки, добавленные до появления цепочки хешей, не получают номера и учитываются сразу
+INSERT INTO leaderboard_daily (username, day, received, given)
+SELECT username, day, SUM(received), SUM(given) FROM (
+    SELECT to_username AS username, (COALESCE(created_at, NOW()) AT TIME ZONE 'UTC')::date AS day, amount AS received, 0 AS given
+    FROM transactions WHERE NOT chained
+    UNION ALL
+    SELECT from_username, (COALESCE(created_at, NOW()) AT TIME ZONE 'UTC')::date, 0, amount
+    FROM transactions WHERE NOT chained
+) legacy GROUP BY username, day
+ON CONFLICT (username, day) DO UPDATE SET received = leaderboard_daily.received + EXCLUDED.received,
+    given = leaderboard_daily.given + EXCLUDED.given;
+
+INSERT INTO leaderboard_daily (username, day, spent)
+SELECT username, (COALESCE(created_at, NOW()) AT TIME ZONE 'UTC')::date, SUM(price)
+FROM purchases WHERE NOT chained GROUP BY 1, 2
+ON CONFLICT (username, day) DO UPDATE SET spent = leaderboard_daily.spent + EXCLUDED.spent;
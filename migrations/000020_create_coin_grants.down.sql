@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_purchases_created;
+DROP INDEX IF EXISTS idx_transactions_created;
+DROP TABLE IF EXISTS coin_grants CASCADE;
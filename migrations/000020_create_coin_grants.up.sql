@@ -0,0 +1,28 @@
+-- Создание таблицы начислений монет: выпуск новых монет в оборот, например стартовый баланс при регистрации
+CREATE TABLE IF NOT EXISTS coin_grants (
+    id BIGSERIAL PRIMARY KEY,
+    username TEXT NOT NULL,
+    amount INT NOT NULL,
+    reason TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (username) REFERENCES users(username) ON DELETE CASCADE
+);
+
+-- Добавление индексов для выписок и отчетов за период
+CREATE INDEX IF NOT EXISTS idx_coin_grants_username ON coin_grants(username, created_at);
+CREATE INDEX IF NOT EXISTS idx_coin_grants_created ON coin_grants(created_at);
+CREATE INDEX IF NOT EXISTS idx_transactions_created ON transactions(created_at);
+CREATE INDEX IF NOT EXISTS idx_purchases_created ON purchases(created_at);
+
+-- Восстановление стартовых начислений существующих пользователей: переводы не меняют количество монет,
+-- поэтому начисление равно балансу с учетом потраченного, отправленного и полученного
+INSERT INTO coin_grants (username, amount, reason, created_at)
+SELECT u.username,
+       u.balance + COALESCE(p.spent, 0) + COALESCE(s.sent, 0) - COALESCE(r.received, 0),
+       'signup',
+       u.created_at
+FROM users u
+LEFT JOIN (SELECT username, SUM(price) AS spent FROM purchases GROUP BY username) p ON p.username = u.username
+LEFT JOIN (SELECT from_username, SUM(amount) AS sent FROM transactions GROUP BY from_username) s ON s.from_username = u.username
+LEFT JOIN (SELECT to_username, SUM(amount) AS received FROM transactions GROUP BY to_username) r ON r.to_username = u.username
+WHERE NOT EXISTS (SELECT 1 FROM coin_grants g WHERE g.username = u.username);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_transactions_to_created;
+DROP TABLE IF EXISTS fraud_reviews CASCADE;
@@ -0,0 +1,26 @@
+-- Создание очереди проверки переводов, на которые сработали правила обнаружения мошенничества.
+-- Задержанный перевод хранится здесь до решения администратора, монеты до одобрения не списываются.
+CREATE TABLE IF NOT EXISTS fraud_reviews (
+    id BIGSERIAL PRIMARY KEY,
+    from_username TEXT NOT NULL,
+    to_username TEXT NOT NULL,
+    amount INT NOT NULL CHECK (amount > 0),
+    action TEXT NOT NULL CHECK (action IN ('flag', 'hold', 'block')),
+    rules TEXT[] NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'rejected', 'blocked')),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    reviewed_by TEXT,
+    reviewed_at TIMESTAMP,
+    review_note TEXT,
+    FOREIGN KEY (from_username) REFERENCES users(username) ON DELETE RESTRICT,
+    FOREIGN KEY (to_username) REFERENCES users(username) ON DELETE RESTRICT
+);
+
+-- Добавление индекса для очереди проверки
+CREATE INDEX IF NOT EXISTS idx_fraud_reviews_status ON fraud_reviews(status, id);
+
+-- Добавление индекса для учета задержанных переводов в правилах
+CREATE INDEX IF NOT EXISTS idx_fraud_reviews_pending_holds ON fraud_reviews(created_at) WHERE action = 'hold' AND status = 'pending';
+
+-- Добавление индекса для выборки недавних поступлений получателю
+CREATE INDEX IF NOT EXISTS idx_transactions_to_created ON transactions(to_username, created_at);
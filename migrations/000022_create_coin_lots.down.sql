@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS coin_expirations CASCADE;
+DROP TABLE IF EXISTS coin_lots CASCADE;
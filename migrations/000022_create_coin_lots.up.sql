@@ -0,0 +1,38 @@
+-- Создание партий монет: баланс пользователя равен сумме остатков его партий.
+-- issued_at — время первоначального начисления монет, при переводе партия переходит получателю
+-- с тем же временем, поэтому срок действия монет не продлевается переводами. Время хранится с часовым поясом,
+-- чтобы граница года и срок действия, вычисляемые в UTC, не зависели от часового пояса базы данных.
+CREATE TABLE IF NOT EXISTS coin_lots (
+    id BIGSERIAL PRIMARY KEY,
+    username TEXT NOT NULL,
+    amount INT NOT NULL CHECK (amount > 0),
+    remaining INT NOT NULL CHECK (remaining >= 0 AND remaining <= amount),
+    issued_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (username) REFERENCES users(username) ON DELETE RESTRICT
+);
+
+-- Добавление индекса для списания партий в порядке начисления
+CREATE INDEX IF NOT EXISTS idx_coin_lots_username_issued ON coin_lots(username, issued_at, id) WHERE remaining > 0;
+
+-- Добавление индекса для поиска истекающих партий
+CREATE INDEX IF NOT EXISTS idx_coin_lots_issued ON coin_lots(issued_at) WHERE remaining > 0;
+
+-- Создание журнала сгорания монет
+CREATE TABLE IF NOT EXISTS coin_expirations (
+    id BIGSERIAL PRIMARY KEY,
+    username TEXT NOT NULL,
+    amount INT NOT NULL CHECK (amount > 0),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (username) REFERENCES users(username) ON DELETE RESTRICT
+);
+
+-- Добавление индексов для выписок и отчетов
+CREATE INDEX IF NOT EXISTS idx_coin_expirations_username ON coin_expirations(username, created_at);
+CREATE INDEX IF NOT EXISTS idx_coin_expirations_created ON coin_expirations(created_at);
+
+-- Заполнение партий текущими балансами. История не позволяет восстановить время начисления монет, уже прошедших
+-- через переводы и покупки, поэтому существующие монеты считаются начисленными в момент миграции
+-- и при политике year_end сгорают не раньше конца года миграции.
+INSERT INTO coin_lots (username, amount, remaining, issued_at)
+SELECT username, balance, balance, CURRENT_TIMESTAMP FROM users WHERE balance > 0;
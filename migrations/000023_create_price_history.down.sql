@@ -0,0 +1,3 @@
+ALTER TABLE purchases DROP CONSTRAINT IF EXISTS purchases_price_positive;
+DROP TABLE IF EXISTS promotions CASCADE;
+DROP TABLE IF EXISTS product_prices CASCADE;
@@ -0,0 +1,41 @@
+-- Создание истории цен: цена товара действует с effective_from до следующего изменения.
+-- Запланированное изменение — строка с effective_from в будущем. products.price больше не изменяется
+-- и не читается: новые товары добавляются вместе с начальной ценой в истории.
+CREATE TABLE IF NOT EXISTS product_prices (
+    id BIGSERIAL PRIMARY KEY,
+    item VARCHAR(20) NOT NULL,
+    price INT NOT NULL CHECK (price > 0),
+    effective_from TIMESTAMP NOT NULL,
+    created_by TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (item) REFERENCES products(item) ON DELETE CASCADE,
+    UNIQUE (item, effective_from)
+);
+
+-- Заполнение истории текущими ценами, действующими с начала истории магазина
+INSERT INTO product_prices (item, price, effective_from)
+SELECT item, price, TIMESTAMP '1970-01-01' FROM products
+ON CONFLICT (item, effective_from) DO NOTHING;
+
+-- Создание акций: скидка в процентах или фиксированная на товар или на весь каталог (item IS NULL)
+CREATE TABLE IF NOT EXISTS promotions (
+    id BIGSERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    item VARCHAR(20),
+    kind TEXT NOT NULL CHECK (kind IN ('percent', 'fixed')),
+    value INT NOT NULL CHECK (value > 0 AND (kind <> 'percent' OR value < 100)),
+    starts_at TIMESTAMP NOT NULL,
+    ends_at TIMESTAMP NOT NULL,
+    created_by TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    cancelled_at TIMESTAMP,
+    FOREIGN KEY (item) REFERENCES products(item) ON DELETE CASCADE,
+    CHECK (ends_at > starts_at)
+);
+
+-- Добавление индекса для поиска действующих акций
+CREATE INDEX IF NOT EXISTS idx_promotions_period ON promotions(starts_at, ends_at) WHERE cancelled_at IS NULL;
+
+-- Запрет бесплатных покупок: скидки не опускают цену ниже одной монеты
+ALTER TABLE purchases DROP CONSTRAINT IF EXISTS purchases_price_positive;
+ALTER TABLE purchases ADD CONSTRAINT purchases_price_positive CHECK (price > 0);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS promo_redemptions CASCADE;
+DROP TABLE IF EXISTS promo_codes CASCADE;
@@ -0,0 +1,36 @@
+-- Создание промокодов: скидка в процентах или в монетах на перечисленные товары (items IS NULL — на любой товар).
+-- max_redemptions ограничивает общее число погашений (NULL — без ограничения), per_user_limit — число погашений
+-- одним пользователем, username закрепляет код за одним пользователем.
+CREATE TABLE IF NOT EXISTS promo_codes (
+    id BIGSERIAL PRIMARY KEY,
+    code VARCHAR(32) NOT NULL UNIQUE,
+    description TEXT NOT NULL DEFAULT '',
+    kind TEXT NOT NULL CHECK (kind IN ('percent', 'fixed')),
+    value INT NOT NULL CHECK (value > 0 AND (kind <> 'percent' OR value <= 100)),
+    items TEXT[],
+    username TEXT,
+    max_redemptions INT CHECK (max_redemptions > 0),
+    per_user_limit INT NOT NULL DEFAULT 1 CHECK (per_user_limit > 0),
+    redemptions INT NOT NULL DEFAULT 0,
+    expires_at TIMESTAMP,
+    created_by TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    disabled_at TIMESTAMP,
+    FOREIGN KEY (username) REFERENCES users(username) ON DELETE CASCADE,
+    CHECK (max_redemptions IS NULL OR redemptions <= max_redemptions)
+);
+
+-- Создание погашений промокодов: цена покупки и размер скидки
+CREATE TABLE IF NOT EXISTS promo_redemptions (
+    id BIGSERIAL PRIMARY KEY,
+    code_id BIGINT NOT NULL,
+    username TEXT NOT NULL,
+    item VARCHAR(20) NOT NULL,
+    price INT NOT NULL,
+    discount INT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (code_id) REFERENCES promo_codes(id) ON DELETE CASCADE
+);
+
+-- Добавление индекса для подсчета погашений пользователем
+CREATE INDEX IF NOT EXISTS idx_promo_redemptions_code_user ON promo_redemptions(code_id, username);
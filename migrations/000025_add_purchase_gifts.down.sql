@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_purchases_gift_to;
+ALTER TABLE purchases DROP COLUMN IF EXISTS gift_message;
+ALTER TABLE purchases DROP COLUMN IF EXISTS gift_to;
@@ -0,0 +1,7 @@
+-- Добавление подарков: username остается покупателем, оплатившим товар, а товар попадает в инвентарь gift_to.
+-- Для покупок в хеш цепочки добавляются получатель и сообщение подарка, хеши обычных покупок не меняются.
+ALTER TABLE purchases ADD COLUMN IF NOT EXISTS gift_to TEXT REFERENCES users(username) ON DELETE CASCADE;
+ALTER TABLE purchases ADD COLUMN IF NOT EXISTS gift_message TEXT;
+
+-- Добавление индекса для инвентаря и истории полученных подарков
+CREATE INDEX IF NOT EXISTS idx_purchases_gift_to ON purchases(gift_to) WHERE gift_to IS NOT NULL;
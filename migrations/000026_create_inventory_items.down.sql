@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS item_transfers CASCADE;
+DROP TABLE IF EXISTS trade_items CASCADE;
+DROP TABLE IF EXISTS trades CASCADE;
+DROP TABLE IF EXISTS inventory_items CASCADE;
@@ -0,0 +1,67 @@
+-- Создание экземпляров товаров в инвентаре: покупка остается неизменной записью истории,
+-- а владелец экземпляра меняется при передаче и обмене
+CREATE TABLE IF NOT EXISTS inventory_items (
+    id BIGSERIAL PRIMARY KEY,
+    item VARCHAR(20) NOT NULL,
+    owner TEXT NOT NULL,
+    purchase_id INT NOT NULL UNIQUE,
+    acquired_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (item) REFERENCES products(item) ON DELETE CASCADE,
+    FOREIGN KEY (owner) REFERENCES users(username) ON DELETE CASCADE,
+    FOREIGN KEY (purchase_id) REFERENCES purchases(id) ON DELETE CASCADE
+);
+
+-- Заполнение инвентаря по совершенным покупкам, подарки принадлежат получателю
+INSERT INTO inventory_items (item, owner, purchase_id, acquired_at)
+SELECT item, COALESCE(gift_to, username), id, COALESCE(created_at, CURRENT_TIMESTAMP) FROM purchases
+ON CONFLICT (purchase_id) DO NOTHING;
+
+-- Добавление индекса для инвентаря пользователя и выбора экземпляров для передачи
+CREATE INDEX IF NOT EXISTS idx_inventory_items_owner ON inventory_items(owner, item, acquired_at);
+
+-- Создание предложений обмена: состав предложения и запроса хранится в trade_items
+CREATE TABLE IF NOT EXISTS trades (
+    id BIGSERIAL PRIMARY KEY,
+    proposer TEXT NOT NULL,
+    recipient TEXT NOT NULL,
+    message TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'accepted', 'declined', 'cancelled')),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    resolved_at TIMESTAMP,
+    FOREIGN KEY (proposer) REFERENCES users(username) ON DELETE CASCADE,
+    FOREIGN KEY (recipient) REFERENCES users(username) ON DELETE CASCADE,
+    CHECK (proposer <> recipient)
+);
+
+-- Добавление индексов для списков обменов участников
+CREATE INDEX IF NOT EXISTS idx_trades_proposer ON trades(proposer, id);
+CREATE INDEX IF NOT EXISTS idx_trades_recipient ON trades(recipient, id);
+
+-- Создание состава обмена: offer — товары инициатора, request — товары получателя
+CREATE TABLE IF NOT EXISTS trade_items (
+    trade_id BIGINT NOT NULL,
+    side TEXT NOT NULL CHECK (side IN ('offer', 'request')),
+    item VARCHAR(20) NOT NULL,
+    quantity INT NOT NULL CHECK (quantity > 0),
+    PRIMARY KEY (trade_id, side, item),
+    FOREIGN KEY (trade_id) REFERENCES trades(id) ON DELETE CASCADE,
+    FOREIGN KEY (item) REFERENCES products(item) ON DELETE CASCADE
+);
+
+-- Создание истории передачи товаров: одна строка на товар в каждой передаче или обмене
+CREATE TABLE IF NOT EXISTS item_transfers (
+    id BIGSERIAL PRIMARY KEY,
+    from_username TEXT NOT NULL,
+    to_username TEXT NOT NULL,
+    item VARCHAR(20) NOT NULL,
+    quantity INT NOT NULL CHECK (quantity > 0),
+    trade_id BIGINT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (from_username) REFERENCES users(username) ON DELETE CASCADE,
+    FOREIGN KEY (to_username) REFERENCES users(username) ON DELETE CASCADE,
+    FOREIGN KEY (trade_id) REFERENCES trades(id) ON DELETE SET NULL
+);
+
+-- Добавление индексов для истории передач пользователя
+CREATE INDEX IF NOT EXISTS idx_item_transfers_from ON item_transfers(from_username, id);
+CREATE INDEX IF NOT EXISTS idx_item_transfers_to ON item_transfers(to_username, id);